@@ -162,12 +162,41 @@ type ClBpfApplicationStateStatus struct {
 	//
 	// UnloadError is returned if one or more programs encountered an error when
 	// being unloaded.
+	//
+	// ProgramTypeMismatch is returned if bpfman loaded one or more programs as a
+	// different type of program than requested, so they were unloaded again.
+	//
+	// KernelTooOld is returned if the node's kernel is older than the
+	// application's minKernelVersion, so the programs weren't loaded.
+	//
+	// FunctionNotInBytecode is returned if one or more programs'
+	// bpfFunctionNames aren't programs in the bytecode, so the programs weren't
+	// loaded.
+	//
+	// GatedOff is returned if the feature gate named by the application's
+	// bpfman.io/feature-gate annotation isn't enabled, so the programs weren't
+	// loaded.
 	AppLoadStatus AppLoadStatus `json:"appLoadStatus"`
 	// programs is a list of eBPF programs contained in the parent
 	// ClusterBpfApplication instance. Each entry in the list contains the derived
 	// program attributes as well as the attach status for each program on the
 	// given Kubernetes node.
 	Programs []ClBpfApplicationProgramState `json:"programs,omitempty"`
+	// resolvedBytecode records the bytecode source that was actually used to
+	// load the eBPF programs on the given node, so it is possible to audit what
+	// was loaded. It is not set until the programs are loaded.
+	// +optional
+	ResolvedBytecode *ResolvedBytecode `json:"resolvedBytecode,omitempty"`
+	// observedForceResync is the value of the bpfman.io/force-resync annotation
+	// on the parent application that was last handled by the bpfman agent on
+	// the given node.
+	// +optional
+	ObservedForceResync string `json:"observedForceResync,omitempty"`
+	// lastError is the most recent error the bpfman agent hit while reconciling
+	// the parent application on the given node. It is cleared once the
+	// application is reconciled successfully.
+	// +optional
+	LastError *ReconcileError `json:"lastError,omitempty"`
 	// conditions contains the summary state of the ClusterBpfApplication for the
 	// given Kubernetes node. If one or more programs failed to load or attach to
 	// the designated attachment point, the condition will report the error. If
@@ -192,6 +221,8 @@ type ClBpfApplicationStateStatus struct {
 // instance for each node in a Kubernetes cluster.
 // +kubebuilder:printcolumn:name="Node",type=string,JSONPath=".status.node"
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[0].reason`
+// +kubebuilder:printcolumn:name="Last Error",type=string,JSONPath=`.status.lastError.message`
+// +kubebuilder:printcolumn:name="Last Error Time",type="date",JSONPath=".status.lastError.time",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type ClusterBpfApplicationState struct {
 	metav1.TypeMeta   `json:",inline"`
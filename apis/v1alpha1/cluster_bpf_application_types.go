@@ -269,6 +269,21 @@ type ClBpfApplicationProgram struct {
 	// +unionMember
 	// +optional
 	TracePoint *ClTracepointProgramInfo `json:"tracepoint,omitempty"`
+
+	// tailCalls is an optional field used to add other programs in the same
+	// application to a BPF_MAP_TYPE_PROG_ARRAY map that this program uses for
+	// tail calls. The map is populated once the programs have been loaded.
+	// +optional
+	TailCalls *TailCallInfo `json:"tailCalls,omitempty"`
+
+	// linkPersistence is an optional field that sets what happens to the
+	// program's links when the bpfman agent on a node exits. Persistent, the
+	// default, keeps them attached, so the program keeps running while the
+	// agent is restarted or upgraded. DetachOnAgentExit detaches them when the
+	// agent shuts down. The program stays loaded, and its links are attached
+	// again when the agent starts.
+	// +optional
+	LinkPersistence LinkPersistence `json:"linkPersistence,omitempty"`
 }
 
 // spec defines the desired state of the ClusterBpfApplication. The
@@ -20,6 +20,17 @@ package v1alpha1
 type ClFentryProgramInfo struct {
 	ClFentryLoadInfo `json:",inline"`
 
+	// atomicGroup is an optional field that names a group of FEntry and FExit
+	// programs in the application that are attached together or not at all,
+	// such as an FEntry and FExit pair for the same function. If any program
+	// in the group fails to attach on a node, the others are detached again,
+	// so the function isn't left half instrumented, and the attach is retried
+	// on the next reconcile. atomicGroup must not exceed 63 characters.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	AtomicGroup string `json:"atomicGroup,omitempty"`
+
 	// links is an optional field and is a flag to indicate if the FEntry program
 	// should be attached. The attachment point for a FEntry program is a Linux
 	// kernel function. Unlike other eBPF program types, an FEntry program must be
@@ -58,6 +69,23 @@ type ClFentryAttachInfo struct {
 	// +required
 	// +kubebuilder:validation:Enum=Attach;
 	Mode AttachTypeAttach `json:"mode"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each FEntry link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type ClFentryProgramInfoState struct {
@@ -42,6 +42,23 @@ type ClKretprobeAttachInfo struct {
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:MaxLength=64
 	Function string `json:"function"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each KRetProbe link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type ClKretprobeProgramInfoState struct {
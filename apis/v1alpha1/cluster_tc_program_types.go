@@ -46,6 +46,16 @@ type ClTcAttachInfo struct {
 	// +required
 	InterfaceSelector InterfaceSelector `json:"interfaceSelector"`
 
+	// attachToBondMembers is an optional field that, when an interface the
+	// interfaceSelector selects is a bond or team, also attaches the TC
+	// program to each of the interfaces that are currently its members, as
+	// separate links, since traffic seen by the members isn't covered by a
+	// link on the bond or team itself. Members that join or leave are picked
+	// up the next time the application is reconciled. It has no effect with
+	// interfacesDiscoveryConfig, which already selects every interface.
+	// +optional
+	AttachToBondMembers bool `json:"attachToBondMembers,omitempty"`
+
 	// networkNamespaces is an optional field that identifies the set of network
 	// namespaces in which to attach the eBPF program. If networkNamespaces is not
 	// specified, the eBPF program will be attached in the root network namespace.
@@ -77,6 +87,16 @@ type ClTcAttachInfo struct {
 	// +kubebuilder:default:=1000
 	Priority int32 `json:"priority,omitempty"`
 
+	// autoPriority is an optional field that asks the bpfman agent to choose
+	// the priority. When set, priority is ignored, and each link is given the
+	// lowest priority from min to max that isn't already used by another
+	// TC program on the same interface in the same direction on the node. The
+	// assigned priority is reported in the link's status. If every priority
+	// in the range is in use, the link isn't attached and its linkStatus is
+	// PriorityRangeExhausted.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is an optional field and allows the user to call other TC programs
 	// in a chain, or not call the next program in a chain based on the exit code
 	// of a TC program. Allowed values, which are the possible exit codes from a TC
@@ -88,9 +108,29 @@ type ClTcAttachInfo struct {
 	// using the default values, if a TC program returns Pipe, the next TC
 	// program in the chain will be called. If a TC program returns Stolen, the
 	// next TC program in the chain will NOT be called.
+	// The values are a set, so their order doesn't matter and each may only be
+	// listed once.
 	// +optional
 	// +kubebuilder:default:={Pipe,DispatcherReturn}
+	// +listType=set
 	ProceedOn []TcProceedOnValue `json:"proceedOn,omitempty"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each TC link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type ClTcProgramInfoState struct {
@@ -129,6 +169,11 @@ type ClTcAttachInfoState struct {
 	// +kubebuilder:validation:Maximum=1000
 	Priority int32 `json:"priority"`
 
+	// autoPriority is the range the priority was chosen from, if it was
+	// assigned by the bpfman agent rather than given in the link.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is the provisioned list of proceedOn values. proceedOn allows the
 	// user to call other TC programs in a chain, or not call the next program in a
 	// chain based on the exit code of a TC program .Multiple values are supported.
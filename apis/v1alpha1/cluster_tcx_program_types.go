@@ -36,6 +36,7 @@ type ClTcxProgramInfo struct {
 	Links []ClTcxAttachInfo `json:"links,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.autoPriority) || (!has(self.before) && !has(self.after))",message="autoPriority can't be used with before or after"
 type ClTcxAttachInfo struct {
 	// interfaceSelector is a required field and is used to determine the network
 	// interface (or interfaces) the TCX program is attached. Interface list is set
@@ -74,6 +75,45 @@ type ClTcxAttachInfo struct {
 	// +kubebuilder:validation:Maximum=1000
 	// +kubebuilder:default:=1000
 	Priority int32 `json:"priority,omitempty"`
+
+	// autoPriority is an optional field that asks the bpfman agent to choose
+	// the priority. When set, priority is ignored, and each link is given the
+	// lowest priority from min to max that isn't already used by another
+	// TCX program on the same interface in the same direction on the node. The
+	// assigned priority is reported in the link's status. If every priority
+	// in the range is in use, the link isn't attached and its linkStatus is
+	// PriorityRangeExhausted. autoPriority can't be used with before or after.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
+	// before is an optional field that names another TCX program in the same
+	// application. When set, the priority of this link is adjusted as needed so
+	// it runs before that program's links in the same direction.
+	// +optional
+	Before string `json:"before,omitempty"`
+
+	// after is an optional field that names another TCX program in the same
+	// application. When set, the priority of this link is adjusted as needed so
+	// it runs after that program's links in the same direction.
+	// +optional
+	After string `json:"after,omitempty"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each TCX link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type ClTcxProgramInfoState struct {
@@ -111,4 +151,9 @@ type ClTcxAttachInfoState struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=1000
 	Priority int32 `json:"priority"`
+
+	// autoPriority is the range the priority was chosen from, if it was
+	// assigned by the bpfman agent rather than given in the link.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
 }
@@ -36,6 +36,27 @@ type ClXdpProgramInfo struct {
 	// XDP program can also be installed into a set of network namespaces.
 	// +optional
 	Links []ClXdpAttachInfo `json:"links,omitempty"`
+
+	// interfaceselector is deprecated, use links instead. It's the interface
+	// selector of an XDP program from before a program could have a list of
+	// links, kept so older objects still work. If links is empty, the bpfman
+	// agent reads interfaceselector, priority and proceedon as a single link,
+	// otherwise they're ignored.
+	// +optional
+	InterfaceSelector *InterfaceSelector `json:"interfaceselector,omitempty"`
+
+	// priority is deprecated, use links instead. It's the priority of the
+	// link read from interfaceselector, and defaults to 1000.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	Priority *int32 `json:"priority,omitempty"`
+
+	// proceedon is deprecated, use links instead. It's the proceedOn of the
+	// link read from interfaceselector, and defaults to Pass and
+	// DispatcherReturn.
+	// +optional
+	ProceedOn []XdpProceedOnValue `json:"proceedon,omitempty"`
 }
 
 type ClXdpAttachInfo struct {
@@ -46,6 +67,17 @@ type ClXdpAttachInfo struct {
 	// +required
 	InterfaceSelector InterfaceSelector `json:"interfaceSelector"`
 
+	// additionalInterfaceSelectors is an optional field and is a list of
+	// further interface selectors, for when the interfaces can't be chosen with
+	// a single option, such as eth0 by name and every interface matching en*
+	// by discovery. The XDP program is attached once to each interface
+	// selected by interfaceSelector or any of these. If an interface is also
+	// selected by another link of the same program, the link whose selector
+	// names it most specifically is kept: by name or index, then as the
+	// primary node interface, then by discovery.
+	// +optional
+	AdditionalInterfaceSelectors []InterfaceSelector `json:"additionalInterfaceSelectors,omitempty"`
+
 	// networkNamespaces identifies the set of network namespaces in which to
 	// attach the eBPF program. If networkNamespaces is not specified, the eBPF
 	// program will be attached in the root network namespace.
@@ -64,6 +96,15 @@ type ClXdpAttachInfo struct {
 	// +kubebuilder:default:=1000
 	Priority int32 `json:"priority,omitempty"`
 
+	// autoPriority is an optional field that asks the bpfman agent to choose
+	// the priority. When set, priority is ignored, and each link is given the
+	// lowest priority from min to max that isn't already used by another XDP
+	// program on the same interface on the node. The assigned priority is
+	// reported in the link's status. If every priority in the range is in use,
+	// the link isn't attached and its linkStatus is PriorityRangeExhausted.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is an optional field and allows the user to call other XDP
 	// programs in a chain, or not call the next program in a chain based on the
 	// exit code of an XDP program. Allowed values, which are the possible exit
@@ -74,9 +115,29 @@ type ClXdpAttachInfo struct {
 	// using the default values, if an XDP program returns Pass, the next XDP
 	// program in the chain will be called. If an XDP program returns Drop, the
 	// next XDP program in the chain will NOT be called.
+	// The values are a set, so their order doesn't matter and each may only be
+	// listed once.
 	// +optional
 	// +kubebuilder:default:={Pass,DispatcherReturn}
+	// +listType=set
 	ProceedOn []XdpProceedOnValue `json:"proceedOn,omitempty"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each XDP link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type ClXdpProgramInfoState struct {
@@ -109,6 +170,11 @@ type ClXdpAttachInfoState struct {
 	// +kubebuilder:validation:Maximum=1000
 	Priority int32 `json:"priority"`
 
+	// autoPriority is the range the priority was chosen from, if it was
+	// assigned by the bpfman agent rather than given in the link.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is the provisioned list of proceedOn values. proceedOn allows the
 	// user to call other TC programs in a chain, or not call the next program in a
 	// chain based on the exit code of a TC program .Multiple values are supported.
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeBpfProgram describes an eBPF program loaded in a node's kernel, as
+// reported by bpfman.
+type NodeBpfProgram struct {
+	// id is the kernel's ID for the program.
+	Id uint32 `json:"id"`
+
+	// name is the program's name as seen by the kernel, which may be a
+	// truncated version of the name in the bytecode.
+	Name string `json:"name"`
+
+	// type is the kernel's program type, such as xdp or tracepoint.
+	Type string `json:"type"`
+
+	// bpfmanName is the name bpfman loaded the program with. It's empty if
+	// bpfman didn't load the program.
+	// +optional
+	BpfmanName string `json:"bpfmanName,omitempty"`
+
+	// links are the IDs of the bpfman links that attach the program.
+	// +optional
+	Links []uint32 `json:"links,omitempty"`
+
+	// loadedAt is when the program was loaded, as reported by the kernel.
+	// +optional
+	LoadedAt string `json:"loadedAt,omitempty"`
+
+	// tag is the hash of the program's instructions.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// gplCompatible is true if the program's license is GPL compatible.
+	// +optional
+	GplCompatible bool `json:"gplCompatible,omitempty"`
+
+	// mapIds are the IDs of the maps the program uses.
+	// +optional
+	MapIds []uint32 `json:"mapIds,omitempty"`
+
+	// btfId is the ID of the program's BTF object.
+	// +optional
+	BtfId uint32 `json:"btfId,omitempty"`
+
+	// bytesXlated is the size of the program's translated instructions.
+	// +optional
+	BytesXlated uint32 `json:"bytesXlated,omitempty"`
+
+	// jited is true if the program was JIT compiled.
+	// +optional
+	Jited bool `json:"jited,omitempty"`
+
+	// bytesJited is the size of the program's JIT compiled instructions.
+	// +optional
+	BytesJited uint32 `json:"bytesJited,omitempty"`
+
+	// bytesMemlock is the memory locked for the program.
+	// +optional
+	BytesMemlock uint32 `json:"bytesMemlock,omitempty"`
+
+	// verifiedInsns is the number of instructions the verifier processed.
+	// +optional
+	VerifiedInsns uint32 `json:"verifiedInsns,omitempty"`
+}
+
+// NodeBpfStateStatus lists the eBPF programs loaded on a node.
+type NodeBpfStateStatus struct {
+	// node is the name of the Kubernetes node.
+	Node string `json:"node"`
+
+	// lastUpdated is when programs was last changed. bpfman is listed
+	// periodically, but programs is only updated when the list changes.
+	// +optional
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+
+	// programs are the eBPF programs loaded in the node's kernel, whether or
+	// not bpfman loaded them, ordered by ID.
+	// +optional
+	Programs []NodeBpfProgram `json:"programs,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// NodeBpfState lists the eBPF programs loaded on a Kubernetes node, as
+// reported by bpfman. When enabled, the bpfman agent on each node keeps a
+// NodeBpfState named after its node up to date.
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=".status.node"
+// +kubebuilder:printcolumn:name="Last Updated",type="date",JSONPath=".status.lastUpdated"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type NodeBpfState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// status lists the eBPF programs loaded on the node.
+	Status NodeBpfStateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// NodeBpfStateList contains a list of NodeBpfState objects
+type NodeBpfStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeBpfState `json:"items"`
+}
@@ -53,8 +53,8 @@ type InterfaceDiscovery struct {
 }
 
 // InterfaceSelector describes the set of interfaces to attach a program to.
-// +kubebuilder:validation:MaxProperties=1
 // +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:XValidation:rule="[has(self.interfacesDiscoveryConfig), has(self.interfaces), has(self.interfaceIndexes), has(self.primaryNodeInterface)].filter(x, x).size() <= 1",message="only one of interfacesDiscoveryConfig, interfaces, interfaceIndexes and primaryNodeInterface may be set"
 type InterfaceSelector struct {
 	// interfacesDiscoveryConfig is an optional field that is used to control if
 	// and how to automatically discover interfaces. If the agent should
@@ -69,11 +69,33 @@ type InterfaceSelector struct {
 	// +optional
 	Interfaces []string `json:"interfaces,omitempty"`
 
+	// interfaceIndexes is an optional field and is a list of network interface
+	// indexes (ifindex) to attach the eBPF program. Each index is resolved to
+	// the name of the interface that currently has that index in the Kubernetes
+	// node's host network namespace. Use this when interface names aren't
+	// stable across reboots.
+	// +optional
+	// +kubebuilder:validation:items:Minimum=1
+	InterfaceIndexes []int32 `json:"interfaceIndexes,omitempty"`
+
 	// primaryNodeInterface is and optional field and indicates to attach the eBPF
 	// program to the primary interface on the Kubernetes node. Only 'true' is
 	// accepted.
 	// +optional
 	PrimaryNodeInterface *bool `json:"primaryNodeInterface,omitempty"`
+
+	// excludeInterfaces is an optional field and is a list of network interface
+	// names that are removed from the interfaces selected by the other fields,
+	// such as to attach to every interface discovered except one. If an entry is
+	// enclosed by slashes, such as `/^veth/`, then the entry is considered as a
+	// regular expression for matching. Otherwise, the interface names in the
+	// list are case-sensitive. Excluding an interface that isn't selected has no
+	// effect. If excludeInterfaces is the only field set, every interface in the
+	// Kubernetes node's host network namespace is selected except loopback
+	// interfaces and the excluded ones.
+	// +optional
+	// +kubebuilder:validation:items:MinLength=1
+	ExcludeInterfaces []string `json:"excludeInterfaces,omitempty"`
 }
 
 // ClContainerSelector identifies a set of containers.
@@ -93,6 +115,17 @@ type ClContainerSelector struct {
 	// in the pod are selected.
 	// +optional
 	ContainerNames []string `json:"containerNames,omitempty"`
+
+	// containerImages is an optional field and is a list of container image
+	// references. A container is selected if its image, as given in the pod
+	// spec, is one of them, or if an entry ends in *, starts with the rest of
+	// the entry, such as quay.io/example/server:* for every tag of an image.
+	// This selects containers running an image regardless of their pods'
+	// labels. If containerNames is also given, a container must match both.
+	// Each selected container in a pod gets its own link.
+	// +optional
+	// +kubebuilder:validation:items:MinLength=1
+	ContainerImages []string `json:"containerImages,omitempty"`
 }
 
 // ContainerSelector identifies a set of containers. It is different from ClContainerSelector
@@ -109,6 +142,17 @@ type ContainerSelector struct {
 	// in the pod are selected.
 	// +optional
 	ContainerNames []string `json:"containerNames,omitempty"`
+
+	// containerImages is an optional field and is a list of container image
+	// references. A container is selected if its image, as given in the pod
+	// spec, is one of them, or if an entry ends in *, starts with the rest of
+	// the entry, such as quay.io/example/server:* for every tag of an image.
+	// This selects containers running an image regardless of their pods'
+	// labels. If containerNames is also given, a container must match both.
+	// Each selected container in a pod gets its own link.
+	// +optional
+	// +kubebuilder:validation:items:MinLength=1
+	ContainerImages []string `json:"containerImages,omitempty"`
 }
 
 // ClNetworkNamespaceSelector identifies a network namespace for network-related
@@ -123,6 +167,14 @@ type ClNetworkNamespaceSelector struct {
 	// use the standard metav1.LabelSelector semantics and make it empty.
 	// +required
 	Pods metav1.LabelSelector `json:"pods"`
+
+	// includeHost is an optional field. When set to true, the program is also
+	// attached to the selected interfaces in the host's network namespace, as
+	// a separate link from those in the pods' network namespaces. Pods that
+	// use the host's network namespace are covered by the host's link.
+	// Defaults to false.
+	// +optional
+	IncludeHost bool `json:"includeHost,omitempty"`
 }
 
 // NetworkNamespaceSelector identifies a network namespace for network-related
@@ -152,12 +204,42 @@ type BpfAppCommon struct {
 	// +optional
 	GlobalData map[string][]byte `json:"globalData,omitempty"`
 
+	// globalDataFrom is an optional field that sets global variables from keys
+	// in ConfigMaps or Secrets, which suits values that are large or binary
+	// and awkward to inline in globalData. Each entry sets one global
+	// variable. If a variable is also set in globalData, the value in
+	// globalData is used. If a ConfigMap, Secret or key doesn't exist, the
+	// programs aren't loaded, and the ClusterBpfApplicationState or
+	// BpfApplicationState object reports a GlobalDataNotFound condition.
+	// +optional
+	GlobalDataFrom []GlobalDataSource `json:"globalDataFrom,omitempty"`
+
+	// globalDataOverrides is an optional field that sets global variables
+	// differently on different groups of nodes, such as a rate limit per
+	// class of node. The first override whose nodeSelector matches a node's
+	// labels is merged over globalData and globalDataFrom on that node, and
+	// its values take precedence. Programs that are already loaded aren't
+	// reloaded when a node's labels change.
+	// +optional
+	GlobalDataOverrides []GlobalDataOverride `json:"globalDataOverrides,omitempty"`
+
 	// bytecode is a required field and configures where the eBPF program's
 	// bytecode should be loaded from. The image must contain one or more
 	// eBPF programs.
 	// +required
 	ByteCode ByteCodeSelector `json:"byteCode"`
 
+	// additionalByteCode is an optional field that loads some of the
+	// application's programs from other eBPF objects, for programs that are
+	// shipped in several object files but share maps. Each entry names the
+	// programs loaded from its bytecode, and the rest are loaded from
+	// byteCode. The other objects are loaded after byteCode, and use the same
+	// maps as its programs, so a map with the same name in several objects is
+	// a single map. All of the programs are unloaded together.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	AdditionalByteCode []AdditionalByteCode `json:"additionalByteCode,omitempty"`
+
 	// mapOwnerSelector is an optional field used to share maps across
 	// applications. eBPF programs loaded with the same ClusterBpfApplication or
 	// BpfApplication instance do not need to use this field. This label selector
@@ -166,6 +248,206 @@ type BpfAppCommon struct {
 	// TODO: mapOwnerSelector is currently not supported due to recent code rework.
 	// +optional
 	MapOwnerSelector *metav1.LabelSelector `json:"mapOwnerSelector,omitempty"`
+
+	// mapPinPath is an optional field that gives a directory under
+	// /sys/fs/bpf/bpfman-operator where the bpfman agent pins the
+	// application's maps, each under its own name, so userspace programs on
+	// the node can find them at a predictable location. A BpfApplication's
+	// mapPinPath must be under /sys/fs/bpf/bpfman-operator/namespaces/ followed
+	// by its namespace. bpfman also keeps the maps pinned in its own
+	// directory. The agent doesn't replace a pin of another map, and when the
+	// programs are unloaded it only removes the pins it made, and the
+	// directory if that leaves it empty. mapPinPath can't be changed once the
+	// application is created.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:XValidation:rule="self.startsWith('/sys/fs/bpf/bpfman-operator/') && !self.contains('/../') && !self.endsWith('/..')",message="mapPinPath must be a directory under /sys/fs/bpf/bpfman-operator"
+	MapPinPath string `json:"mapPinPath,omitempty"`
+
+	// sharedMap is an optional field that names a map, created by eBPF programs
+	// already loaded on the node, that this application's programs use instead
+	// of creating their own. The map is found by name when the programs are
+	// loaded, so the application doesn't need to know the id bpfman gave the
+	// program that owns it, which differs from node to node. bpfman shares all
+	// of the owning program's maps, not just the named one. If no program on
+	// the node has the map yet, the programs aren't loaded, and the
+	// ClusterBpfApplicationState or BpfApplicationState object reports a
+	// SharedMapNotFound condition until one does. A BpfApplication can only
+	// share maps with BpfApplications in its own namespace.
+	// +optional
+	SharedMap *SharedMapReference `json:"sharedMap,omitempty"`
+
+	// adoptExisting is an optional field. When set to true, the bpfman agent
+	// doesn't load the bytecode. Instead, on each selected node, it adopts the
+	// eBPF programs that bpfman already has loaded, matching each entry in the
+	// programs list by name, and manages them as if it had loaded them.
+	// Programs owned by another ClusterBpfApplication or BpfApplication are
+	// never adopted. If a program can't be found, the application reports an
+	// error. Adopted programs are unloaded when the application is deleted.
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// bpfAppStateLabels is an optional field that sets extra labels, such as a
+	// team or cost center, on the ClusterBpfApplicationState or
+	// BpfApplicationState objects that the bpfman agent creates on each node
+	// for this application. The labels are set when those objects are
+	// created. The labels the agent uses itself, such as
+	// kubernetes.io/hostname, can't be overridden. Keys and values must be
+	// valid Kubernetes labels.
+	// +optional
+	BpfAppStateLabels map[string]string `json:"bpfAppStateLabels,omitempty"`
+
+	// expectedTopology is an optional field that describes the node topology
+	// the bytecode was built for, such as per-CPU maps sized for a fixed number
+	// of CPUs. It is advisory: the programs are loaded and attached regardless,
+	// but on a node whose topology doesn't match, the
+	// ClusterBpfApplicationState or BpfApplicationState object reports a
+	// TopologyMismatch condition instead of Success.
+	// +optional
+	ExpectedTopology *NodeTopology `json:"expectedTopology,omitempty"`
+
+	// minKernelVersion is an optional field that gives the oldest kernel the
+	// bytecode can be loaded on, as major.minor or major.minor.patch, for
+	// example 5.15. On a node running an older kernel, the bpfman agent
+	// doesn't load the programs, and the ClusterBpfApplicationState or
+	// BpfApplicationState object reports a KernelTooOld condition. The node's
+	// kernel version is taken from the node status.
+	// +kubebuilder:validation:Pattern=`^[0-9]+\.[0-9]+(\.[0-9]+)?$`
+	// +optional
+	MinKernelVersion string `json:"minKernelVersion,omitempty"`
+
+	// failurePolicy is an optional field that sets what happens to a
+	// program's links on a node when some of them fail to attach. With
+	// BestEffort, the links that attached are kept, the failed links report
+	// the error, and the program isn't failed because of them. With
+	// AllOrNothing, the program's links that attached are detached again,
+	// so the program is either attached everywhere it should be or nowhere,
+	// and the attach is retried on the next reconcile. When unset, the links
+	// that attached are kept and the program reports an error.
+	// +optional
+	FailurePolicy LinkFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// detached is an optional field. When set to true, the programs stay
+	// loaded on each selected node, but all of their links are detached, and
+	// none are attached until it's set back to false. This allows expensive
+	// bytecode to be loaded ahead of time, and attached or detached without
+	// reloading it.
+	// +optional
+	Detached bool `json:"detached,omitempty"`
+}
+
+// LinkFailurePolicy describes what happens to a program's links when some of
+// them fail to attach.
+// +kubebuilder:validation:Enum=BestEffort;AllOrNothing
+type LinkFailurePolicy string
+
+const (
+	// LinkFailureBestEffort keeps the links that attached, and only the
+	// failed links report an error.
+	LinkFailureBestEffort LinkFailurePolicy = "BestEffort"
+	// LinkFailureAllOrNothing detaches all of a program's links if any of
+	// them fails to attach.
+	LinkFailureAllOrNothing LinkFailurePolicy = "AllOrNothing"
+)
+
+// GlobalDataSource sets a global variable from a key in a ConfigMap or a
+// Secret.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="exactly one of configMapKeyRef or secretKeyRef must be set"
+type GlobalDataSource struct {
+	// name is a required field and is the name of the global variable.
+	// +required
+	Name string `json:"name"`
+
+	// configMapKeyRef is an optional field and selects a key in a ConfigMap.
+	// The value is read from binaryData if the key is present there, otherwise
+	// it's read from data as a base64 encoded string.
+	// +optional
+	ConfigMapKeyRef *GlobalDataKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// secretKeyRef is an optional field and selects a key in a Secret. The
+	// value is the key's raw bytes.
+	// +optional
+	SecretKeyRef *GlobalDataKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// GlobalDataOverride sets global variables on the nodes selected by its
+// nodeSelector.
+type GlobalDataOverride struct {
+	// nodeSelector is a required field and selects the nodes the override
+	// applies to. An empty selector selects all nodes.
+	// +required
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+
+	// globalData is a required field and is the global variables set on the
+	// selected nodes, in the same format as the application's globalData.
+	// +required
+	GlobalData map[string][]byte `json:"globalData"`
+}
+
+// AdditionalByteCode is an eBPF object that some of an application's programs
+// are loaded from.
+type AdditionalByteCode struct {
+	// byteCode is a required field and configures where the object's bytecode
+	// should be loaded from.
+	// +required
+	ByteCode ByteCodeSelector `json:"byteCode"`
+
+	// programs is a required field and lists the names of the entries in the
+	// application's programs that are loaded from byteCode. A program may
+	// only be listed once, and at least one program must be left to load from
+	// the application's byteCode.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Programs []string `json:"programs"`
+}
+
+// SharedMapReference names a map owned by eBPF programs that are already
+// loaded on the node.
+type SharedMapReference struct {
+	// name is a required field and is the name of the map, as given in the
+	// bytecode of the programs that create it.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// applicationName is an optional field and is the name of the
+	// ClusterBpfApplication or BpfApplication whose programs own the map. It
+	// only needs to be set if programs loaded by more than one application
+	// have a map with this name.
+	// +optional
+	ApplicationName string `json:"applicationName,omitempty"`
+}
+
+// GlobalDataKeySelector selects a key in a ConfigMap or a Secret.
+type GlobalDataKeySelector struct {
+	// name is a required field and is the name of the ConfigMap or Secret.
+	// +required
+	Name string `json:"name"`
+
+	// namespace is a required field and is the namespace of the ConfigMap or
+	// Secret. For a BpfApplication, it must be the BpfApplication's namespace.
+	// +required
+	Namespace string `json:"namespace"`
+
+	// key is a required field and is the key that holds the value.
+	// +required
+	Key string `json:"key"`
+}
+
+// NodeTopology describes the CPU and NUMA layout of a Kubernetes node.
+type NodeTopology struct {
+	// cpus is an optional field and is the number of CPUs the node is
+	// expected to have, as reported in the node's cpu capacity.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	CPUs *int32 `json:"cpus,omitempty"`
+
+	// numaNodes is an optional field and is the number of NUMA nodes the node
+	// is expected to have.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	NUMANodes *int32 `json:"numaNodes,omitempty"`
 }
 
 // status reflects the status of a BPF Application and indicates if all the
@@ -178,6 +460,15 @@ type BpfAppStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	// nodesExpected is the number of nodes selected by the nodeSelector.
+	// Nodes with taints that the bpfman agent doesn't tolerate aren't
+	// counted, since the agent doesn't run on them.
+	// +optional
+	NodesExpected int32 `json:"nodesExpected,omitempty"`
+	// nodesLoaded is the number of selected nodes on which the BPF Application
+	// has been successfully loaded and attached.
+	// +optional
+	NodesLoaded int32 `json:"nodesLoaded,omitempty"`
 }
 
 // AttachInfoStateCommon reflects the status for one attach point for a given bpf
@@ -198,6 +489,10 @@ type AttachInfoStateCommon struct {
 	// successfully, and if not, why.
 	// +required
 	LinkStatus LinkStatus `json:"linkStatus"`
+	// metadata is the user-specified metadata added to the link in bpfman,
+	// alongside its uuid.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type BpfProgramStateCommon struct {
@@ -213,6 +508,127 @@ type BpfProgramStateCommon struct {
 	// program is loaded.
 	// +optional
 	ProgramId *uint32 `json:"programId,omitempty"`
+	// loadDurationMs is how long, in milliseconds, bpfman took to load the
+	// program. An application's programs are loaded together, so they all
+	// report the same duration. Not set until the program is loaded.
+	// +optional
+	LoadDurationMs *int64 `json:"loadDurationMs,omitempty"`
+	// attachDurationMs is how long, in milliseconds, bpfman took to attach the
+	// program's links the last time any were attached. Not set until a link is
+	// attached.
+	// +optional
+	AttachDurationMs *int64 `json:"attachDurationMs,omitempty"`
+	// kernelInfo is the kernel's accounting for the program, refreshed each
+	// time the agent checks that the program is still loaded. Not set until
+	// the program is loaded.
+	// +optional
+	KernelInfo *ProgramKernelInfo `json:"kernelInfo,omitempty"`
+}
+
+// ProgramKernelInfo is the kernel's accounting for a loaded eBPF program, so
+// the memory eBPF programs use on a node can be tracked.
+type ProgramKernelInfo struct {
+	// memlockBytes is the memory, in bytes, the kernel has allocated for the
+	// program.
+	// +required
+	MemlockBytes uint32 `json:"memlockBytes"`
+	// jitedSizeBytes is the size, in bytes, of the program's JIT compiled
+	// code. It's 0 if the program isn't JIT compiled.
+	// +required
+	JitedSizeBytes uint32 `json:"jitedSizeBytes"`
+	// mapIds are the ids of the maps the program uses.
+	// +optional
+	MapIds []uint32 `json:"mapIds,omitempty"`
+}
+
+// ResolvedBytecode records the bytecode source that was actually used to load
+// an eBPF application on a given node.
+type ResolvedBytecode struct {
+	// image is the URL of the container image the bytecode was loaded from.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// digest is the digest of the container image the bytecode was loaded from.
+	// It is only set when bpfman reports the image by digest, or when the image
+	// was referenced by digest.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// path is the path of the bytecode object file on the Kubernetes node that
+	// the bytecode was loaded from.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// LinkPersistence is what happens to a program's links when the bpfman agent
+// exits.
+// +kubebuilder:validation:Enum=Persistent;DetachOnAgentExit
+type LinkPersistence string
+
+const (
+	// LinkPersistent keeps the links attached when the agent exits.
+	LinkPersistent LinkPersistence = "Persistent"
+	// LinkDetachOnAgentExit detaches the links when the agent shuts down.
+	LinkDetachOnAgentExit LinkPersistence = "DetachOnAgentExit"
+)
+
+// TailCallInfo defines the programs that a program tail calls.
+type TailCallInfo struct {
+	// mapName is a required field and is the name of the BPF_MAP_TYPE_PROG_ARRAY
+	// map, as declared in the bytecode, that the program uses for tail calls.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	MapName string `json:"mapName"`
+
+	// targets is a required field that lists the programs to add to the map.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Targets []TailCallTarget `json:"targets"`
+}
+
+// TailCallTarget is a program that is added to a tail call map.
+type TailCallTarget struct {
+	// index is a required field and is the key in the map at which the program
+	// is added. It's the index the calling program passes to bpf_tail_call().
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	Index uint32 `json:"index"`
+
+	// bpfFunctionName is a required field and is the name of the program to add
+	// to the map. It must be the name of another program in the same
+	// application.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	BpfFunctionName string `json:"bpfFunctionName"`
+}
+
+// ReconcileError describes the most recent error the bpfman agent hit while
+// reconciling an eBPF application on a given node.
+type ReconcileError struct {
+	// message is the error message. Long messages are truncated.
+	// +required
+	Message string `json:"message"`
+	// time is when the error was first seen. It isn't updated while the same
+	// error keeps occurring.
+	// +required
+	Time metav1.Time `json:"time"`
+}
+
+// PriorityRange is a range of attach priorities that the bpfman agent can
+// choose a link's priority from.
+// +kubebuilder:validation:XValidation:rule="self.min <= self.max",message="min must not be greater than max"
+type PriorityRange struct {
+	// min is a required field and is the lowest priority in the range. It must
+	// be between 0 and 1000.
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	Min int32 `json:"min"`
+
+	// max is a required field and is the highest priority in the range. It
+	// must be between 0 and 1000, and not less than min.
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	Max int32 `json:"max"`
 }
 
 // PullPolicy describes a policy for if/when to pull a container image
@@ -242,6 +658,33 @@ type ByteCodeSelector struct {
 	// +optional
 	// +kubebuilder:validation:Pattern=`^(/[^/\0]+)+/?$`
 	Path *string `json:"path,omitempty"`
+
+	// configMapRef is an optional field and used to specify a bytecode object
+	// stored in a ConfigMap. Because of the ConfigMap size limit, the bytecode
+	// can be at most 1MiB.
+	// +optional
+	ConfigMapRef *ByteCodeConfigMapSelector `json:"configMapRef,omitempty"`
+}
+
+// ByteCodeConfigMapSelector defines how to reference bytecode stored in a
+// ConfigMap.
+type ByteCodeConfigMapSelector struct {
+	// name is a required field and is the name of the ConfigMap which contains
+	// the bytecode.
+	// +required
+	Name string `json:"name"`
+
+	// namespace is a required field and is the namespace of the ConfigMap which
+	// contains the bytecode. For a BpfApplication, it must be the
+	// BpfApplication's namespace.
+	// +required
+	Namespace string `json:"namespace"`
+
+	// key is a required field and is the key in the ConfigMap that holds the
+	// bytecode. The bytecode is read from binaryData if the key is present
+	// there, otherwise it's read from data as a base64 encoded string.
+	// +required
+	Key string `json:"key"`
 }
 
 // ByteCodeImage defines how to specify a bytecode container image.
@@ -275,6 +718,16 @@ type ByteCodeImage struct {
 	// the credentials to access the image repository.
 	// +optional
 	ImagePullSecret *ImagePullSecretSelector `json:"imagePullSecret,omitempty"`
+
+	// refreshInterval is an optional field that makes the agent check the
+	// image's tag this often and, when the tag refers to a different image,
+	// pull it and reload the programs from it. The new image must be seen on
+	// two checks in a row before the programs are reloaded, so a tag that
+	// keeps moving doesn't keep reloading them. Intervals shorter than a
+	// minute are treated as a minute. Images referenced by digest are never
+	// refreshed.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
 }
 
 // ImagePullSecretSelector defines the name and namespace of an image pull secret.
@@ -315,6 +768,16 @@ const (
 	// BpfAppCondDeleteError indicates that the BPF Application was marked for
 	// deletion, but deletion was unsuccessful on one or more nodes.
 	BpfAppCondDeleteError BpfApplicationConditionType = "DeleteError"
+
+	// BpfAppCondPartialNodeCoverage indicates that the BPF Application has
+	// been loaded and attached on some of the selected nodes, but an error has
+	// occurred on the others.
+	BpfAppCondPartialNodeCoverage BpfApplicationConditionType = "PartialNodeCoverage"
+
+	// BpfAppCondReconciling indicates that the BPF Application's spec has
+	// changed and some nodes that reported an error for the previous spec
+	// haven't applied the new one yet.
+	BpfAppCondReconciling BpfApplicationConditionType = "Reconciling"
 )
 
 // Condition is a helper method to promote any given BpfApplicationConditionType
@@ -373,6 +836,28 @@ func (b BpfApplicationConditionType) Condition(message string) metav1.Condition
 			Reason:  "DeleteError",
 			Message: message,
 		}
+	case BpfAppCondPartialNodeCoverage:
+		if len(message) == 0 {
+			message = "BPF application configuration applied on some of the selected nodes"
+		}
+		condType := string(BpfAppCondPartialNodeCoverage)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "PartialNodeCoverage",
+			Message: message,
+		}
+	case BpfAppCondReconciling:
+		if len(message) == 0 {
+			message = "Waiting for the nodes to apply the updated BPF application configuration"
+		}
+		condType := string(BpfAppCondReconciling)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Reconciling",
+			Message: message,
+		}
 	}
 
 	return cond
@@ -407,6 +892,92 @@ const (
 	// BpfAppStateCondUnloaded indicates that the BPF Application was marked
 	// for deletion, and has been successfully unloaded.
 	BpfAppStateCondUnloaded BpfApplicationStateConditionType = "Unloaded"
+
+	// BpfAppStateCondAttachConflict indicates that the BPF Application has
+	// been loaded and attached on the given node, but one or more of its links
+	// shares an interface with another attachment in a way that is known not
+	// to work as expected.
+	BpfAppStateCondAttachConflict BpfApplicationStateConditionType = "AttachConflict"
+
+	// BpfAppStateCondWaitingForContainers indicates that the BPF Application
+	// has been loaded on the given node, but one or more of its container
+	// selectors doesn't match any containers yet, so nothing is attached for
+	// them.
+	BpfAppStateCondWaitingForContainers BpfApplicationStateConditionType = "WaitingForContainers"
+
+	// BpfAppStateCondProgramTypeMismatch indicates that bpfman loaded one or
+	// more of the BPF Application's programs as a different type of program
+	// than requested on the given node, so the programs were unloaded again.
+	BpfAppStateCondProgramTypeMismatch BpfApplicationStateConditionType = "ProgramTypeMismatch"
+
+	// BpfAppStateCondTopologyMismatch indicates that the BPF Application has
+	// been loaded and attached on the given node, but the node's CPU or NUMA
+	// layout isn't the one given in the application's expectedTopology.
+	BpfAppStateCondTopologyMismatch BpfApplicationStateConditionType = "TopologyMismatch"
+
+	// BpfAppStateCondNameCollision indicates that the BPF Application has been
+	// loaded and attached on the given node, but the node also has a
+	// BpfApplicationState that belongs to another application with the same
+	// name, such as one that was deleted before the agent could unload it.
+	// That BpfApplicationState is left alone.
+	BpfAppStateCondNameCollision BpfApplicationStateConditionType = "NameCollision"
+
+	// BpfAppStateCondKernelTooOld indicates that the BPF Application has not
+	// been loaded on the given node because the node's kernel is older than
+	// the application's minKernelVersion.
+	BpfAppStateCondKernelTooOld BpfApplicationStateConditionType = "KernelTooOld"
+
+	// BpfAppStateCondGatedOff indicates that the BPF Application has not been
+	// loaded on the given node because the feature gate named by its
+	// bpfman.io/feature-gate annotation isn't enabled.
+	BpfAppStateCondGatedOff BpfApplicationStateConditionType = "GatedOff"
+
+	// BpfAppStateCondUnsupportedDirection indicates that one or more of the
+	// BPF Application's TCX links weren't attached on the given node because
+	// their interface doesn't support the requested direction.
+	BpfAppStateCondUnsupportedDirection BpfApplicationStateConditionType = "UnsupportedDirection"
+
+	// BpfAppStateCondForeignXdpProgram indicates that one or more of the BPF
+	// Application's XDP links weren't attached on the given node because
+	// their interface already has an XDP program that bpfman didn't attach.
+	BpfAppStateCondForeignXdpProgram BpfApplicationStateConditionType = "ForeignXdpProgram"
+
+	// BpfAppStateCondTargetNotFound indicates that one or more of the BPF
+	// Application's uprobe links weren't attached on the given node because
+	// their target is an absolute path that doesn't exist on the node.
+	BpfAppStateCondTargetNotFound BpfApplicationStateConditionType = "TargetNotFound"
+
+	// BpfAppStateCondSharedMapNotFound indicates that the BPF Application has
+	// not been loaded on the given node because no program on the node has
+	// the map named by its sharedMap yet.
+	BpfAppStateCondSharedMapNotFound BpfApplicationStateConditionType = "SharedMapNotFound"
+
+	// BpfAppStateCondGlobalDataNotFound indicates that the BPF Application has
+	// not been loaded on the given node because a ConfigMap, Secret or key
+	// referenced by its globalDataFrom doesn't exist.
+	BpfAppStateCondGlobalDataNotFound BpfApplicationStateConditionType = "GlobalDataNotFound"
+
+	// BpfAppStateCondBpfmanUnavailable indicates that the BPF Application
+	// couldn't be loaded on the given node because bpfman couldn't be
+	// reached. The bpfman agent keeps retrying until it can.
+	BpfAppStateCondBpfmanUnavailable BpfApplicationStateConditionType = "BpfmanUnavailable"
+
+	// BpfAppStateCondNoProgramsDefined indicates that the BPF Application
+	// doesn't define any programs, so nothing is loaded on the given node.
+	// Any programs it had before are unloaded.
+	BpfAppStateCondNoProgramsDefined BpfApplicationStateConditionType = "NoProgramsDefined"
+
+	// BpfAppStateCondFunctionNotInBytecode indicates that the BPF Application
+	// has not been loaded on the given node because one or more of its
+	// programs' bpfFunctionNames aren't programs in its bytecode.
+	BpfAppStateCondFunctionNotInBytecode BpfApplicationStateConditionType = "FunctionNotInBytecode"
+
+	// BpfAppStateCondMissingDispatcherReturn indicates that the BPF
+	// Application has been loaded and attached on the given node, but one or
+	// more of its XDP links' proceedOn doesn't include DispatcherReturn, so
+	// a program returning to the dispatcher stops the rest of the chain from
+	// running.
+	BpfAppStateCondMissingDispatcherReturn BpfApplicationStateConditionType = "MissingDispatcherReturn"
 )
 
 // Condition is a helper method to promote any given
@@ -440,12 +1011,20 @@ func (b BpfApplicationStateConditionType) Condition() metav1.Condition {
 			Reason:  "Error",
 			Message: "An error has occurred",
 		}
+	case BpfAppStateCondProgramListChangedError:
+		condType := string(BpfAppStateCondProgramListChangedError)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ProgramListChangedError",
+			Message: "The application's programs changed since it was loaded",
+		}
 	case BpfAppStateCondUnloadError:
 		condType := string(BpfAppStateCondUnloadError)
 		cond = metav1.Condition{
 			Type:    condType,
 			Status:  metav1.ConditionTrue,
-			Reason:  "Unload Error",
+			Reason:  "UnloadError",
 			Message: "Unload failed for one or more programs",
 		}
 	case BpfAppStateCondUnloaded:
@@ -456,6 +1035,134 @@ func (b BpfApplicationStateConditionType) Condition() metav1.Condition {
 			Reason:  "Unloaded",
 			Message: "The application has been successfully unloaded",
 		}
+	case BpfAppStateCondAttachConflict:
+		condType := string(BpfAppStateCondAttachConflict)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "AttachConflict",
+			Message: "Attached, but one or more links conflict with other attachments on the same interface",
+		}
+	case BpfAppStateCondWaitingForContainers:
+		condType := string(BpfAppStateCondWaitingForContainers)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "WaitingForContainers",
+			Message: "Loaded, but no containers match one or more container selectors yet",
+		}
+	case BpfAppStateCondProgramTypeMismatch:
+		condType := string(BpfAppStateCondProgramTypeMismatch)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ProgramTypeMismatch",
+			Message: "One or more programs in the bytecode are not the type of program requested",
+		}
+	case BpfAppStateCondTopologyMismatch:
+		condType := string(BpfAppStateCondTopologyMismatch)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "TopologyMismatch",
+			Message: "Attached, but the node's topology doesn't match the expected topology",
+		}
+	case BpfAppStateCondNameCollision:
+		condType := string(BpfAppStateCondNameCollision)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "NameCollision",
+			Message: "Attached, but the node has a BpfApplicationState for another application with the same name",
+		}
+	case BpfAppStateCondKernelTooOld:
+		condType := string(BpfAppStateCondKernelTooOld)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "KernelTooOld",
+			Message: "Not loaded, the node's kernel is older than the minimum kernel version",
+		}
+	case BpfAppStateCondGatedOff:
+		condType := string(BpfAppStateCondGatedOff)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "GatedOff",
+			Message: "Not loaded, the application's feature gate isn't enabled",
+		}
+	case BpfAppStateCondUnsupportedDirection:
+		condType := string(BpfAppStateCondUnsupportedDirection)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "UnsupportedDirection",
+			Message: "One or more TCX links are for a direction their interface doesn't support",
+		}
+	case BpfAppStateCondForeignXdpProgram:
+		condType := string(BpfAppStateCondForeignXdpProgram)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ForeignXdpProgram",
+			Message: "One or more XDP links weren't attached, as their interface has an XDP program that bpfman didn't attach",
+		}
+	case BpfAppStateCondTargetNotFound:
+		condType := string(BpfAppStateCondTargetNotFound)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "TargetNotFound",
+			Message: "One or more uprobe links weren't attached, as their target doesn't exist on the node",
+		}
+	case BpfAppStateCondSharedMapNotFound:
+		condType := string(BpfAppStateCondSharedMapNotFound)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "SharedMapNotFound",
+			Message: "Not loaded, no program on the node has the map named by sharedMap",
+		}
+	case BpfAppStateCondGlobalDataNotFound:
+		condType := string(BpfAppStateCondGlobalDataNotFound)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "GlobalDataNotFound",
+			Message: "Not loaded, a ConfigMap, Secret or key referenced by globalDataFrom doesn't exist",
+		}
+	case BpfAppStateCondBpfmanUnavailable:
+		condType := string(BpfAppStateCondBpfmanUnavailable)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "BpfmanUnavailable",
+			Message: "Not loaded, bpfman on the node can't be reached",
+		}
+	case BpfAppStateCondNoProgramsDefined:
+		condType := string(BpfAppStateCondNoProgramsDefined)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "NoProgramsDefined",
+			Message: "Nothing is loaded, the application doesn't define any programs",
+		}
+	case BpfAppStateCondFunctionNotInBytecode:
+		condType := string(BpfAppStateCondFunctionNotInBytecode)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "FunctionNotInBytecode",
+			Message: "Not loaded, one or more programs aren't in the bytecode",
+		}
+	case BpfAppStateCondMissingDispatcherReturn:
+		condType := string(BpfAppStateCondMissingDispatcherReturn)
+		cond = metav1.Condition{
+			Type:    condType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "MissingDispatcherReturn",
+			Message: "Attached, but one or more XDP links' proceedOn doesn't include DispatcherReturn",
+		}
 	}
 	return cond
 }
@@ -477,6 +1184,14 @@ const (
 	NotSelected AppLoadStatus = "NotSelected"
 	// The program list has changed which is not allowed
 	ProgListChangedError AppLoadStatus = "ProgramListChangedError"
+	// One or more programs were loaded as a different type than requested
+	ProgTypeMismatchError AppLoadStatus = "ProgramTypeMismatch"
+	// The node's kernel is older than the app's minimum kernel version
+	KernelTooOld AppLoadStatus = "KernelTooOld"
+	// The feature gate named by the app's annotation isn't enabled
+	GatedOff AppLoadStatus = "GatedOff"
+	// One or more programs' functions aren't in the app's bytecode
+	FunctionNotInBytecode AppLoadStatus = "FunctionNotInBytecode"
 )
 
 type ProgramLinkStatus string
@@ -490,6 +1205,18 @@ const (
 	ProgAttachError ProgramLinkStatus = "Error"
 	// There was an error updating the attach info
 	UpdateAttachInfoError ProgramLinkStatus = "UpdateAttachInfoError"
+	// One or more attachments are waiting for their interface to come up
+	ProgAttachDeferred ProgramLinkStatus = "Deferred"
+	// One or more container selectors don't match any containers yet
+	ProgAttachWaitingForContainers ProgramLinkStatus = "WaitingForContainers"
+	// One or more attachments are for a direction the interface doesn't support
+	ProgAttachUnsupportedDirection ProgramLinkStatus = "UnsupportedDirection"
+	// One or more attachments are to an interface with a foreign XDP program
+	ProgAttachForeignXdpProgram ProgramLinkStatus = "ForeignXdpProgram"
+	// One or more attachments are to a uprobe target that doesn't exist
+	ProgAttachTargetNotFound ProgramLinkStatus = "TargetNotFound"
+	// Some attachments failed, but the app's failurePolicy is BestEffort
+	ProgAttachPartial ProgramLinkStatus = "PartiallyAttached"
 )
 
 type LinkStatus string
@@ -503,4 +1230,18 @@ const (
 	ApAttachError LinkStatus = "AttachError"
 	// A detach was attempted, but there was an error
 	ApDetachError LinkStatus = "DetachError"
+	// The attach is waiting for the interface to come up
+	ApAttachDeferred LinkStatus = "AttachDeferred"
+	// The target's build-id doesn't match the requested build-id
+	ApBuildIDMismatch LinkStatus = "BuildIdMismatch"
+	// The interface doesn't support the requested TCX direction
+	ApUnsupportedDirection LinkStatus = "UnsupportedDirection"
+	// The interface has an XDP program that bpfman didn't attach
+	ApForeignXdpProgram LinkStatus = "ForeignXdpProgram"
+	// The uprobe's target doesn't exist on the node
+	ApTargetNotFound LinkStatus = "TargetNotFound"
+	// Every priority in the link's autoPriority range is in use
+	ApPriorityRangeExhausted LinkStatus = "PriorityRangeExhausted"
+	// The link was detached because another of the program's links failed
+	ApAttachRolledBack LinkStatus = "RolledBack"
 )
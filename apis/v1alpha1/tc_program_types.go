@@ -73,6 +73,16 @@ type TcAttachInfo struct {
 	// +kubebuilder:default:=1000
 	Priority int32 `json:"priority,omitempty"`
 
+	// autoPriority is an optional field that asks the bpfman agent to choose
+	// the priority. When set, priority is ignored, and each link is given the
+	// lowest priority from min to max that isn't already used by another
+	// TC program on the same interface in the same direction on the node. The
+	// assigned priority is reported in the link's status. If every priority
+	// in the range is in use, the link isn't attached and its linkStatus is
+	// PriorityRangeExhausted.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is an optional field and allows the user to call other TC programs
 	// in a chain, or not call the next program in a chain based on the exit code
 	// of a TC program. Allowed values, which are the possible exit codes from a TC
@@ -84,9 +94,29 @@ type TcAttachInfo struct {
 	// using the default values, if a TC program returns Pipe, the next TC
 	// program in the chain will be called. If a TC program returns Stolen, the
 	// next TC program in the chain will NOT be called.
+	// The values are a set, so their order doesn't matter and each may only be
+	// listed once.
 	// +optional
 	// +kubebuilder:default:={Pipe,DispatcherReturn}
+	// +listType=set
 	ProceedOn []TcProceedOnValue `json:"proceedOn,omitempty"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each TC link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type TcProgramInfoState struct {
@@ -125,6 +155,11 @@ type TcAttachInfoState struct {
 	// +kubebuilder:validation:Maximum=1000
 	Priority int32 `json:"priority"`
 
+	// autoPriority is the range the priority was chosen from, if it was
+	// assigned by the bpfman agent rather than given in the link.
+	// +optional
+	AutoPriority *PriorityRange `json:"autoPriority,omitempty"`
+
 	// proceedOn is the provisioned list of proceedOn values. proceedOn allows the
 	// user to call other TC programs in a chain, or not call the next program in a
 	// chain based on the exit code of a TC program .Multiple values are supported.
@@ -35,6 +35,7 @@ type UprobeProgramInfo struct {
 	Links []UprobeAttachInfo `json:"links,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.buildId) || self.target.startsWith('/')",message="buildId requires an absolute target path"
 type UprobeAttachInfo struct {
 	// function is an optional field and specifies the name of a user-space function
 	// to attach the UProbe or URetProbe program. If not provided, the eBPF program
@@ -58,6 +59,17 @@ type UprobeAttachInfo struct {
 	// +required
 	Target string `json:"target"`
 
+	// buildId is an optional field and is the GNU build-id of the target, as
+	// hex digits such as those printed by readelf -n. If set, the build-id of
+	// the target in each selected container is checked before attaching, and
+	// the link isn't attached if it differs, so that the program isn't
+	// attached to the wrong binary after an image update.
+	// buildId requires an absolute target path.
+	// +optional
+	// +kubebuilder:validation:Pattern="^[0-9a-fA-F]+$"
+	// +kubebuilder:validation:MaxLength=128
+	BuildID string `json:"buildId,omitempty"`
+
 	// pid is an optional field and if provided, limits the execution of the UProbe
 	// or URetProbe to the provided process identification number (PID). If pid is
 	// not provided, the UProbe or URetProbe executes for all PIDs.
@@ -69,6 +81,23 @@ type UprobeAttachInfo struct {
 	// specified, the eBPF program will be attached in the bpfman container.
 	// uprobe.
 	Containers ContainerSelector `json:"containers"`
+
+	// metadata is an optional field of key/value pairs that are added to the
+	// metadata bpfman keeps for each UProbe or URetProbe link created from this entry, so
+	// that external tooling can correlate the links with it. There may be at
+	// most 16 entries, keys must not exceed 63 characters, values must not
+	// exceed 256 characters, and the keys the bpfman agent sets itself, such as
+	// bpfman.io/uuid with the default metadata key prefix, are reserved.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	// +kubebuilder:validation:XValidation:rule="self.all(k, size(k) > 0 && size(k) <= 63 && size(self[k]) <= 256)",message="metadata keys must be 1 to 63 characters and values must not exceed 256 characters"
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// enabled is an optional field. When set to false, the links created from
+	// this entry are detached, but the entry is kept so they can be attached
+	// again by setting it back to true. Defaults to true.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 type UprobeProgramInfoState struct {
@@ -99,6 +128,11 @@ type UprobeAttachInfoState struct {
 	// +required
 	Target string `json:"target"`
 
+	// buildId is the provisioned build-id the target was checked against
+	// before attaching.
+	// +optional
+	BuildID string `json:"buildId,omitempty"`
+
 	// pid is the provisioned pid. If set, pid limits the execution of the UProbe
 	// or URetProbe to the provided process identification number (PID). If pid is
 	// not provided, the UProbe or URetProbe executes for all PIDs.
@@ -25,6 +25,27 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalByteCode) DeepCopyInto(out *AdditionalByteCode) {
+	*out = *in
+	in.ByteCode.DeepCopyInto(&out.ByteCode)
+	if in.Programs != nil {
+		in, out := &in.Programs, &out.Programs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalByteCode.
+func (in *AdditionalByteCode) DeepCopy() *AdditionalByteCode {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalByteCode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AttachInfoStateCommon) DeepCopyInto(out *AttachInfoStateCommon) {
 	*out = *in
@@ -33,6 +54,13 @@ func (in *AttachInfoStateCommon) DeepCopyInto(out *AttachInfoStateCommon) {
 		*out = new(uint32)
 		**out = **in
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttachInfoStateCommon.
@@ -65,12 +93,50 @@ func (in *BpfAppCommon) DeepCopyInto(out *BpfAppCommon) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.GlobalDataFrom != nil {
+		in, out := &in.GlobalDataFrom, &out.GlobalDataFrom
+		*out = make([]GlobalDataSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GlobalDataOverrides != nil {
+		in, out := &in.GlobalDataOverrides, &out.GlobalDataOverrides
+		*out = make([]GlobalDataOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.ByteCode.DeepCopyInto(&out.ByteCode)
+	if in.AdditionalByteCode != nil {
+		in, out := &in.AdditionalByteCode, &out.AdditionalByteCode
+		*out = make([]AdditionalByteCode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.MapOwnerSelector != nil {
 		in, out := &in.MapOwnerSelector, &out.MapOwnerSelector
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SharedMap != nil {
+		in, out := &in.SharedMap, &out.SharedMap
+		*out = new(SharedMapReference)
+		**out = **in
+	}
+	if in.BpfAppStateLabels != nil {
+		in, out := &in.BpfAppStateLabels, &out.BpfAppStateLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExpectedTopology != nil {
+		in, out := &in.ExpectedTopology, &out.ExpectedTopology
+		*out = new(NodeTopology)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BpfAppCommon.
@@ -192,6 +258,11 @@ func (in *BpfApplicationProgram) DeepCopyInto(out *BpfApplicationProgram) {
 		*out = new(UprobeProgramInfo)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TailCalls != nil {
+		in, out := &in.TailCalls, &out.TailCalls
+		*out = new(TailCallInfo)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BpfApplicationProgram.
@@ -336,6 +407,16 @@ func (in *BpfApplicationStateStatus) DeepCopyInto(out *BpfApplicationStateStatus
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ResolvedBytecode != nil {
+		in, out := &in.ResolvedBytecode, &out.ResolvedBytecode
+		*out = new(ResolvedBytecode)
+		**out = **in
+	}
+	if in.LastError != nil {
+		in, out := &in.LastError, &out.LastError
+		*out = new(ReconcileError)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -363,6 +444,21 @@ func (in *BpfProgramStateCommon) DeepCopyInto(out *BpfProgramStateCommon) {
 		*out = new(uint32)
 		**out = **in
 	}
+	if in.LoadDurationMs != nil {
+		in, out := &in.LoadDurationMs, &out.LoadDurationMs
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AttachDurationMs != nil {
+		in, out := &in.AttachDurationMs, &out.AttachDurationMs
+		*out = new(int64)
+		**out = **in
+	}
+	if in.KernelInfo != nil {
+		in, out := &in.KernelInfo, &out.KernelInfo
+		*out = new(ProgramKernelInfo)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BpfProgramStateCommon.
@@ -375,6 +471,21 @@ func (in *BpfProgramStateCommon) DeepCopy() *BpfProgramStateCommon {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByteCodeConfigMapSelector) DeepCopyInto(out *ByteCodeConfigMapSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByteCodeConfigMapSelector.
+func (in *ByteCodeConfigMapSelector) DeepCopy() *ByteCodeConfigMapSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ByteCodeConfigMapSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ByteCodeImage) DeepCopyInto(out *ByteCodeImage) {
 	*out = *in
@@ -383,6 +494,11 @@ func (in *ByteCodeImage) DeepCopyInto(out *ByteCodeImage) {
 		*out = new(ImagePullSecretSelector)
 		**out = **in
 	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByteCodeImage.
@@ -408,6 +524,11 @@ func (in *ByteCodeSelector) DeepCopyInto(out *ByteCodeSelector) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(ByteCodeConfigMapSelector)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByteCodeSelector.
@@ -473,6 +594,11 @@ func (in *ClBpfApplicationProgram) DeepCopyInto(out *ClBpfApplicationProgram) {
 		*out = new(ClTracepointProgramInfo)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TailCalls != nil {
+		in, out := &in.TailCalls, &out.TailCalls
+		*out = new(TailCallInfo)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClBpfApplicationProgram.
@@ -584,6 +710,16 @@ func (in *ClBpfApplicationStateStatus) DeepCopyInto(out *ClBpfApplicationStateSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ResolvedBytecode != nil {
+		in, out := &in.ResolvedBytecode, &out.ResolvedBytecode
+		*out = new(ResolvedBytecode)
+		**out = **in
+	}
+	if in.LastError != nil {
+		in, out := &in.LastError, &out.LastError
+		*out = new(ReconcileError)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -612,6 +748,11 @@ func (in *ClContainerSelector) DeepCopyInto(out *ClContainerSelector) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ContainerImages != nil {
+		in, out := &in.ContainerImages, &out.ContainerImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClContainerSelector.
@@ -627,6 +768,18 @@ func (in *ClContainerSelector) DeepCopy() *ClContainerSelector {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClFentryAttachInfo) DeepCopyInto(out *ClFentryAttachInfo) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClFentryAttachInfo.
@@ -677,7 +830,9 @@ func (in *ClFentryProgramInfo) DeepCopyInto(out *ClFentryProgramInfo) {
 	if in.Links != nil {
 		in, out := &in.Links, &out.Links
 		*out = make([]ClFentryAttachInfo, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -717,6 +872,18 @@ func (in *ClFentryProgramInfoState) DeepCopy() *ClFentryProgramInfoState {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClFexitAttachInfo) DeepCopyInto(out *ClFexitAttachInfo) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClFexitAttachInfo.
@@ -767,7 +934,9 @@ func (in *ClFexitProgramInfo) DeepCopyInto(out *ClFexitProgramInfo) {
 	if in.Links != nil {
 		in, out := &in.Links, &out.Links
 		*out = make([]ClFexitAttachInfo, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -807,6 +976,18 @@ func (in *ClFexitProgramInfoState) DeepCopy() *ClFexitProgramInfoState {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClKprobeAttachInfo) DeepCopyInto(out *ClKprobeAttachInfo) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClKprobeAttachInfo.
@@ -841,7 +1022,9 @@ func (in *ClKprobeProgramInfo) DeepCopyInto(out *ClKprobeProgramInfo) {
 	if in.Links != nil {
 		in, out := &in.Links, &out.Links
 		*out = make([]ClKprobeAttachInfo, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -880,6 +1063,18 @@ func (in *ClKprobeProgramInfoState) DeepCopy() *ClKprobeProgramInfoState {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClKretprobeAttachInfo) DeepCopyInto(out *ClKretprobeAttachInfo) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClKretprobeAttachInfo.
@@ -914,7 +1109,9 @@ func (in *ClKretprobeProgramInfo) DeepCopyInto(out *ClKretprobeProgramInfo) {
 	if in.Links != nil {
 		in, out := &in.Links, &out.Links
 		*out = make([]ClKretprobeAttachInfo, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -975,11 +1172,28 @@ func (in *ClTcAttachInfo) DeepCopyInto(out *ClTcAttachInfo) {
 		*out = new(ClNetworkNamespaceSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]TcProceedOnValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClTcAttachInfo.
@@ -996,6 +1210,11 @@ func (in *ClTcAttachInfo) DeepCopy() *ClTcAttachInfo {
 func (in *ClTcAttachInfoState) DeepCopyInto(out *ClTcAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]TcProceedOnValue, len(*in))
@@ -1066,6 +1285,23 @@ func (in *ClTcxAttachInfo) DeepCopyInto(out *ClTcxAttachInfo) {
 		*out = new(ClNetworkNamespaceSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClTcxAttachInfo.
@@ -1082,6 +1318,11 @@ func (in *ClTcxAttachInfo) DeepCopy() *ClTcxAttachInfo {
 func (in *ClTcxAttachInfoState) DeepCopyInto(out *ClTcxAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClTcxAttachInfoState.
@@ -1141,6 +1382,18 @@ func (in *ClTcxProgramInfoState) DeepCopy() *ClTcxProgramInfoState {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClTracepointAttachInfo) DeepCopyInto(out *ClTracepointAttachInfo) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClTracepointAttachInfo.
@@ -1175,7 +1428,9 @@ func (in *ClTracepointProgramInfo) DeepCopyInto(out *ClTracepointProgramInfo) {
 	if in.Links != nil {
 		in, out := &in.Links, &out.Links
 		*out = make([]ClTracepointAttachInfo, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -1224,6 +1479,18 @@ func (in *ClUprobeAttachInfo) DeepCopyInto(out *ClUprobeAttachInfo) {
 		*out = new(ClContainerSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClUprobeAttachInfo.
@@ -1310,16 +1577,40 @@ func (in *ClUprobeProgramInfoState) DeepCopy() *ClUprobeProgramInfoState {
 func (in *ClXdpAttachInfo) DeepCopyInto(out *ClXdpAttachInfo) {
 	*out = *in
 	in.InterfaceSelector.DeepCopyInto(&out.InterfaceSelector)
+	if in.AdditionalInterfaceSelectors != nil {
+		in, out := &in.AdditionalInterfaceSelectors, &out.AdditionalInterfaceSelectors
+		*out = make([]InterfaceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.NetworkNamespaces != nil {
 		in, out := &in.NetworkNamespaces, &out.NetworkNamespaces
 		*out = new(ClNetworkNamespaceSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]XdpProceedOnValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClXdpAttachInfo.
@@ -1336,6 +1627,11 @@ func (in *ClXdpAttachInfo) DeepCopy() *ClXdpAttachInfo {
 func (in *ClXdpAttachInfoState) DeepCopyInto(out *ClXdpAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]XdpProceedOnValue, len(*in))
@@ -1363,6 +1659,21 @@ func (in *ClXdpProgramInfo) DeepCopyInto(out *ClXdpProgramInfo) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InterfaceSelector != nil {
+		in, out := &in.InterfaceSelector, &out.InterfaceSelector
+		*out = new(InterfaceSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ProceedOn != nil {
+		in, out := &in.ProceedOn, &out.ProceedOn
+		*out = make([]XdpProceedOnValue, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClXdpProgramInfo.
@@ -1523,6 +1834,11 @@ func (in *ContainerSelector) DeepCopyInto(out *ContainerSelector) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ContainerImages != nil {
+		in, out := &in.ContainerImages, &out.ContainerImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerSelector.
@@ -1535,6 +1851,78 @@ func (in *ContainerSelector) DeepCopy() *ContainerSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalDataKeySelector) DeepCopyInto(out *GlobalDataKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalDataKeySelector.
+func (in *GlobalDataKeySelector) DeepCopy() *GlobalDataKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalDataKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalDataOverride) DeepCopyInto(out *GlobalDataOverride) {
+	*out = *in
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+	if in.GlobalData != nil {
+		in, out := &in.GlobalData, &out.GlobalData
+		*out = make(map[string][]byte, len(*in))
+		for key, val := range *in {
+			var outVal []byte
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]byte, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalDataOverride.
+func (in *GlobalDataOverride) DeepCopy() *GlobalDataOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalDataOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalDataSource) DeepCopyInto(out *GlobalDataSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(GlobalDataKeySelector)
+		**out = **in
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(GlobalDataKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalDataSource.
+func (in *GlobalDataSource) DeepCopy() *GlobalDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImagePullSecretSelector) DeepCopyInto(out *ImagePullSecretSelector) {
 	*out = *in
@@ -1593,11 +1981,21 @@ func (in *InterfaceSelector) DeepCopyInto(out *InterfaceSelector) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.InterfaceIndexes != nil {
+		in, out := &in.InterfaceIndexes, &out.InterfaceIndexes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 	if in.PrimaryNodeInterface != nil {
 		in, out := &in.PrimaryNodeInterface, &out.PrimaryNodeInterface
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ExcludeInterfaces != nil {
+		in, out := &in.ExcludeInterfaces, &out.ExcludeInterfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterfaceSelector.
@@ -1626,16 +2024,280 @@ func (in *NetworkNamespaceSelector) DeepCopy() *NetworkNamespaceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeBpfProgram) DeepCopyInto(out *NodeBpfProgram) {
+	*out = *in
+	if in.Links != nil {
+		in, out := &in.Links, &out.Links
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+	if in.MapIds != nil {
+		in, out := &in.MapIds, &out.MapIds
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeBpfProgram.
+func (in *NodeBpfProgram) DeepCopy() *NodeBpfProgram {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeBpfProgram)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeBpfState) DeepCopyInto(out *NodeBpfState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeBpfState.
+func (in *NodeBpfState) DeepCopy() *NodeBpfState {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeBpfState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeBpfState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeBpfStateList) DeepCopyInto(out *NodeBpfStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeBpfState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeBpfStateList.
+func (in *NodeBpfStateList) DeepCopy() *NodeBpfStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeBpfStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeBpfStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeBpfStateStatus) DeepCopyInto(out *NodeBpfStateStatus) {
+	*out = *in
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.Programs != nil {
+		in, out := &in.Programs, &out.Programs
+		*out = make([]NodeBpfProgram, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeBpfStateStatus.
+func (in *NodeBpfStateStatus) DeepCopy() *NodeBpfStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeBpfStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTopology) DeepCopyInto(out *NodeTopology) {
+	*out = *in
+	if in.CPUs != nil {
+		in, out := &in.CPUs, &out.CPUs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NUMANodes != nil {
+		in, out := &in.NUMANodes, &out.NUMANodes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTopology.
+func (in *NodeTopology) DeepCopy() *NodeTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityRange) DeepCopyInto(out *PriorityRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityRange.
+func (in *PriorityRange) DeepCopy() *PriorityRange {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgramKernelInfo) DeepCopyInto(out *ProgramKernelInfo) {
+	*out = *in
+	if in.MapIds != nil {
+		in, out := &in.MapIds, &out.MapIds
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgramKernelInfo.
+func (in *ProgramKernelInfo) DeepCopy() *ProgramKernelInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgramKernelInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileError) DeepCopyInto(out *ReconcileError) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileError.
+func (in *ReconcileError) DeepCopy() *ReconcileError {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedBytecode) DeepCopyInto(out *ResolvedBytecode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedBytecode.
+func (in *ResolvedBytecode) DeepCopy() *ResolvedBytecode {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedBytecode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedMapReference) DeepCopyInto(out *SharedMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedMapReference.
+func (in *SharedMapReference) DeepCopy() *SharedMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TailCallInfo) DeepCopyInto(out *TailCallInfo) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]TailCallTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TailCallInfo.
+func (in *TailCallInfo) DeepCopy() *TailCallInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(TailCallInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TailCallTarget) DeepCopyInto(out *TailCallTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TailCallTarget.
+func (in *TailCallTarget) DeepCopy() *TailCallTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(TailCallTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TcAttachInfo) DeepCopyInto(out *TcAttachInfo) {
 	*out = *in
 	in.InterfaceSelector.DeepCopyInto(&out.InterfaceSelector)
 	in.NetworkNamespaces.DeepCopyInto(&out.NetworkNamespaces)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]TcProceedOnValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TcAttachInfo.
@@ -1652,6 +2314,11 @@ func (in *TcAttachInfo) DeepCopy() *TcAttachInfo {
 func (in *TcAttachInfoState) DeepCopyInto(out *TcAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]TcProceedOnValue, len(*in))
@@ -1718,6 +2385,23 @@ func (in *TcxAttachInfo) DeepCopyInto(out *TcxAttachInfo) {
 	*out = *in
 	in.InterfaceSelector.DeepCopyInto(&out.InterfaceSelector)
 	in.NetworkNamespaces.DeepCopyInto(&out.NetworkNamespaces)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TcxAttachInfo.
@@ -1734,6 +2418,11 @@ func (in *TcxAttachInfo) DeepCopy() *TcxAttachInfo {
 func (in *TcxAttachInfoState) DeepCopyInto(out *TcxAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TcxAttachInfoState.
@@ -1799,6 +2488,18 @@ func (in *UprobeAttachInfo) DeepCopyInto(out *UprobeAttachInfo) {
 		**out = **in
 	}
 	in.Containers.DeepCopyInto(&out.Containers)
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UprobeAttachInfo.
@@ -1881,11 +2582,28 @@ func (in *XdpAttachInfo) DeepCopyInto(out *XdpAttachInfo) {
 	*out = *in
 	in.InterfaceSelector.DeepCopyInto(&out.InterfaceSelector)
 	in.NetworkNamespaces.DeepCopyInto(&out.NetworkNamespaces)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]XdpProceedOnValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XdpAttachInfo.
@@ -1902,6 +2620,11 @@ func (in *XdpAttachInfo) DeepCopy() *XdpAttachInfo {
 func (in *XdpAttachInfoState) DeepCopyInto(out *XdpAttachInfoState) {
 	*out = *in
 	in.AttachInfoStateCommon.DeepCopyInto(&out.AttachInfoStateCommon)
+	if in.AutoPriority != nil {
+		in, out := &in.AutoPriority, &out.AutoPriority
+		*out = new(PriorityRange)
+		**out = **in
+	}
 	if in.ProceedOn != nil {
 		in, out := &in.ProceedOn, &out.ProceedOn
 		*out = make([]XdpProceedOnValue, len(*in))
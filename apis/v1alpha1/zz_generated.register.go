@@ -69,6 +69,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterBpfApplicationList{},
 		&ClusterBpfApplicationState{},
 		&ClusterBpfApplicationStateList{},
+		&NodeBpfState{},
+		&NodeBpfStateList{},
 	)
 	// AddToGroupVersion allows the serialization of client types like ListOptions.
 	v1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -28,6 +28,7 @@ import (
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	bpfmanagent "github.com/bpfman/bpfman-operator/controllers/bpfman-agent"
+	"github.com/bpfman/bpfman-operator/internal"
 	"github.com/bpfman/bpfman-operator/internal/conn"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 
@@ -58,6 +59,14 @@ const (
 	// Internal metrics socket path for metrics-proxy
 	// communication.
 	internalMetricsSocketPath = "/var/run/bpfman-agent/metrics.sock"
+
+	// detachOnShutdownTimeout bounds the time spent detaching links on
+	// shutdown. It must fit within the pod's termination grace period.
+	detachOnShutdownTimeout = 10 * time.Second
+
+	// programSummaryDeleteTimeout bounds the time spent deleting the
+	// program summary on shutdown.
+	programSummaryDeleteTimeout = 5 * time.Second
 )
 
 var scheme = runtime.NewScheme()
@@ -81,15 +90,24 @@ type agentMetricsServer struct {
 	server     *http.Server
 }
 
+// agentDebugServer provides an HTTP server for the agent's debug endpoint,
+// which reports the agent's view of the applications on its node.
+type agentDebugServer struct {
+	addr   string
+	server *http.Server
+}
+
 // interfaceDiscovery monitors network interface creation and deletion
 // events.
 //
 // It wraps the netobserv interface watcher and registerer with a
 // subscribed events channel and an interfaces map for tracking
-// discovered interfaces.
+// discovered interfaces. The reconcilers' interface cache is
+// invalidated whenever an interface is added or removed.
 type interfaceDiscovery struct {
 	events     <-chan ifaces.Event
 	interfaces *sync.Map
+	cache      *bpfmanagent.InterfaceCache
 }
 
 // newInterfaceDiscovery creates an interfaceDiscovery instance and
@@ -101,7 +119,7 @@ type interfaceDiscovery struct {
 // method to process events.
 //
 // Returns an error if the subscription to interface events fails.
-func newInterfaceDiscovery(ctx context.Context, interfaces *sync.Map) (*interfaceDiscovery, error) {
+func newInterfaceDiscovery(ctx context.Context, interfaces *sync.Map, cache *bpfmanagent.InterfaceCache) (*interfaceDiscovery, error) {
 	informer := ifaces.NewWatcher(buffersLength)
 	registerer := ifaces.NewRegisterer(informer, buffersLength)
 
@@ -113,6 +131,7 @@ func newInterfaceDiscovery(ctx context.Context, interfaces *sync.Map) (*interfac
 	return &interfaceDiscovery{
 		events:     ifaceEvents,
 		interfaces: interfaces,
+		cache:      cache,
 	}, nil
 }
 
@@ -140,9 +159,11 @@ func (id *interfaceDiscovery) run(ctx context.Context, logger logr.Logger) error
 			case ifaces.EventAdded:
 				logger.Info("interface created", "Name", iface.Name, "netns", iface.NSName, "NsHandle", iface.NetNS)
 				id.interfaces.Store(iface, true)
+				id.cache.Invalidate()
 			case ifaces.EventDeleted:
 				logger.Info("interface deleted", "Name", iface.Name, "netns", iface.NSName, "NsHandle", iface.NetNS)
 				id.interfaces.Delete(iface)
+				id.cache.Invalidate()
 			default:
 			}
 		}
@@ -190,16 +211,8 @@ func newAgentMetricsServer(socketPath string) (*agentMetricsServer, error) {
 }
 
 // run starts the HTTP server and blocks until the context is
-// cancelled or the server fails.
-//
-// This is a synchronous function that starts the HTTP server in a
-// goroutine and blocks waiting for either context cancellation or a
-// server error. When the context is cancelled, it performs an orderly
-// shutdown with a 5-second timeout. The socket file is removed when
-// the function exits.
-//
-// Returns nil on successful shutdown, or an error if the server
-// encounters a runtime error during serving.
+// cancelled or the server fails, as serveHTTP does. The socket file is
+// removed when the function exits.
 func (ms *agentMetricsServer) run(ctx context.Context, logger logr.Logger) error {
 	defer func() {
 		if err := os.Remove(ms.socketPath); err != nil && !os.IsNotExist(err) {
@@ -207,12 +220,48 @@ func (ms *agentMetricsServer) run(ctx context.Context, logger logr.Logger) error
 		}
 	}()
 
+	logger.Info("starting", "socket", ms.socketPath)
+	return serveHTTP(ctx, ms.server, func() error { return ms.server.Serve(ms.listener) },
+		fmt.Sprintf("socket %q", ms.socketPath))
+}
+
+// newAgentDebugServer creates an agentDebugServer that serves handler on
+// addr. The server must be started by calling its run() method.
+func newAgentDebugServer(addr string, handler http.Handler) *agentDebugServer {
+	return &agentDebugServer{
+		addr: addr,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// run starts the HTTP server and blocks until the context is
+// cancelled or the server fails, as serveHTTP does.
+func (ds *agentDebugServer) run(ctx context.Context, logger logr.Logger) error {
+	logger.Info("starting", "address", ds.addr)
+	return serveHTTP(ctx, ds.server, ds.server.ListenAndServe, fmt.Sprintf("%q", ds.addr))
+}
+
+// serveHTTP calls serve, which serves server, and blocks until the
+// context is cancelled or serving fails.
+//
+// This is a synchronous function that serves in a goroutine and
+// blocks waiting for either context cancellation or a server error.
+// When the context is cancelled, it performs an orderly shutdown with
+// a 5-second timeout. where names what the server listens on in
+// errors.
+//
+// Returns nil on successful shutdown, or an error if the server
+// encounters a runtime error during serving.
+func serveHTTP(ctx context.Context, server *http.Server, serve func() error, where string) error {
 	errCh := make(chan error, 1)
 
 	go func() {
-		logger.Info("starting", "socket", ms.socketPath)
-		if err := ms.server.Serve(ms.listener); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("serve on socket %q: %w", ms.socketPath, err)
+		if err := serve(); err != nil && err != http.ErrServerClosed {
+			errCh <- fmt.Errorf("serve on %s: %w", where, err)
 		}
 	}()
 
@@ -220,8 +269,8 @@ func (ms *agentMetricsServer) run(ctx context.Context, logger logr.Logger) error
 	case <-ctx.Done():
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		if err := ms.server.Shutdown(shutdownCtx); err != nil {
-			return fmt.Errorf("shutdown on socket %q: %w", ms.socketPath, err)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("shutdown on %s: %w", where, err)
 		}
 		return nil
 	case err := <-errCh:
@@ -236,6 +285,7 @@ func (ms *agentMetricsServer) run(ctx context.Context, logger logr.Logger) error
 //   - Controller manager (mgr.Start) - always started
 //   - Metrics server (agentMetricsServer) - always started
 //   - Interface discovery (interfaceDiscovery) - optional, if enabled
+//   - Debug server (agentDebugServer) - optional, if enabled
 //
 // Each component runs in its own goroutine and receives a named
 // logger derived from the provided logger (e.g., "agent.metrics",
@@ -263,9 +313,20 @@ func (ms *agentMetricsServer) run(ctx context.Context, logger logr.Logger) error
 // Returns nil on successful coordinated shutdown, or the first
 // component error encountered. In both cases, all components are
 // guaranteed to have completed their shutdown sequence before return.
-func runAgent(ctx context.Context, mgr ctrl.Manager, metricsServer *agentMetricsServer, ifaceDiscovery *interfaceDiscovery, logger logr.Logger) error {
+func runAgent(ctx context.Context, mgr ctrl.Manager, metricsServer *agentMetricsServer, ifaceDiscovery *interfaceDiscovery, debugServer *agentDebugServer, logger logr.Logger) error {
 	g, ctx := errgroup.WithContext(ctx)
 
+	if debugServer != nil {
+		g.Go(func() error {
+			log := logger.WithName("debug")
+			if err := debugServer.run(ctx, log); err != nil {
+				return fmt.Errorf("debug server: %w", err)
+			}
+			log.Info("shut down")
+			return nil
+		})
+	}
+
 	if ifaceDiscovery != nil {
 		g.Go(func() error {
 			log := logger.WithName("interface-discovery")
@@ -310,13 +371,55 @@ func main() {
 	var opts zap.Options
 	var enableHTTP2, enableInterfacesDiscovery bool
 	var pprofAddr string
+	var debugAddr string
 	var certDir string
+	var podEventQPS float64
+	var podEventBurst int
+	var eventCoalesceWindow time.Duration
+	var driftCheckInterval time.Duration
+	var successRequeueInterval time.Duration
+	var namespaceUpdateQPS float64
+	var namespaceUpdateBurst int
+	var attachConflicts string
+	var detachOnShutdownTypes string
+	var deferAttachOnInterfaceDown bool
+	var replaceForeignXdpPrograms bool
+	var nodeCondition bool
+	var programSummaryInterval time.Duration
+	var nodeBpfStateInterval time.Duration
+	var loadConflictPolicy string
+	var xdpDispatcherReturnPolicy string
+	var persistPrograms bool
+	var featureGates string
+	var metadataKeyPrefix string
+	var namespaceBpfmanSockets string
 
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8175", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", enableHTTP2, "If HTTP/2 should be enabled for the metrics and webhook servers.")
 	flag.StringVar(&pprofAddr, "profiling-bind-address", "", "The address the profiling endpoint binds to, such as ':6060'. Leave unset to disable profiling.")
+	flag.StringVar(&debugAddr, "debug-bind-address", "", "The address the debug endpoint binds to, such as 'localhost:8176'. It serves the agent's view of the programs on the node, and the programs bpfman has loaded, as JSON on "+bpfmanagent.DebugStatePath+". Leave unset to disable the debug endpoint.")
 	flag.BoolVar(&enableInterfacesDiscovery, "enable-interfaces-discovery", true, "Enable ebpfman agent process to auto detect interfaces creation and deletion")
 	flag.StringVar(&certDir, "cert-dir", "/tmp/k8s-webhook-server/serving-certs", "The directory containing TLS certificates for HTTPS servers.")
+	flag.Float64Var(&podEventQPS, "pod-event-qps", bpfmanagent.DefaultPodEventQPS, "Maximum rate at which pod events trigger a reconcile. Set to 0 to disable pod event rate limiting.")
+	flag.IntVar(&podEventBurst, "pod-event-burst", bpfmanagent.DefaultPodEventBurst, "Number of pod-triggered reconciles allowed in a burst before --pod-event-qps applies.")
+	flag.DurationVar(&eventCoalesceWindow, "event-coalesce-window", bpfmanagent.DefaultEventCoalesceWindow, "How long node and pod events are held back so a burst of them results in a single reconcile. Set to 0 to disable coalescing.")
+	flag.DurationVar(&driftCheckInterval, "drift-check-interval", bpfmanagent.DefaultDriftCheckInterval, "How often every application is reconciled to reattach links that were detached outside of the agent. Set to 0 to disable.")
+	flag.DurationVar(&successRequeueInterval, "success-requeue-interval", 0, "How long after a successful reconcile the applications are reconciled again to re-verify the programs and links with bpfman. Leave at 0 to only reconcile on changes and the drift check.")
+	flag.Float64Var(&namespaceUpdateQPS, "namespace-update-qps", bpfmanagent.DefaultNamespaceUpdateQPS, "Maximum rate at which the BpfApplicationStates in each namespace are updated, so one namespace can't hold up the others. Set to 0 to disable.")
+	flag.IntVar(&namespaceUpdateBurst, "namespace-update-burst", bpfmanagent.DefaultNamespaceUpdateBurst, "Number of updates to the BpfApplicationStates in a namespace allowed in a burst before --namespace-update-qps applies.")
+	flag.StringVar(&attachConflicts, "attach-conflicts", "", "Comma separated list of hook pairs that are incompatible on the same interface, such as 'XDP+TC/egress'. Hooks are XDP, TC/ingress, TC/egress, TCX/ingress and TCX/egress. Leave unset to use the defaults, or set to 'none' to disable the check.")
+	flag.StringVar(&detachOnShutdownTypes, "detach-on-shutdown-types", "", "Comma separated list of program types, such as 'XDP,TC,TCX', whose links are detached when the agent shuts down. The programs stay loaded and are reattached when the agent restarts. Leave unset to keep all links attached.")
+	flag.BoolVar(&deferAttachOnInterfaceDown, "defer-attach-on-interface-down", false, "Wait for an interface that's down to come up before attaching XDP programs to it, rather than failing the attach.")
+	flag.BoolVar(&replaceForeignXdpPrograms, "replace-foreign-xdp-programs", false, "Attach XDP programs to interfaces that already have an XDP program that bpfman didn't attach, replacing it, rather than refusing to attach.")
+	flag.BoolVar(&nodeCondition, "node-condition", false, "Set the BpfProgramsHealthy condition on the agent's node, which is false if any BPF application has failed on the node.")
+	flag.DurationVar(&programSummaryInterval, "program-summary-interval", 0, "Keep a ConfigMap named bpfman-programs-<node> in the agent's namespace, from POD_NAMESPACE or "+internal.BpfmanNamespace+" if it's unset, summarizing the programs loaded on the agent's node, updated at most this often, such as '30s'. It's deleted when the agent shuts down. Set to 0 to disable the summary.")
+	flag.DurationVar(&nodeBpfStateInterval, "node-bpf-state-interval", 0, "Keep a NodeBpfState named after the agent's node listing the eBPF programs bpfman reports on the node, including those it didn't load, listed at most this often, such as '1m'. Set to 0 to disable the NodeBpfState.")
+	flag.StringVar(&loadConflictPolicy, "load-conflict-policy", string(bpfmanagent.LoadConflictFail), "What to do when bpfman reports that an application's programs are already loaded, such as after an agent restart: 'fail' reports the error, 'adopt' adopts the loaded programs if they match the application, and 'reload' also unloads and reloads them if they don't match.")
+	flag.StringVar(&xdpDispatcherReturnPolicy, "xdp-dispatcher-return-policy", string(bpfmanagent.XdpDispatcherReturnWarn), "What to do when an XDP link's proceedOn doesn't include DispatcherReturn, which stops the rest of the dispatcher's chain from running when a program returns to it: 'warn' attaches it as given and sets the application state's MissingDispatcherReturn condition, 'add' logs a warning and adds it, 'fail' doesn't attach the link, and 'ignore' attaches it as given.")
+	flag.BoolVar(&persistPrograms, "persist-programs", false, "Adopt programs that bpfman already has loaded for an application when the agent starts, such as during an upgrade, instead of loading them again, if they match the application.")
+	flag.StringVar(&metadataKeyPrefix, "metadata-key-prefix", internal.DefaultMetadataKeyPrefix, "Prefix of the keys of the metadata the agent sets on the programs and links it has bpfman load and attach. Give the agents of operators that share a bpfman different prefixes so they don't see each other's programs.")
+	flag.StringVar(&namespaceBpfmanSockets, "namespace-bpfman-sockets", "", "Comma separated list of namespace=socket pairs, such as 'tenant-a=/run/bpfman-tenant-a/bpfman.sock', naming the sockets of namespace-scoped bpfman daemons. The programs of BpfApplications in those namespaces are loaded by the namespace's bpfman rather than the node's.")
+	flag.StringVar(&featureGates, "feature-gates", "", "Comma separated list of enabled feature gates. Applications annotated with "+internal.FeatureGateAnnotation+" are only loaded while the gate they name is enabled.")
 
 	flag.Parse()
 
@@ -355,10 +458,11 @@ func main() {
 			BindAddress: "0",
 		},
 		LeaderElection: false,
-		// Specify that Secrets's should not be cached.
+		// Specify that Secrets's and ConfigMaps should not be cached, they're
+		// only read when loading bytecode.
 		Client: client.Options{
 			Cache: &client.CacheOptions{
-				DisableFor: []client.Object{&v1.Secret{}},
+				DisableFor: []client.Object{&v1.Secret{}, &v1.ConfigMap{}},
 			},
 		},
 	})
@@ -367,6 +471,23 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Namespace-scoped bpfman daemons are connected to before conn is
+	// shadowed by the node's bpfman connection.
+	sockets, err := bpfmanagent.ParseNamespaceBpfmanSockets(namespaceBpfmanSockets)
+	if err != nil {
+		setupLog.Error(err, "invalid --namespace-bpfman-sockets")
+		os.Exit(1)
+	}
+	namespaceClients := map[string]gobpfman.BpfmanClient{}
+	for namespace, path := range sockets {
+		nsConn, err := conn.CreateConnectionToSocket(context.Background(), path, insecure.NewCredentials())
+		if err != nil {
+			setupLog.Error(err, "unable to connect to namespace-scoped bpfman", "Namespace", namespace)
+			os.Exit(1)
+		}
+		namespaceClients[namespace] = gobpfman.NewBpfmanClient(nsConn)
+	}
+
 	// Set up a connection to bpfman, block until bpfman is up.
 	setupLog.Info("Waiting for active connection to bpfman")
 	conn, err := conn.CreateConnection(context.Background(), insecure.NewCredentials())
@@ -381,20 +502,68 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The program summary is kept in the agent's own namespace, which is
+	// where the operator deploys it.
+	agentNamespace := os.Getenv("POD_NAMESPACE")
+	if agentNamespace == "" {
+		agentNamespace = internal.BpfmanNamespace
+	}
+
 	containerGetter, err := bpfmanagent.NewRealContainerGetter(nodeName)
 	if err != nil {
 		setupLog.Error(err, "unable to create containerGetter")
 		os.Exit(1)
 	}
 
+	conflicts, err := bpfmanagent.ParseAttachConflicts(attachConflicts)
+	if err != nil {
+		setupLog.Error(err, "invalid --attach-conflicts")
+		os.Exit(1)
+	}
+
+	conflictPolicy, err := bpfmanagent.ParseLoadConflictPolicy(loadConflictPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid --load-conflict-policy")
+		os.Exit(1)
+	}
+
+	dispatcherReturnPolicy, err := bpfmanagent.ParseXdpDispatcherReturnPolicy(xdpDispatcherReturnPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid --xdp-dispatcher-return-policy")
+		os.Exit(1)
+	}
+
+	detachTypes, err := bpfmanagent.ParseProgramTypes(detachOnShutdownTypes)
+	if err != nil {
+		setupLog.Error(err, "invalid --detach-on-shutdown-types")
+		os.Exit(1)
+	}
+
 	commonApp := bpfmanagent.ReconcilerCommon{
-		Client:       mgr.GetClient(),
-		Scheme:       mgr.GetScheme(),
-		GrpcConn:     conn,
-		BpfmanClient: gobpfman.NewBpfmanClient(conn),
-		NodeName:     nodeName,
-		Containers:   containerGetter,
-		Interfaces:   &sync.Map{},
+		Client:                     mgr.GetClient(),
+		Scheme:                     mgr.GetScheme(),
+		GrpcConn:                   conn,
+		BpfmanClient:               gobpfman.NewBpfmanClient(conn),
+		NodeName:                   nodeName,
+		Containers:                 containerGetter,
+		Interfaces:                 &sync.Map{},
+		InterfaceCache:             bpfmanagent.NewInterfaceCache(bpfmanagent.DefaultInterfaceCacheTTL),
+		InterfaceLocks:             bpfmanagent.NewInterfaceLocks(),
+		PodEventQPS:                podEventQPS,
+		PodEventBurst:              podEventBurst,
+		EventCoalesceWindow:        eventCoalesceWindow,
+		DriftCheckInterval:         driftCheckInterval,
+		SuccessRequeueInterval:     successRequeueInterval,
+		NamespaceUpdateQPS:         namespaceUpdateQPS,
+		NamespaceUpdateBurst:       namespaceUpdateBurst,
+		AttachConflicts:            conflicts,
+		DeferAttachOnInterfaceDown: deferAttachOnInterfaceDown,
+		ReplaceForeignXdpPrograms:  replaceForeignXdpPrograms,
+		LoadConflictPolicy:         conflictPolicy,
+		XdpDispatcherReturnPolicy:  dispatcherReturnPolicy,
+		PersistPrograms:            persistPrograms,
+		FeatureGates:               bpfmanagent.ParseFeatureGates(featureGates),
+		MetadataKeyPrefix:          metadataKeyPrefix,
 	}
 
 	if err = (&bpfmanagent.ClBpfApplicationReconciler{
@@ -405,19 +574,57 @@ func main() {
 	}
 
 	if err = (&bpfmanagent.NsBpfApplicationReconciler{
-		ReconcilerCommon: commonApp,
+		ReconcilerCommon:       commonApp,
+		NamespaceBpfmanClients: namespaceClients,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create BpfNsApplicationReconciler")
 		os.Exit(1)
 	}
 
+	if nodeCondition {
+		if err = (&bpfmanagent.NodeConditionReconciler{
+			Client:   mgr.GetClient(),
+			NodeName: nodeName,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create NodeConditionReconciler")
+			os.Exit(1)
+		}
+	}
+
+	if programSummaryInterval > 0 {
+		if err = (&bpfmanagent.ProgramSummaryReconciler{
+			Client:    mgr.GetClient(),
+			NodeName:  nodeName,
+			Namespace: agentNamespace,
+			Interval:  programSummaryInterval,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create ProgramSummaryReconciler")
+			os.Exit(1)
+		}
+	}
+
+	if nodeBpfStateInterval > 0 {
+		if err = (&bpfmanagent.NodeBpfStateReconciler{
+			Client:       mgr.GetClient(),
+			BpfmanClient: commonApp.BpfmanClient,
+			NodeName:     nodeName,
+			Interval:     nodeBpfStateInterval,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create NodeBpfStateReconciler")
+			os.Exit(1)
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	// The agent isn't ready until it can reach the bpfman daemon.
+	bpfmanChecker := bpfmanagent.NewBpfmanChecker(commonApp.BpfmanClient,
+		bpfmanagent.DefaultBpfmanCheckTimeout, bpfmanagent.DefaultBpfmanCheckTTL)
+	if err := mgr.AddReadyzCheck("readyz", bpfmanChecker.Check); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
@@ -432,18 +639,43 @@ func main() {
 
 	var ifaceDiscovery *interfaceDiscovery
 	if enableInterfacesDiscovery {
-		ifaceDiscovery, err = newInterfaceDiscovery(ctx, commonApp.Interfaces)
+		ifaceDiscovery, err = newInterfaceDiscovery(ctx, commonApp.Interfaces, commonApp.InterfaceCache)
 		if err != nil {
 			setupLog.Error(err, "failed to set up interface discovery")
 			os.Exit(1)
 		}
 	}
 
+	var debugServer *agentDebugServer
+	if debugAddr != "" {
+		debugServer = newAgentDebugServer(debugAddr, bpfmanagent.NewDebugHandler(mgr.GetClient(), commonApp.BpfmanClient, nodeName))
+	}
+
 	setupLog.Info("starting Bpfman-Agent")
-	if err := runAgent(ctx, mgr, metricsServer, ifaceDiscovery, ctrl.Log.WithName("agent")); err != nil {
+	if err := runAgent(ctx, mgr, metricsServer, ifaceDiscovery, debugServer, ctrl.Log.WithName("agent")); err != nil {
 		setupLog.Error(err, "agent runtime failed, exiting")
 		os.Exit(1)
 	}
 
+	// The manager has stopped, so read the application states directly from
+	// the API server. This runs even without --detach-on-shutdown-types, as
+	// programs can ask for their links to be detached when the agent exits.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), detachOnShutdownTimeout)
+	err = bpfmanagent.DetachOnShutdown(shutdownCtx, mgr.GetAPIReader(), commonApp.BpfmanClient,
+		namespaceClients, nodeName, detachTypes, ctrl.Log.WithName("agent.shutdown"))
+	cancel()
+	if err != nil {
+		setupLog.Error(err, "failed to detach links on shutdown")
+	}
+
+	if programSummaryInterval > 0 {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), programSummaryDeleteTimeout)
+		err := bpfmanagent.DeleteProgramSummary(shutdownCtx, mgr.GetClient(), agentNamespace, nodeName)
+		cancel()
+		if err != nil {
+			setupLog.Error(err, "failed to delete program summary on shutdown")
+		}
+	}
+
 	// Normal shutdown (SIGTERM/SIGINT) exits with status code 0.
 }
@@ -19,12 +19,15 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	bpfmanoperator "github.com/bpfman/bpfman-operator/controllers/bpfman-operator"
 	"github.com/bpfman/bpfman-operator/internal"
+	webhookv1alpha1 "github.com/bpfman/bpfman-operator/internal/webhook/v1alpha1"
 
 	osv1 "github.com/openshift/api/security/v1"
 	"go.uber.org/zap/zapcore"
@@ -91,14 +94,26 @@ func main() {
 	var opts zap.Options
 	var enableHTTP2 bool
 	var certDir string
+	var enableWebhooks bool
+	var watchNamespace string
+	var finalizerTimeout time.Duration
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8443", "The address the metric endpoint binds to. Use \"0\" to disable.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8175", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 15*time.Second, "How long a non-leader waits after the last renewal before trying to take over leadership.")
+	flag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 10*time.Second, "How long the leader keeps retrying to renew its lease before giving up leadership. Must be less than --leader-elect-lease-duration.")
+	flag.DurationVar(&retryPeriod, "leader-elect-retry-period", 2*time.Second, "How long leader election clients wait between attempts to acquire or renew leadership. Must be less than --leader-elect-renew-deadline.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", enableHTTP2, "If HTTP/2 should be enabled for the metrics and webhook servers.")
 	flag.StringVar(&certDir, "cert-dir", "/tmp/k8s-webhook-server/serving-certs", "The directory containing TLS certificates for HTTPS servers.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Enable the validating webhooks that reject invalid BpfApplications and ClusterBpfApplications, and changes to their immutable fields. The webhook server needs a TLS certificate in --cert-dir.")
+	flag.StringVar(&watchNamespace, "watch-namespace", "", "If set, only BpfApplications in this namespace are reconciled. ClusterBpfApplications are always reconciled cluster-wide.")
+	flag.DurationVar(&finalizerTimeout, "finalizer-timeout", 0, "How long a deleted application waits for its programs to be unloaded before the operator removes its finalizer anyway, which may leave programs loaded. Set to 0 to wait forever.")
 	flag.Parse()
 
 	// Get the Log level for bpfman deployment where this pod is running
@@ -132,6 +147,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := validateLeaderElection(leaseDuration, renewDeadline, retryPeriod); err != nil {
+		setupLog.Error(err, "invalid leader election settings")
+		os.Exit(1)
+	}
+
 	metricsOptions := server.Options{
 		BindAddress:    metricsAddr,
 		SecureServing:  true,
@@ -144,16 +164,24 @@ func main() {
 
 	certWatcher := setupCertWatcher(certDir, &metricsOptions.TLSOpts)
 
+	if watchNamespace != "" {
+		setupLog.Info("Watching BpfApplications in a single namespace", "namespace", watchNamespace)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:  scheme,
 		Metrics: metricsOptions,
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port:    9443,
+			CertDir: certDir,
 			TLSOpts: []func(*tls.Config){disableHTTP2},
 		}),
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "8730d955.bpfman.io",
+		LeaseDuration:          &leaseDuration,
+		RenewDeadline:          &renewDeadline,
+		RetryPeriod:            &retryPeriod,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -165,13 +193,7 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
-		Cache: cache.Options{
-			ByObject: map[client.Object]cache.ByObject{
-				&corev1.ConfigMap{}: {
-					Field: fields.SelectorFromSet(fields.Set{"metadata.name": internal.BpfmanConfigName}),
-				},
-			},
-		},
+		Cache: newCacheOptions(watchNamespace),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -187,9 +209,18 @@ func main() {
 		}
 	}
 
+	// The config reconciler deploys the bpfman DaemonSet in the namespace of
+	// the bpfman-config ConfigMap, which is installed with the operator.
+	bpfmanNamespace := os.Getenv("POD_NAMESPACE")
+	if bpfmanNamespace == "" {
+		bpfmanNamespace = internal.BpfmanNamespace
+	}
+
 	commonApp := bpfmanoperator.ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		FinalizerTimeout: finalizerTimeout,
+		BpfmanNamespace:  bpfmanNamespace,
 	}
 
 	commonClusterApp := bpfmanoperator.ClusterApplicationReconciler{
@@ -197,8 +228,10 @@ func main() {
 	}
 
 	commonNsApp := bpfmanoperator.ReconcilerCommon[bpfmaniov1alpha1.BpfApplicationState, bpfmaniov1alpha1.BpfApplicationStateList]{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		FinalizerTimeout: finalizerTimeout,
+		BpfmanNamespace:  bpfmanNamespace,
 	}
 
 	commonNamespaceApp := bpfmanoperator.NamespaceApplicationReconciler{
@@ -245,6 +278,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&bpfmanoperator.NodeCleanupReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create NodeCleanupReconciler controller")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		if err = webhookv1alpha1.SetupClusterBpfApplicationWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterBpfApplication")
+			os.Exit(1)
+		}
+		if err = webhookv1alpha1.SetupBpfApplicationWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BpfApplication")
+			os.Exit(1)
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -263,6 +315,46 @@ func main() {
 	}
 }
 
+// newCacheOptions returns the manager's cache options. Of the ConfigMaps, the
+// cache only holds bpfman-config. If watchNamespace is set, it only holds the
+// BpfApplications and BpfApplicationStates in that namespace, so the operator
+// never sees, and so never reconciles, BpfApplications in other namespaces.
+// Only the namespaced application types are restricted, since the operator
+// also needs its own config and deployments in the bpfman namespace.
+func newCacheOptions(watchNamespace string) cache.Options {
+	cacheOptions := cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.ConfigMap{}: {
+				Field: fields.SelectorFromSet(fields.Set{"metadata.name": internal.BpfmanConfigName}),
+			},
+		},
+	}
+	if watchNamespace != "" {
+		namespaces := map[string]cache.Config{watchNamespace: {}}
+		cacheOptions.ByObject[&bpfmaniov1alpha1.BpfApplication{}] = cache.ByObject{Namespaces: namespaces}
+		cacheOptions.ByObject[&bpfmaniov1alpha1.BpfApplicationState{}] = cache.ByObject{Namespaces: namespaces}
+	}
+	return cacheOptions
+}
+
+// validateLeaderElection checks that the leader election timings can work
+// together: the leader must give up its lease before another replica can take
+// over, and must get more than one attempt at renewing it.
+func validateLeaderElection(leaseDuration, renewDeadline, retryPeriod time.Duration) error {
+	if retryPeriod <= 0 {
+		return fmt.Errorf("--leader-elect-retry-period must be positive, got %s", retryPeriod)
+	}
+	if renewDeadline <= retryPeriod {
+		return fmt.Errorf("--leader-elect-renew-deadline (%s) must be greater than --leader-elect-retry-period (%s)",
+			renewDeadline, retryPeriod)
+	}
+	if leaseDuration <= renewDeadline {
+		return fmt.Errorf("--leader-elect-lease-duration (%s) must be greater than --leader-elect-renew-deadline (%s)",
+			leaseDuration, renewDeadline)
+	}
+	return nil
+}
+
 // setupCertWatcher creates and configures a certificate watcher.
 // Returns the watcher or nil if creation failed.
 func setupCertWatcher(certDir string, tlsOpts *[]func(*tls.Config)) *certwatcher.CertWatcher {
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+func TestValidateLeaderElection(t *testing.T) {
+	tests := []struct {
+		name          string
+		leaseDuration time.Duration
+		renewDeadline time.Duration
+		retryPeriod   time.Duration
+		wantErr       string
+	}{
+		{
+			name:          "defaults",
+			leaseDuration: 15 * time.Second,
+			renewDeadline: 10 * time.Second,
+			retryPeriod:   2 * time.Second,
+		},
+		{
+			name:          "high latency API server",
+			leaseDuration: 60 * time.Second,
+			renewDeadline: 40 * time.Second,
+			retryPeriod:   5 * time.Second,
+		},
+		{
+			name:          "renew deadline equal to lease duration",
+			leaseDuration: 15 * time.Second,
+			renewDeadline: 15 * time.Second,
+			retryPeriod:   2 * time.Second,
+			wantErr:       "--leader-elect-lease-duration (15s) must be greater than --leader-elect-renew-deadline (15s)",
+		},
+		{
+			name:          "renew deadline longer than lease duration",
+			leaseDuration: 10 * time.Second,
+			renewDeadline: 15 * time.Second,
+			retryPeriod:   2 * time.Second,
+			wantErr:       "--leader-elect-lease-duration (10s) must be greater than --leader-elect-renew-deadline (15s)",
+		},
+		{
+			name:          "retry period longer than renew deadline",
+			leaseDuration: 15 * time.Second,
+			renewDeadline: 10 * time.Second,
+			retryPeriod:   12 * time.Second,
+			wantErr:       "--leader-elect-renew-deadline (10s) must be greater than --leader-elect-retry-period (12s)",
+		},
+		{
+			name:          "zero retry period",
+			leaseDuration: 15 * time.Second,
+			renewDeadline: 10 * time.Second,
+			wantErr:       "--leader-elect-retry-period must be positive, got 0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLeaderElection(tt.leaseDuration, tt.renewDeadline, tt.retryPeriod)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCacheOptionsWatchNamespace(t *testing.T) {
+	// namespaces returns the namespaces the cache is restricted to for each
+	// type of object that's restricted.
+	namespaces := func(opts cache.Options) map[string][]string {
+		restricted := map[string][]string{}
+		for obj, byObject := range opts.ByObject {
+			if byObject.Namespaces == nil {
+				continue
+			}
+			restricted[reflect.TypeOf(obj).Elem().Name()] = slices.Sorted(maps.Keys(byObject.Namespaces))
+		}
+		return restricted
+	}
+
+	// Without a watch namespace, BpfApplications in every namespace are
+	// cached.
+	assert.Empty(t, namespaces(newCacheOptions("")))
+
+	// With one, only the namespaced application types are restricted to it.
+	assert.Equal(t, map[string][]string{
+		"BpfApplication":      {"tenant-a"},
+		"BpfApplicationState": {"tenant-a"},
+	}, namespaces(newCacheOptions("tenant-a")))
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main implements bpfman-validate, which checks whether the
+// node it runs on can satisfy a ClusterBpfApplication or BpfApplication
+// manifest without loading anything:
+//
+//	bpfman-validate <manifest.yaml|->
+//
+// It checks that the local bpfman is reachable, that the kernel is new
+// enough for the application's minKernelVersion and program types, and
+// that the interfaces the application names in the host network
+// namespace exist. The report is printed as JSON, and the exit status
+// is 1 if the node can't satisfy the manifest or it can't be read.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	bpfmanagent "github.com/bpfman/bpfman-operator/controllers/bpfman-agent"
+	"github.com/bpfman/bpfman-operator/internal/conn"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// kernelBTFPath is where the kernel exposes its BTF.
+const kernelBTFPath = "/sys/kernel/btf/vmlinux"
+
+// localNode is the node bpfman-validate runs on.
+type localNode struct{}
+
+func (localNode) CheckBpfman(ctx context.Context) error {
+	c, err := conn.CreateConnection(ctx, insecure.NewCredentials())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if _, err := gobpfman.NewBpfmanClient(c).List(ctx, &gobpfman.ListRequest{}); err != nil {
+		return fmt.Errorf("failed to list programs: %w", err)
+	}
+	return nil
+}
+
+func (localNode) KernelRelease() (string, error) {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return "", err
+	}
+	return unix.ByteSliceToString(uname.Release[:]), nil
+}
+
+func (localNode) HasKernelBTF() bool {
+	_, err := os.Stat(kernelBTFPath)
+	return err == nil
+}
+
+func (localNode) Interfaces() ([]net.Interface, error) {
+	return net.Interfaces()
+}
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	code := run(ctx, os.Args, os.Stdin, os.Stdout, os.Stderr, localNode{})
+	cancel()
+	os.Exit(code)
+}
+
+// run validates the manifest named by args against node, writing the report
+// to stdout and any error to stderr, and returns the exit status.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer,
+	node bpfmanagent.NodeCapabilities) int {
+	if len(args) != 2 {
+		fmt.Fprintf(stderr, "Usage: %s <manifest.yaml|->\n", args[0])
+		return 1
+	}
+
+	var manifest []byte
+	var err error
+	if args[1] == "-" {
+		manifest, err = io.ReadAll(stdin)
+	} else {
+		manifest, err = os.ReadFile(args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error reading manifest: %v\n", err)
+		return 1
+	}
+
+	report, err := bpfmanagent.ValidateManifest(ctx, manifest, node)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	jsonOutput, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(jsonOutput))
+
+	if !report.Satisfiable {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bpfmanagent "github.com/bpfman/bpfman-operator/controllers/bpfman-agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+type fakeNode struct {
+	release string
+}
+
+func (fakeNode) CheckBpfman(ctx context.Context) error { return nil }
+func (n fakeNode) KernelRelease() (string, error)      { return n.release, nil }
+func (fakeNode) HasKernelBTF() bool                    { return true }
+func (fakeNode) Interfaces() ([]net.Interface, error) {
+	return []net.Interface{{Index: 2, Name: "eth0"}}, nil
+}
+
+const tcxManifest = `apiVersion: bpfman.io/v1alpha1
+kind: ClusterBpfApplication
+metadata:
+  name: tcx-example
+spec:
+  byteCode:
+    image:
+      url: quay.io/bpfman-bytecode/tcx_test:latest
+  programs:
+    - name: tcx_stats
+      type: TCX
+      tcx:
+        links:
+          - interfaceSelector:
+              interfaces: [eth0]
+            direction: Ingress
+            priority: 100
+`
+
+func TestRun(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(tcxManifest), 0o644))
+
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		release     string
+		wantCode    int
+		wantStderr  string
+		satisfiable *bool
+	}{
+		{
+			name:       "no manifest",
+			args:       []string{"bpfman-validate"},
+			wantCode:   1,
+			wantStderr: "Usage: bpfman-validate <manifest.yaml|->\n",
+		},
+		{
+			name:       "too many arguments",
+			args:       []string{"bpfman-validate", manifestPath, manifestPath},
+			wantCode:   1,
+			wantStderr: "Usage: bpfman-validate <manifest.yaml|->\n",
+		},
+		{
+			name:       "missing manifest",
+			args:       []string{"bpfman-validate", filepath.Join(t.TempDir(), "missing.yaml")},
+			wantCode:   1,
+			wantStderr: "Error reading manifest: ",
+		},
+		{
+			name:       "unsupported kind",
+			args:       []string{"bpfman-validate", "-"},
+			stdin:      "apiVersion: v1\nkind: ConfigMap\n",
+			wantCode:   1,
+			wantStderr: `Error: unsupported kind "ConfigMap"`,
+		},
+		{
+			name:        "satisfiable from file",
+			args:        []string{"bpfman-validate", manifestPath},
+			release:     "6.8.0",
+			satisfiable: ptr.To(true),
+		},
+		{
+			name:        "satisfiable from stdin",
+			args:        []string{"bpfman-validate", "-"},
+			stdin:       tcxManifest,
+			release:     "6.8.0",
+			satisfiable: ptr.To(true),
+		},
+		{
+			// The report is printed even though the node can't
+			// satisfy the manifest.
+			name:        "kernel too old",
+			args:        []string{"bpfman-validate", manifestPath},
+			release:     "5.14.0",
+			wantCode:    1,
+			satisfiable: ptr.To(false),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.TODO(), tc.args, strings.NewReader(tc.stdin), &stdout, &stderr,
+				fakeNode{release: tc.release})
+			assert.Equal(t, tc.wantCode, code)
+
+			if tc.satisfiable == nil {
+				assert.Empty(t, stdout.String())
+				assert.True(t, strings.HasPrefix(stderr.String(), tc.wantStderr),
+					"stderr %q doesn't start with %q", stderr.String(), tc.wantStderr)
+				return
+			}
+			assert.Empty(t, stderr.String())
+			var report bpfmanagent.ValidationReport
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+			assert.Equal(t, "ClusterBpfApplication", report.Kind)
+			assert.Equal(t, "tcx-example", report.Name)
+			assert.Equal(t, *tc.satisfiable, report.Satisfiable)
+		})
+	}
+}
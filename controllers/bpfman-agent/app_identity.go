@@ -0,0 +1,159 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// appIdentity returns the identity app is annotated with, or "" if it has
+// none.
+func appIdentity(app metav1.Object) string {
+	return app.GetAnnotations()[internal.AppIdentityAnnotation]
+}
+
+// canAdoptAppState returns true if app may take over a BpfApplicationState
+// that has its identity but is controlled by owner, another application that
+// still exists. It may if owner is being deleted or was created before app,
+// so the newest application with an identity ends up with its state.
+// Applications created in the same second are ordered by UID.
+func canAdoptAppState(app, owner metav1.Object) bool {
+	if !owner.GetDeletionTimestamp().IsZero() {
+		return true
+	}
+	appCreated, ownerCreated := app.GetCreationTimestamp(), owner.GetCreationTimestamp()
+	if !appCreated.Equal(&ownerCreated) {
+		return ownerCreated.Before(&appCreated)
+	}
+	return app.GetUID() > owner.GetUID()
+}
+
+// adoptAppState makes app the controller of appState, which belonged to
+// another application with the same identity, and relabels it so it's found
+// under app's name. Its status, and so the programs loaded for it, are kept.
+func (r *ReconcilerCommon) adoptAppState(ctx context.Context, app, appState client.Object) error {
+	previous := ""
+	refs := []metav1.OwnerReference{}
+	for _, ref := range appState.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			previous = ref.Name
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	appState.SetOwnerReferences(refs)
+	if err := ctrl.SetControllerReference(app, appState, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set BpfApplicationState %s owner reference: %w", appState.GetName(), err)
+	}
+	labels := appState.GetLabels()
+	labels[internal.BpfAppStateOwner] = internal.AppStateOwnerLabel(app.GetName())
+	appState.SetLabels(labels)
+	if err := r.Update(ctx, appState); err != nil {
+		return fmt.Errorf("failed to adopt BpfApplicationState %s: %w", appState.GetName(), err)
+	}
+	r.Logger.Info("Adopted BpfApplicationState of an application with the same identity",
+		"Name", app.GetName(), "AppState Name", appState.GetName(), "Previous Owner", previous,
+		"Identity", appIdentity(app))
+	return nil
+}
+
+// getBpfAppStateByIdentity looks for a ClusterBpfApplicationState on the node
+// that has the current application's identity but belongs to another
+// application, such as the one it was renamed from, and takes it over if it
+// can. It returns nil if there's none to take over, setting r.supersededBy if
+// there's one that belongs to a newer application.
+func (r *ClBpfApplicationReconciler) getBpfAppStateByIdentity(ctx context.Context) (*bpfmaniov1alpha1.ClusterBpfApplicationState, error) {
+	identity := appIdentity(r.currentApp)
+	if identity == "" {
+		return nil, nil
+	}
+
+	appStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := r.List(ctx, appStates, client.MatchingLabels{
+		internal.AppIdentityLabel: identity,
+		internal.K8sHostLabel:     r.NodeName,
+	}); err != nil {
+		return nil, err
+	}
+	for i := range appStates.Items {
+		appState := &appStates.Items[i]
+		if owner := metav1.GetControllerOf(appState); owner != nil {
+			ownerApp := &bpfmaniov1alpha1.ClusterBpfApplication{}
+			err := r.Get(ctx, types.NamespacedName{Name: owner.Name}, ownerApp)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			if err == nil && ownerApp.UID == owner.UID && !canAdoptAppState(r.currentApp, ownerApp) {
+				r.supersededBy = ownerApp.Name
+				return nil, nil
+			}
+		}
+		if err := r.adoptAppState(ctx, r.currentApp, appState); err != nil {
+			return nil, err
+		}
+		return appState, nil
+	}
+	return nil, nil
+}
+
+// getBpfAppStateByIdentity looks for a BpfApplicationState on the node that
+// has the current application's identity but belongs to another application
+// in the same namespace, such as the one it was renamed from, and takes it
+// over if it can. It returns nil if there's none to take over, setting
+// r.supersededBy if there's one that belongs to a newer application.
+func (r *NsBpfApplicationReconciler) getBpfAppStateByIdentity(ctx context.Context) (*bpfmaniov1alpha1.BpfApplicationState, error) {
+	identity := appIdentity(r.currentApp)
+	if identity == "" {
+		return nil, nil
+	}
+
+	appStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := r.List(ctx, appStates, client.InNamespace(r.currentApp.Namespace), client.MatchingLabels{
+		internal.AppIdentityLabel: identity,
+		internal.K8sHostLabel:     r.NodeName,
+	}); err != nil {
+		return nil, err
+	}
+	for i := range appStates.Items {
+		appState := &appStates.Items[i]
+		if owner := metav1.GetControllerOf(appState); owner != nil {
+			ownerApp := &bpfmaniov1alpha1.BpfApplication{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: r.currentApp.Namespace, Name: owner.Name}, ownerApp)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			if err == nil && ownerApp.UID == owner.UID && !canAdoptAppState(r.currentApp, ownerApp) {
+				r.supersededBy = ownerApp.Name
+				return nil, nil
+			}
+		}
+		if err := r.adoptAppState(ctx, r.currentApp, appState); err != nil {
+			return nil, err
+		}
+		return appState, nil
+	}
+	return nil, nil
+}
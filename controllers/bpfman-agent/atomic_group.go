@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// errAtomicGroupRolledBack is returned when a program's links are detached
+// because another program in its atomicGroup failed to attach.
+var errAtomicGroupRolledBack = errors.New("atomic group rolled back")
+
+// clAtomicGroup returns the atomicGroup of an FEntry or FExit program, or ""
+// if it isn't in one.
+func clAtomicGroup(prog *bpfmaniov1alpha1.ClBpfApplicationProgram) string {
+	switch {
+	case prog.FEntry != nil:
+		return prog.FEntry.AtomicGroup
+	case prog.FExit != nil:
+		return prog.FExit.AtomicGroup
+	}
+	return ""
+}
+
+// rollbackAtomicGroups is called once an application's programs have been
+// reconciled, with its programs grouped by atomicGroup. If any program in a
+// group isn't attached as it should be, the links of the group's other
+// programs are detached, so the group is attached together or not at all. The
+// bytecode's programs are always loaded together, so only attaching can fail
+// for part of a group.
+func (r *ReconcilerCommon) rollbackAtomicGroups(ctx context.Context, groups map[string][]ProgramReconciler) error {
+	var errs []error
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		programs := groups[group]
+		failed := slices.IndexFunc(programs, func(program ProgramReconciler) bool {
+			return program.getProgramLinkStatus() != bpfmaniov1alpha1.ProgAttachSuccess
+		})
+		if failed < 0 {
+			continue
+		}
+
+		failedName := programs[failed].getProgName()
+		for _, program := range programs {
+			if program.getProgramLinkStatus() != bpfmaniov1alpha1.ProgAttachSuccess {
+				continue
+			}
+			r.Logger.Info("Detaching program as another program in its atomic group failed to attach",
+				"Name", program.getProgName(), "Group", group, "Failed", failedName)
+			if err := program.rollbackLinks(ctx, program); err != nil {
+				errs = append(errs, fmt.Errorf("program %s: %w", program.getProgName(), err))
+			}
+			errs = append(errs, fmt.Errorf("program %s: program %s in atomic group %s failed to attach: %w",
+				program.getProgName(), failedName, group, errAtomicGroupRolledBack))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AttachHook identifies a network hook that programs can be attached to.
+type AttachHook string
+
+const (
+	HookXDP        AttachHook = "XDP"
+	HookTCIngress  AttachHook = "TC/ingress"
+	HookTCEgress   AttachHook = "TC/egress"
+	HookTCXIngress AttachHook = "TCX/ingress"
+	HookTCXEgress  AttachHook = "TCX/egress"
+)
+
+var attachHooks = []AttachHook{HookXDP, HookTCIngress, HookTCEgress, HookTCXIngress, HookTCXEgress}
+
+// AttachConflict describes a pair of hooks that don't work as expected when
+// programs are attached to both of them on the same interface.
+type AttachConflict struct {
+	First  AttachHook
+	Second AttachHook
+	Reason string
+}
+
+// DefaultAttachConflicts is the list of attach conflicts that the agent warns
+// about unless it's overridden with --attach-conflicts.
+var DefaultAttachConflicts = []AttachConflict{
+	{
+		First:  HookTCIngress,
+		Second: HookTCXIngress,
+		Reason: "TCX programs run before TC programs, so a TCX verdict can stop the TC programs from running",
+	},
+	{
+		First:  HookTCEgress,
+		Second: HookTCXEgress,
+		Reason: "TCX programs run before TC programs, so a TCX verdict can stop the TC programs from running",
+	},
+}
+
+// ParseAttachConflicts parses a comma separated list of conflicting hook
+// pairs, such as "XDP+TC/egress,TC/ingress+TCX/ingress". An empty string
+// returns the DefaultAttachConflicts, and "none" disables conflict checking.
+func ParseAttachConflicts(s string) ([]AttachConflict, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return DefaultAttachConflicts, nil
+	case "none":
+		return []AttachConflict{}, nil
+	}
+
+	conflicts := []AttachConflict{}
+	for _, pair := range strings.Split(s, ",") {
+		hooks := strings.Split(strings.TrimSpace(pair), "+")
+		if len(hooks) != 2 {
+			return nil, fmt.Errorf("invalid attach conflict %q: expected <hook>+<hook>", pair)
+		}
+		conflict := AttachConflict{Reason: "configured as incompatible"}
+		for i, h := range hooks {
+			hook, err := parseAttachHook(h)
+			if err != nil {
+				return nil, fmt.Errorf("invalid attach conflict %q: %w", pair, err)
+			}
+			if i == 0 {
+				conflict.First = hook
+			} else {
+				conflict.Second = hook
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+func parseAttachHook(s string) (AttachHook, error) {
+	for _, hook := range attachHooks {
+		if strings.EqualFold(strings.TrimSpace(s), string(hook)) {
+			return hook, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hook %q", s)
+}
+
+func tcAttachHook(direction bpfmaniov1alpha1.TCDirectionType, tcx bool) AttachHook {
+	switch {
+	case tcx && direction == bpfmaniov1alpha1.TCEgress:
+		return HookTCXEgress
+	case tcx:
+		return HookTCXIngress
+	case direction == bpfmaniov1alpha1.TCEgress:
+		return HookTCEgress
+	default:
+		return HookTCIngress
+	}
+}
+
+// attachPoint is a program attached to a hook on a given interface.
+type attachPoint struct {
+	hook      AttachHook
+	iface     string
+	netnsPath string
+	owner     string
+	priority  int32
+}
+
+func (a attachPoint) String() string {
+	netns := a.netnsPath
+	if netns == "" {
+		netns = "host"
+	}
+	return fmt.Sprintf("%s (%s on %s in netns %s)", a.owner, a.hook, a.iface, netns)
+}
+
+// clAppStateAttachPoints returns the network attach points for the links in
+// a ClusterBpfApplicationState that are attached.
+func clAppStateAttachPoints(appState *bpfmaniov1alpha1.ClusterBpfApplicationState) []attachPoint {
+	points := []attachPoint{}
+	for _, program := range appState.Status.Programs {
+		owner := appState.Name + "/" + program.Name
+		switch {
+		case program.XDP != nil:
+			for _, l := range program.XDP.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{HookXDP, l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		case program.TC != nil:
+			for _, l := range program.TC.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{tcAttachHook(l.Direction, false), l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		case program.TCX != nil:
+			for _, l := range program.TCX.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{tcAttachHook(l.Direction, true), l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// nsAppStateAttachPoints returns the network attach points for the links in
+// a BpfApplicationState that are attached.
+func nsAppStateAttachPoints(appState *bpfmaniov1alpha1.BpfApplicationState) []attachPoint {
+	points := []attachPoint{}
+	for _, program := range appState.Status.Programs {
+		owner := appState.Namespace + "/" + appState.Name + "/" + program.Name
+		switch {
+		case program.XDP != nil:
+			for _, l := range program.XDP.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{HookXDP, l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		case program.TC != nil:
+			for _, l := range program.TC.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{tcAttachHook(l.Direction, false), l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		case program.TCX != nil:
+			for _, l := range program.TCX.Links {
+				if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+					points = append(points, attachPoint{tcAttachHook(l.Direction, true), l.InterfaceName, l.NetnsPath, owner, l.Priority})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// getNodeAttachPoints returns the attach points of all the application state
+// objects on this node, other than the one with UID skip, whose programs
+// bpfman reports as loaded.
+func (r *ReconcilerCommon) getNodeAttachPoints(ctx context.Context, skip string) ([]attachPoint, error) {
+	loaded, err := bpfmanagentinternal.ListBpfmanAttachments(ctx, r.BpfmanClient, internal.AllPrograms,
+		r.metadataKeys().Uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bpfman programs: %w", err)
+	}
+
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: r.NodeName}}
+	points := []attachPoint{}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := r.List(ctx, clAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	for i := range clAppStates.Items {
+		uid := string(clAppStates.Items[i].UID)
+		if _, ok := loaded[uid]; ok && uid != skip {
+			points = append(points, clAppStateAttachPoints(&clAppStates.Items[i])...)
+		}
+	}
+
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := r.List(ctx, nsAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+	for i := range nsAppStates.Items {
+		uid := string(nsAppStates.Items[i].UID)
+		if _, ok := loaded[uid]; ok && uid != skip {
+			points = append(points, nsAppStateAttachPoints(&nsAppStates.Items[i])...)
+		}
+	}
+
+	return points, nil
+}
+
+// sameInterface returns true if a and b are attached to the same interface in
+// the same network namespace.
+func (r *ReconcilerCommon) sameInterface(a, b attachPoint) bool {
+	if a.iface != b.iface {
+		return false
+	}
+	if a.netnsPath == b.netnsPath {
+		return true
+	}
+	aId, bId := r.getNetnsId(a.netnsPath), r.getNetnsId(b.netnsPath)
+	return aId != nil && bId != nil && *aId == *bId
+}
+
+// findAttachConflicts checks the attach points of the application state being
+// reconciled, whose UID is appStateUID, against each other and against the
+// other attachments on this node, and returns a description of each
+// incompatible pair found.
+func (r *ReconcilerCommon) findAttachConflicts(ctx context.Context, appStateUID string, own []attachPoint) []string {
+	conflicts := r.AttachConflicts
+	if conflicts == nil {
+		conflicts = DefaultAttachConflicts
+	}
+	if len(conflicts) == 0 || len(own) == 0 {
+		return nil
+	}
+
+	others, err := r.getNodeAttachPoints(ctx, appStateUID)
+	if err != nil {
+		// Conflicts are only warnings, so don't fail the reconcile.
+		r.Logger.Error(err, "failed to get attachments on node, only checking this application for attach conflicts")
+	}
+
+	found := []string{}
+	check := func(a, b attachPoint) {
+		if !r.sameInterface(a, b) {
+			return
+		}
+		for _, c := range conflicts {
+			if (a.hook == c.First && b.hook == c.Second) || (a.hook == c.Second && b.hook == c.First) {
+				found = append(found, fmt.Sprintf("%s conflicts with %s: %s", a, b, c.Reason))
+			}
+		}
+	}
+	for i := range own {
+		for j := i + 1; j < len(own); j++ {
+			check(own[i], own[j])
+		}
+		for j := range others {
+			check(own[i], others[j])
+		}
+	}
+	return found
+}
+
+// checkAttachConflicts logs a warning for each attach conflict and returns
+// true if any were found.
+func (r *ReconcilerCommon) checkAttachConflicts(ctx context.Context, appStateUID string, own []attachPoint) bool {
+	conflicts := r.findAttachConflicts(ctx, appStateUID, own)
+	for _, c := range conflicts {
+		r.Logger.Info("WARNING: incompatible attachments on the same interface", "conflict", c)
+	}
+	return len(conflicts) > 0
+}
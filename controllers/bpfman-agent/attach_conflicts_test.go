@@ -0,0 +1,47 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAttachConflicts(t *testing.T) {
+	conflicts, err := ParseAttachConflicts("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultAttachConflicts, conflicts)
+
+	conflicts, err = ParseAttachConflicts("none")
+	require.NoError(t, err)
+	require.NotNil(t, conflicts)
+	require.Empty(t, conflicts)
+
+	conflicts, err = ParseAttachConflicts("XDP+TC/egress, tcx/ingress+tc/ingress")
+	require.NoError(t, err)
+	require.Len(t, conflicts, 2)
+	require.Equal(t, HookXDP, conflicts[0].First)
+	require.Equal(t, HookTCEgress, conflicts[0].Second)
+	require.Equal(t, HookTCXIngress, conflicts[1].First)
+	require.Equal(t, HookTCIngress, conflicts[1].Second)
+
+	_, err = ParseAttachConflicts("XDP")
+	require.Error(t, err)
+	_, err = ParseAttachConflicts("XDP+bogus")
+	require.Error(t, err)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// errPriorityRangeExhausted is returned when a link with an autoPriority
+// range isn't attached because every priority in the range is in use.
+var errPriorityRangeExhausted = errors.New("priority range exhausted")
+
+// priorityAllocator tracks the priorities in use on the node while an
+// application's programs are reconciled, so links with an autoPriority range
+// are given distinct priorities.
+type priorityAllocator struct {
+	// appStateUID is the UID of the application state being reconciled.
+	appStateUID string
+	// used holds the attach points whose priorities are taken. It starts
+	// with the application's own attached links, and the other applications'
+	// are added the first time a priority is assigned.
+	used []attachPoint
+	// loadedOthers is set once the other applications' links have been
+	// added to used.
+	loadedOthers bool
+}
+
+func newPriorityAllocator(appStateUID string, own []attachPoint) *priorityAllocator {
+	return &priorityAllocator{appStateUID: appStateUID, used: own}
+}
+
+// assignAutoPriority is called before a link with an autoPriority range is
+// attached, and sets priority to the lowest priority in the range that isn't
+// used by another link on the same hook and interface. If there isn't one,
+// the link is reported as ApPriorityRangeExhausted.
+func (r *ReconcilerCommon) assignAutoPriority(ctx context.Context, rec ProgramReconciler,
+	autoPriority *bpfmaniov1alpha1.PriorityRange, point attachPoint, priority *int32) error {
+	if autoPriority == nil || r.priorities == nil || r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+
+	alloc := r.priorities
+	if !alloc.loadedOthers {
+		others, err := r.getNodeAttachPoints(ctx, alloc.appStateUID)
+		if err != nil {
+			r.Logger.Error(err, "failed to get attachments on node, only checking this application for used priorities")
+		}
+		alloc.used = append(alloc.used, others...)
+		alloc.loadedOthers = true
+	}
+
+	used := map[int32]bool{}
+	for _, p := range alloc.used {
+		if p.hook == point.hook && r.sameInterface(p, point) {
+			used[p.priority] = true
+		}
+	}
+	for p := autoPriority.Min; p <= autoPriority.Max; p++ {
+		if !used[p] {
+			*priority = p
+			point.priority = p
+			alloc.used = append(alloc.used, point)
+			r.Logger.V(1).Info("Assigned priority", "Program", rec.getProgName(), "Interface", point.iface,
+				"Hook", point.hook, "Priority", p)
+			return nil
+		}
+	}
+
+	rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApPriorityRangeExhausted)
+	return fmt.Errorf("no free %s priority between %d and %d on interface %s: %w",
+		point.hook, autoPriority.Min, autoPriority.Max, point.iface, errPriorityRangeExhausted)
+}
+
+// samePriority returns true if two links have the same priority. Links with
+// an autoPriority range are the same if their ranges are, as the priority of
+// an attached link is the one it was assigned.
+func samePriority(aPriority int32, aAuto *bpfmaniov1alpha1.PriorityRange,
+	bPriority int32, bAuto *bpfmaniov1alpha1.PriorityRange) bool {
+	if aAuto != nil || bAuto != nil {
+		return reflect.DeepEqual(aAuto, bAuto)
+	}
+	return aPriority == bPriority
+}
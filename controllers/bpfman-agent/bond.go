@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/vishvananda/netlink"
+)
+
+// bondMembers returns the names of the interfaces that are members of the
+// named interface in the network namespace at netnsPath, or the agent's
+// namespace if netnsPath is empty, if it's a bond or team. It returns
+// nothing for any other interface, or one that doesn't exist. It's a
+// variable so tests can replace it.
+var bondMembers = func(name, netnsPath string) ([]string, error) {
+	h, err := netlinkHandleAt(netnsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	master, err := h.LinkByName(name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if master.Type() != "bond" && master.Type() != "team" {
+		return nil, nil
+	}
+
+	links, err := h.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+	members := []string{}
+	for _, link := range links {
+		if link.Attrs().MasterIndex == master.Attrs().Index {
+			members = append(members, link.Attrs().Name)
+		}
+	}
+	slices.Sort(members)
+	return members, nil
+}
+
+// withBondMembers returns interfaces with the members of each bond or team
+// among them added after it. An interface that's already in the list isn't
+// added again.
+func withBondMembers(interfaces []string, netnsPath string) ([]string, error) {
+	expanded := []string{}
+	for _, iface := range interfaces {
+		if !slices.Contains(expanded, iface) {
+			expanded = append(expanded, iface)
+		}
+		members, err := bondMembers(iface, netnsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the members of %s: %w", iface, err)
+		}
+		for _, member := range members {
+			if !slices.Contains(expanded, member) && !slices.Contains(interfaces, member) {
+				expanded = append(expanded, member)
+			}
+		}
+	}
+	return expanded, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+
+	"github.com/bpfman/bpfman-operator/internal"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// bpfmanContainerStatus returns the status of the bpfman container if pod is
+// the bpfman daemon pod, which runs the bpfman and bpfman-agent containers.
+func bpfmanContainerStatus(pod *v1.Pod) (*v1.ContainerStatus, bool) {
+	var bpfman *v1.ContainerStatus
+	agent := false
+	for i := range pod.Status.ContainerStatuses {
+		switch pod.Status.ContainerStatuses[i].Name {
+		case internal.BpfmanContainerName:
+			bpfman = &pod.Status.ContainerStatuses[i]
+		case internal.BpfmanAgentContainerName:
+			agent = true
+		}
+	}
+	return bpfman, bpfman != nil && agent
+}
+
+// bpfmanRestarted returns true if the bpfman container has restarted or
+// become ready between oldPod and newPod. A crash and restart can show up as
+// either, depending on how quickly the kubelet reports them.
+func bpfmanRestarted(oldPod, newPod *v1.Pod) bool {
+	oldStatus, ok := bpfmanContainerStatus(oldPod)
+	if !ok {
+		return false
+	}
+	newStatus, ok := bpfmanContainerStatus(newPod)
+	if !ok {
+		return false
+	}
+	return newStatus.RestartCount > oldStatus.RestartCount || (!oldStatus.Ready && newStatus.Ready)
+}
+
+// bpfmanRestartPredicate passes updates to the bpfman daemon pod on nodeName
+// in which the bpfman container has restarted or become ready. Other events
+// are filtered out, as the agent runs in the same pod and reconciles every
+// application when it starts.
+func bpfmanRestartPredicate(nodeName string) predicate.TypedFuncs[*v1.Pod] {
+	return predicate.TypedFuncs[*v1.Pod]{
+		CreateFunc:  func(event.TypedCreateEvent[*v1.Pod]) bool { return false },
+		DeleteFunc:  func(event.TypedDeleteEvent[*v1.Pod]) bool { return false },
+		GenericFunc: func(event.TypedGenericEvent[*v1.Pod]) bool { return false },
+		UpdateFunc: func(e event.TypedUpdateEvent[*v1.Pod]) bool {
+			return e.ObjectNew.Spec.NodeName == nodeName && bpfmanRestarted(e.ObjectOld, e.ObjectNew)
+		},
+	}
+}
+
+// bpfmanRestartHandler enqueues a resync of every application on the node.
+// It doesn't go through the pod event rate limit, so a restart is acted on
+// straight away.
+func bpfmanRestartHandler() handler.TypedEventHandler[*v1.Pod, reconcile.Request] {
+	return handler.TypedEnqueueRequestsFromMapFunc(func(_ context.Context, _ *v1.Pod) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: resyncRequestName}}}
+	})
+}
+
+// bpfmanRestartSource returns a source that triggers a reconcile when bpfman
+// restarts on our node. Each reconcile checks that bpfman still has every
+// program and link the application states say it has, and loads or attaches
+// them again if not, so this recovers from a bpfman crash without waiting for
+// the periodic drift check.
+func (r *ReconcilerCommon) bpfmanRestartSource(mgr ctrl.Manager) source.Source {
+	return source.Kind(mgr.GetCache(), &v1.Pod{}, bpfmanRestartHandler(), bpfmanRestartPredicate(r.NodeName))
+}
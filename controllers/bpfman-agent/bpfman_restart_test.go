@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// daemonPod returns a bpfman daemon pod on nodeName whose bpfman container
+// has restarted restarts times.
+func daemonPod(nodeName string, restarts int32, ready bool) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "bpfman-daemon-abcde", Namespace: internal.BpfmanNamespace},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: internal.BpfmanContainerName, RestartCount: restarts, Ready: ready},
+				{Name: internal.BpfmanAgentContainerName, Ready: true},
+			},
+		},
+	}
+}
+
+func TestBpfmanRestartPredicate(t *testing.T) {
+	otherPod := daemonPod("fake-control-plane", 1, true)
+	otherPod.Status.ContainerStatuses = otherPod.Status.ContainerStatuses[:1]
+
+	tests := []struct {
+		name   string
+		oldPod *v1.Pod
+		newPod *v1.Pod
+		want   bool
+	}{
+		{
+			name:   "restarted",
+			oldPod: daemonPod("fake-control-plane", 0, true),
+			newPod: daemonPod("fake-control-plane", 1, true),
+			want:   true,
+		},
+		{
+			name:   "became ready",
+			oldPod: daemonPod("fake-control-plane", 1, false),
+			newPod: daemonPod("fake-control-plane", 1, true),
+			want:   true,
+		},
+		{
+			name:   "became unready",
+			oldPod: daemonPod("fake-control-plane", 1, true),
+			newPod: daemonPod("fake-control-plane", 1, false),
+			want:   false,
+		},
+		{
+			name:   "unchanged",
+			oldPod: daemonPod("fake-control-plane", 1, true),
+			newPod: daemonPod("fake-control-plane", 1, true),
+			want:   false,
+		},
+		{
+			name:   "other node",
+			oldPod: daemonPod("other-node", 0, true),
+			newPod: daemonPod("other-node", 1, true),
+			want:   false,
+		},
+		{
+			name:   "not the daemon pod",
+			oldPod: daemonPod("fake-control-plane", 0, true),
+			newPod: otherPod,
+			want:   false,
+		},
+	}
+
+	p := bpfmanRestartPredicate("fake-control-plane")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, p.Update(event.TypedUpdateEvent[*v1.Pod]{ObjectOld: tc.oldPod, ObjectNew: tc.newPod}))
+		})
+	}
+	require.False(t, p.Create(event.TypedCreateEvent[*v1.Pod]{Object: daemonPod("fake-control-plane", 0, true)}))
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+// reportBpfmanUnavailable is called when an application couldn't be loaded
+// because bpfman couldn't be reached. Every application on the node fails the
+// same way until bpfman is back, so the error is only logged when bpfman
+// first becomes unavailable, and at V(1) after that.
+func (r *ReconcilerCommon) reportBpfmanUnavailable(err error) {
+	if !r.bpfmanUnavailable {
+		r.bpfmanUnavailable = true
+		r.Logger.Error(err, "bpfman is unavailable, retrying with backoff")
+		return
+	}
+	r.Logger.V(1).Info("bpfman is still unavailable", "error", err.Error())
+}
+
+// reportBpfmanAvailable is called when an application's load was reconciled,
+// and logs if bpfman has become available again.
+func (r *ReconcilerCommon) reportBpfmanAvailable() {
+	if r.bpfmanUnavailable {
+		r.bpfmanUnavailable = false
+		r.Logger.Info("bpfman is available again")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// ntGnuBuildID is the type of the ELF note holding a GNU build-id.
+const ntGnuBuildID = 3
+
+// errBuildIDMismatch is returned when a uprobe isn't attached because its
+// target's build-id isn't the one requested.
+var errBuildIDMismatch = errors.New("build-id mismatch")
+
+// resolveBuildID returns the GNU build-id of the ELF binary or library at
+// path as lower case hex. It's a variable so tests can replace it.
+var resolveBuildID = elfBuildID
+
+func elfBuildID(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	for _, section := range f.Sections {
+		if section.Type != elf.SHT_NOTE {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			return "", fmt.Errorf("failed to read section %s: %w", section.Name, err)
+		}
+		if buildID, ok := gnuBuildIDFromNotes(data, f.ByteOrder); ok {
+			return buildID, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no build-id", path)
+}
+
+// gnuBuildIDFromNotes looks for a GNU build-id in the contents of an ELF note
+// section. Each note is a name size, description size and type, followed by
+// the name and description, each padded to four bytes.
+func gnuBuildIDFromNotes(data []byte, order binary.ByteOrder) (string, bool) {
+	align := func(n uint32) uint32 { return (n + 3) &^ 3 }
+	for len(data) >= 12 {
+		nameSize, descSize, noteType := order.Uint32(data[0:4]), order.Uint32(data[4:8]), order.Uint32(data[8:12])
+		data = data[12:]
+		if uint64(align(nameSize))+uint64(align(descSize)) > uint64(len(data)) {
+			return "", false
+		}
+		name := data[:nameSize]
+		desc := data[align(nameSize) : align(nameSize)+descSize]
+		data = data[align(nameSize)+align(descSize):]
+		if noteType == ntGnuBuildID && string(name) == "GNU\x00" {
+			return hex.EncodeToString(desc), true
+		}
+	}
+	return "", false
+}
+
+// containerBinaryPath returns the path of target in the root filesystem of
+// the container whose process has the given pid.
+func (r *ReconcilerCommon) containerBinaryPath(containerPid int32, target string) string {
+	return filepath.Join(r.hostProcPath(), strconv.Itoa(int(containerPid)), "root", target)
+}
+
+// checkUprobeBuildID is called before a uprobe link is attached, and checks
+// that the target in the container has the requested build-id, so the probe
+// isn't attached to a different build of the binary after an image update. On
+// a mismatch, the link's status is set to ApBuildIDMismatch.
+func (r *ReconcilerCommon) checkUprobeBuildID(ctx context.Context, rec ProgramReconciler, buildID, target string,
+	containerPid int32) error {
+	if buildID == "" || r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+
+	path := r.containerBinaryPath(containerPid, target)
+	actual, err := resolveBuildID(path)
+	if err != nil {
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachError)
+		return fmt.Errorf("failed to read the build-id of %s in container pid %d: %w", target, containerPid, err)
+	}
+	if !strings.EqualFold(actual, buildID) {
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApBuildIDMismatch)
+		return fmt.Errorf("%s in container pid %d has build-id %s, expected %s: %w",
+			target, containerPid, actual, buildID, errBuildIDMismatch)
+	}
+	return nil
+}
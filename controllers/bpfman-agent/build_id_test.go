@@ -0,0 +1,54 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGnuBuildIDFromNotes(t *testing.T) {
+	note := func(name string, noteType uint32, desc []byte) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, uint32(len(name)))
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(desc)))
+		b = binary.LittleEndian.AppendUint32(b, noteType)
+		b = append(b, name...)
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+		b = append(b, desc...)
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+
+	// The build-id follows another note whose name needs padding.
+	data := append(note("Go\x00", 4, []byte("abcde")), note("GNU\x00", ntGnuBuildID, []byte{0xde, 0xad, 0xbe, 0xef})...)
+	buildID, ok := gnuBuildIDFromNotes(data, binary.LittleEndian)
+	require.True(t, ok)
+	require.Equal(t, "deadbeef", buildID)
+
+	_, ok = gnuBuildIDFromNotes(note("GNU\x00", 1, []byte{0x01}), binary.LittleEndian)
+	require.False(t, ok)
+
+	// A truncated note is ignored rather than read past the end.
+	_, ok = gnuBuildIDFromNotes(data[:len(data)-2], binary.LittleEndian)
+	require.False(t, ok)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// errFunctionNotInBytecode is returned when an application isn't loaded
+// because one of its programs' bpfFunctionNames isn't a program in its
+// bytecode.
+var errFunctionNotInBytecode = errors.New("function not in bytecode")
+
+// bytecodeFunctions returns the names of the programs in the eBPF object
+// file at path. It's a variable so tests can replace it.
+var bytecodeFunctions = elfBytecodeFunctions
+
+// elfBytecodeFunctions returns the global functions in the executable
+// sections of an eBPF object file, other than .text, which holds
+// subprograms rather than programs.
+func elfBytecodeFunctions(path string) ([]string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	symbols, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols: %w", err)
+	}
+	functions := []string{}
+	for _, sym := range symbols {
+		if elf.ST_TYPE(sym.Info) != elf.STT_FUNC || elf.ST_BIND(sym.Info) != elf.STB_GLOBAL {
+			continue
+		}
+		if sym.Section < elf.SHN_LORESERVE && int(sym.Section) < len(f.Sections) {
+			section := f.Sections[sym.Section]
+			if section.Flags&elf.SHF_EXECINSTR != 0 && section.Name != ".text" {
+				functions = append(functions, sym.Name)
+			}
+		}
+	}
+	slices.Sort(functions)
+	return functions, nil
+}
+
+// checkBytecodeFunctions is called before an application is loaded, and
+// returns an error wrapping errFunctionNotInBytecode if the function of any
+// program in the load request isn't a program in the bytecode, so the mistake
+// is reported along with the functions that are there, rather than as a load
+// error from bpfman. Only bytecode in a file the agent can read is checked;
+// images are pulled by bpfman.
+func (r *ReconcilerCommon) checkBytecodeFunctions(loadRequest *gobpfman.LoadRequest) error {
+	path := loadRequest.GetBytecode().GetFile()
+	if path == "" {
+		return nil
+	}
+	functions, err := bytecodeFunctions(path)
+	if err != nil {
+		// The file may only be visible to bpfman, so leave it to report any
+		// problem with it.
+		r.Logger.V(1).Info("Not checking the bytecode's functions", "Path", path, "Reason", err.Error())
+		return nil
+	}
+
+	missing := []string{}
+	for _, info := range loadRequest.GetInfo() {
+		if !slices.Contains(functions, info.GetName()) {
+			missing = append(missing, info.GetName())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s not found in bytecode %s, available functions are %s: %w",
+			strings.Join(missing, ", "), path, strings.Join(functions, ", "), errFunctionNotInBytecode)
+	}
+	return nil
+}
@@ -18,8 +18,10 @@ package bpfmanagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
@@ -30,13 +32,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
@@ -49,6 +50,7 @@ import (
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get
 
 type ClBpfApplicationReconciler struct {
 	ReconcilerCommon
@@ -73,6 +75,31 @@ func (r *ClBpfApplicationReconciler) getNodeSelector() *metav1.LabelSelector {
 	return &r.currentApp.Spec.NodeSelector
 }
 
+func (r *ClBpfApplicationReconciler) getByteCode() *bpfmaniov1alpha1.ByteCodeSelector {
+	return &r.currentApp.Spec.ByteCode
+}
+
+// getAppNamespace returns "" since a ClusterBpfApplication isn't namespaced.
+func (r *ClBpfApplicationReconciler) getAppNamespace() string {
+	return ""
+}
+
+func (r *ClBpfApplicationReconciler) getResolvedBytecode() *bpfmaniov1alpha1.ResolvedBytecode {
+	return r.currentAppState.Status.ResolvedBytecode
+}
+
+func (r *ClBpfApplicationReconciler) getMinKernelVersion() string {
+	return r.currentApp.Spec.MinKernelVersion
+}
+
+func (r *ClBpfApplicationReconciler) getFeatureGate() string {
+	return r.currentApp.GetAnnotations()[internal.FeatureGateAnnotation]
+}
+
+func (r *ClBpfApplicationReconciler) hasPrograms() bool {
+	return len(r.currentApp.Spec.Programs) > 0
+}
+
 func (r *ClBpfApplicationReconciler) getAppStateConditions() *[]metav1.Condition {
 	return &r.currentAppState.Status.Conditions
 }
@@ -81,11 +108,23 @@ func (r *ClBpfApplicationReconciler) isBeingDeleted() bool {
 	return !r.currentApp.GetDeletionTimestamp().IsZero()
 }
 
+func (r *ClBpfApplicationReconciler) getAppGeneration() int64 {
+	return r.currentApp.GetGeneration()
+}
+
 func (r *ClBpfApplicationReconciler) setAppStateConditions(condition metav1.Condition) {
 	r.currentAppState.Status.Conditions = nil
 	meta.SetStatusCondition(&r.currentAppState.Status.Conditions, condition)
 }
 
+func (r *ClBpfApplicationReconciler) getAppStateLastError() *bpfmaniov1alpha1.ReconcileError {
+	return r.currentAppState.Status.LastError
+}
+
+func (r *ClBpfApplicationReconciler) setAppStateLastError(lastError *bpfmaniov1alpha1.ReconcileError) {
+	r.currentAppState.Status.LastError = lastError
+}
+
 func (r *ClBpfApplicationReconciler) setAppLoadStatus(status bpfmaniov1alpha1.AppLoadStatus) {
 	r.currentAppState.Status.AppLoadStatus = status
 }
@@ -95,8 +134,16 @@ func (r *ClBpfApplicationReconciler) setAppLoadStatus(status bpfmaniov1alpha1.Ap
 // programs on the node via bpfman, and create or update a BpfApplicationState
 // object to reflect per node state information.
 func (r *ClBpfApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&bpfmaniov1alpha1.ClusterBpfApplication{}, builder.WithPredicates(predicate.And(predicate.GenerationChangedPredicate{}, predicate.ResourceVersionChangedPredicate{}))).
+	resyncHandler := r.resyncEventHandler()
+	driftCheck, err := r.driftCheckSource(mgr, resyncHandler)
+	if err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&bpfmaniov1alpha1.ClusterBpfApplication{}, builder.WithPredicates(predicate.And(
+			predicate.Or(predicate.GenerationChangedPredicate{}, forceResyncPredicate()),
+			predicate.ResourceVersionChangedPredicate{}))).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Owns(&bpfmaniov1alpha1.ClusterBpfApplicationState{},
 			builder.WithPredicates(internal.BpfNodePredicate(r.NodeName)),
@@ -106,22 +153,29 @@ func (r *ClBpfApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		// care about node events specific to our node
 		Watches(
 			&v1.Node{},
-			&handler.EnqueueRequestForObject{},
+			resyncHandler,
 			builder.WithPredicates(predicate.And(predicate.LabelChangedPredicate{}, nodePredicate(r.NodeName))),
 		).
 		// Watch for changes in Pod resources in case we are using a container
 		// or network namespace selector.
 		Watches(
 			&v1.Pod{},
-			&handler.EnqueueRequestForObject{},
+			resyncHandler,
 			builder.WithPredicates(podOnNodePredicate(r.NodeName)),
-		).
-		Complete(r)
+		)
+
+	// Resync straight away when bpfman restarts.
+	b = b.WatchesRawSource(r.bpfmanRestartSource(mgr))
+
+	// Periodically check for links detached outside of the agent.
+	if driftCheck != nil {
+		b = b.WatchesRawSource(driftCheck)
+	}
+	return b.Complete(r)
 }
 
 func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	// Initialize node and current program
-	r.ourNode = &v1.Node{}
 	r.Logger = ctrl.Log.WithName("cluster-app")
 	r.finalizer = internal.ClBpfApplicationControllerFinalizer
 	r.recType = internal.ApplicationString
@@ -129,10 +183,16 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	r.Logger.Info("Enter ClusterBpfApplication Reconcile", "Name", req.Name)
 
-	// Lookup K8s node object for this bpfman-agent This should always succeed
-	if err := r.Get(ctx, types.NamespacedName{Namespace: v1.NamespaceAll, Name: r.NodeName}, r.ourNode); err != nil {
-		return ctrl.Result{Requeue: false}, fmt.Errorf("failed getting bpfman-agent node %s : %v",
-			req.NamespacedName, err)
+	// Lookup K8s node object for this bpfman-agent. A failure other than
+	// NotFound is returned so the request is retried with backoff rather
+	// than dropped.
+	found, err := r.getOurNode(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !found {
+		r.Logger.Info("bpfman-agent node not found, nothing to reconcile", "Node", r.NodeName)
+		return ctrl.Result{}, nil
 	}
 
 	// Get the list of existing BpfApplication objects
@@ -147,8 +207,26 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{Requeue: false}, nil
 	}
 
+	// requeue is set if an application needs to be reconciled again even
+	// though nothing changed.
+	requeue := false
+	// deferred is set if an application has links waiting for their
+	// interface to come up.
+	deferred := false
+	// imageRefresh is the shortest interval at which an application's
+	// image is checked for a new one.
+	imageRefresh := time.Duration(0)
+	// waitingForContainers is set if an application has container selectors
+	// that don't match any containers yet.
+	waitingForContainers := false
+	// bpfmanUnavailable is set if an application couldn't be loaded because
+	// bpfman couldn't be reached.
+	bpfmanUnavailable := false
+
 	for appProgramIndex := range appPrograms.Items {
 		r.currentApp = &appPrograms.Items[appProgramIndex]
+		imageRefresh = shorterInterval(imageRefresh, imageRefreshInterval(&r.currentApp.Spec.ByteCode))
+		convertLegacyXdpLinks(r.currentApp)
 
 		r.Logger.Info("Reconciling ClusterBpfApplication", "Name", r.currentApp.Name)
 
@@ -168,6 +246,13 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 					"Name", r.currentApp.Name)
 				continue
 			}
+			if r.supersededBy != "" {
+				// A newer application with the same identity has taken over
+				// this one's BpfApplicationState.
+				r.Logger.Info("BpfApplicationState taken over by an application with the same identity",
+					"Name", r.currentApp.Name, "New Owner", r.supersededBy)
+				continue
+			}
 			// Create a new ClusterBpfApplicationState object first, once it's
 			// created, initialize the Status subresource and then update the
 			// status.
@@ -180,15 +265,53 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		// at the end of the reconcile process.
 		bpfAppStateOriginal := r.currentAppState.DeepCopy()
 
+		// Record any forced resync request so it's only handled once.
+		r.checkForceResync(r.currentApp, &r.currentAppState.Status.ObservedForceResync)
+
 		// Make sure the BpfApplication code is loaded on the node.
 		r.Logger.Info("Calling reconcileLoad()", "isBeingDeleted", r.isBeingDeleted())
 		err = r.reconcileLoad(ctx, r)
 		if err != nil {
 			// There's no point continuing to reconcile the links if we
 			// can't load the code.
-			r.Logger.Error(err, "failed to reconcileLoad")
-			r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError)
-			statusChanged, err := r.updateBpfAppStateStatus(ctx, nil)
+			unavailable := errors.Is(err, bpfmanagentinternal.ErrBpfmanUnavailable)
+			if unavailable {
+				r.reportBpfmanUnavailable(err)
+				bpfmanUnavailable = true
+			} else {
+				r.Logger.Error(err, "failed to reconcileLoad")
+			}
+			// An application waiting for its shared map's owner to be
+			// loaded is checked again later.
+			waitingForSharedMap := errors.Is(err, errSharedMapNotFound)
+			requeue = requeue || waitingForSharedMap
+			setLastReconcileError(r, err)
+			if errors.Is(err, errProgramTypeMismatch) {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondProgramTypeMismatch)
+			} else if errors.Is(err, errKernelTooOld) {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondKernelTooOld)
+			} else if errors.Is(err, errGatedOff) {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondGatedOff)
+			} else if errors.Is(err, errFunctionNotInBytecode) {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondFunctionNotInBytecode)
+			} else if errors.Is(err, bpfmanagentinternal.ErrGlobalDataNotFound) {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondGlobalDataNotFound)
+			} else if waitingForSharedMap {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondSharedMapNotFound)
+			} else if unavailable {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondBpfmanUnavailable)
+			} else {
+				r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError)
+			}
+			// While bpfman is unavailable, or the shared map is missing, the
+			// status is only updated if it changed, so an update doesn't
+			// trigger another reconcile straight away and the retries back
+			// off.
+			var originalAppState *bpfmaniov1alpha1.ClusterBpfApplicationState
+			if unavailable || waitingForSharedMap {
+				originalAppState = bpfAppStateOriginal
+			}
+			statusChanged, err := r.updateBpfAppStateStatus(ctx, originalAppState)
 			if err != nil {
 				r.Logger.Error(err, "failed to update BpfApplicationState status", "Name", r.currentApp.Name)
 				return ctrl.Result{Requeue: true, RequeueAfter: retryDurationAgent}, nil
@@ -201,17 +324,57 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			// Otherwise, one bad BpfApplication can block the rest.
 			continue
 		}
+		r.reportBpfmanAvailable()
 
 		// Initialize the BpfApplicationState status to Success.  It will be set
 		// to Error if any of the programs have an error.
 		bpfApplicationStatus := bpfmaniov1alpha1.BpfAppStateCondSuccess
 
+		// An application with no programs has nothing loaded. reconcileLoad()
+		// unloaded any programs it used to have, so drop their state too.
+		if !r.isBeingDeleted() && !r.hasPrograms() {
+			r.currentAppState.Status.Programs = nil
+			bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondNoProgramsDefined
+		}
+
 		// If the BpfApplication is being deleted, all of the links would have
 		// been detached when the programs were unloaded in the reconcileLoad()
 		// operation, so we don't need to reconcile each program here.
 		if !r.isBeingDeleted() {
-			// Reconcile each program in the BpfApplication
-			for progIndex := range r.currentApp.Spec.Programs {
+			// Populate the tail call maps before the programs are attached.
+			if err := r.populateTailCalls(ctx, clTailCallPrograms(r.currentApp, r.currentAppState)); err != nil {
+				r.Logger.Error(err, "failed to populate tail call maps")
+				setLastReconcileError(r, err)
+				bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondError
+				requeue = requeue || errors.Is(err, errTailCallNotLoaded)
+			}
+
+			if err := r.pinApplicationMaps(ctx, r.currentApp.Namespace, r.currentApp.Spec.MapPinPath, clProgramIds(r.currentAppState)); err != nil {
+				r.Logger.Error(err, "failed to pin maps")
+				setLastReconcileError(r, err)
+				bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondError
+			}
+
+			// Links with an autoPriority range are given priorities that
+			// aren't used by this application's attached links, or by the
+			// other applications on the node.
+			r.priorities = newPriorityAllocator(string(r.currentAppState.UID), clAppStateAttachPoints(r.currentAppState))
+
+			// The failurePolicy decides what happens to a program's links
+			// when some of them fail to attach.
+			r.linkFailurePolicy = r.currentApp.Spec.FailurePolicy
+
+			// Programs in an atomic group are attached together or not at
+			// all, so they're checked once they've all been reconciled.
+			atomicGroups := map[string][]ProgramReconciler{}
+
+			// Reconcile each program in the BpfApplication, each after the
+			// programs it tail calls.
+			order, cyclic := reconcileOrder(clTailCallPrograms(r.currentApp, r.currentAppState))
+			if cyclic {
+				r.Logger.V(1).Info("Programs tail call each other, reconciling them in the order they're declared")
+			}
+			for _, progIndex := range order {
 				prog := &r.currentApp.Spec.Programs[progIndex]
 				progState, err := r.getProgState(prog, r.currentAppState.Status.Programs)
 				if err != nil {
@@ -222,8 +385,9 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 					// and continue.
 					//
 					// See: https://github.com/bpfman/bpfman-operator/issues/391
-					r.Logger.Error(fmt.Errorf("ProgramState not found"),
-						"ProgramState not found", "App Name", r.currentApp.Name, "BpfFunctionName", prog.Name)
+					err = fmt.Errorf("ProgramState not found for program %s", prog.Name)
+					r.Logger.Error(err, "ProgramState not found", "App Name", r.currentApp.Name, "BpfFunctionName", prog.Name)
+					setLastReconcileError(r, err)
 					bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondProgramListChangedError
 					continue
 				}
@@ -232,17 +396,32 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 				if err != nil {
 					bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondError
 					r.Logger.Error(err, "error getting program reconciler", "Name", prog.Name)
+					setLastReconcileError(r, err)
 					// Skip this program and continue to the next one
 					continue
 				}
 
-				err = rec.reconcileProgram(ctx, rec, r.isBeingDeleted())
+				// A detached application's links are all detached, as if it
+				// were being deleted, but its programs stay loaded.
+				err = rec.reconcileProgram(ctx, rec, r.isBeingDeleted() || r.currentApp.Spec.Detached)
+				if attachDurationMs := rec.attachDurationMs(); attachDurationMs != nil {
+					progState.AttachDurationMs = attachDurationMs
+				}
 				if err != nil {
 					r.Logger.Info("Error reconciling program", "Name", rec.getProgName())
+					setLastReconcileError(r, fmt.Errorf("program %s: %w", rec.getProgName(), err))
 				} else {
 					r.Logger.Info("Successfully reconciled program", "Name", rec.getProgName())
 				}
+				if group := clAtomicGroup(prog); group != "" {
+					atomicGroups[group] = append(atomicGroups[group], rec)
+				}
 			}
+			if err := r.rollbackAtomicGroups(ctx, atomicGroups); err != nil {
+				setLastReconcileError(r, err)
+			}
+			r.priorities = nil
+			r.linkFailurePolicy = ""
 		}
 
 		// If the bpfApplicationStatus didn't get changed to an error already,
@@ -252,7 +431,40 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			r.Logger.Info("Checking program status", "Name", r.currentAppState.Name, "Status", bpfApplicationStatus)
 		}
 
+		// Warn about links that share an interface with other attachments in
+		// ways that are known not to work.
+		if bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondSuccess &&
+			r.checkAttachConflicts(ctx, string(r.currentAppState.UID), clAppStateAttachPoints(r.currentAppState)) {
+			bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondAttachConflict
+		}
+
+		// Warn about XDP links that stop the rest of the dispatcher's chain
+		// when their program returns to it.
+		if bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondSuccess &&
+			r.checkDispatcherReturnCondition(clAppStateXdpProceedOn(r.currentAppState)) {
+			bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondMissingDispatcherReturn
+		}
+
+		// Warn if the node's topology isn't the one the bytecode was built
+		// for.
+		if bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondSuccess &&
+			r.checkTopology(r.currentApp.Spec.ExpectedTopology) {
+			bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondTopologyMismatch
+		}
+
+		// Warn if the node has a BpfApplicationState left by another
+		// application with the same name.
+		if bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondSuccess && r.checkAppStateCollision() {
+			bpfApplicationStatus = bpfmaniov1alpha1.BpfAppStateCondNameCollision
+		}
+
+		deferred = deferred || bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondPending
+		waitingForContainers = waitingForContainers ||
+			bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondWaitingForContainers
 		r.updateBpfAppStateCondition(r, bpfApplicationStatus)
+		if bpfApplicationStatus == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+			r.setAppStateLastError(nil)
+		}
 
 		// We've completed reconciling all programs and if something has
 		// changed, we need to update the BpfApplicationState.
@@ -278,13 +490,34 @@ func (r *ClBpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	// We're done with all the BpfApplication objects, so we can return.
 	r.Logger.Info("All BpfApplication objects have been reconciled")
+	if bpfmanUnavailable {
+		// Retry with the controller's backoff, rather than a fixed interval,
+		// until bpfman is back.
+		return ctrl.Result{Requeue: true}, nil
+	}
+	if requeue {
+		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationAgent}, nil
+	}
+	if deferred {
+		return ctrl.Result{Requeue: true, RequeueAfter: interfaceDownRetryInterval}, nil
+	}
+	if waitingForContainers {
+		return ctrl.Result{Requeue: true, RequeueAfter: waitingForContainersRetryInterval}, nil
+	}
+	if requeueAfter := shorterInterval(r.SuccessRequeueInterval, imageRefresh); requeueAfter > 0 {
+		// Check again later that the kernel state hasn't drifted, and for
+		// new images.
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
 func (r *ClBpfApplicationReconciler) createBpfAppState(ctx context.Context) (ctrl.Result, error) {
 	// Create a new ClusterBpfApplicationState object first, once it's created,
 	// initialize the Status subresource and then update the status.
-	if err := r.initBpfAppState(); err != nil {
+	labels, labelErr := bpfAppStateLabels(r.currentApp.Spec.BpfAppStateLabels, r.currentApp.GetName(),
+		appIdentity(r.currentApp), r.NodeName)
+	if err := r.initBpfAppState(labels); err != nil {
 		r.Logger.Error(err, "failed to initialize BpfApplicationState object")
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationAgent}, nil
 	}
@@ -296,6 +529,11 @@ func (r *ClBpfApplicationReconciler) createBpfAppState(ctx context.Context) (ctr
 		r.Logger.Error(err, "failed to initialize BpfApplicationState status")
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationAgent}, nil
 	}
+	if labelErr != nil {
+		// Invalid labels are left off rather than blocking the application.
+		r.Logger.Error(labelErr, "invalid bpfAppStateLabels", "Name", r.currentApp.Name)
+		setLastReconcileError(r, labelErr)
+	}
 	if _, err := r.updateBpfAppStateStatus(ctx, nil); err != nil {
 		r.Logger.Error(err, "failed to update BpfApplicationState status", "Name", r.currentApp.Name)
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationAgent}, nil
@@ -375,10 +613,14 @@ func (r *ClBpfApplicationReconciler) getProgramReconciler(prog *bpfmaniov1alpha1
 		}
 
 	case bpfmaniov1alpha1.ProgTypeTCX:
+		resolved, err := resolveTcxChain(r.currentApp.Spec.Programs, prog, internal.ClTcxChainLinks, withClTcxPriorities)
+		if err != nil {
+			return nil, err
+		}
 		rec = &ClTcxProgramReconciler{
 			ReconcilerCommon: r.ReconcilerCommon,
 			ClProgramReconcilerCommon: ClProgramReconcilerCommon{
-				currentProgram:      prog,
+				currentProgram:      resolved,
 				currentProgramState: progState,
 			},
 		}
@@ -406,12 +648,44 @@ func (r *ClBpfApplicationReconciler) checkProgramStatus() bpfmaniov1alpha1.BpfAp
 	if r.currentAppState.Status.AppLoadStatus == bpfmaniov1alpha1.AppUnLoadSuccess {
 		return bpfmaniov1alpha1.BpfAppStateCondUnloaded
 	}
+	status := bpfmaniov1alpha1.BpfAppStateCondSuccess
+	unsupportedDirection := false
+	foreignXdpProgram := false
+	targetNotFound := false
 	for _, program := range r.currentAppState.Status.Programs {
-		if program.ProgramLinkStatus != bpfmaniov1alpha1.ProgAttachSuccess {
+		switch program.ProgramLinkStatus {
+		case bpfmaniov1alpha1.ProgAttachSuccess:
+		case bpfmaniov1alpha1.ProgAttachPartial:
+			// The failed links report their errors, but with a BestEffort
+			// failurePolicy they don't fail the application.
+		case bpfmaniov1alpha1.ProgAttachUnsupportedDirection:
+			unsupportedDirection = true
+		case bpfmaniov1alpha1.ProgAttachForeignXdpProgram:
+			foreignXdpProgram = true
+		case bpfmaniov1alpha1.ProgAttachTargetNotFound:
+			targetNotFound = true
+		case bpfmaniov1alpha1.ProgAttachDeferred:
+			// Links waiting for an interface to come up leave the
+			// application pending rather than failed.
+			status = bpfmaniov1alpha1.BpfAppStateCondPending
+		case bpfmaniov1alpha1.ProgAttachWaitingForContainers:
+			if status == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+				status = bpfmaniov1alpha1.BpfAppStateCondWaitingForContainers
+			}
+		default:
 			return bpfmaniov1alpha1.BpfAppStateCondError
 		}
 	}
-	return bpfmaniov1alpha1.BpfAppStateCondSuccess
+	if unsupportedDirection {
+		return bpfmaniov1alpha1.BpfAppStateCondUnsupportedDirection
+	}
+	if foreignXdpProgram {
+		return bpfmaniov1alpha1.BpfAppStateCondForeignXdpProgram
+	}
+	if targetNotFound {
+		return bpfmaniov1alpha1.BpfAppStateCondTargetNotFound
+	}
+	return status
 }
 
 // getProgState returns the BpfApplicationProgramState object for the current node.
@@ -535,7 +809,7 @@ func (r *ClBpfApplicationReconciler) getBpfAppState(ctx context.Context) (*bpfma
 
 	opts := []client.ListOption{
 		client.MatchingLabels{
-			internal.BpfAppStateOwner: r.currentApp.GetName(),
+			internal.BpfAppStateOwner: internal.AppStateOwnerLabel(r.currentApp.GetName()),
 			internal.K8sHostLabel:     r.NodeName,
 		},
 	}
@@ -545,30 +819,44 @@ func (r *ClBpfApplicationReconciler) getBpfAppState(ctx context.Context) (*bpfma
 		return nil, err
 	}
 
-	switch len(appProgramList.Items) {
+	// Leave out any that belong to another application with the same name.
+	appStates := []bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	r.foreignAppStates = nil
+	r.supersededBy = ""
+	for _, appState := range appProgramList.Items {
+		if isForeignAppState(r.currentApp, &appState) {
+			r.foreignAppStates = append(r.foreignAppStates, appState.Name)
+			continue
+		}
+		appStates = append(appStates, appState)
+	}
+
+	switch len(appStates) {
 	case 1:
-		r.Logger.V(1).Info("Found BpfApplicationState", "Name", appProgramList.Items[0].Name)
-		return &appProgramList.Items[0], nil
+		r.Logger.V(1).Info("Found BpfApplicationState", "Name", appStates[0].Name)
+		return &appStates[0], nil
 	case 0:
+		// An application with an identity takes over the
+		// BpfApplicationState of the one it was renamed from.
+		if !r.isBeingDeleted() {
+			return r.getBpfAppStateByIdentity(ctx)
+		}
 		// No BpfApplicationState found, so return nil
 		r.Logger.V(1).Info("No BpfApplicationState found")
 		return nil, nil
 	default:
 		// More than one matching BpfApplicationState found. This should never
 		// happen, but if it does, return an error
-		return nil, fmt.Errorf("more than one BpfApplicationState found (%d)", len(appProgramList.Items))
+		return nil, fmt.Errorf("more than one BpfApplicationState found (%d)", len(appStates))
 	}
 }
 
-func (r *ClBpfApplicationReconciler) initBpfAppState() error {
+func (r *ClBpfApplicationReconciler) initBpfAppState(labels map[string]string) error {
 	r.currentAppState = &bpfmaniov1alpha1.ClusterBpfApplicationState{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:       generateUniqueName(r.currentApp.Name),
 			Finalizers: []string{r.finalizer},
-			Labels: map[string]string{
-				internal.BpfAppStateOwner: r.currentApp.GetName(),
-				internal.K8sHostLabel:     r.NodeName,
-			},
+			Labels:     labels,
 		},
 	}
 
@@ -681,13 +969,14 @@ func (r *ClBpfApplicationReconciler) initializeNodeProgramList() error {
 func (r *ClBpfApplicationReconciler) isLoaded(ctx context.Context) bool {
 	allProgramsLoaded := true
 	someProgramsLoaded := false
-	for _, program := range r.currentAppState.Status.Programs {
+	for i, program := range r.currentAppState.Status.Programs {
 		if program.ProgramId == nil {
 			allProgramsLoaded = false
-		} else if _, err := bpfmanagentinternal.GetBpfmanProgramById(ctx, r.BpfmanClient, *program.ProgramId); err != nil {
+		} else if loaded, err := bpfmanagentinternal.GetBpfmanProgramById(ctx, r.BpfmanClient, *program.ProgramId); err != nil {
 			allProgramsLoaded = false
 		} else {
 			someProgramsLoaded = true
+			r.currentAppState.Status.Programs[i].KernelInfo = bpfmanagentinternal.GetProgramKernelInfo(loaded.KernelInfo)
 		}
 	}
 
@@ -703,12 +992,6 @@ func (r *ClBpfApplicationReconciler) isLoaded(ctx context.Context) bool {
 }
 
 func (r *ClBpfApplicationReconciler) getLoadRequest() (*gobpfman.LoadRequest, error) {
-
-	bytecode, err := bpfmanagentinternal.GetBytecode(r.Client, &r.currentApp.Spec.BpfAppCommon.ByteCode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to process bytecode selector: %v", err)
-	}
-
 	loadInfo := []*gobpfman.LoadInfo{}
 
 	for _, program := range r.currentApp.Spec.Programs {
@@ -724,32 +1007,120 @@ func (r *ClBpfApplicationReconciler) getLoadRequest() (*gobpfman.LoadRequest, er
 		loadInfo = append(loadInfo, programLoadInfo)
 	}
 
+	globalData, err := bpfmanagentinternal.GetGlobalData(r.Client, "", r.currentApp.Spec.GlobalData,
+		r.currentApp.Spec.GlobalDataFrom)
+	if err != nil {
+		return nil, err
+	}
+	globalData, err = r.nodeGlobalData(globalData, r.currentApp.Spec.GlobalDataOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the bytecode last, as bytecode from a ConfigMap is written to a
+	// file that load() removes once the request is done.
+	bytecode, err := bpfmanagentinternal.GetBytecode(r.Client, "", &r.currentApp.Spec.BpfAppCommon.ByteCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process bytecode selector: %v", err)
+	}
+
+	keys := r.metadataKeys()
 	loadRequest := gobpfman.LoadRequest{
 		Bytecode:   bytecode,
-		Metadata:   map[string]string{internal.UuidMetadataKey: string(r.currentAppState.UID), internal.ProgramNameKey: r.currentApp.Name},
-		GlobalData: r.currentApp.Spec.GlobalData,
+		Metadata:   map[string]string{keys.Uuid: string(r.currentAppState.UID), keys.ProgramName: r.currentApp.Name},
+		GlobalData: globalData,
 		Uuid:       new(string),
-		// TODO: Get map owner ID working.  For now, just pass nil.
-		// See: https://github.com/bpfman/bpfman-operator/issues/393
+		// MapOwnerId is set by load() once any sharedMap is resolved.
 		MapOwnerId: nil,
 		Info:       loadInfo,
 	}
 
+	if r.currentApp.Spec.MapPinPath != "" {
+		loadRequest.Metadata[keys.MapPinPath] = r.currentApp.Spec.MapPinPath
+	}
+
 	return &loadRequest, nil
 }
 
+// getProgramTypes returns the kernel program type expected for each of the
+// ClusterBpfApplication's programs, by name.
+func (r *ClBpfApplicationReconciler) getProgramTypes() map[string]internal.ProgramType {
+	programTypes := map[string]internal.ProgramType{}
+	for _, program := range r.currentApp.Spec.Programs {
+		progState, err := r.getProgState(&program, r.currentAppState.Status.Programs)
+		if err != nil {
+			continue
+		}
+		progRec, err := r.getProgramReconciler(&program, progState)
+		if err != nil {
+			continue
+		}
+		programTypes[program.Name] = progRec.getProgType()
+	}
+	return programTypes
+}
+
 func (r *ClBpfApplicationReconciler) load(ctx context.Context) error {
+	if r.currentApp.Spec.AdoptExisting {
+		return r.adopt(ctx)
+	}
+
+	mapOwnerId, err := r.resolveSharedMap(ctx, r.currentApp.Spec.SharedMap, string(r.currentAppState.UID), nil)
+	if err != nil {
+		return err
+	}
+
 	loadRequest, err := r.getLoadRequest()
 	if err != nil {
 		return fmt.Errorf("failed to get LoadRequest: %w", err)
 	}
+	loadRequest.MapOwnerId = mapOwnerId
+	additional := r.currentApp.Spec.AdditionalByteCode
+	additionalRequests, err := r.additionalLoadRequests("", additional, loadRequest)
+	if err != nil {
+		bpfmanagentinternal.CleanupBytecode(&r.currentApp.Spec.BpfAppCommon.ByteCode, loadRequest.Bytecode)
+		return err
+	}
+	for _, request := range append([]*gobpfman.LoadRequest{loadRequest}, additionalRequests...) {
+		if err := r.checkBytecodeFunctions(request); err != nil {
+			bpfmanagentinternal.CleanupBytecode(&r.currentApp.Spec.BpfAppCommon.ByteCode, loadRequest.Bytecode)
+			cleanupAdditionalByteCode(additional, additionalRequests)
+			return err
+		}
+	}
 
-	loadResponse, err := r.BpfmanClient.Load(ctx, loadRequest)
+	// Programs kept loaded while the agent was restarted are adopted rather
+	// than loaded again.
+	var loadedPrograms []*gobpfman.LoadResponseInfo
+	if r.PersistPrograms {
+		loadedPrograms = r.findPersistedPrograms(ctx, &r.currentApp.Spec.ByteCode, loadRequest)
+	}
+	persisted := loadedPrograms != nil
+	var loadDurationMs *int64
+	if !persisted {
+		start := time.Now()
+		loadedPrograms, err = bpfmanagentinternal.LoadBpfmanProgram(ctx, r.BpfmanClient, loadRequest)
+		if errors.Is(err, bpfmanagentinternal.ErrAlreadyLoaded) {
+			loadedPrograms, err = r.resolveLoadConflict(ctx, &r.currentApp.Spec.ByteCode, loadRequest, err)
+		}
+		loadDurationMs = ptr.To(time.Since(start).Milliseconds())
+	}
+	if err == nil {
+		// The programs in additionalByteCode share the maps of those loaded
+		// from byteCode, so they're loaded once those are.
+		loadedPrograms, err = r.loadAdditionalByteCode(ctx, additionalRequests, additional, mapOwnerId,
+			loadedPrograms, persisted)
+	}
+	bpfmanagentinternal.CleanupBytecode(&r.currentApp.Spec.BpfAppCommon.ByteCode, loadRequest.Bytecode)
+	cleanupAdditionalByteCode(additional, additionalRequests)
+	if err == nil {
+		err = r.checkProgramTypes(ctx, r.getProgramTypes(), loadedPrograms)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to load eBPF Program: %v", err)
+		return fmt.Errorf("failed to load eBPF Program: %w", err)
 	} else {
 		for p, program := range r.currentAppState.Status.Programs {
-			id, err := bpfmanagentinternal.GetBpfProgramId(program.Name, loadResponse.Programs)
+			id, err := bpfmanagentinternal.GetBpfProgramId(program.Name, loadedPrograms)
 			// This should never happen because the bpfman load is all or nothing,
 			// and we aren't allowing users to add or remove programs from an
 			// existing BpfApplication.  However, if it does happen, log an error.
@@ -758,28 +1129,71 @@ func (r *ClBpfApplicationReconciler) load(ctx context.Context) error {
 				return fmt.Errorf("failed to get program id: %v", err)
 			}
 			r.currentAppState.Status.Programs[p].ProgramId = id
+			r.currentAppState.Status.Programs[p].LoadDurationMs = loadDurationMs
+			r.currentAppState.Status.Programs[p].KernelInfo = bpfmanagentinternal.GetBpfProgramKernelInfo(program.Name, loadedPrograms)
 		}
+		r.currentAppState.Status.ResolvedBytecode = bpfmanagentinternal.GetResolvedBytecode(loadRequest.Bytecode, loadedPrograms)
+		r.recordImageDigest(ctx, &r.currentApp.Spec.ByteCode, loadRequest.Bytecode.GetImage(),
+			r.currentAppState.Status.ResolvedBytecode)
 	}
 	return nil
 }
 
+// adopt records the ids of programs that bpfman already has loaded, instead
+// of loading the ClusterBpfApplication's bytecode.
+func (r *ClBpfApplicationReconciler) adopt(ctx context.Context) error {
+	names := []string{}
+	for _, program := range r.currentAppState.Status.Programs {
+		names = append(names, program.Name)
+	}
+
+	adoptedPrograms, err := bpfmanagentinternal.FindBpfmanProgramsToAdopt(ctx, r.BpfmanClient, r.metadataKeys().Uuid,
+		r.currentAppState.UID, names)
+	if err != nil {
+		return fmt.Errorf("failed to adopt eBPF Program: %w", err)
+	}
+	for p, program := range r.currentAppState.Status.Programs {
+		id, err := bpfmanagentinternal.GetBpfProgramId(program.Name, adoptedPrograms)
+		if err != nil {
+			return fmt.Errorf("failed to get program id: %v", err)
+		}
+		r.Logger.Info("Adopted program", "Program", program.Name, "ProgramId", id)
+		r.currentAppState.Status.Programs[p].ProgramId = id
+		r.currentAppState.Status.Programs[p].KernelInfo = bpfmanagentinternal.GetBpfProgramKernelInfo(program.Name, adoptedPrograms)
+	}
+	r.currentAppState.Status.ResolvedBytecode = bpfmanagentinternal.GetResolvedBytecode(nil, adoptedPrograms)
+	return nil
+}
+
+// unload removes the pins of the application's maps, then unloads its
+// programs, unloading programs that tail call others before their targets.
 func (r *ClBpfApplicationReconciler) unload(ctx context.Context) {
-	for i, program := range r.currentAppState.Status.Programs {
+	r.unpinApplicationMaps(ctx, r.currentApp.Namespace, r.currentApp.Spec.MapPinPath, clProgramIds(r.currentAppState))
+	for _, i := range clUnloadOrder(r.currentApp, r.currentAppState) {
+		program := r.currentAppState.Status.Programs[i]
 		if program.ProgramId != nil {
 			err := bpfmanagentinternal.UnloadBpfmanProgram(ctx, r.BpfmanClient, *program.ProgramId)
-			if err != nil {
-				// This should never happen under normal operations.  However,
-				// it is possible that someone unloaded the program manually. In
-				// that case, we should log the error and continue.
+			if errors.Is(err, bpfmanagentinternal.ErrProgramNotFound) {
+				// Someone unloaded the program manually, so there's nothing
+				// left to do.
+				r.Logger.Info("program already unloaded", "ProgramId", *program.ProgramId)
+			} else if err != nil {
+				// This should never happen under normal operations, so log
+				// the error and continue.
 				r.Logger.Error(err, "failed to unload program", "ProgramId", *program.ProgramId)
 			}
 			r.currentAppState.Status.Programs[i].ProgramId = nil
+			r.currentAppState.Status.Programs[i].LoadDurationMs = nil
+			r.currentAppState.Status.Programs[i].AttachDurationMs = nil
+			r.currentAppState.Status.Programs[i].KernelInfo = nil
 			// When bpfman deletes a program, it also automatically detaches all links, so,
 			// we can just delete the links from the state.
 			r.deleteLinks(&r.currentAppState.Status.Programs[i])
 		}
 		r.currentAppState.Status.Programs[i].ProgramLinkStatus = bpfmaniov1alpha1.ProgAttachSuccess
 	}
+	r.currentAppState.Status.ResolvedBytecode = nil
+	r.forgetImageRefresh(r.currentAppState.Name)
 }
 
 func (r *ClBpfApplicationReconciler) deleteLinks(program *bpfmaniov1alpha1.ClBpfApplicationProgramState) {
@@ -18,20 +18,41 @@ package bpfmanagent
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
 	"github.com/bpfman/bpfman-operator/internal"
 	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/vishvananda/netlink"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -377,6 +398,11 @@ func TestClBpfApplicationControllerCreate(t *testing.T) {
 
 	require.Equal(t, internal.ClBpfApplicationControllerFinalizer, bpfAppState2.Finalizers[0])
 
+	// Make sure the bytecode source used for the load was recorded.
+	require.NotNil(t, bpfAppState2.Status.ResolvedBytecode)
+	require.Equal(t, bytecodePath, bpfAppState2.Status.ResolvedBytecode.Path)
+	require.Empty(t, bpfAppState2.Status.ResolvedBytecode.Image)
+
 	for _, program := range bpfAppState2.Status.Programs {
 		r.Logger.Info("ProgramAttachStatus check", "program", program.Name, "status", program.ProgramLinkStatus)
 		require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, program.ProgramLinkStatus)
@@ -403,3 +429,2998 @@ func TestClBpfApplicationControllerCreate(t *testing.T) {
 	// Check that the bpfAppState was not updated
 	require.True(t, reflect.DeepEqual(bpfAppState2, bpfAppState3))
 }
+
+func TestClBpfApplicationControllerTcNetns(t *testing.T) {
+	var (
+		appProgramName    = "fakeTcNetnsProgram"
+		bytecodePath      = "/tmp/hello.o"
+		tcBpfFunctionName = "TcTest"
+		fakeNode          = testutils.NewNode("fake-control-plane")
+		fakeInt0          = "eth0"
+		fakePodName       = "my-pod"
+		fakeContainerName = "my-container-1"
+		fakePid           = int32(4490)
+		ctx               = context.TODO()
+	)
+
+	// The pod's network namespace doesn't exist on the test host, so stand in
+	// a fake /proc with a file for it.
+	procPath := fakeHostProc(t, fakePid)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: tcBpfFunctionName,
+					Type: bpfmaniov1alpha1.ProgTypeTC,
+					TC: &bpfmaniov1alpha1.ClTcProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTcAttachInfo{
+							{
+								InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{
+									Interfaces: []string{fakeInt0},
+								},
+								NetworkNamespaces: &bpfmaniov1alpha1.ClNetworkNamespaceSelector{
+									Namespace: "default",
+									Pods: metav1.LabelSelector{
+										MatchLabels: map[string]string{"app": "test"},
+									},
+								},
+								Direction: bpfmaniov1alpha1.TCIngress,
+								Priority:  50,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	testContainers := FakeContainerGetter{
+		containerList: &[]ContainerInfo{
+			{
+				podName:       fakePodName,
+				containerName: fakeContainerName,
+				pid:           fakePid,
+			},
+			{
+				// This pod has no network namespace, as if it has exited, so
+				// it must be skipped.
+				podName:       "exited-pod",
+				containerName: fakeContainerName,
+				pid:           fakePid + 1,
+			},
+		},
+	}
+	r.HostProcPath = procPath
+	r.Containers = &testContainers
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	// The first reconcile creates the ClusterBpfApplicationState object and
+	// the second loads and attaches the program.
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+
+	require.Len(t, bpfAppState.Status.Programs, 1)
+	tcState := bpfAppState.Status.Programs[0].TC
+	require.NotNil(t, tcState)
+	require.Len(t, tcState.Links, 1)
+
+	link := tcState.Links[0]
+	require.Equal(t, fakeInt0, link.InterfaceName)
+	require.Equal(t, r.netnsPathFromPID(fakePid), link.NetnsPath)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+	require.NotNil(t, link.LinkId)
+
+	// The netns path must be passed to bpfman in the attach request.
+	attachReq := cli.AttachRequests[int(*link.LinkId)]
+	require.NotNil(t, attachReq)
+	tcAttachInfo := attachReq.GetAttach().GetTcAttachInfo()
+	require.NotNil(t, tcAttachInfo)
+	require.Equal(t, fakeInt0, tcAttachInfo.Iface)
+	require.Equal(t, r.netnsPathFromPID(fakePid), tcAttachInfo.GetNetns())
+}
+
+func TestClBpfApplicationControllerForceResync(t *testing.T) {
+	var (
+		appProgramName        = "fakeForceResyncProgram"
+		kprobeBpfFunctionName = "KprobeTest"
+		fakeNode              = testutils.NewNode("fake-control-plane")
+		ctx                   = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, kprobeBpfFunctionName)
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	reconcileUntilAttached(t, r, req)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Empty(t, bpfAppState.Status.ObservedForceResync)
+	programId := *bpfAppState.Status.Programs[0].ProgramId
+	linkId := *bpfAppState.Status.Programs[0].KProbe.Links[0].LinkId
+
+	// Simulate the link being detached behind bpfman-agent's back.
+	delete(cli.Links, int(linkId))
+	cli.Programs[int(programId)].Info.Links = nil
+
+	// Changing the annotation must get past the controller's predicates even
+	// though the generation doesn't change.
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: appProgramName}, app))
+	oldApp := app.DeepCopy()
+	app.SetAnnotations(map[string]string{internal.ForceResyncAnnotation: "2025-01-01T00:00:00Z"})
+	require.NoError(t, r.Client.Update(ctx, app))
+	require.True(t, forceResyncPredicate().Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: app}))
+	require.False(t, forceResyncPredicate().Update(event.UpdateEvent{ObjectOld: app, ObjectNew: app}))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "2025-01-01T00:00:00Z", bpfAppState.Status.ObservedForceResync)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+
+	// The full pass must have reattached the link.
+	link := bpfAppState.Status.Programs[0].KProbe.Links[0]
+	require.NotNil(t, link.LinkId)
+	require.NotEqual(t, linkId, *link.LinkId)
+	require.True(t, cli.Links[int(*link.LinkId)])
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+}
+
+func TestClBpfApplicationControllerLastError(t *testing.T) {
+	var (
+		appProgramName        = "fakeLastErrorProgram"
+		kprobeBpfFunctionName = "KprobeTest"
+		fakeNode              = testutils.NewNode("fake-control-plane")
+		ctx                   = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, kprobeBpfFunctionName)
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	cli.LoadErr = fmt.Errorf("invalid bytecode: %s", strings.Repeat("x", 2*maxLastErrorLength))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	// Create the ClusterBpfApplicationState, then fail to load.
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondError), bpfAppState.Status.Conditions[0].Type)
+	lastError := bpfAppState.Status.LastError
+	require.NotNil(t, lastError)
+	require.Contains(t, lastError.Message, "invalid bytecode")
+	require.Len(t, lastError.Message, maxLastErrorLength)
+	require.True(t, strings.HasSuffix(lastError.Message, "..."))
+	require.False(t, lastError.Time.IsZero())
+
+	// The same error again must not change the recorded time.
+	firstSeen := lastError.Time
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.True(t, firstSeen.Equal(&bpfAppState.Status.LastError.Time))
+
+	// Once the load succeeds, the error is cleared.
+	cli.LoadErr = nil
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	require.Nil(t, bpfAppState.Status.LastError)
+}
+
+func TestClBpfApplicationControllerAdoptExisting(t *testing.T) {
+	var (
+		appProgramName        = "fakeAdoptProgram"
+		bytecodePath          = "/tmp/hello.o"
+		kprobeBpfFunctionName = "KprobeTest"
+		fakeNode              = testutils.NewNode("fake-control-plane")
+		ctx                   = context.TODO()
+		adoptedId             = uint32(42)
+	)
+
+	newReconciler := func(cli *agenttestutils.BpfmanClientFake) *ClBpfApplicationReconciler {
+		bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: appProgramName,
+			},
+			Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+				BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+					NodeSelector: metav1.LabelSelector{},
+					ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+						Path: &bytecodePath,
+					},
+					AdoptExisting: true,
+				},
+				Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+					{
+						Name: kprobeBpfFunctionName,
+						Type: bpfmaniov1alpha1.ProgTypeKprobe,
+						KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+							Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+								{Function: "try_to_wake_up"},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		r, _ := newTestClReconciler(t, fakeNode, bpfApp)
+		r.BpfmanClient = cli
+		return r
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	t.Run("adopts loaded program", func(t *testing.T) {
+		loaded := &gobpfman.LoadResponseInfo{
+			Info:       &gobpfman.ProgramInfo{Name: kprobeBpfFunctionName},
+			KernelInfo: &gobpfman.KernelProgramInfo{Id: adoptedId, Name: kprobeBpfFunctionName},
+		}
+		cli := agenttestutils.NewBpfmanClientFakeWithPrograms(map[int]*gobpfman.GetResponse{
+			int(adoptedId): {Info: loaded.Info, KernelInfo: loaded.KernelInfo},
+		})
+		cli.ListResults = []*gobpfman.ListResponse_ListResult{
+			{Info: loaded.Info, KernelInfo: loaded.KernelInfo},
+		}
+		r := newReconciler(cli)
+
+		// Create the ClusterBpfApplicationState, then adopt and attach.
+		for i := 0; i < 3; i++ {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Empty(t, cli.LoadRequests)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+		require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+		require.Equal(t, adoptedId, *bpfAppState.Status.Programs[0].ProgramId)
+		require.Len(t, bpfAppState.Status.Programs[0].KProbe.Links, 1)
+	})
+
+	t.Run("fails when program not found", func(t *testing.T) {
+		cli := agenttestutils.NewBpfmanClientFake()
+		r := newReconciler(cli)
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Empty(t, cli.LoadRequests)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondError), bpfAppState.Status.Conditions[0].Type)
+		require.Nil(t, bpfAppState.Status.Programs[0].ProgramId)
+		require.NotNil(t, bpfAppState.Status.LastError)
+		require.Contains(t, bpfAppState.Status.LastError.Message, fmt.Sprintf("no loaded program named %q to adopt", kprobeBpfFunctionName))
+	})
+}
+
+func TestClBpfApplicationControllerBpfAppStateLabels(t *testing.T) {
+	var (
+		appProgramName = "fakeLabelsProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+				BpfAppStateLabels: map[string]string{
+					"team":                    "networking",
+					"example.com/cost-center": "1234",
+					internal.K8sHostLabel:     "other-node",
+					internal.BpfAppStateOwner: "other-app",
+					"not a valid key":         "value",
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "KprobeTest",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+							{Function: "try_to_wake_up"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, _ := newTestClReconciler(t, fakeNode, bpfApp)
+
+	// The first reconcile creates the ClusterBpfApplicationState.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, bpfAppState)
+	require.Equal(t, map[string]string{
+		"team":                    "networking",
+		"example.com/cost-center": "1234",
+		internal.K8sHostLabel:     fakeNode.Name,
+		internal.BpfAppStateOwner: appProgramName,
+	}, bpfAppState.Labels)
+
+	// The invalid label is left off and reported.
+	require.NotNil(t, bpfAppState.Status.LastError)
+	require.Contains(t, bpfAppState.Status.LastError.Message, "spec.bpfAppStateLabels: Invalid value: \"not a valid key\"")
+}
+
+// A ClusterBpfApplication whose name is too long for a label value gets a
+// ClusterBpfApplicationState with a valid name and owner label, and it's
+// found again on the next reconcile.
+func TestClBpfApplicationControllerLongName(t *testing.T) {
+	var (
+		appProgramName = strings.Repeat("long-application-name-", 12)[:252] + "x"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, "KprobeTest")
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	for range 3 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	appStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	require.NoError(t, r.Client.List(ctx, appStates))
+	require.Len(t, appStates.Items, 1)
+	require.Len(t, cli.LoadRequests, 1)
+
+	// The name and label are truncated, and the label is the same every
+	// time, so the state is found again.
+	appState := appStates.Items[0]
+	require.Len(t, appState.Name, 253)
+	require.Empty(t, validation.IsDNS1123Subdomain(appState.Name))
+	ownerLabel := appState.Labels[internal.BpfAppStateOwner]
+	require.Empty(t, validation.IsValidLabelValue(ownerLabel))
+	require.Equal(t, internal.AppStateOwnerLabel(appProgramName), ownerLabel)
+	require.True(t, strings.HasPrefix(ownerLabel, appProgramName[:50]))
+	require.NotEqual(t, ownerLabel, internal.AppStateOwnerLabel(appProgramName[:252]+"y"))
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), appState.Status.Conditions[0].Type)
+}
+
+func TestClBpfApplicationControllerWaitingForContainers(t *testing.T) {
+	var (
+		appProgramName = "fakeUprobeProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "UprobeTest",
+					Type: bpfmaniov1alpha1.ProgTypeUprobe,
+					UProbe: &bpfmaniov1alpha1.ClUprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClUprobeAttachInfo{
+							{
+								Function: "malloc",
+								Target:   "libc",
+								Containers: &bpfmaniov1alpha1.ClContainerSelector{
+									Namespace: "default",
+									Pods: metav1.LabelSelector{
+										MatchLabels: map[string]string{"app": "test"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	// The pods haven't started yet.
+	testContainers := FakeContainerGetter{containerList: &[]ContainerInfo{}}
+	r.Containers = &testContainers
+
+	// Create the ClusterBpfApplicationState, load, then reconcile once more
+	// with nothing left to change.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	var res reconcile.Result
+	var err error
+	for range 3 {
+		res, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, waitingForContainersRetryInterval, res.RequeueAfter)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Len(t, bpfAppState.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondWaitingForContainers), bpfAppState.Status.Conditions[0].Type)
+	require.Equal(t, bpfmaniov1alpha1.ProgAttachWaitingForContainers, bpfAppState.Status.Programs[0].ProgramLinkStatus)
+	require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+	require.Empty(t, bpfAppState.Status.Programs[0].UProbe.Links)
+	require.Empty(t, cli.AttachRequests)
+
+	// Once a pod starts, the program is attached to its container and the
+	// condition is cleared.
+	testContainers.containerList = &[]ContainerInfo{
+		{podName: "my-pod", containerName: "my-container", pid: 4490},
+	}
+	for range 2 {
+		res, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Zero(t, res.RequeueAfter)
+
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, bpfAppState.Status.Programs[0].ProgramLinkStatus)
+	require.Len(t, bpfAppState.Status.Programs[0].UProbe.Links, 1)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, bpfAppState.Status.Programs[0].UProbe.Links[0].LinkStatus)
+	require.Len(t, cli.AttachRequests, 1)
+}
+
+func TestClBpfApplicationControllerNodeLookup(t *testing.T) {
+	var (
+		appProgramName = "fakeKprobeProgram"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, "KprobeTest")
+
+	s := testScheme(t)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	t.Run("node not found", func(t *testing.T) {
+		cl := newTestClient(bpfApp)
+		cli := agenttestutils.NewBpfmanClientFake()
+		r := &ClBpfApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon{
+				Client:       cl,
+				Scheme:       s,
+				BpfmanClient: cli,
+				NodeName:     fakeNode.Name,
+			},
+		}
+
+		// The node is gone, so the request is dropped without an error.
+		res, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, reconcile.Result{}, res)
+
+		appStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+		require.NoError(t, cl.List(ctx, appStates))
+		require.Empty(t, appStates.Items)
+	})
+
+	t.Run("transient error", func(t *testing.T) {
+		failures := 1
+		cl := newTestClientBuilder(fakeNode, bpfApp).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*v1.Node); ok && failures > 0 {
+						failures--
+						return apierrors.NewServerTimeout(v1.Resource("nodes"), "get", 1)
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+			}).Build()
+		cli := agenttestutils.NewBpfmanClientFake()
+		r := &ClBpfApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon{
+				Client:       cl,
+				Scheme:       s,
+				BpfmanClient: cli,
+				NodeName:     fakeNode.Name,
+			},
+		}
+
+		// The error is returned so the request is requeued with backoff.
+		_, err := r.Reconcile(ctx, req)
+		require.Error(t, err)
+		require.True(t, apierrors.IsServerTimeout(errors.Unwrap(err)))
+
+		// The retry finds the node and creates the ClusterBpfApplicationState.
+		_, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		_, err = r.getBpfAppState(ctx)
+		require.NoError(t, err)
+	})
+}
+
+func TestClBpfApplicationControllerNoPrograms(t *testing.T) {
+	var (
+		appProgramName = "fakeNoProgramsProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	kprobeProgram := func(name, function string) bpfmaniov1alpha1.ClBpfApplicationProgram {
+		return bpfmaniov1alpha1.ClBpfApplicationProgram{
+			Name: name,
+			Type: bpfmaniov1alpha1.ProgTypeKprobe,
+			KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+				Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+					{Function: function},
+				},
+			},
+		}
+	}
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				kprobeProgram("KprobeFirst", "try_to_wake_up"),
+				kprobeProgram("KprobeSecond", "do_unlinkat"),
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	reconcileUntilAttached(t, r, req)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	require.Len(t, bpfAppState.Status.Programs, 2)
+	programIds := []int{}
+	for _, program := range bpfAppState.Status.Programs {
+		require.NotNil(t, program.ProgramId)
+		programIds = append(programIds, int(*program.ProgramId))
+	}
+	require.Empty(t, cli.UnloadRequests)
+
+	// Remove both programs. The webhook doesn't allow this, but the agent
+	// must still clean up if it happens.
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: appProgramName}, app))
+	app.Spec.Programs = nil
+	require.NoError(t, r.Client.Update(ctx, app))
+
+	// Unload the programs, then reconcile once more with nothing left to
+	// change.
+	for range 2 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Len(t, bpfAppState.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondNoProgramsDefined), bpfAppState.Status.Conditions[0].Type)
+	require.Equal(t, bpfmaniov1alpha1.AppUnLoadSuccess, bpfAppState.Status.AppLoadStatus)
+	require.Empty(t, bpfAppState.Status.Programs)
+	require.Len(t, cli.UnloadRequests, 2)
+	for _, id := range programIds {
+		require.Contains(t, cli.UnloadRequests, id)
+	}
+	require.Empty(t, cli.Programs)
+}
+
+func TestTruncateMessage(t *testing.T) {
+	require.Equal(t, "short", truncateMessage("short", 10))
+	require.Equal(t, "0123456...", truncateMessage("0123456789abc", 10))
+	// A multi-byte character that doesn't fit is dropped whole.
+	require.Equal(t, "012345...", truncateMessage("012345é789abc", 10))
+}
+
+func TestClBpfApplicationRenameWithIdentity(t *testing.T) {
+	var (
+		bytecodePath = "/tmp/hello.o"
+		fakeNode     = testutils.NewNode("fake-control-plane")
+		ctx          = context.TODO()
+	)
+
+	clApp := func(name, uid string, created time.Time) *bpfmaniov1alpha1.ClusterBpfApplication {
+		return &bpfmaniov1alpha1.ClusterBpfApplication{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				UID:               types.UID(uid),
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations:       map[string]string{internal.AppIdentityAnnotation: "counter"},
+			},
+			Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+				BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+					NodeSelector: metav1.LabelSelector{},
+					ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+						Path: &bytecodePath,
+					},
+				},
+				Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+					{
+						Name: "FentryTest",
+						Type: bpfmaniov1alpha1.ProgTypeFentry,
+						FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+							ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: "do_unlinkat"},
+							Links: []bpfmaniov1alpha1.ClFentryAttachInfo{
+								{Mode: bpfmaniov1alpha1.Attach},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	oldApp := clApp("counter-v1", "old-uid", time.Now().Add(-time.Hour))
+	newApp := clApp("counter-v2", "new-uid", time.Now())
+
+	r, cli := newTestClReconciler(t, fakeNode, oldApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: oldApp.Name}}
+	reconcileApps := func() {
+		for range 4 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	appStates := func() []bpfmaniov1alpha1.ClusterBpfApplicationState {
+		list := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+		require.NoError(t, r.Client.List(ctx, list))
+		return list.Items
+	}
+
+	reconcileApps()
+	states := appStates()
+	require.Len(t, states, 1)
+	require.Equal(t, "counter", states[0].Labels[internal.AppIdentityLabel])
+	require.Equal(t, oldApp.UID, metav1.GetControllerOf(&states[0]).UID)
+	require.Len(t, cli.LoadRequests, 1)
+	programId := states[0].Status.Programs[0].ProgramId
+
+	// The application is renamed by creating it under the new name, which
+	// takes over the old one's state and programs rather than loading them
+	// again.
+	require.NoError(t, r.Client.Create(ctx, newApp))
+	reconcileApps()
+	states = appStates()
+	require.Len(t, states, 1)
+	require.Equal(t, newApp.UID, metav1.GetControllerOf(&states[0]).UID)
+	require.Len(t, states[0].OwnerReferences, 1)
+	require.Equal(t, internal.AppStateOwnerLabel(newApp.Name), states[0].Labels[internal.BpfAppStateOwner])
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), states[0].Status.Conditions[0].Type)
+	require.Equal(t, programId, states[0].Status.Programs[0].ProgramId)
+	require.Len(t, cli.LoadRequests, 1)
+
+	// Deleting the old application leaves the programs loaded.
+	require.NoError(t, r.Client.Delete(ctx, oldApp))
+	reconcileApps()
+	require.Len(t, appStates(), 1)
+	require.Empty(t, cli.UnloadRequests)
+	require.Len(t, cli.LoadRequests, 1)
+}
+
+func TestClBpfApplicationStateNameCollision(t *testing.T) {
+	var (
+		appProgramName = "fakeCollisionProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+			UID:  "new-app-uid",
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "kprobe_test",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+							{Function: "try_to_wake_up"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// A deleted application with the same name left its state behind.
+	staleAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName + "-12345678",
+			Labels: map[string]string{
+				internal.BpfAppStateOwner: appProgramName,
+				internal.K8sHostLabel:     fakeNode.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: bpfmaniov1alpha1.SchemeGroupVersion.String(),
+					Kind:       "ClusterBpfApplication",
+					Name:       appProgramName,
+					UID:        "old-app-uid",
+					Controller: &[]bool{true}[0],
+				},
+			},
+			Finalizers: []string{internal.ClBpfApplicationControllerFinalizer},
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			UpdateCount:   3,
+			Node:          fakeNode.Name,
+			AppLoadStatus: bpfmaniov1alpha1.AppLoadSuccess,
+			Conditions:    []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp, staleAppState)
+
+	// The application gets a state of its own rather than taking over the
+	// stale one, then is loaded and attached.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	for range 3 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.Links, 1)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, staleAppState.Name, bpfAppState.Name)
+	require.Equal(t, types.UID("new-app-uid"), metav1.GetControllerOf(bpfAppState).UID)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondNameCollision), bpfAppState.Status.Conditions[0].Type)
+	require.Equal(t, []string{staleAppState.Name}, r.foreignAppStates)
+
+	// The stale state is left alone.
+	stale := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(staleAppState), stale))
+	require.Equal(t, staleAppState.Status.UpdateCount, stale.Status.UpdateCount)
+	require.Empty(t, stale.Status.Programs)
+	require.Equal(t, staleAppState.Finalizers, stale.Finalizers)
+}
+
+func TestClBpfApplicationAtomicGroup(t *testing.T) {
+	var (
+		appProgramName = "fakeAtomicGroupProgram"
+		bytecodePath   = "/tmp/hello.o"
+		functionName   = "do_unlinkat"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "fentry_test",
+					Type: bpfmaniov1alpha1.ProgTypeFentry,
+					FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+						ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: functionName},
+						AtomicGroup:      "unlinkat",
+						Links:            []bpfmaniov1alpha1.ClFentryAttachInfo{{Mode: bpfmaniov1alpha1.Attach}},
+					},
+				},
+				{
+					Name: "fexit_test",
+					Type: bpfmaniov1alpha1.ProgTypeFexit,
+					FExit: &bpfmaniov1alpha1.ClFexitProgramInfo{
+						ClFexitLoadInfo: bpfmaniov1alpha1.ClFexitLoadInfo{Function: functionName},
+						AtomicGroup:     "unlinkat",
+						Links:           []bpfmaniov1alpha1.ClFexitAttachInfo{{Mode: bpfmaniov1alpha1.Attach}},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	// The FEntry program attaches and the FExit program fails.
+	cli.AttachErrs = []error{nil, fmt.Errorf("failed to attach")}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilAttached(t, r, req)
+	require.Len(t, cli.AttachRequests, 1)
+
+	// The FEntry program is detached again.
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Len(t, bpfAppState.Status.Programs, 2)
+	fentry, fexit := bpfAppState.Status.Programs[0], bpfAppState.Status.Programs[1]
+	require.Equal(t, bpfmaniov1alpha1.ProgAttachError, fentry.ProgramLinkStatus)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachRolledBack, fentry.FEntry.Links[0].LinkStatus)
+	require.Nil(t, fentry.FEntry.Links[0].LinkId)
+	require.Equal(t, bpfmaniov1alpha1.ProgAttachError, fexit.ProgramLinkStatus)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachError, fexit.FExit.Links[0].LinkStatus)
+	require.Empty(t, cli.Links)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondError), bpfAppState.Status.Conditions[0].Type)
+	require.NotNil(t, bpfAppState.Status.LastError)
+	require.Contains(t, bpfAppState.Status.LastError.Message,
+		"program fentry_test: program fexit_test in atomic group unlinkat failed to attach")
+
+	// Both are attached once the FExit program can be.
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	for _, program := range bpfAppState.Status.Programs {
+		require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, program.ProgramLinkStatus)
+	}
+	require.Len(t, cli.Links, 2)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+}
+
+func TestClBpfApplicationAttachConflict(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts []AttachConflict
+		expected  bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:      "default conflicts",
+			conflicts: nil,
+			expected:  bpfmaniov1alpha1.BpfAppStateCondAttachConflict,
+		},
+		{
+			name:      "conflict checking disabled",
+			conflicts: []AttachConflict{},
+			expected:  bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeConflictProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				interfaces     = bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}}
+				ctx            = context.TODO()
+			)
+
+			// A TC and a TCX program on the ingress hook of the same
+			// interface.
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "TcTest",
+							Type: bpfmaniov1alpha1.ProgTypeTC,
+							TC: &bpfmaniov1alpha1.ClTcProgramInfo{
+								Links: []bpfmaniov1alpha1.ClTcAttachInfo{
+									{
+										InterfaceSelector: interfaces,
+										Direction:         bpfmaniov1alpha1.TCIngress,
+										Priority:          50,
+									},
+								},
+							},
+						},
+						{
+							Name: "TcxTest",
+							Type: bpfmaniov1alpha1.ProgTypeTCX,
+							TCX: &bpfmaniov1alpha1.ClTcxProgramInfo{
+								Links: []bpfmaniov1alpha1.ClTcxAttachInfo{
+									{
+										InterfaceSelector: interfaces,
+										Direction:         bpfmaniov1alpha1.TCIngress,
+										Priority:          50,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, _ := newTestClReconciler(t, fakeNode, bpfApp)
+			r.AttachConflicts = tc.conflicts
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			for _, program := range bpfAppState.Status.Programs {
+				require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, program.ProgramLinkStatus)
+			}
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+		})
+	}
+}
+
+func TestClBpfApplicationAutoPriority(t *testing.T) {
+	var (
+		appProgramName = "fakeAutoPriorityProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	// Three TC programs on the same interface and direction share a range
+	// with room for two of them.
+	tcProgram := func(name string) bpfmaniov1alpha1.ClBpfApplicationProgram {
+		return bpfmaniov1alpha1.ClBpfApplicationProgram{
+			Name: name,
+			Type: bpfmaniov1alpha1.ProgTypeTC,
+			TC: &bpfmaniov1alpha1.ClTcProgramInfo{
+				Links: []bpfmaniov1alpha1.ClTcAttachInfo{
+					{
+						InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+						Direction:         bpfmaniov1alpha1.TCIngress,
+						Priority:          1000,
+						AutoPriority:      &bpfmaniov1alpha1.PriorityRange{Min: 100, Max: 101},
+					},
+				},
+			},
+		}
+	}
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				tcProgram("TcFirst"),
+				tcProgram("TcSecond"),
+				tcProgram("TcThird"),
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	// Create the ClusterBpfApplicationState, load and attach, then reconcile
+	// a few more times to check the assigned priorities don't change.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	for range 5 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Len(t, bpfAppState.Status.Programs, 3)
+
+	// The first two programs are given distinct priorities from the range.
+	priorities := []int32{}
+	for _, program := range bpfAppState.Status.Programs[:2] {
+		require.Len(t, program.TC.Links, 1)
+		link := program.TC.Links[0]
+		require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+		require.Equal(t, &bpfmaniov1alpha1.PriorityRange{Min: 100, Max: 101}, link.AutoPriority)
+		priorities = append(priorities, link.Priority)
+	}
+	require.ElementsMatch(t, []int32{100, 101}, priorities)
+
+	attached := []int32{}
+	for _, attachRequest := range cli.AttachRequests {
+		attached = append(attached, attachRequest.Attach.GetTcAttachInfo().Priority)
+	}
+	require.ElementsMatch(t, []int32{100, 101}, attached)
+
+	// The range is exhausted for the third.
+	third := bpfAppState.Status.Programs[2]
+	require.Equal(t, bpfmaniov1alpha1.ApPriorityRangeExhausted, third.TC.Links[0].LinkStatus)
+	require.Equal(t, bpfmaniov1alpha1.ProgAttachError, third.ProgramLinkStatus)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondError), bpfAppState.Status.Conditions[0].Type)
+	require.NotNil(t, bpfAppState.Status.LastError)
+	require.Contains(t, bpfAppState.Status.LastError.Message, "no free TC/ingress priority between 100 and 101 on interface eth0")
+}
+
+func TestClBpfApplicationTcBondMembers(t *testing.T) {
+	var (
+		appProgramName = "fakeTcBondProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	// bond0 starts with two members.
+	members := map[string][]string{"bond0": {"eth0", "eth1"}}
+	origBondMembers := bondMembers
+	bondMembers = func(name, _ string) ([]string, error) { return members[name], nil }
+	defer func() { bondMembers = origBondMembers }()
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "TcTest",
+					Type: bpfmaniov1alpha1.ProgTypeTC,
+					TC: &bpfmaniov1alpha1.ClTcProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTcAttachInfo{
+							{
+								InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{
+									Interfaces: []string{"bond0"},
+								},
+								AttachToBondMembers: true,
+								Direction:           bpfmaniov1alpha1.TCIngress,
+								Priority:            50,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	attachedInterfaces := func() []string {
+		for range 3 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+
+		interfaces := []string{}
+		for _, link := range bpfAppState.Status.Programs[0].TC.Links {
+			require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+			require.Equal(t, link.InterfaceName, cli.AttachRequests[int(*link.LinkId)].GetAttach().GetTcAttachInfo().GetIface())
+			interfaces = append(interfaces, link.InterfaceName)
+		}
+		return interfaces
+	}
+
+	// The bond and each of its members get a link.
+	require.ElementsMatch(t, []string{"bond0", "eth0", "eth1"}, attachedInterfaces())
+	require.Len(t, cli.Links, 3)
+
+	// A member that leaves the bond is detached, and one that joins it is
+	// attached.
+	members["bond0"] = []string{"eth0", "eth2"}
+	require.ElementsMatch(t, []string{"bond0", "eth0", "eth2"}, attachedInterfaces())
+	require.Len(t, cli.Links, 3)
+}
+
+func TestClBpfApplicationResyncsAfterBpfmanRestart(t *testing.T) {
+	var (
+		appProgramName = "fakeRestartProgram"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, "kprobe_test")
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	reconcileUntilAttached(t, r, reconcile.Request{})
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.Links, 1)
+
+	// bpfman restarts and loses its programs.
+	cli.Programs = map[int]*gobpfman.GetResponse{}
+	cli.Links = map[int]bool{}
+	e := event.TypedUpdateEvent[*v1.Pod]{
+		ObjectOld: daemonPod(fakeNode.Name, 0, true),
+		ObjectNew: daemonPod(fakeNode.Name, 1, true),
+	}
+	require.True(t, bpfmanRestartPredicate(fakeNode.Name).Update(e))
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+	bpfmanRestartHandler().Update(ctx, e, q)
+	require.Equal(t, 1, q.Len())
+	req, _ := q.Get()
+	require.Equal(t, resyncRequestName, req.Name)
+
+	// The resync loads and attaches the program again.
+	reconcileUntilAttached(t, r, req)
+	require.Len(t, cli.LoadRequests, 2)
+	require.Len(t, cli.Links, 1)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	program := bpfAppState.Status.Programs[0]
+	require.True(t, cli.Links[int(*program.KProbe.Links[0].LinkId)])
+}
+
+func TestClBpfApplicationBpfmanUnavailable(t *testing.T) {
+	var (
+		bytecodePath = "/tmp/hello.o"
+		fakeNode     = testutils.NewNode("fake-control-plane")
+		ctx          = context.TODO()
+	)
+
+	newApp := func(name string) *bpfmaniov1alpha1.ClusterBpfApplication {
+		return &bpfmaniov1alpha1.ClusterBpfApplication{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+				BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+					NodeSelector: metav1.LabelSelector{},
+					ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+						Path: &bytecodePath,
+					},
+				},
+				Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+					{
+						Name: "FentryTest",
+						Type: bpfmaniov1alpha1.ProgTypeFentry,
+						FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+							ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: "do_unlinkat"},
+							Links: []bpfmaniov1alpha1.ClFentryAttachInfo{
+								{Mode: bpfmaniov1alpha1.Attach},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	apps := []*bpfmaniov1alpha1.ClusterBpfApplication{newApp("fakeAppOne"), newApp("fakeAppTwo")}
+
+	r, cli := newTestClReconciler(t, fakeNode, apps[0], apps[1])
+	cli.LoadErr = status.Error(codes.Unavailable, "connection refused")
+
+	// Each reconcile makes one change and returns, so create both
+	// ClusterBpfApplicationStates and fail to load both applications, then
+	// reconcile once more with nothing left to change.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: apps[0].Name}}
+	var result reconcile.Result
+	for range 5 {
+		var err error
+		result, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.True(t, result.Requeue)
+	require.Zero(t, result.RequeueAfter)
+	require.True(t, r.bpfmanUnavailable)
+
+	for _, app := range apps {
+		r.currentApp = app
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Len(t, bpfAppState.Status.Conditions, 1)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondBpfmanUnavailable), bpfAppState.Status.Conditions[0].Type)
+		require.NotNil(t, bpfAppState.Status.LastError)
+		require.Contains(t, bpfAppState.Status.LastError.Message, "bpfman unavailable")
+	}
+
+	// Once bpfman is back, the applications are loaded and attached.
+	cli.LoadErr = nil
+	for range 4 {
+		var err error
+		result, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.False(t, result.Requeue)
+	require.False(t, r.bpfmanUnavailable)
+
+	for _, app := range apps {
+		r.currentApp = app
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Len(t, bpfAppState.Status.Conditions, 1)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	}
+}
+
+func TestClBpfApplicationUprobeBuildID(t *testing.T) {
+	const containerPid = int32(4490)
+
+	tests := []struct {
+		name       string
+		buildID    string
+		expected   bpfmaniov1alpha1.BpfApplicationStateConditionType
+		linkStatus bpfmaniov1alpha1.LinkStatus
+	}{
+		{
+			name:       "matching build-id",
+			buildID:    "1D8A33B1C5E2",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus: bpfmaniov1alpha1.ApAttachAttached,
+		},
+		{
+			name:       "mismatched build-id",
+			buildID:    "0badc0ffee",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondError,
+			linkStatus: bpfmaniov1alpha1.ApBuildIDMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeUprobeProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			// The binary in the container is a newer build than the one
+			// the mismatched build-id was taken from.
+			resolved := []string{}
+			origResolveBuildID := resolveBuildID
+			resolveBuildID = func(path string) (string, error) {
+				resolved = append(resolved, path)
+				return "1d8a33b1c5e2", nil
+			}
+			defer func() { resolveBuildID = origResolveBuildID }()
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "UprobeTest",
+							Type: bpfmaniov1alpha1.ProgTypeUprobe,
+							UProbe: &bpfmaniov1alpha1.ClUprobeProgramInfo{
+								Links: []bpfmaniov1alpha1.ClUprobeAttachInfo{
+									{
+										Function: "main",
+										Target:   "/usr/bin/server",
+										BuildID:  tc.buildID,
+										Containers: &bpfmaniov1alpha1.ClContainerSelector{
+											Pods: metav1.LabelSelector{
+												MatchLabels: map[string]string{"app": "server"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.Containers = &FakeContainerGetter{
+				containerList: &[]ContainerInfo{
+					{podName: "server", containerName: "server", pid: containerPid},
+				},
+			}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			require.NotEmpty(t, resolved)
+			require.Equal(t, r.containerBinaryPath(containerPid, "/usr/bin/server"), resolved[0])
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			links := bpfAppState.Status.Programs[0].UProbe.Links
+			require.Len(t, links, 1)
+			require.Equal(t, tc.buildID, links[0].BuildID)
+			require.Equal(t, tc.linkStatus, links[0].LinkStatus)
+			if tc.linkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				require.Len(t, cli.AttachRequests, 1)
+				return
+			}
+			require.Empty(t, cli.AttachRequests)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, "has build-id 1d8a33b1c5e2, expected 0badc0ffee")
+		})
+	}
+}
+
+func TestClBpfApplicationFunctionNotInBytecode(t *testing.T) {
+	tests := []struct {
+		name         string
+		functionName string
+		inspectErr   error
+		expected     bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:         "function in bytecode",
+			functionName: "kprobe_counter",
+			expected:     bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:         "function not in bytecode",
+			functionName: "kprobe_counterr",
+			expected:     bpfmaniov1alpha1.BpfAppStateCondFunctionNotInBytecode,
+		},
+		{
+			name:         "bytecode not readable",
+			functionName: "kprobe_counterr",
+			inspectErr:   errors.New("no such file or directory"),
+			expected:     bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeFunctionProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			origBytecodeFunctions := bytecodeFunctions
+			bytecodeFunctions = func(path string) ([]string, error) {
+				require.Equal(t, bytecodePath, path)
+				return []string{"kprobe_counter", "tracepoint_counter"}, tc.inspectErr
+			}
+			defer func() { bytecodeFunctions = origBytecodeFunctions }()
+
+			bpfApp := newTestClKprobeApp(appProgramName, tc.functionName)
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			if tc.expected == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+				require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+				require.Equal(t, bpfmaniov1alpha1.AppLoadSuccess, bpfAppState.Status.AppLoadStatus)
+				return
+			}
+			require.Nil(t, bpfAppState.Status.Programs[0].ProgramId)
+			require.Empty(t, cli.LoadRequests)
+			require.Equal(t, bpfmaniov1alpha1.FunctionNotInBytecode, bpfAppState.Status.AppLoadStatus)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message,
+				"kprobe_counterr not found in bytecode /tmp/hello.o, available functions are kprobe_counter, tracepoint_counter")
+		})
+	}
+}
+
+func TestClBpfApplicationControllerReattachesDriftedLink(t *testing.T) {
+	var (
+		appProgramName        = "fakeDriftProgram"
+		kprobeBpfFunctionName = "KprobeTest"
+		fakeNode              = testutils.NewNode("fake-control-plane")
+		ctx                   = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, kprobeBpfFunctionName)
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	// The periodic drift check enqueues the same request as node and pod
+	// events.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: resyncRequestName}}
+
+	reconcileUntilAttached(t, r, req)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	program := bpfAppState.Status.Programs[0]
+	require.NotNil(t, program.ProgramId)
+	require.Len(t, program.KProbe.Links, 1)
+	link := program.KProbe.Links[0]
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+	require.NotNil(t, link.LinkId)
+	require.Len(t, cli.AttachRequests, 1)
+
+	// Detach the link behind the agent's back, as bpftool would.
+	cli.Programs[int(*program.ProgramId)].Info.Links = nil
+	delete(cli.Links, int(*link.LinkId))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	// The link must have been reattached.
+	require.Len(t, cli.AttachRequests, 2)
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	relinked := bpfAppState.Status.Programs[0].KProbe.Links[0]
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, relinked.LinkStatus)
+	require.NotNil(t, relinked.LinkId)
+	require.NotEqual(t, *link.LinkId, *relinked.LinkId)
+	require.True(t, cli.Links[int(*relinked.LinkId)])
+}
+
+func TestClBpfApplicationSuccessRequeueInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "unset"},
+		{name: "set", interval: time.Minute},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeRequeueProgram"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			bpfApp := newTestClKprobeApp(appProgramName, "kprobe_test")
+
+			r, _ := newTestClReconciler(t, fakeNode, bpfApp)
+			r.SuccessRequeueInterval = tc.interval
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: resyncRequestName}}
+			reconcileUntilAttached(t, r, req)
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+
+			result, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			require.Equal(t, tc.interval, result.RequeueAfter)
+			require.False(t, result.Requeue)
+		})
+	}
+}
+
+// A ClusterBpfApplication annotated with a feature gate is only loaded while
+// the gate is enabled.
+func TestClBpfApplicationFeatureGate(t *testing.T) {
+	var (
+		appProgramName = "fakeGatedProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        appProgramName,
+			Annotations: map[string]string{internal.FeatureGateAnnotation: "new-probes"},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "kprobe_test",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileAll := func() {
+		for range 4 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	appState := func() *bpfmaniov1alpha1.ClusterBpfApplicationState {
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, bpfAppState)
+		require.Len(t, bpfAppState.Status.Conditions, 1)
+		return bpfAppState
+	}
+
+	// The gate isn't enabled, so nothing is loaded.
+	reconcileAll()
+	bpfAppState := appState()
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondGatedOff), bpfAppState.Status.Conditions[0].Type)
+	require.Equal(t, bpfmaniov1alpha1.GatedOff, bpfAppState.Status.AppLoadStatus)
+	require.Empty(t, cli.LoadRequests)
+
+	// Once it's enabled, the application is loaded and attached.
+	r.FeatureGates = ParseFeatureGates("new-probes")
+	reconcileAll()
+	bpfAppState = appState()
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.AttachRequests, 1)
+
+	// Turning it off again unloads the application.
+	r.FeatureGates = ParseFeatureGates("xdp-v2")
+	reconcileAll()
+	bpfAppState = appState()
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondGatedOff), bpfAppState.Status.Conditions[0].Type)
+	require.Len(t, cli.UnloadRequests, 1)
+	require.Empty(t, cli.Programs)
+}
+
+func TestClBpfApplicationGlobalDataFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		expected bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:     "key found",
+			key:      "sampling",
+			expected: bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:     "key not found",
+			key:      "missing",
+			expected: bpfmaniov1alpha1.BpfAppStateCondGlobalDataNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeGlobalDataFromProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+				Data:       map[string][]byte{"sampling": {0x01}},
+			}
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+						GlobalData: map[string][]byte{"debug": {0x01}},
+						GlobalDataFrom: []bpfmaniov1alpha1.GlobalDataSource{
+							{
+								Name: "sampling",
+								SecretKeyRef: &bpfmaniov1alpha1.GlobalDataKeySelector{
+									Name:      "config",
+									Namespace: "default",
+									Key:       tc.key,
+								},
+							},
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "FentryTest",
+							Type: bpfmaniov1alpha1.ProgTypeFentry,
+							FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+								ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: "do_unlinkat"},
+								Links: []bpfmaniov1alpha1.ClFentryAttachInfo{
+									{Mode: bpfmaniov1alpha1.Attach},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp, secret)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			if tc.expected == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+				require.Len(t, cli.LoadRequests, 1)
+				require.Equal(t, map[string][]byte{"debug": {0x01}, "sampling": {0x01}}, cli.LoadRequests[0].GlobalData)
+				return
+			}
+			require.Empty(t, cli.LoadRequests)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, `key "missing" not found in Secret default/config`)
+		})
+	}
+}
+
+func TestClBpfApplicationGlobalDataOverrides(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeClass  string
+		globalData map[string][]byte
+	}{
+		{
+			name:       "small nodes",
+			nodeClass:  "small",
+			globalData: map[string][]byte{"debug": {0x01}, "rate_limit": {0x0a}},
+		},
+		{
+			name:       "large nodes",
+			nodeClass:  "large",
+			globalData: map[string][]byte{"debug": {0x01}, "rate_limit": {0x64}},
+		},
+		{
+			name:       "other nodes",
+			nodeClass:  "medium",
+			globalData: map[string][]byte{"debug": {0x01}, "rate_limit": {0x32}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeGlobalDataOverridesProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+			fakeNode.Labels = map[string]string{"node-class": tc.nodeClass}
+
+			nodeClass := func(class string) metav1.LabelSelector {
+				return metav1.LabelSelector{MatchLabels: map[string]string{"node-class": class}}
+			}
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+						GlobalData: map[string][]byte{"debug": {0x01}, "rate_limit": {0x32}},
+						GlobalDataOverrides: []bpfmaniov1alpha1.GlobalDataOverride{
+							{NodeSelector: nodeClass("small"), GlobalData: map[string][]byte{"rate_limit": {0x0a}}},
+							{NodeSelector: nodeClass("large"), GlobalData: map[string][]byte{"rate_limit": {0x64}}},
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "FentryTest",
+							Type: bpfmaniov1alpha1.ProgTypeFentry,
+							FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+								ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: "do_unlinkat"},
+								Links: []bpfmaniov1alpha1.ClFentryAttachInfo{
+									{Mode: bpfmaniov1alpha1.Attach},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			for range 3 {
+				_, err := r.Reconcile(ctx, req)
+				require.NoError(t, err)
+			}
+
+			require.Len(t, cli.LoadRequests, 1)
+			require.Equal(t, tc.globalData, cli.LoadRequests[0].GlobalData)
+			// The application's own globalData is left as it was.
+			require.Equal(t, []byte{0x32}, bpfApp.Spec.GlobalData["rate_limit"])
+		})
+	}
+}
+
+// A ClusterBpfApplication with an image refresh interval is reloaded once its
+// image's tag has referred to a new image on two checks in a row, and not
+// while the tag is unchanged.
+func TestClBpfApplicationImageRefresh(t *testing.T) {
+	var (
+		appProgramName = "fakeImageRefreshProgram"
+		imageUrl       = "quay.io/bpfman-bytecode/go-kprobe-counter:latest"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+		oldDigest      = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		newDigest      = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	digest := oldDigest
+	origResolveImageDigest := resolveImageDigest
+	t.Cleanup(func() { resolveImageDigest = origResolveImageDigest })
+	resolveImageDigest = func(ctx context.Context, image *gobpfman.BytecodeImage) (string, error) {
+		require.Equal(t, imageUrl, image.GetUrl())
+		return digest, nil
+	}
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       appProgramName,
+			Finalizers: []string{internal.ClBpfApplicationControllerFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Image: &bpfmaniov1alpha1.ByteCodeImage{
+						Url:             imageUrl,
+						RefreshInterval: &metav1.Duration{Duration: time.Second},
+					},
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "kprobe_test",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileOnce := func() ctrl.Result {
+		res, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		return res
+	}
+	// checkImage reconciles once the next check of the image is due.
+	checkImage := func() {
+		for _, refresh := range r.imageRefreshes {
+			refresh.lastCheck = refresh.lastCheck.Add(-minImageRefreshInterval)
+		}
+		reconcileOnce()
+	}
+	resolvedDigest := func() string {
+		appState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, appState.Status.ResolvedBytecode)
+		return appState.Status.ResolvedBytecode.Digest
+	}
+
+	// The digest the programs were loaded from is recorded, and the
+	// application is reconciled again to check its image, no sooner than a
+	// minute.
+	var res ctrl.Result
+	for range 3 {
+		res = reconcileOnce()
+	}
+	require.Len(t, cli.LoadRequests, 1)
+	require.Equal(t, oldDigest, resolvedDigest())
+	require.Equal(t, minImageRefreshInterval, res.RequeueAfter)
+
+	// An unchanged tag doesn't reload the programs.
+	checkImage()
+	checkImage()
+	require.Len(t, cli.LoadRequests, 1)
+	require.Empty(t, cli.PullBytecodeRequests)
+
+	// A new image isn't used until it's been seen twice.
+	digest = newDigest
+	checkImage()
+	require.Len(t, cli.LoadRequests, 1)
+	require.Empty(t, cli.PullBytecodeRequests)
+
+	// Then it's pulled, and the programs are loaded from it and attached
+	// again.
+	checkImage()
+	require.Len(t, cli.PullBytecodeRequests, 1)
+	require.Equal(t, imageUrl, cli.PullBytecodeRequests[0].Image.Url)
+	require.Equal(t, int32(0), cli.PullBytecodeRequests[0].Image.ImagePullPolicy)
+	require.Len(t, cli.LoadRequests, 2)
+	require.Len(t, cli.UnloadRequests, 1)
+	reconcileOnce()
+	require.Equal(t, newDigest, resolvedDigest())
+	require.Len(t, cli.AttachRequests, 2)
+	appState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), appState.Status.Conditions[0].Type)
+
+	// A tag that moves back and forth on every check doesn't reload them.
+	for _, d := range []string{oldDigest, newDigest, oldDigest, newDigest} {
+		digest = d
+		checkImage()
+	}
+	require.Len(t, cli.LoadRequests, 2)
+
+	// Deleting the application unloads its programs and stops tracking the
+	// checks of its image.
+	require.Contains(t, r.imageRefreshes, appState.Name)
+	require.NoError(t, r.Client.Delete(ctx, bpfApp))
+	for range 2 {
+		reconcileOnce()
+	}
+	require.Empty(t, cli.Programs)
+	require.Empty(t, r.imageRefreshes)
+}
+
+func TestClBpfApplicationMinKernelVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		kernelVersion string
+		expected      bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:          "kernel new enough",
+			kernelVersion: "6.8.0-45-generic",
+			expected:      bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:          "kernel is the minimum",
+			kernelVersion: "5.15.0-91-generic",
+			expected:      bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:          "kernel too old",
+			kernelVersion: "5.4.0-150-generic",
+			expected:      bpfmaniov1alpha1.BpfAppStateCondKernelTooOld,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeMinKernelProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+			fakeNode.Status.NodeInfo.KernelVersion = tc.kernelVersion
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+						MinKernelVersion: "5.15",
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "FentryTest",
+							Type: bpfmaniov1alpha1.ProgTypeFentry,
+							FEntry: &bpfmaniov1alpha1.ClFentryProgramInfo{
+								ClFentryLoadInfo: bpfmaniov1alpha1.ClFentryLoadInfo{Function: "do_unlinkat"},
+								Links: []bpfmaniov1alpha1.ClFentryAttachInfo{
+									{Mode: bpfmaniov1alpha1.Attach},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			if tc.expected == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+				require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+				require.Equal(t, bpfmaniov1alpha1.AppLoadSuccess, bpfAppState.Status.AppLoadStatus)
+				return
+			}
+			require.Nil(t, bpfAppState.Status.Programs[0].ProgramId)
+			require.Empty(t, cli.Programs)
+			require.Equal(t, bpfmaniov1alpha1.KernelTooOld, bpfAppState.Status.AppLoadStatus)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message,
+				"node kernel 5.4.0-150-generic is older than the minimum kernel version 5.15")
+		})
+	}
+}
+
+func TestClBpfApplicationLinkFailurePolicy(t *testing.T) {
+	var (
+		appProgramName = "fakeLinkFailureProgram"
+		bytecodePath   = "/tmp/hello.o"
+		ctx            = context.TODO()
+	)
+
+	tests := []struct {
+		name   string
+		policy bpfmaniov1alpha1.LinkFailurePolicy
+		// firstStatus is the status of the link that attached.
+		firstStatus   bpfmaniov1alpha1.LinkStatus
+		programStatus bpfmaniov1alpha1.ProgramLinkStatus
+		condition     bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:          "unset",
+			firstStatus:   bpfmaniov1alpha1.ApAttachAttached,
+			programStatus: bpfmaniov1alpha1.ProgAttachError,
+			condition:     bpfmaniov1alpha1.BpfAppStateCondError,
+		},
+		{
+			name:          "best effort",
+			policy:        bpfmaniov1alpha1.LinkFailureBestEffort,
+			firstStatus:   bpfmaniov1alpha1.ApAttachAttached,
+			programStatus: bpfmaniov1alpha1.ProgAttachPartial,
+			condition:     bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:          "all or nothing",
+			policy:        bpfmaniov1alpha1.LinkFailureAllOrNothing,
+			firstStatus:   bpfmaniov1alpha1.ApAttachRolledBack,
+			programStatus: bpfmaniov1alpha1.ProgAttachError,
+			condition:     bpfmaniov1alpha1.BpfAppStateCondError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeNode := testutils.NewNode("fake-control-plane")
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+						FailurePolicy: tc.policy,
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "kprobe_test",
+							Type: bpfmaniov1alpha1.ProgTypeKprobe,
+							KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+								Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+									{Function: "try_to_wake_up"},
+									{Function: "do_sys_open"},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			// The first link attaches and the second fails.
+			cli.AttachErrs = []error{nil, fmt.Errorf("failed to attach")}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilAttached(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Programs, 1)
+			program := bpfAppState.Status.Programs[0]
+			require.Equal(t, tc.programStatus, program.ProgramLinkStatus)
+			require.Equal(t, string(tc.condition), bpfAppState.Status.Conditions[0].Type)
+
+			require.Len(t, program.KProbe.Links, 2)
+			first, second := program.KProbe.Links[0], program.KProbe.Links[1]
+			require.Equal(t, tc.firstStatus, first.LinkStatus)
+			require.Equal(t, bpfmaniov1alpha1.ApAttachError, second.LinkStatus)
+			require.Nil(t, second.LinkId)
+
+			if tc.policy == bpfmaniov1alpha1.LinkFailureAllOrNothing {
+				// The link that attached is detached again.
+				require.Nil(t, first.LinkId)
+				require.Empty(t, cli.Links)
+				require.NotNil(t, bpfAppState.Status.LastError)
+				require.Contains(t, bpfAppState.Status.LastError.Message, "some links failed to attach")
+
+				// Both links are attached once they can be.
+				_, err = r.Reconcile(ctx, req)
+				require.NoError(t, err)
+				bpfAppState, err = r.getBpfAppState(ctx)
+				require.NoError(t, err)
+				program = bpfAppState.Status.Programs[0]
+				require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, program.ProgramLinkStatus)
+				for _, link := range program.KProbe.Links {
+					require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+				}
+				require.Len(t, cli.Links, 2)
+				require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+			} else {
+				// The link that attached is kept.
+				require.NotNil(t, first.LinkId)
+				require.Len(t, cli.Links, 1)
+				require.True(t, cli.Links[int(*first.LinkId)])
+			}
+		})
+	}
+}
+
+func TestClBpfApplicationLoadConflict(t *testing.T) {
+	const staleProgramId = 42
+
+	tests := []struct {
+		name         string
+		policy       LoadConflictPolicy
+		loadedFile   string
+		expected     bpfmaniov1alpha1.BpfApplicationStateConditionType
+		adopted      bool
+		reloaded     bool
+		errorMessage string
+	}{
+		{
+			name:         "fail",
+			policy:       LoadConflictFail,
+			loadedFile:   "/tmp/hello.o",
+			expected:     bpfmaniov1alpha1.BpfAppStateCondError,
+			errorMessage: "program already loaded",
+		},
+		{
+			name:       "adopt on match",
+			policy:     LoadConflictAdopt,
+			loadedFile: "/tmp/hello.o",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			adopted:    true,
+		},
+		{
+			name:         "adopt on mismatch",
+			policy:       LoadConflictAdopt,
+			loadedFile:   "/tmp/goodbye.o",
+			expected:     bpfmaniov1alpha1.BpfAppStateCondError,
+			errorMessage: "the loaded programs can't be adopted",
+		},
+		{
+			name:       "reload on match",
+			policy:     LoadConflictReload,
+			loadedFile: "/tmp/hello.o",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			adopted:    true,
+		},
+		{
+			name:       "reload on mismatch",
+			policy:     LoadConflictReload,
+			loadedFile: "/tmp/goodbye.o",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			reloaded:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeLoadConflictProgram"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			bpfApp := newTestClKprobeApp(appProgramName, "KprobeTest")
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.LoadConflictPolicy = tc.policy
+
+			// The first reconcile creates the ClusterBpfApplicationState.
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+
+			// bpfman still has the program from before the agent restarted,
+			// so loading it fails until it's unloaded.
+			staleProgram := &gobpfman.ListResponse_ListResult{
+				Info: &gobpfman.ProgramInfo{
+					Name:     "KprobeTest",
+					Bytecode: &gobpfman.BytecodeLocation{Location: &gobpfman.BytecodeLocation_File{File: tc.loadedFile}},
+					Metadata: map[string]string{internal.NewMetadataKeys("").Uuid: string(bpfAppState.UID)},
+				},
+				KernelInfo: &gobpfman.KernelProgramInfo{Id: staleProgramId, Name: "KprobeTest"},
+			}
+			cli.ListResults = []*gobpfman.ListResponse_ListResult{staleProgram}
+			cli.Programs[staleProgramId] = &gobpfman.GetResponse{Info: staleProgram.Info, KernelInfo: staleProgram.KernelInfo}
+			alreadyLoaded := status.Error(codes.AlreadyExists, "program already loaded")
+			if tc.reloaded {
+				cli.LoadErrs = []error{alreadyLoaded}
+			} else {
+				cli.LoadErr = alreadyLoaded
+			}
+
+			// Load, then attach, then reconcile once more with nothing left
+			// to change.
+			for range 3 {
+				_, err = r.Reconcile(ctx, req)
+				require.NoError(t, err)
+			}
+
+			bpfAppState, err = r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			if tc.errorMessage != "" {
+				require.NotNil(t, bpfAppState.Status.LastError)
+				require.Contains(t, bpfAppState.Status.LastError.Message, tc.errorMessage)
+				require.Nil(t, bpfAppState.Status.Programs[0].ProgramId)
+				require.Empty(t, cli.UnloadRequests)
+				return
+			}
+
+			require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+			if tc.adopted {
+				require.Equal(t, uint32(staleProgramId), *bpfAppState.Status.Programs[0].ProgramId)
+				require.Empty(t, cli.UnloadRequests)
+			}
+			if tc.reloaded {
+				require.NotEqual(t, uint32(staleProgramId), *bpfAppState.Status.Programs[0].ProgramId)
+				require.Contains(t, cli.UnloadRequests, staleProgramId)
+			}
+			require.Len(t, cli.AttachRequests, 1)
+		})
+	}
+}
+
+func TestClBpfApplicationPersistPrograms(t *testing.T) {
+	const persistedProgramId = 42
+
+	tests := []struct {
+		name       string
+		persist    bool
+		loadedFile string
+		adopted    bool
+	}{
+		{
+			name:       "persist on match",
+			persist:    true,
+			loadedFile: "/tmp/hello.o",
+			adopted:    true,
+		},
+		{
+			name:       "persist on mismatch",
+			persist:    true,
+			loadedFile: "/tmp/goodbye.o",
+		},
+		{
+			name:       "persist disabled",
+			loadedFile: "/tmp/hello.o",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakePersistProgram"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			bpfApp := newTestClKprobeApp(appProgramName, "KprobeTest")
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.PersistPrograms = tc.persist
+
+			// The first reconcile creates the ClusterBpfApplicationState.
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+
+			// The previous agent loaded the program, but was restarted
+			// before it recorded the program id.
+			persisted := &gobpfman.ListResponse_ListResult{
+				Info: &gobpfman.ProgramInfo{
+					Name:     "KprobeTest",
+					Bytecode: &gobpfman.BytecodeLocation{Location: &gobpfman.BytecodeLocation_File{File: tc.loadedFile}},
+					Metadata: map[string]string{internal.NewMetadataKeys("").Uuid: string(bpfAppState.UID)},
+				},
+				KernelInfo: &gobpfman.KernelProgramInfo{Id: persistedProgramId, Name: "KprobeTest"},
+			}
+			cli.ListResults = []*gobpfman.ListResponse_ListResult{persisted}
+			cli.Programs[persistedProgramId] = &gobpfman.GetResponse{Info: persisted.Info, KernelInfo: persisted.KernelInfo}
+
+			// Load, then attach, then reconcile once more with nothing left
+			// to change.
+			for range 3 {
+				_, err = r.Reconcile(ctx, req)
+				require.NoError(t, err)
+			}
+
+			bpfAppState, err = r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+			require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+			require.Len(t, cli.AttachRequests, 1)
+			if tc.adopted {
+				require.Empty(t, cli.LoadRequests)
+				require.Equal(t, uint32(persistedProgramId), *bpfAppState.Status.Programs[0].ProgramId)
+				return
+			}
+			require.Len(t, cli.LoadRequests, 1)
+			require.NotEqual(t, uint32(persistedProgramId), *bpfAppState.Status.Programs[0].ProgramId)
+		})
+	}
+}
+
+func TestClBpfApplicationMapPinPath(t *testing.T) {
+	var (
+		appProgramName = "fakeMapPinProgram"
+		bytecodePath   = "/tmp/hello.o"
+		mapPinPath     = "/sys/fs/bpf/bpfman-operator/fake-app"
+		bpfmanMapsDir  = "/run/bpfman/fs/maps/1"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	type pin struct{ srcDir, dstDir string }
+	pins := []pin{}
+	unpinned := []pin{}
+	origPinMaps, origUnpinMaps := pinMaps, unpinMaps
+	pinMaps = func(srcDir, dstDir string) error {
+		pins = append(pins, pin{srcDir, dstDir})
+		return nil
+	}
+	unpinMaps = func(srcDir, dstDir string) error {
+		unpinned = append(unpinned, pin{srcDir, dstDir})
+		return nil
+	}
+	defer func() { pinMaps, unpinMaps = origPinMaps, origUnpinMaps }()
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+				MapPinPath: mapPinPath,
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "kprobe_test",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+							{Function: "try_to_wake_up"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilAttached(t, r, req)
+
+	// The pin path is recorded with the programs in bpfman.
+	require.Len(t, cli.LoadRequests, 1)
+	require.Equal(t, mapPinPath, cli.LoadRequests[0].Metadata[internal.NewMetadataKeys("").MapPinPath])
+
+	// The fake bpfman doesn't pin any maps, so there's nothing to pin until
+	// it reports where the program's maps are.
+	require.Empty(t, pins)
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	programId := int(*bpfAppState.Status.Programs[0].ProgramId)
+	cli.Programs[programId].Info.MapPinPath = bpfmanMapsDir
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []pin{{bpfmanMapsDir, mapPinPath}}, pins)
+
+	// The pins of the programs' maps are removed before the programs are
+	// unloaded, while bpfman still has the maps pinned.
+	bpfAppState, err = r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	r.currentApp = bpfApp
+	r.currentAppState = bpfAppState
+	r.unload(ctx)
+	require.Equal(t, []pin{{bpfmanMapsDir, mapPinPath}}, unpinned)
+	require.Empty(t, cli.Programs)
+}
+
+func TestClBpfApplicationControllerAdditionalByteCode(t *testing.T) {
+	var (
+		appProgramName     = "fakeAdditionalByteCodeProgram"
+		bytecodePath       = "/tmp/hello.o"
+		additionalPath     = "/tmp/stats.o"
+		fakeNode           = testutils.NewNode("fake-control-plane")
+		ctx                = context.TODO()
+		kprobeProgram      = "kprobe_counter"
+		tracepointProgram  = "tracepoint_stats"
+		tracepointFunction = "syscalls/sys_enter_kill"
+	)
+
+	// The tracepoint program is in another object, and shares the kprobe
+	// program's maps.
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       appProgramName,
+			Finalizers: []string{internal.ClBpfApplicationControllerFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+				AdditionalByteCode: []bpfmaniov1alpha1.AdditionalByteCode{{
+					ByteCode: bpfmaniov1alpha1.ByteCodeSelector{Path: &additionalPath},
+					Programs: []string{tracepointProgram},
+				}},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: tracepointProgram,
+					Type: bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTracepointAttachInfo{{Name: tracepointFunction}},
+					},
+				},
+				{
+					Name: kprobeProgram,
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	reconcileUntilAttached(t, r, req)
+
+	// Each object is loaded with its own programs, the additional one using
+	// the maps of the program loaded first.
+	require.Len(t, cli.LoadRequests, 2)
+	require.Equal(t, bytecodePath, cli.LoadRequests[0].Bytecode.GetFile())
+	require.Len(t, cli.LoadRequests[0].Info, 1)
+	require.Equal(t, kprobeProgram, cli.LoadRequests[0].Info[0].Name)
+	require.Nil(t, cli.LoadRequests[0].MapOwnerId)
+	require.Equal(t, additionalPath, cli.LoadRequests[1].Bytecode.GetFile())
+	require.Len(t, cli.LoadRequests[1].Info, 1)
+	require.Equal(t, tracepointProgram, cli.LoadRequests[1].Info[0].Name)
+	require.Equal(t, cli.LoadRequests[0].Metadata, cli.LoadRequests[1].Metadata)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	ids := map[string]int{}
+	for _, program := range bpfAppState.Status.Programs {
+		require.NotNil(t, program.ProgramId)
+		ids[program.Name] = int(*program.ProgramId)
+	}
+	require.NotNil(t, cli.LoadRequests[1].MapOwnerId)
+	require.Equal(t, ids[kprobeProgram], int(*cli.LoadRequests[1].MapOwnerId))
+	require.Equal(t, bytecodePath, bpfAppState.Status.ResolvedBytecode.Path)
+
+	// Deleting the application unloads both programs, the one using the
+	// other's maps first.
+	require.NoError(t, r.Client.Delete(ctx, bpfApp))
+	for range 2 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []int{ids[tracepointProgram], ids[kprobeProgram]}, cli.UnloadOrder)
+	require.Empty(t, cli.Programs)
+}
+
+func TestClBpfApplicationProgramType(t *testing.T) {
+	tests := []struct {
+		name       string
+		loadedType internal.ProgramType
+		expected   bpfmaniov1alpha1.BpfApplicationStateConditionType
+	}{
+		{
+			name:       "matching type",
+			loadedType: internal.Kprobe,
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		},
+		{
+			name:       "mismatched type",
+			loadedType: internal.Xdp,
+			expected:   bpfmaniov1alpha1.BpfAppStateCondProgramTypeMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeKprobeProgram"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			bpfApp := newTestClKprobeApp(appProgramName, "KprobeTest")
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			// The bytecode's KprobeTest section holds a program of the
+			// loaded type.
+			cli.LoadedProgramTypes = map[string]uint32{"KprobeTest": uint32(tc.loadedType)}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			if tc.expected == bpfmaniov1alpha1.BpfAppStateCondSuccess {
+				require.Equal(t, bpfmaniov1alpha1.AppLoadSuccess, bpfAppState.Status.AppLoadStatus)
+				require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+				require.Len(t, cli.AttachRequests, 1)
+				require.Empty(t, cli.UnloadRequests)
+				return
+			}
+
+			// The program was unloaded again and never attached.
+			require.Equal(t, bpfmaniov1alpha1.ProgTypeMismatchError, bpfAppState.Status.AppLoadStatus)
+			require.Nil(t, bpfAppState.Status.Programs[0].ProgramId)
+			require.Empty(t, cli.AttachRequests)
+			require.NotEmpty(t, cli.UnloadRequests)
+			require.Empty(t, cli.Programs)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, "KprobeTest was loaded as a xdp program, expected kprobe")
+		})
+	}
+}
+
+// Two ClusterBpfApplications that name the same shared map are both loaded
+// with the map's owner, once it's loaded.
+func TestClBpfApplicationSharedMap(t *testing.T) {
+	var (
+		bytecodePath = "/tmp/hello.o"
+		fakeNode     = testutils.NewNode("fake-control-plane")
+		ctx          = context.TODO()
+		appNames     = []string{"fake-map-user-a", "fake-map-user-b"}
+	)
+
+	objs := []runtime.Object{fakeNode}
+	for _, name := range appNames {
+		objs = append(objs, &bpfmaniov1alpha1.ClusterBpfApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+				BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+					NodeSelector: metav1.LabelSelector{},
+					ByteCode:     bpfmaniov1alpha1.ByteCodeSelector{Path: &bytecodePath},
+					SharedMap:    &bpfmaniov1alpha1.SharedMapReference{Name: "shared_counts"},
+				},
+				Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+					{
+						Name: "kprobe_test",
+						Type: bpfmaniov1alpha1.ProgTypeKprobe,
+						KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+							Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+						},
+					},
+				},
+			},
+		})
+	}
+	r, cli := newTestClReconciler(t, objs...)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appNames[0]}}
+	reconcileAll := func() ctrl.Result {
+		var res ctrl.Result
+		for range 8 {
+			var err error
+			res, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+		return res
+	}
+	appCondition := func(name string) string {
+		r.currentApp = &bpfmaniov1alpha1.ClusterBpfApplication{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		appState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, appState)
+		return appState.Status.Conditions[0].Type
+	}
+
+	// Nothing on the node has the map yet, so neither application is
+	// loaded, and they're checked again later.
+	res := reconcileAll()
+	require.NotZero(t, res.RequeueAfter)
+	require.Empty(t, cli.LoadRequests)
+	for _, name := range appNames {
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSharedMapNotFound), appCondition(name))
+	}
+
+	// Once the map's owner is loaded, both applications use its maps.
+	cli.ListResults = []*gobpfman.ListResponse_ListResult{
+		mapOwnerProgram(t, 7, "fake-map-owner", "owner-uid", "shared_counts", "other_map"),
+		mapOwnerProgram(t, 8, "fake-other-app", "other-uid", "other_map"),
+	}
+	reconcileAll()
+	require.Len(t, cli.LoadRequests, len(appNames))
+	for _, loadRequest := range cli.LoadRequests {
+		require.NotNil(t, loadRequest.MapOwnerId)
+		require.Equal(t, uint32(7), *loadRequest.MapOwnerId)
+	}
+	for _, name := range appNames {
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), appCondition(name))
+	}
+}
+
+func TestClBpfApplicationControllerTailCalls(t *testing.T) {
+	var (
+		appProgramName = "fakeTailCallProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				tailCallKprobe("dispatcher", &bpfmaniov1alpha1.TailCallInfo{
+					MapName: "jmp_table",
+					Targets: []bpfmaniov1alpha1.TailCallTarget{
+						{Index: 0, BpfFunctionName: "first"},
+						{Index: 1, BpfFunctionName: "second"},
+					},
+				}),
+				tailCallKprobe("first", nil),
+				tailCallKprobe("second", nil),
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	updates := recordTailCalls(t)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+
+	reconcileUntilAttached(t, r, req)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+
+	ids := map[string]uint32{}
+	for _, p := range bpfAppState.Status.Programs {
+		require.NotNil(t, p.ProgramId)
+		ids[p.Name] = *p.ProgramId
+	}
+	require.Equal(t, []tailCallUpdate{
+		{"jmp_table", 0, ids["first"]},
+		{"jmp_table", 1, ids["second"]},
+	}, *updates)
+
+	// The dispatcher is declared first, but it's attached after the
+	// programs it tail calls.
+	names := map[uint32]string{}
+	for name, id := range ids {
+		names[id] = name
+	}
+	linkIds := slices.Sorted(maps.Keys(cli.AttachRequests))
+	attached := []string{}
+	for _, linkId := range linkIds {
+		attached = append(attached, names[cli.AttachRequests[linkId].Id])
+	}
+	require.Equal(t, []string{"first", "second", "dispatcher"}, attached)
+}
+
+func TestClBpfApplicationUnloadOrder(t *testing.T) {
+	var (
+		appProgramName = "fakeUnloadOrderProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	// b tail calls a, so depends on it, but comes after it in the list.
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				tailCallKprobe("a", nil),
+				tailCallKprobe("b", &bpfmaniov1alpha1.TailCallInfo{
+					MapName: "jmp_table",
+					Targets: []bpfmaniov1alpha1.TailCallTarget{{Index: 0, BpfFunctionName: "a"}},
+				}),
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	recordTailCalls(t)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilAttached(t, r, req)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	ids := map[string]int{}
+	for _, p := range bpfAppState.Status.Programs {
+		require.NotNil(t, p.ProgramId)
+		ids[p.Name] = int(*p.ProgramId)
+	}
+
+	r.currentApp = bpfApp
+	r.currentAppState = bpfAppState
+	r.unload(ctx)
+
+	require.Equal(t, []int{ids["b"], ids["a"]}, cli.UnloadOrder)
+	for _, p := range r.currentAppState.Status.Programs {
+		require.Nil(t, p.ProgramId)
+	}
+}
+
+func TestClBpfApplicationTcxDirection(t *testing.T) {
+	tests := []struct {
+		name       string
+		direction  bpfmaniov1alpha1.TCDirectionType
+		expected   bpfmaniov1alpha1.BpfApplicationStateConditionType
+		linkStatus bpfmaniov1alpha1.LinkStatus
+	}{
+		{
+			name:       "supported direction",
+			direction:  bpfmaniov1alpha1.TCEgress,
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus: bpfmaniov1alpha1.ApAttachAttached,
+		},
+		{
+			name:       "unsupported direction",
+			direction:  bpfmaniov1alpha1.TCIngress,
+			expected:   bpfmaniov1alpha1.BpfAppStateCondUnsupportedDirection,
+			linkStatus: bpfmaniov1alpha1.ApUnsupportedDirection,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeTcxProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			// ifb0 is an ifb device, which only supports egress.
+			origLookupLinkType := lookupLinkType
+			lookupLinkType = func(name, _ string) (string, error) {
+				if name == "ifb0" {
+					return "ifb", nil
+				}
+				return "", netlink.LinkNotFoundError{}
+			}
+			defer func() { lookupLinkType = origLookupLinkType }()
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "TcxTest",
+							Type: bpfmaniov1alpha1.ProgTypeTCX,
+							TCX: &bpfmaniov1alpha1.ClTcxProgramInfo{
+								Links: []bpfmaniov1alpha1.ClTcxAttachInfo{
+									{
+										InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"ifb0"}},
+										Direction:         tc.direction,
+										Priority:          50,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			links := bpfAppState.Status.Programs[0].TCX.Links
+			require.Len(t, links, 1)
+			require.Equal(t, tc.linkStatus, links[0].LinkStatus)
+			if tc.linkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				require.Len(t, cli.AttachRequests, 1)
+				return
+			}
+			require.Empty(t, cli.AttachRequests)
+			require.Equal(t, bpfmaniov1alpha1.ProgAttachUnsupportedDirection, bpfAppState.Status.Programs[0].ProgramLinkStatus)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, "interface ifb0 doesn't support the Ingress direction")
+		})
+	}
+}
+
+func TestClBpfApplicationTopologyMismatch(t *testing.T) {
+	var (
+		appProgramName = "fakeKprobeProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = newTopologyNode(t)
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+				// The bytecode's per-CPU maps were sized for 64 CPUs.
+				ExpectedTopology: &bpfmaniov1alpha1.NodeTopology{CPUs: ptr.To[int32](64)},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "KprobeTest",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+							{Function: "try_to_wake_up"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilSettled(t, r, req)
+
+	// The mismatch is only a warning, so the program is still attached.
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Len(t, bpfAppState.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondTopologyMismatch), bpfAppState.Status.Conditions[0].Type)
+	require.Len(t, cli.AttachRequests, 1)
+}
+
+func TestClBpfApplicationUprobeTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		expected   bpfmaniov1alpha1.BpfApplicationStateConditionType
+		linkStatus bpfmaniov1alpha1.LinkStatus
+	}{
+		{
+			name:       "static binary on the host",
+			target:     "/usr/local/bin/server",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus: bpfmaniov1alpha1.ApAttachAttached,
+		},
+		{
+			name:       "missing binary",
+			target:     "/usr/local/bin/missing",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondTargetNotFound,
+			linkStatus: bpfmaniov1alpha1.ApTargetNotFound,
+		},
+		{
+			name:       "library name",
+			target:     "libc",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus: bpfmaniov1alpha1.ApAttachAttached,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeUprobeProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			// Stand in a fake /proc whose pid 1 root has the server binary.
+			procPath := fakeHostProc(t)
+			serverPath := filepath.Join(procPath, "1", "root", "usr/local/bin/server")
+			require.NoError(t, os.MkdirAll(filepath.Dir(serverPath), 0o755))
+			require.NoError(t, os.WriteFile(serverPath, nil, 0o755))
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "UprobeTest",
+							Type: bpfmaniov1alpha1.ProgTypeUprobe,
+							UProbe: &bpfmaniov1alpha1.ClUprobeProgramInfo{
+								Links: []bpfmaniov1alpha1.ClUprobeAttachInfo{
+									{
+										Function: "handle_request",
+										Target:   tc.target,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.HostProcPath = procPath
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			links := bpfAppState.Status.Programs[0].UProbe.Links
+			require.Len(t, links, 1)
+			require.Equal(t, tc.linkStatus, links[0].LinkStatus)
+			if tc.linkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				require.Len(t, cli.AttachRequests, 1)
+				return
+			}
+			require.Empty(t, cli.AttachRequests)
+			require.Equal(t, bpfmaniov1alpha1.ProgAttachTargetNotFound, bpfAppState.Status.Programs[0].ProgramLinkStatus)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, "/usr/local/bin/missing doesn't exist on the node")
+		})
+	}
+}
+
+// tailCallKprobe returns a kprobe program attached to try_to_wake_up with the
+// given tail calls.
+func tailCallKprobe(name string, tailCalls *bpfmaniov1alpha1.TailCallInfo) bpfmaniov1alpha1.ClBpfApplicationProgram {
+	return bpfmaniov1alpha1.ClBpfApplicationProgram{
+		Name: name,
+		Type: bpfmaniov1alpha1.ProgTypeKprobe,
+		KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+			Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+				{Function: "try_to_wake_up"},
+			},
+		},
+		TailCalls: tailCalls,
+	}
+}
@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -95,7 +96,7 @@ func (r *ClFentryProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Attach: &gobpfman.AttachInfo{
 			Info: &gobpfman.AttachInfo_FentryAttachInfo{
 				FentryAttachInfo: &gobpfman.FentryAttachInfo{
-					Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+					Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 				},
 			},
 		},
@@ -121,6 +122,11 @@ func (r *ClFentryProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 	if r.currentProgram.FEntry != nil && r.currentProgram.FEntry.Links != nil {
 		for _, attachInfo := range r.currentProgram.FEntry.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -146,11 +152,16 @@ func (r *ClFentryProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 	return nil
 }
 
-func (r *ClFentryProgramReconciler) findLink(_ bpfmaniov1alpha1.ClFentryAttachInfoState) *int {
-	for i := range r.currentProgramState.FEntry.Links {
-		return &i
+func (r *ClFentryProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClFentryAttachInfoState) *int {
+	matches := []int{}
+	for i, a := range r.currentProgramState.FEntry.Links {
+		// There's only ever one FEntry link, so attachInfoState is the same as
+		// a unless its Metadata has changed.
+		if maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
+		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.FEntry.Links[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -215,7 +226,7 @@ func (r *ClFentryProgramReconciler) removeLinks(links []bpfmaniov1alpha1.ClFentr
 
 // getExpectedLinks expands *AttachInfo into a list of specific attach
 // points.
-func (r *ClFentryProgramReconciler) getExpectedLinks(_ bpfmaniov1alpha1.ClFentryAttachInfo,
+func (r *ClFentryProgramReconciler) getExpectedLinks(attachInfo bpfmaniov1alpha1.ClFentryAttachInfo,
 ) ([]bpfmaniov1alpha1.ClFentryAttachInfoState, error) {
 	nodeLinks := []bpfmaniov1alpha1.ClFentryAttachInfoState{}
 
@@ -225,6 +236,7 @@ func (r *ClFentryProgramReconciler) getExpectedLinks(_ bpfmaniov1alpha1.ClFentry
 			UUID:         uuid.New().String(),
 			LinkId:       nil,
 			LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+			Metadata:     attachInfo.Metadata,
 		},
 	}
 	nodeLinks = append(nodeLinks, link)
@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -95,7 +96,7 @@ func (r *ClFexitProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Attach: &gobpfman.AttachInfo{
 			Info: &gobpfman.AttachInfo_FexitAttachInfo{
 				FexitAttachInfo: &gobpfman.FexitAttachInfo{
-					Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+					Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 				},
 			},
 		},
@@ -120,6 +121,11 @@ func (r *ClFexitProgramReconciler) updateLinks(ctx context.Context, isBeingDelet
 
 	if r.currentProgram.FExit != nil && r.currentProgram.FExit.Links != nil {
 		for _, attachInfo := range r.currentProgram.FExit.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -146,11 +152,16 @@ func (r *ClFexitProgramReconciler) updateLinks(ctx context.Context, isBeingDelet
 	return nil
 }
 
-func (r *ClFexitProgramReconciler) findLink(_ bpfmaniov1alpha1.ClFexitAttachInfoState) *int {
-	for i := range r.currentProgramState.FExit.Links {
-		return &i
+func (r *ClFexitProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClFexitAttachInfoState) *int {
+	matches := []int{}
+	for i, a := range r.currentProgramState.FExit.Links {
+		// There's only ever one FExit link, so attachInfoState is the same as
+		// a unless its Metadata has changed.
+		if maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
+		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.FExit.Links[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -215,7 +226,7 @@ func (r *ClFexitProgramReconciler) removeLinks(links []bpfmaniov1alpha1.ClFexitA
 
 // getExpectedLinks expands *AttachInfo into a list of specific attach
 // points.
-func (r *ClFexitProgramReconciler) getExpectedLinks(_ bpfmaniov1alpha1.ClFexitAttachInfo,
+func (r *ClFexitProgramReconciler) getExpectedLinks(attachInfo bpfmaniov1alpha1.ClFexitAttachInfo,
 ) ([]bpfmaniov1alpha1.ClFexitAttachInfoState, error) {
 	nodeLinks := []bpfmaniov1alpha1.ClFexitAttachInfoState{}
 
@@ -225,6 +236,7 @@ func (r *ClFexitProgramReconciler) getExpectedLinks(_ bpfmaniov1alpha1.ClFexitAt
 			UUID:         uuid.New().String(),
 			LinkId:       nil,
 			LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+			Metadata:     attachInfo.Metadata,
 		},
 	}
 	nodeLinks = append(nodeLinks, link)
@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -96,7 +97,7 @@ func (r *ClKprobeProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 				KprobeAttachInfo: &gobpfman.KprobeAttachInfo{
 					FnName:   r.currentLink.Function,
 					Offset:   r.currentLink.Offset,
-					Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+					Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 				},
 			},
 		},
@@ -124,6 +125,11 @@ func (r *ClKprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 	appLinks := r.getAppLinks()
 	for _, attachInfo := range *appLinks {
+		if !linkEnabled(attachInfo.Enabled) {
+			// Links from a disabled entry keep ShouldAttach false, so
+			// they're detached until it's enabled again.
+			continue
+		}
 		expectedLinks, error := r.getExpectedLinks(attachInfo)
 		if error != nil {
 			return fmt.Errorf("failed to get node links: %v", error)
@@ -151,14 +157,16 @@ func (r *ClKprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 func (r *ClKprobeProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClKprobeAttachInfoState,
 	links *[]bpfmaniov1alpha1.ClKprobeAttachInfoState) *int {
+	matches := []int{}
 	for i, a := range *links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: Function and Offset.
-		if a.Function == attachInfoState.Function && a.Offset == attachInfoState.Offset {
-			return &i
+		// same: Function, Offset, and Metadata.
+		if a.Function == attachInfoState.Function && a.Offset == attachInfoState.Offset &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return (*links)[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -260,6 +268,7 @@ func (r *ClKprobeProgramReconciler) getExpectedLinks(attachInfo bpfmaniov1alpha1
 			UUID:         uuid.New().String(),
 			LinkId:       nil,
 			LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+			Metadata:     attachInfo.Metadata,
 		},
 		Function: attachInfo.Function,
 		Offset:   attachInfo.Offset,
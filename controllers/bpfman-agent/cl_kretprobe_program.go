@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -95,7 +96,7 @@ func (r *ClKretprobeProgramReconciler) getAttachRequest() *gobpfman.AttachReques
 			Info: &gobpfman.AttachInfo_KprobeAttachInfo{
 				KprobeAttachInfo: &gobpfman.KprobeAttachInfo{
 					FnName:   r.currentLink.Function,
-					Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+					Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 				},
 			},
 		},
@@ -123,6 +124,11 @@ func (r *ClKretprobeProgramReconciler) updateLinks(ctx context.Context, isBeingD
 
 	appLinks := r.getAppLinks()
 	for _, attachInfo := range *appLinks {
+		if !linkEnabled(attachInfo.Enabled) {
+			// Links from a disabled entry keep ShouldAttach false, so
+			// they're detached until it's enabled again.
+			continue
+		}
 		expectedLinks, error := r.getExpectedLinks(attachInfo)
 		if error != nil {
 			return fmt.Errorf("failed to get node links: %v", error)
@@ -150,14 +156,16 @@ func (r *ClKretprobeProgramReconciler) updateLinks(ctx context.Context, isBeingD
 
 func (r *ClKretprobeProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClKretprobeAttachInfoState,
 	links *[]bpfmaniov1alpha1.ClKretprobeAttachInfoState) *int {
+	matches := []int{}
 	for i, a := range *links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: Function.
-		if a.Function == attachInfoState.Function {
-			return &i
+		// same: Function and Metadata.
+		if a.Function == attachInfoState.Function &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return (*links)[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -259,6 +267,7 @@ func (r *ClKretprobeProgramReconciler) getExpectedLinks(attachInfo bpfmaniov1alp
 			UUID:         uuid.New().String(),
 			LinkId:       nil,
 			LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+			Metadata:     attachInfo.Metadata,
 		},
 		Function: attachInfo.Function,
 	}
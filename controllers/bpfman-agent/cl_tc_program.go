@@ -19,7 +19,9 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -58,7 +60,8 @@ func (r *ClTcProgramReconciler) isAttached(ctx context.Context) bool {
 	if r.currentProgramState.ProgramId == nil || r.currentLink.LinkId == nil {
 		return false
 	}
-	return r.doesLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId)
+	return r.doesInterfaceLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId,
+		r.currentLink.InterfaceName, r.currentLink.NetnsPath, true)
 }
 
 func (r *ClTcProgramReconciler) getUUID() string {
@@ -89,7 +92,8 @@ func (r *ClTcProgramReconciler) getCurrentLinkStatus() bpfmaniov1alpha1.LinkStat
 	return r.currentLink.LinkStatus
 }
 
-// Must match with bpfman internal types
+// Must match with bpfman internal types. The values are returned in order,
+// without duplicates.
 func tcProceedOnToInt(proceedOn []bpfmaniov1alpha1.TcProceedOnValue) []int32 {
 	var out []int32
 
@@ -120,7 +124,9 @@ func tcProceedOnToInt(proceedOn []bpfmaniov1alpha1.TcProceedOnValue) []int32 {
 		}
 	}
 
-	return out
+	// proceedOn is a set, so the order it's given in doesn't matter.
+	slices.Sort(out)
+	return slices.Compact(out)
 }
 
 func (r *ClTcProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
@@ -135,7 +141,7 @@ func (r *ClTcProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Iface:     r.currentLink.InterfaceName,
 		Direction: directionToStr(r.currentLink.Direction),
 		ProceedOn: tcProceedOnToInt(r.currentLink.ProceedOn),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 		Netns:     netnsPath,
 	}
 
@@ -168,6 +174,11 @@ func (r *ClTcProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 
 	if r.currentProgram.TC != nil && r.currentProgram.TC.Links != nil {
 		for _, attachInfo := range r.currentProgram.TC.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, err := r.getExpectedLinks(ctx, attachInfo)
 			if err != nil {
 				r.Logger.V(1).Info("updateLinks() failed", "error", err)
@@ -217,18 +228,21 @@ func (r *ClTcProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClTcAt
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.TC.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Direction, Priority, ProceedOn, and network namespace.
+		// same: InterfaceName, Direction, Priority, ProceedOn, network
+		// namespace, and Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
 			a.Direction == attachInfoState.Direction &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(a.ProceedOn, attachInfoState.ProceedOn) &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			sameProceedOn(a.ProceedOn, attachInfoState.ProceedOn) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.TC.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -245,6 +259,13 @@ func (r *ClTcProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range r.currentProgramState.TC.Links {
 		r.currentLink = &r.currentProgramState.TC.Links[i]
+		point := attachPoint{hook: tcAttachHook(r.currentLink.Direction, false), iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching TC program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -303,10 +324,12 @@ func (r *ClTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 				UUID:         uuid.New().String(),
 				LinkId:       nil,
 				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
 			},
 			InterfaceName: interfaceName,
 			NetnsPath:     netnsPath,
 			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
 			Direction:     attachInfo.Direction,
 			ProceedOn:     attachInfo.ProceedOn,
 		}
@@ -314,7 +337,10 @@ func (r *ClTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 
 	// Handle interface discovery
 	if isInterfacesDiscoveryEnabled(&attachInfo.InterfaceSelector) {
-		discoveredInterfaces := getDiscoveredInterfaces(&attachInfo.InterfaceSelector, r.Interfaces)
+		discoveredInterfaces, err := getDiscoveredInterfaces(&attachInfo.InterfaceSelector, r.Interfaces)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get discovered interfaces: %w", err)
+		}
 		r.Logger.Info("getExpectedLinks", "num discoveredInterfaces", len(discoveredInterfaces))
 		for _, intf := range discoveredInterfaces {
 			nodeLinks = append(nodeLinks, createLinkEntry(intf.interfaceName, intf.netNSPath))
@@ -324,10 +350,10 @@ func (r *ClTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 	}
 
 	// Fetch interfaces if discovery is disabled
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		r.Logger.V(1).Info("getExpectedLinks failed to get interfaces", "error", err)
-		return nil, fmt.Errorf("failed to get interfaces for XdpProgram: %w", err)
+		return nil, fmt.Errorf("failed to get interfaces for TcProgram: %w", err)
 	}
 
 	r.Logger.Info("getExpectedLinks", "Number of interfaces", len(interfaces))
@@ -348,21 +374,48 @@ func (r *ClTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 
 		if containerInfo == nil {
 			r.Logger.Info("NetworkNamespaces is configured but no matching container found")
-			return nodeLinks, nil
+			containerInfo = &[]ContainerInfo{}
 		}
 
+		includeHost := attachInfo.NetworkNamespaces.IncludeHost
+		hostNetwork := false
 		containerInfo = GetOneContainerPerPod(containerInfo)
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
-			for _, iface := range interfaces {
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so the host's links cover them all at once.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
+			// TC attaches to an interface inside the pod's network namespace,
+			// so make sure the namespace can be resolved. The pod may have
+			// exited since its containers were listed.
+			if r.getNetnsId(netnsPath) == nil {
+				r.Logger.Info("Skipping pod with unresolvable network namespace",
+					"pod", container.podName, "netnsPath", netnsPath)
+				continue
+			}
+			podInterfaces, err := r.linkInterfaces(attachInfo, interfaces, netnsPath)
+			if err != nil {
+				return nil, err
+			}
+			for _, iface := range podInterfaces {
 				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
 			}
 		}
-		r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
-		return nodeLinks, nil
+		if !includeHost && !hostNetwork {
+			r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
+			return nodeLinks, nil
+		}
 	}
 
-	// Fallback: Assign interfaces without a namespace
+	// Assign interfaces in the host's network namespace, which is the
+	// fallback if no network namespaces were given.
+	interfaces, err = r.linkInterfaces(attachInfo, interfaces, "")
+	if err != nil {
+		return nil, err
+	}
 	for _, iface := range interfaces {
 		nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 	}
@@ -371,6 +424,17 @@ func (r *ClTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 	return nodeLinks, nil
 }
 
+// linkInterfaces returns the interfaces in the network namespace at netnsPath
+// that a link is attached to: interfaces, with the members of any bond or
+// team among them if attachInfo asks for them.
+func (r *ClTcProgramReconciler) linkInterfaces(attachInfo bpfmaniov1alpha1.ClTcAttachInfo, interfaces []string,
+	netnsPath string) ([]string, error) {
+	if !attachInfo.AttachToBondMembers {
+		return interfaces, nil
+	}
+	return withBondMembers(interfaces, netnsPath)
+}
+
 func (r *ClTcProgramReconciler) getProgramLoadInfo() *gobpfman.LoadInfo {
 	return &gobpfman.LoadInfo{
 		Name:        r.currentProgram.Name,
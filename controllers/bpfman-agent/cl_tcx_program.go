@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -100,7 +101,7 @@ func (r *ClTcxProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Priority:  r.currentLink.Priority,
 		Iface:     r.currentLink.InterfaceName,
 		Direction: directionToStr(r.currentLink.Direction),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 		Netns:     netnsPath,
 	}
 
@@ -133,6 +134,11 @@ func (r *ClTcxProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 
 	if r.currentProgram.TCX != nil && r.currentProgram.TCX.Links != nil {
 		for _, attachInfo := range r.currentProgram.TCX.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, err := r.getExpectedLinks(ctx, attachInfo)
 			if err != nil {
 				r.Logger.V(1).Info("updateLinks() failed", "error", err)
@@ -182,17 +188,20 @@ func (r *ClTcxProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClTcx
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.TCX.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Direction, Priority, and network namespace.
+		// same: InterfaceName, Direction, Priority, network namespace, and
+		// Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
 			a.Direction == attachInfoState.Direction &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.TCX.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -209,6 +218,19 @@ func (r *ClTcxProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range r.currentProgramState.TCX.Links {
 		r.currentLink = &r.currentProgramState.TCX.Links[i]
+		if err := r.checkTcxDirection(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath,
+			r.currentLink.Direction); err != nil {
+			r.Logger.Error(err, "Not attaching TCX program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		point := attachPoint{hook: tcAttachHook(r.currentLink.Direction, true), iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching TCX program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -235,13 +257,18 @@ func (r *ClTcxProgramReconciler) processLinks(ctx context.Context) error {
 }
 
 func (r *ClTcxProgramReconciler) updateProgramAttachStatus() {
+	status := bpfmaniov1alpha1.ProgAttachSuccess
 	for _, link := range r.currentProgramState.TCX.Links {
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApUnsupportedDirection {
+			status = bpfmaniov1alpha1.ProgAttachUnsupportedDirection
+			continue
+		}
 		if !isAttachSuccess(link.ShouldAttach, link.LinkStatus) {
 			r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachError)
 			return
 		}
 	}
-	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
+	r.setProgramLinkStatus(status)
 }
 
 // removeLinks removes links from a slice of links based on the keys in the map.
@@ -267,17 +294,22 @@ func (r *ClTcxProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 				UUID:         uuid.New().String(),
 				LinkId:       nil,
 				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
 			},
 			InterfaceName: interfaceName,
 			NetnsPath:     netnsPath,
 			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
 			Direction:     attachInfo.Direction,
 		}
 	}
 
 	// Handle interface discovery
 	if isInterfacesDiscoveryEnabled(&attachInfo.InterfaceSelector) {
-		discoveredInterfaces := getDiscoveredInterfaces(&attachInfo.InterfaceSelector, r.Interfaces)
+		discoveredInterfaces, err := getDiscoveredInterfaces(&attachInfo.InterfaceSelector, r.Interfaces)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get discovered interfaces: %w", err)
+		}
 
 		r.Logger.Info("getExpectedLinks", "num discoveredInterfaces", len(discoveredInterfaces))
 		for _, intf := range discoveredInterfaces {
@@ -288,7 +320,7 @@ func (r *ClTcxProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 	}
 
 	// Fetch interfaces if discovery is disabled
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		r.Logger.V(1).Info("getExpectedLinks failed to get interfaces", "error", err)
 		return nil, fmt.Errorf("failed to get interfaces for XdpProgram: %w", err)
@@ -312,21 +344,32 @@ func (r *ClTcxProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 
 		if containerInfo == nil {
 			r.Logger.Info("NetworkNamespaces is configured but no matching container found")
-			return nodeLinks, nil
+			containerInfo = &[]ContainerInfo{}
 		}
 
+		includeHost := attachInfo.NetworkNamespaces.IncludeHost
+		hostNetwork := false
 		containerInfo = GetOneContainerPerPod(containerInfo)
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so the host's links cover them all at once.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
 			for _, iface := range interfaces {
 				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
 			}
 		}
-		r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
-		return nodeLinks, nil
+		if !includeHost && !hostNetwork {
+			r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
+			return nodeLinks, nil
+		}
 	}
 
-	// Fallback: Assign interfaces without a namespace
+	// Assign interfaces in the host's network namespace, which is the
+	// fallback if no network namespaces were given.
 	for _, iface := range interfaces {
 		nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 	}
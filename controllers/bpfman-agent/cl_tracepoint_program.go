@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -95,7 +96,7 @@ func (r *ClTracepointProgramReconciler) getAttachRequest() *gobpfman.AttachReque
 			Info: &gobpfman.AttachInfo_TracepointAttachInfo{
 				TracepointAttachInfo: &gobpfman.TracepointAttachInfo{
 					Tracepoint: r.currentLink.Name,
-					Metadata:   map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+					Metadata:   r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 				},
 			},
 		},
@@ -121,6 +122,11 @@ func (r *ClTracepointProgramReconciler) updateLinks(ctx context.Context, isBeing
 
 	if r.currentProgram.TracePoint != nil && r.currentProgram.TracePoint.Links != nil {
 		for _, attachInfo := range r.currentProgram.TracePoint.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -148,14 +154,16 @@ func (r *ClTracepointProgramReconciler) updateLinks(ctx context.Context, isBeing
 }
 
 func (r *ClTracepointProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClTracepointAttachInfoState) *int {
+	matches := []int{}
 	for i, a := range r.currentProgramState.TracePoint.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: Name.
-		if a.Name == attachInfoState.Name {
-			return &i
+		// same: Name and Metadata.
+		if a.Name == attachInfoState.Name &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.TracePoint.Links[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -230,6 +238,7 @@ func (r *ClTracepointProgramReconciler) getExpectedLinks(attachInfo bpfmaniov1al
 			UUID:         uuid.New().String(),
 			LinkId:       nil,
 			LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+			Metadata:     attachInfo.Metadata,
 		},
 		Name: attachInfo.Name,
 	}
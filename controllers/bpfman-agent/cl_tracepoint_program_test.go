@@ -0,0 +1,345 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// A Tracepoint program is attached to each of the tracepoints in its links.
+func TestClTracepointProgramMultipleTracepoints(t *testing.T) {
+	var (
+		appProgramName = "fakeTracepointProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+		tracepoints    = []string{"syscalls/sys_enter_openat", "syscalls/sys_enter_kill", "sched/sched_switch"}
+	)
+
+	links := []bpfmaniov1alpha1.ClTracepointAttachInfo{}
+	for _, tracepoint := range tracepoints {
+		links = append(links, bpfmaniov1alpha1.ClTracepointAttachInfo{Name: tracepoint})
+	}
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name:       "tracepoint_test",
+					Type:       bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{Links: links},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilAttached(t, r, req)
+
+	// The program is loaded once and attached once to each tracepoint.
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.AttachRequests, len(tracepoints))
+	attached := []string{}
+	for _, attachRequest := range cli.AttachRequests {
+		attached = append(attached, attachRequest.Attach.GetTracepointAttachInfo().Tracepoint)
+	}
+	require.ElementsMatch(t, tracepoints, attached)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	linkStates := bpfAppState.Status.Programs[0].TracePoint.Links
+	require.Len(t, linkStates, len(tracepoints))
+	linkIds := map[uint32]bool{}
+	for i, link := range linkStates {
+		require.Equal(t, tracepoints[i], link.Name)
+		require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+		require.NotNil(t, link.LinkId)
+		linkIds[*link.LinkId] = true
+	}
+	require.Len(t, linkIds, len(tracepoints))
+
+	// How long bpfman took to load the program and attach its links is
+	// reported, and kept when a later reconcile has nothing to attach.
+	for range 2 {
+		program := bpfAppState.Status.Programs[0]
+		require.NotNil(t, program.LoadDurationMs)
+		require.GreaterOrEqual(t, *program.LoadDurationMs, int64(0))
+		require.NotNil(t, program.AttachDurationMs)
+		require.GreaterOrEqual(t, *program.AttachDurationMs, int64(0))
+
+		_, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		bpfAppState, err = r.getBpfAppState(ctx)
+		require.NoError(t, err)
+	}
+	require.Len(t, cli.AttachRequests, len(tracepoints))
+}
+
+// Disabling one of a Tracepoint program's links detaches it while leaving the
+// others attached, and enabling it again attaches it again.
+func TestClTracepointProgramDisableLink(t *testing.T) {
+	var (
+		appProgramName = "fakeTracepointProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "tracepoint_test",
+					Type: bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTracepointAttachInfo{
+							{Name: "syscalls/sys_enter_openat"},
+							{Name: "syscalls/sys_enter_kill"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileAll := func() {
+		for range 3 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	setEnabled := func(enabled bool) {
+		app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+		require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: appProgramName}, app))
+		app.Spec.Programs[0].TracePoint.Links[1].Enabled = &enabled
+		require.NoError(t, r.Client.Update(ctx, app))
+	}
+	attachedLinks := func() []string {
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+		names := []string{}
+		for _, link := range bpfAppState.Status.Programs[0].TracePoint.Links {
+			if link.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				names = append(names, link.Name)
+			}
+		}
+		return names
+	}
+
+	reconcileAll()
+	require.Len(t, cli.AttachRequests, 2)
+	require.Len(t, cli.Links, 2)
+
+	// The disabled link is detached, and the other is left alone.
+	setEnabled(false)
+	reconcileAll()
+	require.Len(t, cli.AttachRequests, 2)
+	require.Len(t, cli.Links, 1)
+	require.Equal(t, []string{"syscalls/sys_enter_openat"}, attachedLinks())
+
+	// Enabling it again attaches it again.
+	setEnabled(true)
+	reconcileAll()
+	require.Len(t, cli.AttachRequests, 3)
+	killAttaches := 0
+	for _, attachRequest := range cli.AttachRequests {
+		if attachRequest.Attach.GetTracepointAttachInfo().Tracepoint == "syscalls/sys_enter_kill" {
+			killAttaches++
+		}
+	}
+	require.Equal(t, 2, killAttaches)
+	require.Len(t, cli.Links, 2)
+	require.ElementsMatch(t, []string{"syscalls/sys_enter_openat", "syscalls/sys_enter_kill"}, attachedLinks())
+}
+
+func TestClTracepointProgramDetached(t *testing.T) {
+	var (
+		appProgramName = "fakeTracepointProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "tracepoint_test",
+					Type: bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTracepointAttachInfo{
+							{Name: "syscalls/sys_enter_openat"},
+							{Name: "syscalls/sys_enter_kill"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileAll := func() {
+		for range 3 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	setDetached := func(detached bool) {
+		app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+		require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: appProgramName}, app))
+		app.Spec.Detached = detached
+		require.NoError(t, r.Client.Update(ctx, app))
+	}
+
+	reconcileAll()
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.Links, 2)
+
+	// Detaching the application detaches its links, but leaves its program
+	// loaded.
+	setDetached(true)
+	reconcileAll()
+	require.Empty(t, cli.Links)
+	require.Empty(t, cli.UnloadRequests)
+	require.Len(t, cli.LoadRequests, 1)
+	require.Len(t, cli.Programs, 1)
+
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+	require.NotNil(t, bpfAppState.Status.Programs[0].ProgramId)
+	require.Empty(t, bpfAppState.Status.Programs[0].TracePoint.Links)
+
+	// Attaching it again doesn't reload it.
+	setDetached(false)
+	reconcileAll()
+	require.Len(t, cli.Links, 2)
+	require.Len(t, cli.AttachRequests, 4)
+	require.Len(t, cli.LoadRequests, 1)
+	require.Empty(t, cli.UnloadRequests)
+}
+
+func TestClTracepointProgramKernelInfo(t *testing.T) {
+	var (
+		appProgramName = "fakeTracepointProgram"
+		bytecodePath   = "/tmp/hello.o"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: appProgramName,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "tracepoint_test",
+					Type: bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTracepointAttachInfo{
+							{Name: "syscalls/sys_enter_openat"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileAll := func() {
+		for range 3 {
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	kernelInfo := func() *bpfmaniov1alpha1.ProgramKernelInfo {
+		bpfAppState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		return bpfAppState.Status.Programs[0].KernelInfo
+	}
+
+	reconcileAll()
+	require.Equal(t, &bpfmaniov1alpha1.ProgramKernelInfo{}, kernelInfo())
+
+	// The kernel's accounting is refreshed when the program is checked.
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	loaded := cli.Programs[int(*bpfAppState.Status.Programs[0].ProgramId)]
+	loaded.KernelInfo.BytesMemlock = 4096
+	loaded.KernelInfo.BytesJited = 312
+	loaded.KernelInfo.MapIds = []uint32{7, 9}
+
+	reconcileAll()
+	require.Equal(t, &bpfmaniov1alpha1.ProgramKernelInfo{
+		MemlockBytes:   4096,
+		JitedSizeBytes: 312,
+		MapIds:         []uint32{7, 9},
+	}, kernelInfo())
+}
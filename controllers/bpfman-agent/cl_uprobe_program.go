@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -32,6 +33,9 @@ type ClUprobeProgramReconciler struct {
 	ReconcilerCommon
 	ClProgramReconcilerCommon
 	currentLink *bpfmaniov1alpha1.ClUprobeAttachInfoState
+	// waitingForContainers is set by updateLinks if a container selector
+	// that names pods doesn't match any containers yet.
+	waitingForContainers bool
 }
 
 func (r *ClUprobeProgramReconciler) getProgId() *uint32 {
@@ -96,7 +100,7 @@ func (r *ClUprobeProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Offset:   r.currentLink.Offset,
 		Target:   r.currentLink.Target,
 		Pid:      r.currentLink.Pid,
-		Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 	}
 
 	if r.currentLink.ContainerPid != nil {
@@ -135,6 +139,11 @@ func (r *ClUprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 	appLinks := r.getAppLinks()
 	for _, attachInfo := range *appLinks {
+		if !linkEnabled(attachInfo.Enabled) {
+			// Links from a disabled entry keep ShouldAttach false, so
+			// they're detached until it's enabled again.
+			continue
+		}
 		expectedLinks, error := r.getExpectedLinks(ctx, attachInfo)
 		if error != nil {
 			return fmt.Errorf("failed to get node links: %v", error)
@@ -162,17 +171,20 @@ func (r *ClUprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 func (r *ClUprobeProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClUprobeAttachInfoState,
 	links *[]bpfmaniov1alpha1.ClUprobeAttachInfoState) *int {
+	matches := []int{}
 	for i, a := range *links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: Function, Offset, Target, Pid, and ContainerPid.
+		// same: Function, Offset, Target, BuildID, Pid, ContainerPid, and
+		// Metadata.
 		if a.Function == attachInfoState.Function && a.Offset == attachInfoState.Offset &&
-			a.Target == attachInfoState.Target &&
+			a.Target == attachInfoState.Target && a.BuildID == attachInfoState.BuildID &&
 			reflect.DeepEqual(a.Pid, attachInfoState.Pid) &&
-			reflect.DeepEqual(a.ContainerPid, attachInfoState.ContainerPid) {
-			return &i
+			reflect.DeepEqual(a.ContainerPid, attachInfoState.ContainerPid) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return (*links)[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -190,6 +202,18 @@ func (r *ClUprobeProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range *appStateLinks {
 		r.currentLink = &(*appStateLinks)[i]
+		if r.currentLink.ContainerPid != nil {
+			if err := r.checkUprobeBuildID(ctx, r, r.currentLink.BuildID, r.currentLink.Target,
+				*r.currentLink.ContainerPid); err != nil {
+				r.Logger.Error(err, "Not attaching uprobe", "index", i)
+				lastReconcileLinkError = err
+				continue
+			}
+		} else if err := r.checkUprobeTarget(ctx, r, r.currentLink.Target); err != nil {
+			r.Logger.Error(err, "Not attaching uprobe", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -217,12 +241,25 @@ func (r *ClUprobeProgramReconciler) processLinks(ctx context.Context) error {
 
 func (r *ClUprobeProgramReconciler) updateProgramAttachStatus() {
 	appStateLinks := r.getAppStateLinks()
+	targetNotFound := false
 	for _, link := range *appStateLinks {
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApTargetNotFound {
+			targetNotFound = true
+			continue
+		}
 		if !isAttachSuccess(link.ShouldAttach, link.LinkStatus) {
 			r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachError)
 			return
 		}
 	}
+	if targetNotFound {
+		r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachTargetNotFound)
+		return
+	}
+	if r.waitingForContainers {
+		r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachWaitingForContainers)
+		return
+	}
 	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
 }
 
@@ -288,6 +325,8 @@ func (r *ClUprobeProgramReconciler) getExpectedLinks(ctx context.Context, attach
 			return nil, fmt.Errorf("failed to get container pids: %v", err)
 		}
 
+		containerInfo = filterContainersByImage(containerInfo, attachInfo.Containers.ContainerImages)
+
 		if containerInfo != nil && len(*containerInfo) != 0 {
 			// Containers were found, so create links.
 			for i := range *containerInfo {
@@ -299,15 +338,22 @@ func (r *ClUprobeProgramReconciler) getExpectedLinks(ctx context.Context, attach
 						UUID:         uuid.New().String(),
 						LinkId:       nil,
 						LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+						Metadata:     attachInfo.Metadata,
 					},
 					Function:     attachInfo.Function,
 					Offset:       attachInfo.Offset,
 					Target:       attachInfo.Target,
+					BuildID:      attachInfo.BuildID,
 					Pid:          attachInfo.Pid,
 					ContainerPid: &containerPid,
 				}
 				nodeLinks = append(nodeLinks, link)
 			}
+		} else if selectsPods(attachInfo.Containers.Pods) || len(attachInfo.Containers.ContainerImages) != 0 {
+			// The pods may not have been scheduled or started yet.
+			r.Logger.Info("Waiting for containers matching the container selector",
+				"Namespace", attachInfo.Containers.Namespace, "Pods", attachInfo.Containers.Pods)
+			r.waitingForContainers = true
 		}
 	} else {
 		link := bpfmaniov1alpha1.ClUprobeAttachInfoState{
@@ -316,6 +362,7 @@ func (r *ClUprobeProgramReconciler) getExpectedLinks(ctx context.Context, attach
 				UUID:         uuid.New().String(),
 				LinkId:       nil,
 				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
 			},
 			Function: attachInfo.Function,
 			Offset:   attachInfo.Offset,
@@ -19,7 +19,9 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	internal "github.com/bpfman/bpfman-operator/internal"
@@ -58,7 +60,8 @@ func (r *ClXdpProgramReconciler) isAttached(ctx context.Context) bool {
 	if r.currentProgramState.ProgramId == nil || r.currentLink.LinkId == nil {
 		return false
 	}
-	return r.doesLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId)
+	return r.doesInterfaceLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId,
+		r.currentLink.InterfaceName, r.currentLink.NetnsPath, false)
 }
 
 func (r *ClXdpProgramReconciler) getUUID() string {
@@ -89,7 +92,8 @@ func (r *ClXdpProgramReconciler) getCurrentLinkStatus() bpfmaniov1alpha1.LinkSta
 	return r.currentLink.LinkStatus
 }
 
-// Must match with bpfman internal types
+// Must match with bpfman internal types. The values are returned in order,
+// without duplicates.
 func xdpProceedOnToInt(proceedOn []bpfmaniov1alpha1.XdpProceedOnValue) []int32 {
 	var out []int32
 
@@ -110,7 +114,9 @@ func xdpProceedOnToInt(proceedOn []bpfmaniov1alpha1.XdpProceedOnValue) []int32 {
 		}
 	}
 
-	return out
+	// proceedOn is a set, so the order it's given in doesn't matter.
+	slices.Sort(out)
+	return slices.Compact(out)
 }
 
 func (r *ClXdpProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
@@ -123,8 +129,8 @@ func (r *ClXdpProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 	attachInfo := &gobpfman.XDPAttachInfo{
 		Priority:  r.currentLink.Priority,
 		Iface:     r.currentLink.InterfaceName,
-		ProceedOn: xdpProceedOnToInt(r.currentLink.ProceedOn),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		ProceedOn: r.xdpProceedOn(r.currentLink.ProceedOn),
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 		Netns:     netnsPath,
 	}
 
@@ -156,26 +162,33 @@ func (r *ClXdpProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 	}
 
 	if r.currentProgram.XDP != nil && r.currentProgram.XDP.Links != nil {
+		candidates := []xdpLinkCandidate{}
 		for _, attachInfo := range r.currentProgram.XDP.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, err := r.getExpectedLinks(ctx, attachInfo)
 			if err != nil {
 				r.Logger.V(1).Info("updateLinks() failed", "error", err)
 				return fmt.Errorf("failed to get node links: %v", err)
 			}
-			for _, link := range expectedLinks {
-				index, err := r.findLink(link)
-				if err != nil {
-					r.Logger.Info("Error", "Invalid link", r.printAttachInfo(link), "Error", err)
-					continue
-				}
-				if index != nil {
-					// Link already exists, so set ShouldAttach to true.
-					r.currentProgramState.XDP.Links[*index].AttachInfoStateCommon.ShouldAttach = true
-				} else {
-					// Link doesn't exist, so add it.
-					r.Logger.Info("Link doesn't exist.  Adding it.")
-					r.currentProgramState.XDP.Links = append(r.currentProgramState.XDP.Links, link)
-				}
+			candidates = append(candidates, expectedLinks...)
+		}
+		for _, link := range uniqueXdpLinks(candidates) {
+			index, err := r.findLink(link)
+			if err != nil {
+				r.Logger.Info("Error", "Invalid link", r.printAttachInfo(link), "Error", err)
+				continue
+			}
+			if index != nil {
+				// Link already exists, so set ShouldAttach to true.
+				r.currentProgramState.XDP.Links[*index].AttachInfoStateCommon.ShouldAttach = true
+			} else {
+				// Link doesn't exist, so add it.
+				r.Logger.Info("Link doesn't exist.  Adding it.")
+				r.currentProgramState.XDP.Links = append(r.currentProgramState.XDP.Links, link)
 			}
 		}
 	}
@@ -206,17 +219,20 @@ func (r *ClXdpProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.ClXdp
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.XDP.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Priority, ProceedOn, and network namespace.
+		// same: InterfaceName, Priority, ProceedOn, network namespace, and
+		// Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(a.ProceedOn, attachInfoState.ProceedOn) &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			sameProceedOn(a.ProceedOn, attachInfoState.ProceedOn) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.XDP.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -231,8 +247,36 @@ func (r *ClXdpProgramReconciler) processLinks(ctx context.Context) error {
 	linksToRemove := make(map[int]bool)
 
 	var lastReconcileLinkError error = nil
+	// deferredLinkError is returned if there were no other errors but one or
+	// more links are waiting for their interface to come up.
+	var deferredLinkError error = nil
 	for i := range r.currentProgramState.XDP.Links {
 		r.currentLink = &r.currentProgramState.XDP.Links[i]
+		if err := r.checkXdpInterface(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath); err != nil {
+			if r.currentLink.LinkStatus == bpfmaniov1alpha1.ApAttachDeferred {
+				deferredLinkError = err
+			} else {
+				lastReconcileLinkError = err
+			}
+			continue
+		}
+		if err := r.checkForeignXdpProgram(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		if err := r.checkXdpDispatcherReturn(ctx, r, r.currentLink.ProceedOn); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		point := attachPoint{hook: HookXDP, iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -255,17 +299,31 @@ func (r *ClXdpProgramReconciler) processLinks(ctx context.Context) error {
 
 	r.updateProgramAttachStatus()
 
+	if lastReconcileLinkError == nil {
+		return deferredLinkError
+	}
 	return lastReconcileLinkError
 }
 
 func (r *ClXdpProgramReconciler) updateProgramAttachStatus() {
+	status := bpfmaniov1alpha1.ProgAttachSuccess
 	for _, link := range r.currentProgramState.XDP.Links {
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApForeignXdpProgram {
+			status = bpfmaniov1alpha1.ProgAttachForeignXdpProgram
+			continue
+		}
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApAttachDeferred {
+			if status == bpfmaniov1alpha1.ProgAttachSuccess {
+				status = bpfmaniov1alpha1.ProgAttachDeferred
+			}
+			continue
+		}
 		if !isAttachSuccess(link.ShouldAttach, link.LinkStatus) {
 			r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachError)
 			return
 		}
 	}
-	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
+	r.setProgramLinkStatus(status)
 }
 
 // removeLinks removes links from a slice of links based on the keys in the map.
@@ -279,9 +337,81 @@ func (r *ClXdpProgramReconciler) removeLinks(links []bpfmaniov1alpha1.ClXdpAttac
 	return remainingLinks
 }
 
+// xdpLinkCandidate is a link expected from one of an XDP program's interface
+// selectors, before links for the same interface are de-duplicated.
+type xdpLinkCandidate struct {
+	link bpfmaniov1alpha1.ClXdpAttachInfoState
+	// specificity is how specifically the selector chose the interface.
+	specificity int
+}
+
+// interfaceSelectorSpecificity ranks how specifically an interface selector
+// chooses its interfaces: by name or index, then as the primary node
+// interface, then by discovery.
+func interfaceSelectorSpecificity(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector) int {
+	switch {
+	case isInterfacesDiscoveryEnabled(interfaceSelector):
+		return 0
+	case interfaceSelector.PrimaryNodeInterface != nil:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// uniqueXdpLinks returns one link for each interface and network namespace
+// among the candidates. When more than one candidate has the same interface,
+// the one chosen most specifically is kept, so its priority and proceedOn
+// win, and if they're equally specific the first is kept.
+func uniqueXdpLinks(candidates []xdpLinkCandidate) []bpfmaniov1alpha1.ClXdpAttachInfoState {
+	type linkKey struct {
+		interfaceName string
+		netnsPath     string
+	}
+	links := []bpfmaniov1alpha1.ClXdpAttachInfoState{}
+	kept := map[linkKey]int{}
+	specificity := map[linkKey]int{}
+	for _, candidate := range candidates {
+		key := linkKey{candidate.link.InterfaceName, candidate.link.NetnsPath}
+		i, ok := kept[key]
+		if !ok {
+			kept[key] = len(links)
+			specificity[key] = candidate.specificity
+			links = append(links, candidate.link)
+			continue
+		}
+		if candidate.specificity > specificity[key] {
+			specificity[key] = candidate.specificity
+			links[i] = candidate.link
+		}
+	}
+	return links
+}
+
 // getExpectedLinks expands *AttachInfo into a list of specific attach
-// points.
-func (r *ClXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.ClXdpAttachInfo) ([]bpfmaniov1alpha1.ClXdpAttachInfoState, error) {
+// points, one for each interface chosen by any of its interface selectors.
+func (r *ClXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.ClXdpAttachInfo) ([]xdpLinkCandidate, error) {
+	interfaceSelectors := append([]bpfmaniov1alpha1.InterfaceSelector{attachInfo.InterfaceSelector},
+		attachInfo.AdditionalInterfaceSelectors...)
+
+	candidates := []xdpLinkCandidate{}
+	for i := range interfaceSelectors {
+		links, err := r.getSelectorLinks(ctx, attachInfo, &interfaceSelectors[i])
+		if err != nil {
+			return nil, err
+		}
+		specificity := interfaceSelectorSpecificity(&interfaceSelectors[i])
+		for _, link := range links {
+			candidates = append(candidates, xdpLinkCandidate{link: link, specificity: specificity})
+		}
+	}
+	return candidates, nil
+}
+
+// getSelectorLinks expands *AttachInfo into a list of specific attach points
+// for the interfaces chosen by one of its interface selectors.
+func (r *ClXdpProgramReconciler) getSelectorLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.ClXdpAttachInfo,
+	interfaceSelector *bpfmaniov1alpha1.InterfaceSelector) ([]bpfmaniov1alpha1.ClXdpAttachInfoState, error) {
 	nodeLinks := []bpfmaniov1alpha1.ClXdpAttachInfoState{}
 	// Helper function to create a ClXdpAttachInfoState entry
 	createLinkEntry := func(interfaceName, netnsPath string) bpfmaniov1alpha1.ClXdpAttachInfoState {
@@ -291,17 +421,22 @@ func (r *ClXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 				UUID:         uuid.New().String(),
 				LinkId:       nil,
 				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
 			},
 			InterfaceName: interfaceName,
 			NetnsPath:     netnsPath,
 			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
 			ProceedOn:     attachInfo.ProceedOn,
 		}
 	}
 
 	// Handle interface discovery
-	if isInterfacesDiscoveryEnabled(&attachInfo.InterfaceSelector) {
-		discoveredInterfaces := getDiscoveredInterfaces(&attachInfo.InterfaceSelector, r.Interfaces)
+	if isInterfacesDiscoveryEnabled(interfaceSelector) {
+		discoveredInterfaces, err := getDiscoveredInterfaces(interfaceSelector, r.Interfaces)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get discovered interfaces: %w", err)
+		}
 		r.Logger.Info("getExpectedLinks", "num discoveredInterfaces", len(discoveredInterfaces))
 		for _, intf := range discoveredInterfaces {
 			nodeLinks = append(nodeLinks, createLinkEntry(intf.interfaceName, intf.netNSPath))
@@ -311,7 +446,7 @@ func (r *ClXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 	}
 
 	// Fetch interfaces if discovery is disabled
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(interfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		r.Logger.V(1).Info("getExpectedLinks failed to get interfaces", "error", err)
 		return nil, fmt.Errorf("failed to get interfaces for XdpProgram: %w", err)
@@ -335,21 +470,32 @@ func (r *ClXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 
 		if containerInfo == nil {
 			r.Logger.Info("NetworkNamespaces is configured but no matching container found")
-			return nodeLinks, nil
+			containerInfo = &[]ContainerInfo{}
 		}
 
+		includeHost := attachInfo.NetworkNamespaces.IncludeHost
+		hostNetwork := false
 		containerInfo = GetOneContainerPerPod(containerInfo)
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so the host's links cover them all at once.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
 			for _, iface := range interfaces {
 				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
 			}
 		}
-		r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
-		return nodeLinks, nil
+		if !includeHost && !hostNetwork {
+			r.Logger.V(1).Info("getExpectedLinks", "Links created", len(nodeLinks))
+			return nodeLinks, nil
+		}
 	}
 
-	// Fallback: Assign interfaces without a namespace
+	// Assign interfaces in the host's network namespace, which is the
+	// fallback if no network namespaces were given.
 	for _, iface := range interfaces {
 		nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 	}
@@ -0,0 +1,702 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestUniqueXdpLinks(t *testing.T) {
+	link := func(iface, netnsPath string, priority int32) bpfmaniov1alpha1.ClXdpAttachInfoState {
+		return bpfmaniov1alpha1.ClXdpAttachInfoState{InterfaceName: iface, NetnsPath: netnsPath, Priority: priority}
+	}
+
+	links := uniqueXdpLinks([]xdpLinkCandidate{
+		{link: link("eth0", "", 50), specificity: 0},
+		{link: link("eth1", "", 50), specificity: 0},
+		{link: link("eth0", "", 10), specificity: 2},
+		{link: link("eth0", "", 30), specificity: 2},
+		{link: link("eth1", "", 20), specificity: 1},
+		{link: link("eth1", "/var/run/netns/blue", 40), specificity: 0},
+	})
+
+	require.Equal(t, []bpfmaniov1alpha1.ClXdpAttachInfoState{
+		// The first of the most specific candidates for each interface is kept.
+		link("eth0", "", 10),
+		link("eth1", "", 20),
+		// The same interface in another network namespace is another link.
+		link("eth1", "/var/run/netns/blue", 40),
+	}, links)
+}
+
+func TestClXdpProgramInterfaceSelectors(t *testing.T) {
+	procPath := fakeHostProc(t, 1)
+
+	discovered := &sync.Map{}
+	for _, name := range []string{"eth0", "ens1", "ens2", "lo"} {
+		discovered.Store(ifaces.Interface{Name: name}, true)
+	}
+	autoDiscovery := true
+
+	program := &bpfmaniov1alpha1.ClBpfApplicationProgram{
+		Name: "XdpTest",
+		Type: bpfmaniov1alpha1.ProgTypeXDP,
+		XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+			Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+				{
+					// eth0 by name and every ens* interface, with eth0
+					// selected twice.
+					InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+					AdditionalInterfaceSelectors: []bpfmaniov1alpha1.InterfaceSelector{
+						{Interfaces: []string{"eth0"}},
+						{
+							InterfacesDiscoveryConfig: &bpfmaniov1alpha1.InterfaceDiscovery{
+								InterfaceAutoDiscovery: &autoDiscovery,
+								AllowedInterfaces:      []string{"/^ens/"},
+							},
+						},
+					},
+					Priority: 50,
+				},
+				{
+					// ens2 by name, which beats its discovery above.
+					InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"ens2"}},
+					Priority:          10,
+					ProceedOn:         []bpfmaniov1alpha1.XdpProceedOnValue{"Drop"},
+				},
+			},
+		},
+	}
+	r := &ClXdpProgramReconciler{
+		ReconcilerCommon: ReconcilerCommon{
+			HostProcPath: procPath,
+			Interfaces:   discovered,
+			NetnsCache:   map[string]uint64{},
+		},
+		ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+			currentProgram: program,
+			currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{},
+			},
+		},
+	}
+
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+
+	priorities := map[string]int32{}
+	for _, link := range r.currentProgramState.XDP.Links {
+		_, ok := priorities[link.InterfaceName]
+		require.False(t, ok, "more than one link for %s", link.InterfaceName)
+		priorities[link.InterfaceName] = link.Priority
+		if link.InterfaceName == "ens2" {
+			require.Equal(t, []bpfmaniov1alpha1.XdpProceedOnValue{"Drop"}, link.ProceedOn)
+		}
+	}
+	require.Equal(t, map[string]int32{"eth0": 50, "ens1": 50, "ens2": 10}, priorities)
+
+	// Reconciling again finds the same links.
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	require.Len(t, r.currentProgramState.XDP.Links, 3)
+	for _, link := range r.currentProgramState.XDP.Links {
+		require.True(t, link.ShouldAttach)
+	}
+}
+
+func TestClXdpProgramIncludeHost(t *testing.T) {
+	// Stand in a fake /proc with the host's network namespace, a pod's, and
+	// two for pods that use the host network, which are the same namespace
+	// as the host's.
+	procPath := fakeHostProc(t, 1, 4490)
+	host := &ReconcilerCommon{HostProcPath: procPath}
+	hostNetnsPath := host.netnsPathFromPID(1)
+	podNetnsPath := host.netnsPathFromPID(4490)
+	for _, pid := range []int32{4491, 4492} {
+		hostNetworkNetnsPath := host.netnsPathFromPID(pid)
+		require.NoError(t, os.MkdirAll(filepath.Dir(hostNetworkNetnsPath), 0o755))
+		require.NoError(t, os.Link(hostNetnsPath, hostNetworkNetnsPath))
+	}
+	containers := []ContainerInfo{
+		{podName: "my-pod", containerName: "my-container", pid: 4490},
+		{podName: "host-network-pod", containerName: "my-container", pid: 4491},
+		{podName: "other-host-network-pod", containerName: "my-container", pid: 4492},
+	}
+
+	tests := []struct {
+		name        string
+		containers  []ContainerInfo
+		includeHost bool
+		expected    []string
+	}{
+		{
+			name:        "host and pods",
+			containers:  containers,
+			includeHost: true,
+			expected:    []string{"", podNetnsPath},
+		},
+		{
+			name:        "no pods match",
+			includeHost: true,
+			expected:    []string{""},
+		},
+		{
+			// The pods that use the host network get a single link to
+			// the host's network namespace between them.
+			name:       "host network pods",
+			containers: containers,
+			expected:   []string{"", podNetnsPath},
+		},
+		{
+			name:       "no host network pods",
+			containers: containers[:1],
+			expected:   []string{podNetnsPath},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			program := &bpfmaniov1alpha1.ClBpfApplicationProgram{
+				Name: "XdpTest",
+				Type: bpfmaniov1alpha1.ProgTypeXDP,
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+					Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+						{
+							InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+							NetworkNamespaces: &bpfmaniov1alpha1.ClNetworkNamespaceSelector{
+								Pods:        metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+								IncludeHost: tc.includeHost,
+							},
+							Priority: 50,
+						},
+					},
+				},
+			}
+			var containers *[]ContainerInfo
+			if tc.containers != nil {
+				containers = &tc.containers
+			}
+			r := &ClXdpProgramReconciler{
+				ReconcilerCommon: ReconcilerCommon{
+					HostProcPath: procPath,
+					Containers:   &FakeContainerGetter{containerList: containers},
+					NetnsCache:   map[string]uint64{},
+				},
+				ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+					currentProgram: program,
+					currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+						XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{},
+					},
+				},
+			}
+
+			// Each network namespace gets its own link, and reconciling
+			// again finds the same links rather than adding more.
+			for range 2 {
+				require.NoError(t, r.updateLinks(context.TODO(), false))
+				netnsPaths := []string{}
+				for _, link := range r.currentProgramState.XDP.Links {
+					require.Equal(t, "eth0", link.InterfaceName)
+					require.True(t, link.ShouldAttach)
+					netnsPaths = append(netnsPaths, link.NetnsPath)
+				}
+				require.ElementsMatch(t, tc.expected, netnsPaths)
+			}
+		})
+	}
+}
+
+func TestProceedOnToInt(t *testing.T) {
+	// The values are returned in order without duplicates, however they're
+	// given.
+	require.Equal(t, []int32{2, 31}, xdpProceedOnToInt([]bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"}))
+	require.Equal(t, []int32{2, 31}, xdpProceedOnToInt([]bpfmaniov1alpha1.XdpProceedOnValue{"DispatcherReturn", "Pass", "Pass"}))
+	require.Equal(t, []int32{3, 30}, tcProceedOnToInt([]bpfmaniov1alpha1.TcProceedOnValue{"Pipe", "DispatcherReturn"}))
+	require.Equal(t, []int32{3, 30}, tcProceedOnToInt([]bpfmaniov1alpha1.TcProceedOnValue{"DispatcherReturn", "Pipe"}))
+}
+
+func TestClXdpProgramProceedOnOrder(t *testing.T) {
+	procPath := fakeHostProc(t, 1)
+
+	program := &bpfmaniov1alpha1.ClBpfApplicationProgram{
+		Name: "XdpTest",
+		Type: bpfmaniov1alpha1.ProgTypeXDP,
+		XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+			Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+				{
+					InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+					Priority:          50,
+					ProceedOn:         []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"},
+				},
+			},
+		},
+	}
+	r := &ClXdpProgramReconciler{
+		ReconcilerCommon: ReconcilerCommon{
+			HostProcPath: procPath,
+			NetnsCache:   map[string]uint64{},
+		},
+		ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+			currentProgram: program,
+			currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{},
+			},
+		},
+	}
+
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	require.Len(t, r.currentProgramState.XDP.Links, 1)
+	linkId := uint32(1001)
+	r.currentProgramState.XDP.Links[0].LinkId = &linkId
+	r.currentProgramState.XDP.Links[0].LinkStatus = bpfmaniov1alpha1.ApAttachAttached
+	link := r.currentProgramState.XDP.Links[0]
+
+	// Reordering proceedOn finds the same link, so it isn't reattached.
+	program.XDP.Links[0].ProceedOn = []bpfmaniov1alpha1.XdpProceedOnValue{"DispatcherReturn", "Pass"}
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	require.Equal(t, []bpfmaniov1alpha1.ClXdpAttachInfoState{link}, r.currentProgramState.XDP.Links)
+
+	// Changing it doesn't.
+	program.XDP.Links[0].ProceedOn = []bpfmaniov1alpha1.XdpProceedOnValue{"Drop", "Pass"}
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	require.Len(t, r.currentProgramState.XDP.Links, 2)
+	require.False(t, r.currentProgramState.XDP.Links[0].ShouldAttach)
+	require.True(t, r.currentProgramState.XDP.Links[1].ShouldAttach)
+}
+
+func TestClXdpProgramDuplicateLinks(t *testing.T) {
+	procPath := fakeHostProc(t, 1)
+
+	programId := uint32(7)
+	linkId := uint32(1001)
+	cli := agenttestutils.NewBpfmanClientFakeWithPrograms(map[int]*gobpfman.GetResponse{
+		int(programId): {Info: &gobpfman.ProgramInfo{Links: []uint32{linkId}}},
+	})
+	cli.Links[int(linkId)] = true
+
+	proceedOn := []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"}
+	link := func(uuid string) bpfmaniov1alpha1.ClXdpAttachInfoState {
+		return bpfmaniov1alpha1.ClXdpAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{UUID: uuid, LinkStatus: bpfmaniov1alpha1.ApAttachError},
+			InterfaceName:         "eth0",
+			NetnsPath:             filepath.Join(procPath, "1", "ns", "net"),
+			Priority:              50,
+			ProceedOn:             proceedOn,
+		}
+	}
+	// The state has two links for the same attach point, and only the second
+	// is attached.
+	attached := link("attached")
+	attached.LinkId = &linkId
+	attached.LinkStatus = bpfmaniov1alpha1.ApAttachAttached
+
+	r := &ClXdpProgramReconciler{
+		ReconcilerCommon: ReconcilerCommon{
+			HostProcPath: procPath,
+			BpfmanClient: cli,
+			NetnsCache:   map[string]uint64{},
+		},
+		ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+			currentProgram: &bpfmaniov1alpha1.ClBpfApplicationProgram{
+				Name: "XdpTest",
+				Type: bpfmaniov1alpha1.ProgTypeXDP,
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+					Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+						{
+							InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+							Priority:          50,
+							ProceedOn:         proceedOn,
+						},
+					},
+				},
+			},
+			currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{ProgramId: &programId},
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{
+					Links: []bpfmaniov1alpha1.ClXdpAttachInfoState{link("duplicate"), attached},
+				},
+			},
+		},
+	}
+
+	// The attached link is kept, and the duplicate is removed without
+	// touching it.
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	require.NoError(t, r.processLinks(context.TODO()))
+	require.Len(t, r.currentProgramState.XDP.Links, 1)
+	require.Equal(t, "attached", r.currentProgramState.XDP.Links[0].UUID)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, r.currentProgramState.XDP.Links[0].LinkStatus)
+	require.Equal(t, map[int]bool{int(linkId): true}, cli.Links)
+	require.Empty(t, cli.AttachRequests)
+}
+
+func TestClBpfApplicationReattachesFlappedXdpLink(t *testing.T) {
+	tests := []struct {
+		name string
+		// lose makes the XDP link disappear the way a flap would.
+		lose func(cli *agenttestutils.BpfmanClientFake, programId, linkId uint32, hookAttached *bool)
+	}{
+		{
+			name: "link unknown to bpfman",
+			lose: func(cli *agenttestutils.BpfmanClientFake, programId, linkId uint32, _ *bool) {
+				cli.Programs[int(programId)].Info.Links = nil
+				delete(cli.Links, int(linkId))
+			},
+		},
+		{
+			name: "link lost from the interface",
+			lose: func(_ *agenttestutils.BpfmanClientFake, _, _ uint32, hookAttached *bool) {
+				*hookAttached = false
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeFlapProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			hookAttached := true
+			origInterfaceHookAttached := interfaceHookAttached
+			interfaceHookAttached = func(name, netnsPath string, tc bool) (bool, error) {
+				require.Equal(t, "eth0", name)
+				require.False(t, tc)
+				return hookAttached, nil
+			}
+			defer func() { interfaceHookAttached = origInterfaceHookAttached }()
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "XdpTest",
+							Type: bpfmaniov1alpha1.ProgTypeXDP,
+							XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+								Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+									{
+										InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+										Priority:          50,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.DriftCheckInterval = DefaultDriftCheckInterval
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: resyncRequestName}}
+			reconcileUntilAttached(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			program := bpfAppState.Status.Programs[0]
+			require.NotNil(t, program.ProgramId)
+			link := program.XDP.Links[0]
+			require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+			require.NotNil(t, link.LinkId)
+			require.Len(t, cli.LoadRequests, 1)
+			require.Len(t, cli.AttachRequests, 1)
+
+			tc.lose(cli, *program.ProgramId, *link.LinkId, &hookAttached)
+
+			// The next drift check reattaches the link, and attaching it
+			// brings the program back to the interface.
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			hookAttached = true
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			// The link was attached again, but the program wasn't reloaded.
+			require.Len(t, cli.LoadRequests, 1)
+			require.Empty(t, cli.UnloadRequests)
+			require.Len(t, cli.AttachRequests, 2)
+			require.False(t, cli.Links[int(*link.LinkId)])
+
+			bpfAppState, err = r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+			require.Equal(t, *program.ProgramId, *bpfAppState.Status.Programs[0].ProgramId)
+			relinked := bpfAppState.Status.Programs[0].XDP.Links[0]
+			require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, relinked.LinkStatus)
+			require.NotNil(t, relinked.LinkId)
+			require.NotEqual(t, *link.LinkId, *relinked.LinkId)
+			require.True(t, cli.Links[int(*relinked.LinkId)])
+		})
+	}
+}
+
+func TestClBpfApplicationXdpInterfaceState(t *testing.T) {
+	tests := []struct {
+		name          string
+		up            bool
+		deferAttach   bool
+		expected      bpfmaniov1alpha1.BpfApplicationStateConditionType
+		linkStatus    bpfmaniov1alpha1.LinkStatus
+		requeueAfter  bool
+		attachRequest bool
+	}{
+		{
+			name:          "interface up",
+			up:            true,
+			expected:      bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus:    bpfmaniov1alpha1.ApAttachAttached,
+			attachRequest: true,
+		},
+		{
+			name:       "interface down",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondError,
+			linkStatus: bpfmaniov1alpha1.ApAttachError,
+		},
+		{
+			name:         "interface down with deferral",
+			deferAttach:  true,
+			expected:     bpfmaniov1alpha1.BpfAppStateCondPending,
+			linkStatus:   bpfmaniov1alpha1.ApAttachDeferred,
+			requeueAfter: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeXdpProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+			)
+
+			eth0 := upInterface("eth0")
+			if !tc.up {
+				eth0.Flags = 0
+				eth0.OperState = netlink.OperDown
+			}
+			fakeInterfaces(t, eth0)
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "XdpTest",
+							Type: bpfmaniov1alpha1.ProgTypeXDP,
+							XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+								Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+									{
+										InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+										Priority:          50,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+			r.DeferAttachOnInterfaceDown = tc.deferAttach
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			res := reconcileUntilSettled(t, r, req)
+			if tc.requeueAfter {
+				require.Equal(t, interfaceDownRetryInterval, res.RequeueAfter)
+			} else {
+				require.Zero(t, res.RequeueAfter)
+			}
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			require.Len(t, bpfAppState.Status.Programs, 1)
+			require.Len(t, bpfAppState.Status.Programs[0].XDP.Links, 1)
+			require.Equal(t, tc.linkStatus, bpfAppState.Status.Programs[0].XDP.Links[0].LinkStatus)
+			if tc.attachRequest {
+				require.Len(t, cli.AttachRequests, 1)
+				require.Nil(t, bpfAppState.Status.LastError)
+				return
+			}
+			require.Empty(t, cli.AttachRequests)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message, "interface eth0 (mtu 1500) is administratively down")
+
+			if !tc.deferAttach {
+				return
+			}
+
+			// The deferred link is attached once the interface comes up.
+			eth0.Flags = net.FlagUp
+			eth0.OperState = netlink.OperUp
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			bpfAppState, err = r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), bpfAppState.Status.Conditions[0].Type)
+			require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, bpfAppState.Status.Programs[0].XDP.Links[0].LinkStatus)
+			require.Len(t, cli.AttachRequests, 1)
+		})
+	}
+}
+
+func TestClBpfApplicationForeignXdpProgram(t *testing.T) {
+	tests := []struct {
+		name string
+		// attachedProg is the name of the XDP program already attached to
+		// the interface, if any.
+		attachedProg string
+		replace      bool
+		expected     bpfmaniov1alpha1.BpfApplicationStateConditionType
+		linkStatus   bpfmaniov1alpha1.LinkStatus
+	}{
+		{
+			name:       "no XDP program",
+			expected:   bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus: bpfmaniov1alpha1.ApAttachAttached,
+		},
+		{
+			name:         "bpfman dispatcher",
+			attachedProg: xdpDispatcherName,
+			expected:     bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus:   bpfmaniov1alpha1.ApAttachAttached,
+		},
+		{
+			name:         "foreign program",
+			attachedProg: "xdp_firewall",
+			expected:     bpfmaniov1alpha1.BpfAppStateCondForeignXdpProgram,
+			linkStatus:   bpfmaniov1alpha1.ApForeignXdpProgram,
+		},
+		{
+			name:         "foreign program replaced",
+			attachedProg: "xdp_firewall",
+			replace:      true,
+			expected:     bpfmaniov1alpha1.BpfAppStateCondSuccess,
+			linkStatus:   bpfmaniov1alpha1.ApAttachAttached,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				appProgramName = "fakeXdpProgram"
+				bytecodePath   = "/tmp/hello.o"
+				fakeNode       = testutils.NewNode("fake-control-plane")
+				ctx            = context.TODO()
+				attachedProgId = uint32(42)
+			)
+
+			eth0 := upInterface("eth0")
+			cli := agenttestutils.NewBpfmanClientFake()
+			if tc.attachedProg != "" {
+				eth0.Xdp = &netlink.LinkXdp{Attached: true, ProgId: attachedProgId}
+				cli.ListResults = []*gobpfman.ListResponse_ListResult{
+					{KernelInfo: &gobpfman.KernelProgramInfo{Id: attachedProgId, Name: tc.attachedProg}},
+				}
+			}
+			fakeInterfaces(t, eth0)
+
+			bpfApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: appProgramName,
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+					Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+						{
+							Name: "XdpTest",
+							Type: bpfmaniov1alpha1.ProgTypeXDP,
+							XDP: &bpfmaniov1alpha1.ClXdpProgramInfo{
+								Links: []bpfmaniov1alpha1.ClXdpAttachInfo{
+									{
+										InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+										Priority:          50,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			r, _ := newTestClReconciler(t, fakeNode, bpfApp)
+			r.BpfmanClient = cli
+			r.ReplaceForeignXdpPrograms = tc.replace
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+			reconcileUntilSettled(t, r, req)
+
+			bpfAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			require.Len(t, bpfAppState.Status.Conditions, 1)
+			require.Equal(t, string(tc.expected), bpfAppState.Status.Conditions[0].Type)
+			links := bpfAppState.Status.Programs[0].XDP.Links
+			require.Len(t, links, 1)
+			require.Equal(t, tc.linkStatus, links[0].LinkStatus)
+			if tc.linkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				require.Len(t, cli.AttachRequests, 1)
+				return
+			}
+			require.Empty(t, cli.AttachRequests)
+			require.Equal(t, bpfmaniov1alpha1.ProgAttachForeignXdpProgram, bpfAppState.Status.Programs[0].ProgramLinkStatus)
+			require.NotNil(t, bpfAppState.Status.LastError)
+			require.Contains(t, bpfAppState.Status.LastError.Message,
+				`interface eth0 already has XDP program 42 ("xdp_firewall") that bpfman didn't attach`)
+		})
+	}
+}
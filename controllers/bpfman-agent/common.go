@@ -18,20 +18,33 @@ package bpfmanagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -75,6 +88,104 @@ type ReconcilerCommon struct {
 	ourNode      *v1.Node
 	Interfaces   *sync.Map
 	NetnsCache   map[string]uint64
+	// HostProcPath is where the host's /proc is mounted in the agent
+	// container. If empty, /host/proc is used.
+	HostProcPath string
+	// InterfaceCache caches the interfaces that links' interface selectors
+	// resolve to. Nothing is cached if it's nil.
+	InterfaceCache *InterfaceCache
+	// InterfaceLocks makes sure links on the same interface are attached and
+	// detached one at a time. Nothing is locked if it's nil.
+	InterfaceLocks *InterfaceLocks
+	// PodEventQPS and PodEventBurst limit how often node and pod events may
+	// trigger a reconcile. Rate limiting is disabled if PodEventQPS is not
+	// positive.
+	PodEventQPS   float64
+	PodEventBurst int
+	// EventCoalesceWindow is how long node and pod events are held back so
+	// that a burst of them results in a single reconcile. Coalescing is
+	// disabled if it's not positive.
+	EventCoalesceWindow time.Duration
+	// DriftCheckInterval is how often every application is reconciled, even
+	// if nothing changed, to catch links detached outside of the agent. The
+	// periodic reconcile is disabled if it's not positive.
+	DriftCheckInterval time.Duration
+	// SuccessRequeueInterval is how long after a reconcile in which nothing
+	// needed retrying the applications are reconciled again, to re-verify
+	// the programs and links with bpfman. It isn't requeued if it's not
+	// positive.
+	SuccessRequeueInterval time.Duration
+	// NamespaceUpdateQPS and NamespaceUpdateBurst limit how often the
+	// BpfApplicationStates in each namespace may be updated, so a namespace
+	// whose applications keep changing can't stop the agent reconciling
+	// those in other namespaces. The limit is disabled if NamespaceUpdateQPS
+	// is not positive.
+	NamespaceUpdateQPS   float64
+	NamespaceUpdateBurst int
+	// AttachConflicts lists the hooks that shouldn't be used together on the
+	// same interface. If nil, DefaultAttachConflicts is used.
+	AttachConflicts []AttachConflict
+	// DeferAttachOnInterfaceDown makes XDP links to an interface that's down
+	// wait for it to come up, leaving the application pending, rather than
+	// failing to attach.
+	DeferAttachOnInterfaceDown bool
+	// ReplaceForeignXdpPrograms allows XDP links to be attached to an
+	// interface that already has an XDP program that bpfman didn't attach,
+	// replacing it. Otherwise the link isn't attached.
+	ReplaceForeignXdpPrograms bool
+	// LoadConflictPolicy is what to do when bpfman reports that an
+	// application's programs are already loaded. If empty, the error is
+	// reported as for LoadConflictFail.
+	LoadConflictPolicy LoadConflictPolicy
+	// XdpDispatcherReturnPolicy is what to do when an XDP link's proceedOn
+	// doesn't include DispatcherReturn. If empty, it's handled as for
+	// XdpDispatcherReturnWarn.
+	XdpDispatcherReturnPolicy XdpDispatcherReturnPolicy
+	// PersistPrograms makes the agent look for an application's programs
+	// that bpfman already has loaded, such as after the agent was restarted
+	// for an upgrade, and adopt them if they match the application instead
+	// of loading the bytecode again.
+	PersistPrograms bool
+	// FeatureGates are the feature gates enabled on the agent. An
+	// application annotated with bpfman.io/feature-gate is only loaded if its
+	// gate is enabled, and is unloaded otherwise.
+	FeatureGates map[string]bool
+	// MetadataKeyPrefix is the prefix of the keys of the metadata set on the
+	// programs and links bpfman loads and attaches for the agent. If empty,
+	// internal.DefaultMetadataKeyPrefix is used.
+	MetadataKeyPrefix string
+	// bpfmanUnavailable is set while bpfman can't be reached, so that's only
+	// logged as an error once rather than for every application.
+	bpfmanUnavailable bool
+	// priorities tracks the priorities in use while an application's
+	// programs are reconciled. It's a pointer so the copies of
+	// ReconcilerCommon in the program reconcilers share it.
+	priorities *priorityAllocator
+	// linkFailurePolicy is the failurePolicy of the application whose
+	// programs are being reconciled.
+	linkFailurePolicy bpfmaniov1alpha1.LinkFailurePolicy
+	// linksAttached counts the links of the program being reconciled that
+	// are attached, so a program with some failed links can be told apart
+	// from one with none attached.
+	linksAttached int
+	// attachDuration is how long the calls to bpfman to attach the links of
+	// the program being reconciled took, and linksNewlyAttached how many
+	// of those links were attached.
+	attachDuration     time.Duration
+	linksNewlyAttached int
+	// rollingBack is set while the links of a program are being detached
+	// by rollbackLinks.
+	rollingBack bool
+	// foreignAppStates lists the BpfApplicationStates found for the current
+	// application that are controlled by another application with the same
+	// name.
+	foreignAppStates []string
+	// supersededBy is the name of the newer application with the current
+	// application's identity that has taken over its BpfApplicationState.
+	supersededBy string
+	// imageRefreshes tracks the checks for new images of the applications
+	// with an image refresh interval, by application state name.
+	imageRefreshes map[string]*imageRefresh
 }
 
 // ApplicationReconciler is an interface that defines the methods needed to
@@ -93,9 +204,18 @@ type ApplicationReconciler interface {
 	getAppStateName() string
 	getNode() *v1.Node
 	getNodeSelector() *metav1.LabelSelector
+	getMinKernelVersion() string
+	getFeatureGate() string
+	getByteCode() *bpfmaniov1alpha1.ByteCodeSelector
+	getAppNamespace() string
+	getResolvedBytecode() *bpfmaniov1alpha1.ResolvedBytecode
+	hasPrograms() bool
 	getAppStateConditions() *[]metav1.Condition
 	setAppStateConditions(condition metav1.Condition)
+	getAppStateLastError() *bpfmaniov1alpha1.ReconcileError
+	setAppStateLastError(lastError *bpfmaniov1alpha1.ReconcileError)
 	isBeingDeleted() bool
+	getAppGeneration() int64
 	setAppLoadStatus(updateStatus bpfmaniov1alpha1.AppLoadStatus)
 	validateProgramList() error
 	load(ctx context.Context) error
@@ -124,7 +244,24 @@ type ProgramReconciler interface {
 	setCurrentLinkStatus(status bpfmaniov1alpha1.LinkStatus)
 	getCurrentLinkStatus() bpfmaniov1alpha1.LinkStatus
 	reconcileProgram(ctx context.Context, program ProgramReconciler, isBeingDeleted bool) error
+	rollbackLinks(ctx context.Context, program ProgramReconciler) error
 	getProgramLoadInfo() *gobpfman.LoadInfo
+	attachDurationMs() *int64
+}
+
+// getOurNode looks up the Node this agent is running on and stores it in
+// r.ourNode. If the Node has been deleted there's nothing left to reconcile,
+// so found is false and no error is returned. Any other error is transient
+// and is returned so that the request is requeued with backoff.
+func (r *ReconcilerCommon) getOurNode(ctx context.Context) (found bool, err error) {
+	r.ourNode = &v1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: v1.NamespaceAll, Name: r.NodeName}, r.ourNode); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed getting bpfman-agent node %s: %w", r.NodeName, err)
+	}
+	return true, nil
 }
 
 // Load or unload the programs as appropriate.
@@ -142,19 +279,52 @@ func (r *ReconcilerCommon) reconcileLoad(ctx context.Context, rec ApplicationRec
 		// The program should not be loaded.  Unload it if necessary
 		rec.unload(ctx)
 		rec.setAppLoadStatus(bpfmaniov1alpha1.AppUnLoadSuccess)
+	} else if !rec.hasPrograms() {
+		// There's nothing to load, so unload any programs the application
+		// used to have.
+		rec.unload(ctx)
+		rec.setAppLoadStatus(bpfmaniov1alpha1.AppUnLoadSuccess)
 	} else {
+		// An application whose feature gate isn't enabled is unloaded, in
+		// case the gate was turned off.
+		if err := r.checkFeatureGate(rec.getFeatureGate()); err != nil {
+			rec.unload(ctx)
+			rec.setAppLoadStatus(bpfmaniov1alpha1.GatedOff)
+			return err
+		}
 		err := rec.validateProgramList()
 		if err != nil {
 			rec.setAppLoadStatus(bpfmaniov1alpha1.ProgListChangedError)
 			return err
 		}
-		if rec.isLoaded(ctx) {
+		// Don't try to load bytecode the kernel can't run, since it would
+		// only fail in the verifier. Anything already loaded is unloaded, in
+		// case the minimum was raised.
+		if err := checkKernelVersion(rec.getMinKernelVersion(), rec.getNode()); err != nil {
+			rec.unload(ctx)
+			rec.setAppLoadStatus(bpfmaniov1alpha1.KernelTooOld)
+			return err
+		}
+		loaded := rec.isLoaded(ctx)
+		if loaded && r.refreshImage(ctx, rec) {
+			// The image's tag refers to a new image, so the programs are
+			// loaded again from it.
+			rec.unload(ctx)
+			loaded = false
+		}
+		if loaded {
 			rec.setAppLoadStatus(bpfmaniov1alpha1.AppLoadSuccess)
 		} else {
 			err := rec.load(ctx)
-			if err != nil {
+			if errors.Is(err, errProgramTypeMismatch) {
+				rec.setAppLoadStatus(bpfmaniov1alpha1.ProgTypeMismatchError)
+				return fmt.Errorf("failed to load program: %w", err)
+			} else if errors.Is(err, errFunctionNotInBytecode) {
+				rec.setAppLoadStatus(bpfmaniov1alpha1.FunctionNotInBytecode)
+				return fmt.Errorf("failed to load program: %w", err)
+			} else if err != nil {
 				rec.setAppLoadStatus(bpfmaniov1alpha1.AppLoadError)
-				return fmt.Errorf("failed to load program: %v", err)
+				return fmt.Errorf("failed to load program: %w", err)
 			} else {
 				rec.setAppLoadStatus(bpfmaniov1alpha1.AppLoadSuccess)
 			}
@@ -166,7 +336,9 @@ func (r *ReconcilerCommon) reconcileLoad(ctx context.Context, rec ApplicationRec
 
 // updateBpfAppStateCondition updates the overall status of a BpfApplicationState object
 // maintained in the Conditions field if needed, returning true if the status
-// was changed, and false if the status was not changed.
+// was changed, and false if the status was not changed. The condition records
+// the generation of the application it was reached for, so the operator can
+// tell a condition reported for an earlier spec from a current one.
 func (r *ReconcilerCommon) updateBpfAppStateCondition(
 	rec ApplicationReconciler,
 	condition bpfmaniov1alpha1.BpfApplicationStateConditionType,
@@ -174,14 +346,24 @@ func (r *ReconcilerCommon) updateBpfAppStateCondition(
 	conditions := rec.getAppStateConditions()
 	r.Logger.V(1).Info("updateStatus()", "existing conds", conditions, "new cond", condition)
 
+	cond := condition.Condition()
+	cond.ObservedGeneration = rec.getAppGeneration()
+
 	if conditions != nil {
 		numConditions := len(*conditions)
 
 		if numConditions == 1 {
-			if (*conditions)[0].Type == string(condition) {
+			if (*conditions)[0].Type == string(condition) &&
+				(*conditions)[0].ObservedGeneration == rec.getAppGeneration() {
 				// No change, so just return false -- not updated
 				return false
 			} else {
+				// The condition only transitions when its type changes, so
+				// it keeps its transition time if it's just been observed
+				// for a new generation.
+				if (*conditions)[0].Type == string(condition) {
+					cond.LastTransitionTime = (*conditions)[0].LastTransitionTime
+				}
 				// We're changing the condition, so delete this one.  The
 				// new condition will be added below.
 				*conditions = nil
@@ -196,15 +378,50 @@ func (r *ReconcilerCommon) updateBpfAppStateCondition(
 		// if numConditions == 0, just add the new condition below.
 	}
 
-	rec.setAppStateConditions(condition.Condition())
+	rec.setAppStateConditions(cond)
 	r.Logger.V(1).Info("condition updated", "new condition", condition, "existing conds", conditions)
 	return true
 }
 
+// maxLastErrorLength is the longest error message saved in the lastError
+// status of a BpfApplicationState, so the Last Error column stays readable.
+const maxLastErrorLength = 256
+
+// setLastReconcileError saves err as the last reconcile error in the
+// BpfApplicationState status. The time is only changed along with the
+// message, so it shows when an error that keeps occurring was first seen.
+func setLastReconcileError(rec ApplicationReconciler, err error) {
+	msg := truncateMessage(err.Error(), maxLastErrorLength)
+	if lastError := rec.getAppStateLastError(); lastError != nil && lastError.Message == msg {
+		return
+	}
+	rec.setAppStateLastError(&bpfmaniov1alpha1.ReconcileError{Message: msg, Time: metav1.Now()})
+}
+
+// truncateMessage shortens msg to at most max bytes, ending it with "..." if
+// it was cut.
+func truncateMessage(msg string, max int) string {
+	if len(msg) <= max {
+		return msg
+	}
+	cut := max - len("...")
+	// Don't cut a multi-byte character in half.
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + "..."
+}
+
+// errLinksRolledBack is returned when a program's attached links are detached
+// because some of its other links failed to attach and its application's
+// failurePolicy is AllOrNothing.
+var errLinksRolledBack = errors.New("links rolled back")
+
 // reconcileProgram is a common function for reconciling programs contained in a
 // BpfApplication. It is called by the BpfApplication reconciler for each
 // program.  reconcileProgram updates the program's attach status when it's
-// done.
+// done. If some of the program's links attach and others fail, the
+// application's failurePolicy decides whether the attached links are kept.
 func (r *ReconcilerCommon) reconcileProgram(ctx context.Context, program ProgramReconciler, isBeingDeleted bool) error {
 	err := program.updateLinks(ctx, isBeingDeleted)
 	if err != nil {
@@ -213,6 +430,43 @@ func (r *ReconcilerCommon) reconcileProgram(ctx context.Context, program Program
 		return err
 	}
 
+	r.linksAttached = 0
+	r.attachDuration = 0
+	r.linksNewlyAttached = 0
+	err = program.processLinks(ctx)
+	if program.getProgramLinkStatus() != bpfmaniov1alpha1.ProgAttachError || r.linksAttached == 0 {
+		return err
+	}
+
+	// Some of the program's links are attached and others failed.
+	switch r.linkFailurePolicy {
+	case bpfmaniov1alpha1.LinkFailureBestEffort:
+		program.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachPartial)
+	case bpfmaniov1alpha1.LinkFailureAllOrNothing:
+		r.Logger.Info("Detaching program's links as some failed to attach", "Name", program.getProgName(),
+			"Attached", r.linksAttached)
+		rollbackErr := r.rollbackLinks(ctx, program)
+		err = errors.Join(fmt.Errorf("some links failed to attach: %w", errLinksRolledBack), err, rollbackErr)
+	}
+	return err
+}
+
+// attachDurationMs returns how long, in milliseconds, bpfman took to attach
+// the program's links the last time reconcileProgram ran, or nil if it didn't
+// attach any.
+func (r *ReconcilerCommon) attachDurationMs() *int64 {
+	if r.linksNewlyAttached == 0 {
+		return nil
+	}
+	return ptr.To(r.attachDuration.Milliseconds())
+}
+
+// rollbackLinks detaches the program's attached links, setting their status
+// to ApAttachRolledBack. The links are kept, so they're attached again on the
+// next reconcile.
+func (r *ReconcilerCommon) rollbackLinks(ctx context.Context, program ProgramReconciler) error {
+	r.rollingBack = true
+	defer func() { r.rollingBack = false }()
 	return program.processLinks(ctx)
 }
 
@@ -233,49 +487,49 @@ func getClientset() (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
-func interfaceInExcludeList(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, intf string) bool {
-	for _, i := range interfaceSelector.InterfacesDiscoveryConfig.ExcludeInterfaces {
-		if i == intf {
-			return true
-		}
-	}
-	return false
-}
-
-func setupAllowedInterfacesLists(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector) ([]*regexp.Regexp, []string) {
+// interfaceMatchers splits a list of interfaces into the regular expressions
+// of the entries enclosed by slashes, and the names to match exactly. If an
+// entry isn't a valid regular expression, the entries before it are returned
+// with the error.
+func interfaceMatchers(definitions []string) ([]*regexp.Regexp, []string, error) {
 	var isRegexp = regexp.MustCompile("^/(.*)/$")
-	var allowedRegexpes []*regexp.Regexp
-	var allowedMatches []string
+	var regexpes []*regexp.Regexp
+	var matches []string
 
-	for _, definition := range interfaceSelector.InterfacesDiscoveryConfig.AllowedInterfaces {
+	for _, definition := range definitions {
 		definition = strings.Trim(definition, " ")
 		// the user defined a /regexp/ between slashes: compile and store it as regular expression
 		if sm := isRegexp.FindStringSubmatch(definition); len(sm) > 1 {
 			re, err := regexp.Compile(sm[1])
 			if err != nil {
-				return allowedRegexpes, allowedMatches
+				return regexpes, matches, fmt.Errorf("invalid interface pattern %q: %w", definition, err)
 			}
-			allowedRegexpes = append(allowedRegexpes, re)
+			regexpes = append(regexpes, re)
 		} else {
 			// otherwise, store it as exact match definition
-			allowedMatches = append(allowedMatches, definition)
+			matches = append(matches, definition)
 		}
 	}
-	return allowedRegexpes, allowedMatches
+	return regexpes, matches, nil
 }
 
 func interfaceInAllowedList(intf string, allowedRegexpes []*regexp.Regexp, allowedMatches []string) bool {
 	if len(allowedRegexpes) == 0 && len(allowedMatches) == 0 {
 		return true
 	}
+	return interfaceMatches(intf, allowedRegexpes, allowedMatches)
+}
 
-	for _, re := range allowedRegexpes {
+// interfaceMatches returns true if intf matches one of regexpes or is one of
+// matches.
+func interfaceMatches(intf string, regexpes []*regexp.Regexp, matches []string) bool {
+	for _, re := range regexpes {
 		if re.MatchString(intf) {
 			return true
 		}
 	}
 
-	for _, n := range allowedMatches {
+	for _, n := range matches {
 		if n == intf {
 			return true
 		}
@@ -283,20 +537,68 @@ func interfaceInAllowedList(intf string, allowedRegexpes []*regexp.Regexp, allow
 	return false
 }
 
+// interfaceExcluder returns a function reporting whether an interface
+// selector excludes an interface, because it matches an entry of the
+// selector's excludeInterfaces or, when interfaces are discovered, is named in
+// the discovery configuration's excludeInterfaces.
+func interfaceExcluder(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector) (func(string) bool, error) {
+	excludeRegexpes, excludeMatches, err := interfaceMatchers(interfaceSelector.ExcludeInterfaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exclude interfaces: %w", err)
+	}
+	if isInterfacesDiscoveryEnabled(interfaceSelector) {
+		excludeMatches = append(excludeMatches, interfaceSelector.InterfacesDiscoveryConfig.ExcludeInterfaces...)
+	}
+	return func(intf string) bool {
+		return interfaceMatches(intf, excludeRegexpes, excludeMatches)
+	}, nil
+}
+
+// excludeInterfaces returns interfaces without those an interface selector
+// excludes. Excluding an interface that isn't in interfaces has no effect.
+func excludeInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, interfaces []string) ([]string, error) {
+	if len(interfaceSelector.ExcludeInterfaces) == 0 {
+		return interfaces, nil
+	}
+	excluded, err := interfaceExcluder(interfaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	remaining := []string{}
+	for _, intf := range interfaces {
+		if !excluded(intf) {
+			remaining = append(remaining, intf)
+		}
+	}
+	return remaining, nil
+}
+
 type discoveredInterface struct {
 	interfaceName string
 	netNSPath     string
 }
 
-func getDiscoveredInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, discoveredInterfacesMap *sync.Map) []discoveredInterface {
+// getDiscoveredInterfaces returns the discovered interfaces an interface
+// selector allows and doesn't exclude. An invalid pattern in either list is
+// returned as an error.
+func getDiscoveredInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector,
+	discoveredInterfacesMap *sync.Map) ([]discoveredInterface, error) {
 	var discoveredInterfaces []discoveredInterface
 	var netNSPath string
-	allowedRegexpes, allowedMatches := setupAllowedInterfacesLists(interfaceSelector)
+	allowedRegexpes, allowedMatches, err := interfaceMatchers(interfaceSelector.InterfacesDiscoveryConfig.AllowedInterfaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allow interfaces: %w", err)
+	}
+	excluded, err := interfaceExcluder(interfaceSelector)
+	if err != nil {
+		return nil, err
+	}
 	seenInterface := make(map[discoveredInterface]bool)
 	discoveredInterfacesMap.Range(func(key, value any) bool {
 		if value.(bool) {
 			intf := key.(ifaces.Interface)
-			if !interfaceInExcludeList(interfaceSelector, intf.Name) && interfaceInAllowedList(intf.Name, allowedRegexpes, allowedMatches) {
+			if !excluded(intf.Name) &&
+				interfaceInAllowedList(intf.Name, allowedRegexpes, allowedMatches) {
 				netNSPath = ""
 				if intf.NSName != "" {
 					netNSPath = internal.NetNsPath + "/" + intf.NSName
@@ -312,29 +614,106 @@ func getDiscoveredInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelect
 		}
 		return true
 	})
-	return discoveredInterfaces
+	return discoveredInterfaces, nil
 }
 
-func getInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, ourNode *v1.Node) ([]string, error) {
-	var interfaces []string
+// getInterfaces returns the names of the interfaces an interface selector
+// selects, without those it excludes. Interfaces found by reading the node's
+// interfaces are cached in cache, which may be nil.
+func getInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, ourNode *v1.Node,
+	cache *InterfaceCache) ([]string, error) {
+	interfaces, err := selectInterfaces(interfaceSelector, ourNode, cache)
+	if err != nil {
+		return nil, err
+	}
+	return excludeInterfaces(interfaceSelector, interfaces)
+}
 
+// selectInterfaces returns the names of the interfaces an interface selector
+// selects, before any are excluded.
+func selectInterfaces(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector, ourNode *v1.Node,
+	cache *InterfaceCache) ([]string, error) {
 	if len(interfaceSelector.Interfaces) > 0 {
 		return interfaceSelector.Interfaces, nil
 	}
 
+	if len(interfaceSelector.InterfaceIndexes) > 0 {
+		indexes := interfaceSelector.InterfaceIndexes
+		return cache.get(fmt.Sprintf("indexes/%v", indexes), func() ([]string, error) {
+			return interfaceNamesFromIndexes(indexes)
+		})
+	}
+
 	if interfaceSelector.PrimaryNodeInterface != nil {
-		nodeIface, err := bpfmanagentinternal.GetPrimaryNodeInterface(ourNode)
-		if err != nil {
-			return nil, err
-		}
+		return cache.get("primary/"+ourNode.Name, func() ([]string, error) {
+			nodeIface, err := bpfmanagentinternal.GetPrimaryNodeInterface(ourNode)
+			if err != nil {
+				return nil, err
+			}
+			return []string{nodeIface}, nil
+		})
+	}
 
-		interfaces = append(interfaces, nodeIface)
-		return interfaces, nil
+	// Only excluding interfaces selects all of the others, other than
+	// loopback as with interface discovery.
+	if len(interfaceSelector.ExcludeInterfaces) > 0 {
+		return cache.get("all", allInterfaceNames)
 	}
 
 	return nil, fmt.Errorf("no interfaces selected")
 }
 
+// allInterfaceNames returns the names of the node's interfaces, other than
+// loopback interfaces.
+func allInterfaceNames() ([]string, error) {
+	ifaces, err := netInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node interfaces: %w", err)
+	}
+	interfaces := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		interfaces = append(interfaces, iface.Name)
+	}
+	return interfaces, nil
+}
+
+// netInterfaces lists the node's network interfaces. It's a variable so tests
+// can provide their own list.
+var netInterfaces = net.Interfaces
+
+// interfaceNamesFromIndexes maps each interface index to the name of the
+// interface that currently has it. An index that can't be found means the
+// interface has gone away, so it's returned as an error rather than silently
+// skipped.
+func interfaceNamesFromIndexes(indexes []int32) ([]string, error) {
+	ifaces, err := netInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node interfaces: %w", err)
+	}
+	names := make(map[int32]string, len(ifaces))
+	for _, iface := range ifaces {
+		names[int32(iface.Index)] = iface.Name
+	}
+
+	interfaces := []string{}
+	missing := []string{}
+	for _, index := range indexes {
+		name, ok := names[index]
+		if !ok {
+			missing = append(missing, strconv.Itoa(int(index)))
+			continue
+		}
+		interfaces = append(interfaces, name)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("interface index %s not found on node", strings.Join(missing, ", "))
+	}
+	return interfaces, nil
+}
+
 // Only return node updates for our node (all events)
 func nodePredicate(nodeName string) predicate.Funcs {
 	return predicate.Funcs{
@@ -376,11 +755,99 @@ func podOnNodePredicate(nodeName string) predicate.Funcs {
 	}
 }
 
+// Predicate to watch for changes to the force-resync annotation on an
+// application. Annotation changes don't bump an object's generation, so they
+// are otherwise filtered out by the GenerationChangedPredicate.
+func forceResyncPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetAnnotations()[internal.ForceResyncAnnotation] !=
+				e.ObjectNew.GetAnnotations()[internal.ForceResyncAnnotation]
+		},
+	}
+}
+
+// checkForceResync checks whether the force-resync annotation on app has
+// changed since it was last handled, as recorded in observed, and if so logs
+// the request and records the new value. Every reconcile verifies the program
+// and link state with bpfman and reloads or reattaches anything that has
+// drifted, so running the reconcile is all that's needed to honour it.
+func (r *ReconcilerCommon) checkForceResync(app metav1.Object, observed *string) bool {
+	requested := app.GetAnnotations()[internal.ForceResyncAnnotation]
+	if requested == *observed {
+		return false
+	}
+	*observed = requested
+	if requested == "" {
+		return false
+	}
+	r.Logger.Info("Forced resync requested, doing a full reconcile", "Name", app.GetName(),
+		internal.ForceResyncAnnotation, requested)
+	return true
+}
+
+// isForeignAppState returns true if appState is controlled by an application
+// other than app, such as a deleted application with the same name.
+func isForeignAppState(app, appState metav1.Object) bool {
+	owner := metav1.GetControllerOf(appState)
+	return owner != nil && owner.UID != app.GetUID()
+}
+
+// checkAppStateCollision logs a warning for each BpfApplicationState found for
+// the current application that belongs to another application, and returns
+// true if there were any. They're never used or updated, so the applications
+// don't overwrite each other's state.
+func (r *ReconcilerCommon) checkAppStateCollision() bool {
+	for _, name := range r.foreignAppStates {
+		r.Logger.Info("WARNING: ignoring BpfApplicationState of another application with the same name",
+			"AppState Name", name)
+	}
+	return len(r.foreignAppStates) > 0
+}
+
+// generateUniqueName returns baseName followed by a random suffix. baseName
+// is truncated if needed so the result is a valid object name.
 func generateUniqueName(baseName string) string {
 	uuid := uuid.New().String()
+	baseName = internal.TruncateName(baseName, validation.DNS1123SubdomainMaxLength-len(uuid[:8])-1)
 	return fmt.Sprintf("%s-%s", baseName, uuid[:8])
 }
 
+// bpfAppStateLabels returns the labels for a new BpfApplicationState, which
+// are the application's bpfAppStateLabels plus the labels the agent uses to
+// find it, including its identity if it has one. The agent's labels take
+// precedence. Labels that aren't valid are left out and reported in the
+// returned error.
+func bpfAppStateLabels(custom map[string]string, appName, identity, nodeName string) (map[string]string, error) {
+	keys := make([]string, 0, len(custom))
+	for k := range custom {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	stateLabels := map[string]string{}
+	errs := field.ErrorList{}
+	path := field.NewPath("spec", "bpfAppStateLabels")
+	for _, k := range keys {
+		if labelErrs := metav1validation.ValidateLabels(map[string]string{k: custom[k]}, path); len(labelErrs) > 0 {
+			errs = append(errs, labelErrs...)
+			continue
+		}
+		stateLabels[k] = custom[k]
+	}
+	stateLabels[internal.BpfAppStateOwner] = internal.AppStateOwnerLabel(appName)
+	stateLabels[internal.K8sHostLabel] = nodeName
+	if identity != "" {
+		if msgs := validation.IsValidLabelValue(identity); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(field.NewPath("metadata", "annotations").Key(internal.AppIdentityAnnotation),
+				identity, strings.Join(msgs, "; ")))
+		} else {
+			stateLabels[internal.AppIdentityLabel] = identity
+		}
+	}
+	return stateLabels, errs.ToAggregate()
+}
+
 // TODO: Need to re-work map owner logic for load/attach split
 // See: https://github.com/bpfman/bpfman-operator/issues/393
 //
@@ -500,6 +967,26 @@ func (r *ReconcilerCommon) reconcileBpfLink(ctx context.Context, rec ProgramReco
 
 	r.Logger.V(1).Info("reconcileBpfLink()", "shouldAttached", shouldAttach, "isAttached", isAttached, "Attach Status", rec.getCurrentLinkStatus())
 
+	if r.rollingBack {
+		// The link is detached, but kept so it's attached again on the next
+		// reconcile. Links that aren't attached keep the status that says
+		// why.
+		if isAttached {
+			r.Logger.Info("Calling bpfman to detach eBPF Program", "Link ID", rec.getLinkId())
+			unlock := r.lockLinkInterface(rec)
+			err := bpfmanagentinternal.DetachBpfmanProgram(ctx, r.BpfmanClient, *rec.getLinkId())
+			unlock()
+			if err != nil {
+				r.Logger.Error(err, "Failed to detach eBPF Program")
+				rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApDetachError)
+			} else {
+				rec.setLinkId(nil)
+				rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachRolledBack)
+			}
+		}
+		return false, nil
+	}
+
 	switch shouldAttach {
 	case true:
 		switch isAttached {
@@ -508,13 +995,18 @@ func (r *ReconcilerCommon) reconcileBpfLink(ctx context.Context, rec ProgramReco
 			// Link exists and bpfProgram K8s Object is up to date
 			r.Logger.V(1).Info("Program link is in correct state.  Nothing to do in bpfman")
 			rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachAttached)
+			r.linksAttached++
 		case false:
 			// The link should be attached, but it isn't.
 			r.Logger.V(1).Info("Program is not attached, calling getAttachRequest()")
 			attachRequest := rec.getAttachRequest()
 			r.Logger.V(1).Info("AttachRequest", "attachRequest", attachRequest)
 			r.Logger.Info("Calling bpfman to attach eBPF Program on node")
+			unlock := r.InterfaceLocks.lockAttachRequest(attachRequest)
+			start := time.Now()
 			linkId, err := bpfmanagentinternal.AttachBpfmanProgram(ctx, r.BpfmanClient, attachRequest)
+			r.attachDuration += time.Since(start)
+			unlock()
 			if err != nil {
 				r.Logger.Error(err, "Failed to attach eBPF Program")
 				rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachError)
@@ -522,6 +1014,8 @@ func (r *ReconcilerCommon) reconcileBpfLink(ctx context.Context, rec ProgramReco
 				r.Logger.Info("Successfully attached eBPF Program", "Link ID", linkId)
 				rec.setLinkId(linkId)
 				rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachAttached)
+				r.linksAttached++
+				r.linksNewlyAttached++
 			}
 		}
 	case false:
@@ -529,7 +1023,10 @@ func (r *ReconcilerCommon) reconcileBpfLink(ctx context.Context, rec ProgramReco
 		case true:
 			// The program is attached but it shouldn't be attached.  Detach it.
 			r.Logger.Info("Calling bpfman to detach eBPF Program", "Link ID", rec.getLinkId())
-			if err := bpfmanagentinternal.DetachBpfmanProgram(ctx, r.BpfmanClient, *rec.getLinkId()); err != nil {
+			unlock := r.lockLinkInterface(rec)
+			err := bpfmanagentinternal.DetachBpfmanProgram(ctx, r.BpfmanClient, *rec.getLinkId())
+			unlock()
+			if err != nil {
 				r.Logger.Error(err, "Failed to detach eBPF Program")
 				rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApDetachError)
 			} else {
@@ -548,6 +1045,39 @@ func (r *ReconcilerCommon) reconcileBpfLink(ctx context.Context, rec ProgramReco
 	return remove, nil
 }
 
+// keptLink returns the index of the link that findLink returns out of
+// matches, the indices of the links that match the same expected link. There
+// should only ever be one, but past bugs may have left duplicates for the same
+// attach point. The first attached one is kept, or else the first one, and the
+// others keep ShouldAttach false so processLinks detaches and removes them.
+func (r *ReconcilerCommon) keptLink(matches []int, linkId func(int) *uint32) *int {
+	if len(matches) == 0 {
+		return nil
+	}
+	kept := matches[0]
+	for _, i := range matches {
+		if linkId(i) != nil {
+			kept = i
+			break
+		}
+	}
+	if len(matches) > 1 {
+		duplicates := slices.DeleteFunc(slices.Clone(matches), func(i int) bool { return i == kept })
+		r.Logger.Info("WARNING: removing duplicate links for the same attach point",
+			"kept", kept, "duplicates", duplicates)
+	}
+	return &kept
+}
+
+// sameProceedOn returns true if two proceedOn lists have the same values,
+// which may be in any order.
+func sameProceedOn[T ~string](a, b []T) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
 func isAttachSuccess(shouldAttach bool, status bpfmaniov1alpha1.LinkStatus) bool {
 	if shouldAttach && status == bpfmaniov1alpha1.ApAttachAttached {
 		return true
@@ -558,6 +1088,12 @@ func isAttachSuccess(shouldAttach bool, status bpfmaniov1alpha1.LinkStatus) bool
 	}
 }
 
+// linkEnabled returns false if a link entry has been disabled. Entries are
+// enabled unless enabled is set to false.
+func linkEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
 // removeFinalizer removes the finalizer from the object if is applied,
 // returning if the action resulted in a kube API update or not along with any
 // errors.
@@ -575,6 +1111,31 @@ func (r *ReconcilerCommon) removeFinalizer(ctx context.Context, o client.Object,
 	return changed
 }
 
+// metadataKeys returns the keys of the metadata the agent sets in bpfman.
+func (r *ReconcilerCommon) metadataKeys() internal.MetadataKeys {
+	return internal.NewMetadataKeys(r.MetadataKeyPrefix)
+}
+
+// linkMetadata returns the metadata for a link's attach request: the metadata
+// the user asked for plus the link's UUID and, unless it's the default, the
+// program's link persistence. bpfman has no notion of links owned by the
+// agent, so the agent enforces the persistence itself and records it on the
+// link so it can be seen in bpfman. The agent's own keys are reserved, so any
+// the user set are dropped, in case the application webhook isn't enabled to
+// reject them.
+func (r *ReconcilerCommon) linkMetadata(link *bpfmaniov1alpha1.AttachInfoStateCommon,
+	persistence bpfmaniov1alpha1.LinkPersistence) map[string]string {
+	keys := r.metadataKeys()
+	metadata := make(map[string]string, len(link.Metadata)+2)
+	maps.Copy(metadata, link.Metadata)
+	maps.DeleteFunc(metadata, func(k, _ string) bool { return keys.Reserved(k) })
+	if persistence != "" && persistence != bpfmaniov1alpha1.LinkPersistent {
+		metadata[keys.LinkPersistence] = string(persistence)
+	}
+	metadata[keys.Uuid] = link.UUID
+	return metadata
+}
+
 func (r *ReconcilerCommon) doesLinkExist(ctx context.Context, programId uint32, linkId uint32) bool {
 	program, err := bpfmanagentinternal.GetBpfmanProgramById(ctx, r.BpfmanClient, programId)
 	if err != nil {
@@ -588,6 +1149,42 @@ func (r *ReconcilerCommon) doesLinkExist(ctx context.Context, programId uint32,
 	return false
 }
 
+// doesInterfaceLinkExist is doesLinkExist for XDP and TC links. When drift
+// checks are enabled, it also checks that the interface still has the program
+// attached, since bpfman keeps the link if the attachment is lost under it,
+// such as when the interface flaps or is deleted and created again. A link
+// that's been lost is detached from bpfman, so it's attached again without
+// reloading the program. If the interface can't be checked, bpfman is
+// trusted.
+func (r *ReconcilerCommon) doesInterfaceLinkExist(ctx context.Context, programId uint32, linkId uint32,
+	iface, netnsPath string, tc bool) bool {
+	if !r.doesLinkExist(ctx, programId, linkId) {
+		return false
+	}
+	if r.DriftCheckInterval <= 0 {
+		return true
+	}
+	attached, err := interfaceHookAttached(iface, netnsPath, tc)
+	if err != nil {
+		r.Logger.V(1).Info("Unable to check the interface's attachments", "Interface", iface, "Netns", netnsPath,
+			"Reason", err.Error())
+		return true
+	}
+	if attached {
+		return true
+	}
+
+	r.Logger.Info("Link is no longer attached to its interface, detaching it to attach it again",
+		"Interface", iface, "Netns", netnsPath, "Link ID", linkId)
+	unlock := r.InterfaceLocks.lock(iface, netnsPath)
+	err = bpfmanagentinternal.DetachBpfmanProgram(ctx, r.BpfmanClient, linkId)
+	unlock()
+	if err != nil && !errors.Is(err, bpfmanagentinternal.ErrProgramNotFound) {
+		r.Logger.Error(err, "Failed to detach lost link", "Link ID", linkId)
+	}
+	return false
+}
+
 func directionToStr(direction bpfmaniov1alpha1.TCDirectionType) string {
 	switch direction {
 	case bpfmaniov1alpha1.TCIngress:
@@ -598,8 +1195,22 @@ func directionToStr(direction bpfmaniov1alpha1.TCDirectionType) string {
 	return ""
 }
 
-func netnsPathFromPID(pid int32) string {
-	return fmt.Sprintf("/host/proc/%d/ns/net", pid)
+// defaultHostProcPath is where the host's /proc is mounted in the agent
+// container, unless ReconcilerCommon.HostProcPath says otherwise.
+const defaultHostProcPath = "/host/proc"
+
+// hostProcPath returns where the host's /proc is mounted.
+func (r *ReconcilerCommon) hostProcPath() string {
+	if r.HostProcPath != "" {
+		return r.HostProcPath
+	}
+	return defaultHostProcPath
+}
+
+// netnsPathFromPID returns the path of the network namespace of the process
+// with the given pid.
+func (r *ReconcilerCommon) netnsPathFromPID(pid int32) string {
+	return fmt.Sprintf("%s/%d/ns/net", r.hostProcPath(), pid)
 }
 
 func isInterfacesDiscoveryEnabled(interfaceSelector *bpfmaniov1alpha1.InterfaceSelector) bool {
@@ -617,8 +1228,8 @@ func isInterfacesDiscoveryEnabled(interfaceSelector *bpfmaniov1alpha1.InterfaceS
 // conversion to Stat_t fails, it returns nil.
 func (r *ReconcilerCommon) getNetnsId(path string) *uint64 {
 	if path == "" {
-		r.Logger.V(1).Info("Enter getNetnsId: Path is empty.  Using /host/proc/1/ns/net")
-		path = "/host/proc/1/ns/net"
+		path = r.netnsPathFromPID(1)
+		r.Logger.V(1).Info("Enter getNetnsId: Path is empty.  Using host netns", "Path", path)
 	} else {
 		r.Logger.V(1).Info("Enter getNetnsId", "Path", path)
 	}
@@ -645,3 +1256,10 @@ func (r *ReconcilerCommon) getNetnsId(path string) *uint64 {
 	r.Logger.V(1).Info("Exit getNetnsId", "Path", path, "inode", stat.Ino)
 	return &stat.Ino
 }
+
+// inHostNetns returns true if path is the host's network namespace, such as
+// for a pod that uses the host network.
+func (r *ReconcilerCommon) inHostNetns(path string) bool {
+	id, hostId := r.getNetnsId(path), r.getNetnsId("")
+	return id != nil && hostId != nil && *id == *hostId
+}
@@ -0,0 +1,497 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// testScheme returns the client-go scheme, which the fake clients use, with
+// the bpfman types registered.
+func testScheme(t *testing.T) *runtime.Scheme {
+	require.NoError(t, bpfmaniov1alpha1.Install(scheme.Scheme))
+	return scheme.Scheme
+}
+
+// newTestClientBuilder returns a fake client builder with objs, and with the
+// status subresource of the application and application state kinds, as in
+// a cluster.
+func newTestClientBuilder(objs ...runtime.Object) *fake.ClientBuilder {
+	return fake.NewClientBuilder().
+		WithStatusSubresource(
+			&bpfmaniov1alpha1.ClusterBpfApplication{},
+			&bpfmaniov1alpha1.ClusterBpfApplicationState{},
+			&bpfmaniov1alpha1.BpfApplication{},
+			&bpfmaniov1alpha1.BpfApplicationState{},
+		).
+		WithRuntimeObjects(objs...)
+}
+
+// newTestClient returns the client of newTestClientBuilder.
+func newTestClient(objs ...runtime.Object) client.Client {
+	return newTestClientBuilder(objs...).Build()
+}
+
+// newTestReconcilerCommon returns a ReconcilerCommon for the node in objs,
+// with a fake client holding objs and a fake bpfman, which is also returned.
+// The host's /proc is a temporary one holding the network namespace of pid 1.
+func newTestReconcilerCommon(t *testing.T, objs ...runtime.Object) (ReconcilerCommon, *agenttestutils.BpfmanClientFake) {
+	var node *v1.Node
+	for _, obj := range objs {
+		if n, ok := obj.(*v1.Node); ok {
+			node = n
+		}
+	}
+	require.NotNil(t, node, "no node in the test objects")
+
+	s := testScheme(t)
+	cli := agenttestutils.NewBpfmanClientFake()
+	return ReconcilerCommon{
+		HostProcPath: fakeHostProc(t, 1),
+		Client:       newTestClient(objs...),
+		Scheme:       s,
+		BpfmanClient: cli,
+		NodeName:     node.Name,
+		ourNode:      node,
+	}, cli
+}
+
+// newTestClReconciler returns a ClBpfApplicationReconciler built on
+// newTestReconcilerCommon, and its fake bpfman.
+func newTestClReconciler(t *testing.T, objs ...runtime.Object) (*ClBpfApplicationReconciler, *agenttestutils.BpfmanClientFake) {
+	rc, cli := newTestReconcilerCommon(t, objs...)
+	return &ClBpfApplicationReconciler{ReconcilerCommon: rc}, cli
+}
+
+// newTestNsReconciler returns a NsBpfApplicationReconciler built on
+// newTestReconcilerCommon, and its fake bpfman.
+func newTestNsReconciler(t *testing.T, objs ...runtime.Object) (*NsBpfApplicationReconciler, *agenttestutils.BpfmanClientFake) {
+	rc, cli := newTestReconcilerCommon(t, objs...)
+	return &NsBpfApplicationReconciler{ReconcilerCommon: rc}, cli
+}
+
+// reconcileUntilAttached reconciles req until its application is loaded and
+// attached: the first reconcile creates the application state, and the second
+// loads and attaches the programs.
+func reconcileUntilAttached(t *testing.T, r reconcile.Reconciler, req reconcile.Request) {
+	for range 2 {
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+	}
+}
+
+// reconcileUntilSettled reconciles req until its application is loaded and
+// attached, then once more with nothing left to change, and returns the result
+// of that last reconcile.
+func reconcileUntilSettled(t *testing.T, r reconcile.Reconciler, req reconcile.Request) reconcile.Result {
+	reconcileUntilAttached(t, r, req)
+	res, err := r.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	return res
+}
+
+// fakeHostProc returns a temporary directory to use as the host's /proc, with
+// a file standing in for the network namespace of each of pids. Links are
+// matched by network namespace, so tests attaching to the host's pass pid 1.
+func fakeHostProc(t *testing.T, pids ...int32) string {
+	procPath := t.TempDir()
+	r := &ReconcilerCommon{HostProcPath: procPath}
+	for _, pid := range pids {
+		netnsPath := r.netnsPathFromPID(pid)
+		require.NoError(t, os.MkdirAll(filepath.Dir(netnsPath), 0o755))
+		require.NoError(t, os.WriteFile(netnsPath, nil, 0o644))
+	}
+	return procPath
+}
+
+// newTestClKprobeApp returns a ClusterBpfApplication that loads the kprobe
+// program programName from /tmp/hello.o and attaches it to try_to_wake_up.
+func newTestClKprobeApp(name, programName string) *bpfmaniov1alpha1.ClusterBpfApplication {
+	bytecodePath := "/tmp/hello.o"
+	return &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: programName,
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+							{Function: "try_to_wake_up"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newTestNsUprobeApp returns a BpfApplication that loads a uprobe from
+// /tmp/hello.o and attaches it to pid in the containers of the pods labelled
+// app=test.
+func newTestNsUprobeApp(name, namespace string, pid int32) *bpfmaniov1alpha1.BpfApplication {
+	bytecodePath := "/tmp/hello.o"
+	return &bpfmaniov1alpha1.BpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: bpfmaniov1alpha1.BpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgram{
+				{
+					Name: "UprobeTest",
+					Type: bpfmaniov1alpha1.ProgTypeUprobe,
+					UProbe: &bpfmaniov1alpha1.UprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.UprobeAttachInfo{
+							{
+								Function: "malloc",
+								Target:   "/bin/bash",
+								Pid:      &pid,
+								Containers: bpfmaniov1alpha1.ContainerSelector{
+									Pods: metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetInterfacesFromIndexes(t *testing.T) {
+	origNetInterfaces := netInterfaces
+	t.Cleanup(func() { netInterfaces = origNetInterfaces })
+	netInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 1, Name: "lo"},
+			{Index: 2, Name: "eno1"},
+			{Index: 7, Name: "ens3f0"},
+		}, nil
+	}
+	node := testutils.NewNode("fake-control-plane")
+
+	tests := []struct {
+		name    string
+		indexes []int32
+		want    []string
+		wantErr string
+	}{
+		{name: "single index", indexes: []int32{2}, want: []string{"eno1"}},
+		{name: "keeps order", indexes: []int32{7, 1}, want: []string{"ens3f0", "lo"}},
+		{name: "missing index", indexes: []int32{2, 3}, wantErr: "interface index 3 not found on node"},
+		{name: "missing indexes", indexes: []int32{4, 2, 5}, wantErr: "interface index 4, 5 not found on node"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interfaces, err := getInterfaces(&bpfmaniov1alpha1.InterfaceSelector{InterfaceIndexes: tt.indexes}, node, nil)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, interfaces)
+		})
+	}
+}
+
+func TestGetInterfacesExclude(t *testing.T) {
+	origNetInterfaces := netInterfaces
+	t.Cleanup(func() { netInterfaces = origNetInterfaces })
+	netInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 1, Name: "lo", Flags: net.FlagLoopback},
+			{Index: 2, Name: "en0"},
+			{Index: 3, Name: "en1"},
+			{Index: 4, Name: "enp2s0"},
+			{Index: 5, Name: "veth1a2b"},
+		}, nil
+	}
+	node := testutils.NewNode("fake-control-plane")
+
+	tests := []struct {
+		name     string
+		selector bpfmaniov1alpha1.InterfaceSelector
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "include then exclude",
+			selector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"en0", "en1", "enp2s0"}, ExcludeInterfaces: []string{"en0"}},
+			want:     []string{"en1", "enp2s0"},
+		},
+		{
+			name:     "exclude pattern",
+			selector: bpfmaniov1alpha1.InterfaceSelector{InterfaceIndexes: []int32{2, 3, 4}, ExcludeInterfaces: []string{"/^enp/"}},
+			want:     []string{"en0", "en1"},
+		},
+		{
+			name:     "exclude missing interface",
+			selector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"en0", "en1"}, ExcludeInterfaces: []string{"eth7"}},
+			want:     []string{"en0", "en1"},
+		},
+		{
+			name:     "exclude everything",
+			selector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"en0"}, ExcludeInterfaces: []string{"en0"}},
+			want:     []string{},
+		},
+		{
+			name:     "exclude only",
+			selector: bpfmaniov1alpha1.InterfaceSelector{ExcludeInterfaces: []string{"/^veth/"}},
+			want:     []string{"en0", "en1", "enp2s0"},
+		},
+		{
+			name:     "exclude only leaves out loopback",
+			selector: bpfmaniov1alpha1.InterfaceSelector{ExcludeInterfaces: []string{"en1"}},
+			want:     []string{"en0", "enp2s0", "veth1a2b"},
+		},
+		{
+			name:     "invalid pattern",
+			selector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"en0"}, ExcludeInterfaces: []string{"/en[/"}},
+			wantErr:  `invalid interface pattern "/en[/"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.selector.DeepCopy()
+			interfaces, err := getInterfaces(&tt.selector, node, nil)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, interfaces)
+			// The selector's own lists aren't modified.
+			require.Equal(t, spec, &tt.selector)
+		})
+	}
+}
+
+func TestGetDiscoveredInterfacesExclude(t *testing.T) {
+	discovered := &sync.Map{}
+	for _, name := range []string{"lo", "en0", "en1", "enp2s0"} {
+		discovered.Store(ifaces.Interface{Name: name}, true)
+	}
+	autoDiscovery := true
+	selector := &bpfmaniov1alpha1.InterfaceSelector{
+		InterfacesDiscoveryConfig: &bpfmaniov1alpha1.InterfaceDiscovery{
+			InterfaceAutoDiscovery: &autoDiscovery,
+			ExcludeInterfaces:      []string{"lo"},
+			AllowedInterfaces:      []string{"/^en/"},
+		},
+		ExcludeInterfaces: []string{"en0", "eth7"},
+	}
+
+	discoveredNames := func() []string {
+		interfaces, err := getDiscoveredInterfaces(selector, discovered)
+		require.NoError(t, err)
+		names := []string{}
+		for _, intf := range interfaces {
+			names = append(names, intf.interfaceName)
+		}
+		return names
+	}
+	require.ElementsMatch(t, []string{"en1", "enp2s0"}, discoveredNames())
+
+	// Only excluding interfaces leaves out those named in the discovery
+	// configuration too.
+	selector.InterfacesDiscoveryConfig.AllowedInterfaces = nil
+	selector.ExcludeInterfaces = []string{"/^enp/"}
+	require.ElementsMatch(t, []string{"en0", "en1"}, discoveredNames())
+
+	// An invalid pattern in either list is an error rather than ending the
+	// list.
+	selector.ExcludeInterfaces = []string{"/en[/"}
+	_, err := getDiscoveredInterfaces(selector, discovered)
+	require.ErrorContains(t, err, `invalid interface pattern "/en[/"`)
+
+	selector.ExcludeInterfaces = nil
+	selector.InterfacesDiscoveryConfig.AllowedInterfaces = []string{"/en[/"}
+	_, err = getDiscoveredInterfaces(selector, discovered)
+	require.ErrorContains(t, err, `invalid interface pattern "/en[/"`)
+}
+
+func TestLinkMetadata(t *testing.T) {
+	programId := uint32(7)
+	r := &ClKprobeProgramReconciler{
+		ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+			currentProgram: &bpfmaniov1alpha1.ClBpfApplicationProgram{
+				Name: "KprobeTest",
+				Type: bpfmaniov1alpha1.ProgTypeKprobe,
+				KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+					Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{
+						{
+							Function: "try_to_wake_up",
+							Metadata: map[string]string{"team": "networking", "trace-id": "a1b2"},
+						},
+					},
+				},
+			},
+			currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{ProgramId: &programId},
+				Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+				KProbe:                &bpfmaniov1alpha1.ClKprobeProgramInfoState{},
+			},
+		},
+	}
+
+	keys := r.metadataKeys()
+
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	links := r.currentProgramState.KProbe.Links
+	require.Len(t, links, 1)
+	r.currentLink = &links[0]
+
+	// The custom metadata is sent alongside the link's UUID.
+	metadata := r.getAttachRequest().Attach.GetKprobeAttachInfo().Metadata
+	require.Equal(t, map[string]string{
+		"team":     "networking",
+		"trace-id": "a1b2",
+		keys.Uuid:  links[0].UUID,
+	}, metadata)
+
+	// The UUID can't be overridden, nor can the agent's other keys be set.
+	links[0].Metadata[keys.Uuid] = "not-the-uuid"
+	links[0].Metadata[keys.LinkPersistence] = "DetachOnAgentExit"
+	metadata = r.getAttachRequest().Attach.GetKprobeAttachInfo().Metadata
+	require.Equal(t, links[0].UUID, metadata[keys.Uuid])
+	require.NotContains(t, metadata, keys.LinkPersistence)
+	delete(links[0].Metadata, keys.Uuid)
+	delete(links[0].Metadata, keys.LinkPersistence)
+
+	// Changing the metadata replaces the link, since bpfman only takes it
+	// when the link is attached.
+	r.currentProgram.KProbe.Links[0].Metadata = map[string]string{"team": "observability"}
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	links = r.currentProgramState.KProbe.Links
+	require.Len(t, links, 2)
+	require.False(t, links[0].ShouldAttach)
+	require.True(t, links[1].ShouldAttach)
+	require.Equal(t, map[string]string{"team": "observability"}, links[1].Metadata)
+}
+
+func TestLinkPersistence(t *testing.T) {
+	programId := uint32(7)
+	r := &ClKprobeProgramReconciler{
+		ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+			currentProgram: &bpfmaniov1alpha1.ClBpfApplicationProgram{
+				Name: "KprobeTest",
+				Type: bpfmaniov1alpha1.ProgTypeKprobe,
+				KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+					Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+				},
+			},
+			currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{ProgramId: &programId},
+				Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+				KProbe:                &bpfmaniov1alpha1.ClKprobeProgramInfoState{},
+			},
+		},
+	}
+
+	keys := r.metadataKeys()
+
+	require.NoError(t, r.updateLinks(context.TODO(), false))
+	links := r.currentProgramState.KProbe.Links
+	require.Len(t, links, 1)
+	r.currentLink = &links[0]
+
+	// Links are persistent by default, which isn't recorded.
+	metadata := r.getAttachRequest().Attach.GetKprobeAttachInfo().Metadata
+	require.NotContains(t, metadata, keys.LinkPersistence)
+
+	r.currentProgram.LinkPersistence = bpfmaniov1alpha1.LinkPersistent
+	metadata = r.getAttachRequest().Attach.GetKprobeAttachInfo().Metadata
+	require.NotContains(t, metadata, keys.LinkPersistence)
+
+	r.currentProgram.LinkPersistence = bpfmaniov1alpha1.LinkDetachOnAgentExit
+	metadata = r.getAttachRequest().Attach.GetKprobeAttachInfo().Metadata
+	require.Equal(t, map[string]string{
+		keys.LinkPersistence: "DetachOnAgentExit",
+		keys.Uuid:            links[0].UUID,
+	}, metadata)
+}
+
+func TestBpfAppStateConditionTransitions(t *testing.T) {
+	r := &ClBpfApplicationReconciler{
+		currentApp:      &bpfmaniov1alpha1.ClusterBpfApplication{ObjectMeta: metav1.ObjectMeta{Generation: 1}},
+		currentAppState: &bpfmaniov1alpha1.ClusterBpfApplicationState{},
+	}
+	condition := func() metav1.Condition {
+		require.Len(t, r.currentAppState.Status.Conditions, 1)
+		return r.currentAppState.Status.Conditions[0]
+	}
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	require.True(t, r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError))
+	require.Equal(t, "Error", condition().Reason)
+	require.False(t, condition().LastTransitionTime.Time.IsZero())
+	r.currentAppState.Status.Conditions[0].LastTransitionTime = past
+
+	// Observing a new generation with the same result isn't a transition.
+	r.currentApp.Generation = 2
+	require.True(t, r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError))
+	require.Equal(t, int64(2), condition().ObservedGeneration)
+	require.Equal(t, past, condition().LastTransitionTime)
+
+	// Error to success and back again are.
+	require.True(t, r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondSuccess))
+	require.Equal(t, "Success", condition().Reason)
+	require.True(t, condition().LastTransitionTime.After(past.Time))
+	r.currentAppState.Status.Conditions[0].LastTransitionTime = past
+
+	require.True(t, r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError))
+	require.Equal(t, "Error", condition().Reason)
+	require.True(t, condition().LastTransitionTime.After(past.Time))
+
+	require.False(t, r.updateBpfAppStateCondition(r, bpfmaniov1alpha1.BpfAppStateCondError))
+}
@@ -18,6 +18,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -33,12 +34,19 @@ const (
 	// container PID discovery from the container runtime
 	// interface (CRI).
 	containerDiscoveryTimeout = 10 * time.Second
+
+	// waitingForContainersRetryInterval is how often an application with a
+	// container selector that doesn't match any containers yet is reconciled
+	// again, in case the containers started without a pod event.
+	waitingForContainersRetryInterval = 30 * time.Second
 )
 
 type ContainerInfo struct {
 	podName       string
 	containerName string
-	pid           int32
+	// image is the container's image reference from the pod spec.
+	image string
+	pid   int32
 }
 
 // Create an interface for getting the list of containers in which the program
@@ -166,6 +174,9 @@ func getContainerInfo(ctx context.Context, podList *v1.PodList, containerNames *
 		if err != nil {
 			return nil, fmt.Errorf("failed to get container info for pod %s: %w", pod.Name, err)
 		}
+		for j := range containerInfos {
+			containerInfos[j].image = containerImage(&pod, containerInfos[j].containerName)
+		}
 
 		containers = append(containers, containerInfos...)
 	}
@@ -212,6 +223,56 @@ func getContainerInfoFromPod(ctx context.Context, podName string, containerNames
 	return result, nil
 }
 
+// containerImage returns the image reference of the named container in pod,
+// or "" if there isn't one.
+func containerImage(pod *v1.Pod, containerName string) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+// imageMatches returns whether image is one of refs, or starts with the part
+// of a ref before a trailing *.
+func imageMatches(image string, refs []string) bool {
+	for _, ref := range refs {
+		if prefix, ok := strings.CutSuffix(ref, "*"); ok {
+			if strings.HasPrefix(image, prefix) {
+				return true
+			}
+		} else if image == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// filterContainersByImage returns the containers whose images match one of
+// images, as for a container selector's containerImages. Every matching
+// container is kept, including several in the same pod. If images is empty,
+// containers is returned as is.
+func filterContainersByImage(containers *[]ContainerInfo, images []string) *[]ContainerInfo {
+	if containers == nil || len(images) == 0 {
+		return containers
+	}
+	matched := []ContainerInfo{}
+	for _, container := range *containers {
+		if imageMatches(container.image, images) {
+			matched = append(matched, container)
+		}
+	}
+	return &matched
+}
+
+// selectsPods returns whether a container selector names the pods it wants,
+// as opposed to an empty selector matching whatever pods there are. Only a
+// selector that names pods is expected to match containers eventually.
+func selectsPods(selector metav1.LabelSelector) bool {
+	return len(selector.MatchLabels) != 0 || len(selector.MatchExpressions) != 0
+}
+
 func GetOneContainerPerPod(containers *[]ContainerInfo) *[]ContainerInfo {
 	uniquePods := make(map[string]bool)
 	uniqueContainers := []ContainerInfo{}
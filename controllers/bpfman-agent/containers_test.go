@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageMatches(t *testing.T) {
+	refs := []string{"quay.io/example/server:*", "docker.io/library/nginx:1.27"}
+
+	require.True(t, imageMatches("quay.io/example/server:v1", refs))
+	require.True(t, imageMatches("quay.io/example/server:", refs))
+	require.True(t, imageMatches("docker.io/library/nginx:1.27", refs))
+	require.False(t, imageMatches("docker.io/library/nginx:1.28", refs))
+	require.False(t, imageMatches("quay.io/example/server-debug:v1", refs))
+	require.False(t, imageMatches("", refs))
+}
+
+func TestClUprobeContainersByImagePrefix(t *testing.T) {
+	// Two containers in the first pod and one in the second run the server
+	// image.
+	containers := &FakeContainerGetter{
+		containerList: &[]ContainerInfo{
+			{podName: "pod-a", containerName: "server", image: "quay.io/example/server:v1", pid: 100},
+			{podName: "pod-a", containerName: "sidecar", image: "quay.io/example/proxy:v1", pid: 101},
+			{podName: "pod-a", containerName: "server-canary", image: "quay.io/example/server:v2", pid: 102},
+			{podName: "pod-b", containerName: "server", image: "quay.io/example/server:v1", pid: 200},
+			{podName: "pod-c", containerName: "other", image: "quay.io/other/server:v1", pid: 300},
+		},
+	}
+	r := &ClUprobeProgramReconciler{
+		ReconcilerCommon: ReconcilerCommon{
+			Containers: containers,
+		},
+	}
+
+	links, err := r.getExpectedLinks(context.TODO(), bpfmaniov1alpha1.ClUprobeAttachInfo{
+		Function: "main",
+		Target:   "/usr/bin/server",
+		Containers: &bpfmaniov1alpha1.ClContainerSelector{
+			ContainerImages: []string{"quay.io/example/server:*"},
+		},
+	})
+	require.NoError(t, err)
+
+	// Every matching container gets its own link.
+	pids := []int32{}
+	for _, link := range links {
+		require.NotNil(t, link.ContainerPid)
+		pids = append(pids, *link.ContainerPid)
+	}
+	require.Equal(t, []int32{100, 102, 200}, pids)
+
+	// Without containerImages every container is selected.
+	links, err = r.getExpectedLinks(context.TODO(), bpfmaniov1alpha1.ClUprobeAttachInfo{
+		Function:   "main",
+		Target:     "/usr/bin/server",
+		Containers: &bpfmaniov1alpha1.ClContainerSelector{},
+	})
+	require.NoError(t, err)
+	require.Len(t, links, 5)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DebugStatePath is the path the agent's debug endpoint serves its state on.
+const DebugStatePath = "/debug/state"
+
+// DebugState is the agent's view of the applications on its node, as served
+// by the debug endpoint.
+type DebugState struct {
+	NodeName            string                                                                 `json:"nodeName"`
+	ClusterApplications []DebugApplicationState[bpfmaniov1alpha1.ClBpfApplicationProgramState] `json:"clusterApplications"`
+	Applications        []DebugApplicationState[bpfmaniov1alpha1.BpfApplicationProgramState]   `json:"applications"`
+	// LoadedPrograms are the programs bpfman has loaded, which the
+	// applications' programs and links can be compared with.
+	LoadedPrograms []DebugLoadedProgram `json:"loadedPrograms"`
+}
+
+// DebugApplicationState is the agent's view of one application on its node.
+// Programs holds the application state's programs, including the links the
+// agent expects for each of them, their statuses and their kernel ids.
+type DebugApplicationState[P any] struct {
+	Namespace    string                           `json:"namespace,omitempty"`
+	Name         string                           `json:"name"`
+	AppStateName string                           `json:"appStateName"`
+	UpdateCount  int64                            `json:"updateCount"`
+	Conditions   []metav1.Condition               `json:"conditions,omitempty"`
+	LastError    *bpfmaniov1alpha1.ReconcileError `json:"lastError,omitempty"`
+	Programs     []P                              `json:"programs"`
+}
+
+// DebugLoadedProgram is a program bpfman has loaded, as bpfman lists it.
+type DebugLoadedProgram struct {
+	Id          uint32            `json:"id"`
+	Name        string            `json:"name"`
+	ProgramType string            `json:"programType"`
+	Links       []uint32          `json:"links,omitempty"`
+	MapOwnerId  uint32            `json:"mapOwnerId,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// GetDebugState returns the agent's view of the applications on nodeName,
+// read from their application state objects, and the programs bpfman has
+// loaded.
+func GetDebugState(ctx context.Context, reader client.Reader, bpfmanClient gobpfman.BpfmanClient,
+	nodeName string) (*DebugState, error) {
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: nodeName}}
+	state := &DebugState{
+		NodeName:            nodeName,
+		ClusterApplications: []DebugApplicationState[bpfmaniov1alpha1.ClBpfApplicationProgramState]{},
+		Applications:        []DebugApplicationState[bpfmaniov1alpha1.BpfApplicationProgramState]{},
+		LoadedPrograms:      []DebugLoadedProgram{},
+	}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := reader.List(ctx, clAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	for _, appState := range clAppStates.Items {
+		state.ClusterApplications = append(state.ClusterApplications,
+			DebugApplicationState[bpfmaniov1alpha1.ClBpfApplicationProgramState]{
+				Name:         appStateOwnerName(&appState),
+				AppStateName: appState.Name,
+				UpdateCount:  appState.Status.UpdateCount,
+				Conditions:   appState.Status.Conditions,
+				LastError:    appState.Status.LastError,
+				Programs:     appState.Status.Programs,
+			})
+	}
+
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := reader.List(ctx, nsAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+	for _, appState := range nsAppStates.Items {
+		state.Applications = append(state.Applications,
+			DebugApplicationState[bpfmaniov1alpha1.BpfApplicationProgramState]{
+				Namespace:    appState.Namespace,
+				Name:         appStateOwnerName(&appState),
+				AppStateName: appState.Name,
+				UpdateCount:  appState.Status.UpdateCount,
+				Conditions:   appState.Status.Conditions,
+				LastError:    appState.Status.LastError,
+				Programs:     appState.Status.Programs,
+			})
+	}
+
+	bpfmanProgramsOnly := true
+	listResponse, err := bpfmanClient.List(ctx, &gobpfman.ListRequest{BpfmanProgramsOnly: &bpfmanProgramsOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list programs via bpfman: %w", err)
+	}
+	for _, result := range listResponse.GetResults() {
+		info := result.GetInfo()
+		kernelInfo := result.GetKernelInfo()
+		state.LoadedPrograms = append(state.LoadedPrograms, DebugLoadedProgram{
+			Id:          kernelInfo.GetId(),
+			Name:        kernelInfo.GetName(),
+			ProgramType: internal.ProgramType(kernelInfo.GetProgramType()).String(),
+			Links:       info.GetLinks(),
+			MapOwnerId:  info.GetMapOwnerId(),
+			Metadata:    info.GetMetadata(),
+		})
+	}
+	return state, nil
+}
+
+// appStateOwnerName returns the name of the application that appState belongs
+// to, or "" if it has no controller. It's read from its controller, since the
+// BpfAppStateOwner label is truncated for long names.
+func appStateOwnerName(appState metav1.Object) string {
+	if owner := metav1.GetControllerOf(appState); owner != nil {
+		return owner.Name
+	}
+	return ""
+}
+
+// NewDebugHandler returns an HTTP handler that serves the agent's view of the
+// applications on nodeName as JSON on DebugStatePath, so support can see
+// every program's links in one place instead of describing each application
+// state object.
+func NewDebugHandler(reader client.Reader, bpfmanClient gobpfman.BpfmanClient, nodeName string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(DebugStatePath, func(w http.ResponseWriter, r *http.Request) {
+		state, err := GetDebugState(r.Context(), reader, bpfmanClient, nodeName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(state); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDebugHandler(t *testing.T) {
+	var (
+		appProgramName = "fakeDebugProgram"
+		fakeNode       = testutils.NewNode("fake-control-plane")
+		ctx            = context.TODO()
+	)
+
+	bpfApp := newTestClKprobeApp(appProgramName, "kprobe_test")
+
+	r, cli := newTestClReconciler(t, fakeNode, bpfApp)
+	cli.LoadedProgramTypes = map[string]uint32{"kprobe_test": uint32(internal.Kprobe)}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: appProgramName}}
+	reconcileUntilAttached(t, r, req)
+	bpfAppState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+
+	// bpfman lists the program it loaded.
+	programId := int(*bpfAppState.Status.Programs[0].ProgramId)
+	loaded := cli.Programs[programId]
+	cli.ListResults = []*gobpfman.ListResponse_ListResult{{Info: loaded.Info, KernelInfo: loaded.KernelInfo}}
+
+	handler := NewDebugHandler(r.Client, cli, fakeNode.Name)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, DebugStatePath, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var state DebugState
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	require.Equal(t, fakeNode.Name, state.NodeName)
+	require.Empty(t, state.Applications)
+	require.Len(t, state.ClusterApplications, 1)
+
+	app := state.ClusterApplications[0]
+	require.Equal(t, appProgramName, app.Name)
+	require.Equal(t, bpfAppState.Name, app.AppStateName)
+	require.Len(t, app.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), app.Conditions[0].Type)
+
+	// The program is reported with its kernel id and its link's status.
+	require.Len(t, app.Programs, 1)
+	program := app.Programs[0]
+	require.Equal(t, "kprobe_test", program.Name)
+	require.NotNil(t, program.ProgramId)
+	require.Equal(t, bpfAppState.Status.Programs[0].ProgramId, program.ProgramId)
+	require.Len(t, program.KProbe.Links, 1)
+	link := program.KProbe.Links[0]
+	require.True(t, link.ShouldAttach)
+	require.Equal(t, bpfmaniov1alpha1.ApAttachAttached, link.LinkStatus)
+	require.NotNil(t, link.LinkId)
+
+	// So is the program as bpfman lists it, with the same link.
+	require.Equal(t, []DebugLoadedProgram{{
+		Id:          uint32(programId),
+		Name:        "kprobe_test",
+		ProgramType: "kprobe",
+		Links:       []uint32{*link.LinkId},
+		Metadata:    loaded.Info.GetMetadata(),
+	}}, state.LoadedPrograms)
+
+	// Other paths aren't served.
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/other", nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAppStateOwnerName(t *testing.T) {
+	// The name is read from the controller, as the label is truncated.
+	longName := strings.Repeat("a", 70)
+	appState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "long-12345",
+			Labels: map[string]string{internal.BpfAppStateOwner: internal.AppStateOwnerLabel(longName)},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: bpfmaniov1alpha1.SchemeGroupVersion.String(),
+				Kind:       "ClusterBpfApplication",
+				Name:       longName,
+				UID:        "long-app-uid",
+				Controller: &[]bool{true}[0],
+			}},
+		},
+	}
+	require.NotEqual(t, longName, appState.Labels[internal.BpfAppStateOwner])
+	require.Equal(t, longName, appStateOwnerName(appState))
+
+	// Without a controller, the application isn't known.
+	appState.OwnerReferences = nil
+	require.Empty(t, appStateOwnerName(appState))
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// xdpDispatcherReturn is the proceedOn value that lets bpfman's XDP
+// dispatcher call the next program in the chain when a program returns to
+// the dispatcher.
+const xdpDispatcherReturn bpfmaniov1alpha1.XdpProceedOnValue = "DispatcherReturn"
+
+// errMissingDispatcherReturn is returned when an XDP link's proceedOn doesn't
+// include DispatcherReturn and the XdpDispatcherReturnPolicy is
+// XdpDispatcherReturnFail.
+var errMissingDispatcherReturn = errors.New("proceedOn doesn't include DispatcherReturn")
+
+// XdpDispatcherReturnPolicy is what the agent does when an XDP link's
+// proceedOn doesn't include DispatcherReturn. bpfman always attaches XDP
+// programs through its dispatcher, and without DispatcherReturn a program
+// that returns to the dispatcher silently stops the rest of the chain from
+// running.
+type XdpDispatcherReturnPolicy string
+
+const (
+	// XdpDispatcherReturnWarn attaches the link with the proceedOn it's
+	// given, logs a warning, and sets the application state's
+	// MissingDispatcherReturn condition.
+	XdpDispatcherReturnWarn XdpDispatcherReturnPolicy = "warn"
+	// XdpDispatcherReturnAdd logs a warning and adds DispatcherReturn to the
+	// proceedOn bpfman is given. The application isn't changed.
+	XdpDispatcherReturnAdd XdpDispatcherReturnPolicy = "add"
+	// XdpDispatcherReturnFail doesn't attach the link, and reports the
+	// error.
+	XdpDispatcherReturnFail XdpDispatcherReturnPolicy = "fail"
+	// XdpDispatcherReturnIgnore attaches the link with the proceedOn it's
+	// given.
+	XdpDispatcherReturnIgnore XdpDispatcherReturnPolicy = "ignore"
+)
+
+// ParseXdpDispatcherReturnPolicy parses the value of
+// --xdp-dispatcher-return-policy. An empty string returns
+// XdpDispatcherReturnWarn.
+func ParseXdpDispatcherReturnPolicy(s string) (XdpDispatcherReturnPolicy, error) {
+	switch policy := XdpDispatcherReturnPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return XdpDispatcherReturnWarn, nil
+	case XdpDispatcherReturnWarn, XdpDispatcherReturnAdd, XdpDispatcherReturnFail, XdpDispatcherReturnIgnore:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown XDP dispatcher return policy %q: expected %s, %s, %s or %s",
+			s, XdpDispatcherReturnWarn, XdpDispatcherReturnAdd, XdpDispatcherReturnFail, XdpDispatcherReturnIgnore)
+	}
+}
+
+// missingDispatcherReturn returns true if proceedOn is set but doesn't include
+// DispatcherReturn. bpfman uses its default, which includes it, if proceedOn
+// is empty.
+func missingDispatcherReturn(proceedOn []bpfmaniov1alpha1.XdpProceedOnValue) bool {
+	return len(proceedOn) > 0 && !slices.Contains(proceedOn, xdpDispatcherReturn)
+}
+
+// checkXdpDispatcherReturn is called before an XDP link is attached, and
+// checks that its proceedOn includes DispatcherReturn. If it doesn't, with
+// XdpDispatcherReturnAdd a warning is logged, and with XdpDispatcherReturnFail
+// the link's status is set to ApAttachError and an error is returned so it
+// isn't attached. XdpDispatcherReturnWarn is reported by
+// checkDispatcherReturnCondition once the links are attached.
+func (r *ReconcilerCommon) checkXdpDispatcherReturn(ctx context.Context, rec ProgramReconciler,
+	proceedOn []bpfmaniov1alpha1.XdpProceedOnValue) error {
+	if !missingDispatcherReturn(proceedOn) || r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+	switch r.XdpDispatcherReturnPolicy {
+	case XdpDispatcherReturnFail:
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachError)
+		return fmt.Errorf("%w: %v", errMissingDispatcherReturn, proceedOn)
+	case XdpDispatcherReturnAdd:
+		r.Logger.Info("WARNING: adding DispatcherReturn to XDP proceedOn so the rest of the chain runs",
+			"proceedOn", proceedOn)
+	}
+	return nil
+}
+
+// xdpProceedOn returns the proceedOn values for an XDP attach request, adding
+// DispatcherReturn if it's missing and the XdpDispatcherReturnPolicy is
+// XdpDispatcherReturnAdd.
+func (r *ReconcilerCommon) xdpProceedOn(proceedOn []bpfmaniov1alpha1.XdpProceedOnValue) []int32 {
+	if r.XdpDispatcherReturnPolicy == XdpDispatcherReturnAdd && missingDispatcherReturn(proceedOn) {
+		proceedOn = append(slices.Clone(proceedOn), xdpDispatcherReturn)
+	}
+	return xdpProceedOnToInt(proceedOn)
+}
+
+// checkDispatcherReturnCondition logs a warning for each attached XDP link
+// whose proceedOn doesn't include DispatcherReturn, and returns true if there
+// were any, when the XdpDispatcherReturnPolicy is XdpDispatcherReturnWarn or
+// unset. links maps a description of each link to its proceedOn.
+func (r *ReconcilerCommon) checkDispatcherReturnCondition(links map[string][]bpfmaniov1alpha1.XdpProceedOnValue) bool {
+	if r.XdpDispatcherReturnPolicy != "" && r.XdpDispatcherReturnPolicy != XdpDispatcherReturnWarn {
+		return false
+	}
+	missing := false
+	for _, link := range slices.Sorted(maps.Keys(links)) {
+		if missingDispatcherReturn(links[link]) {
+			r.Logger.Info("WARNING: XDP proceedOn doesn't include DispatcherReturn, so the rest of the chain "+
+				"won't run when the program returns to the dispatcher", "Link", link, "proceedOn", links[link])
+			missing = true
+		}
+	}
+	return missing
+}
+
+// clAppStateXdpProceedOn returns the proceedOn of each attached XDP link in a
+// ClusterBpfApplicationState, keyed by its program and interface.
+func clAppStateXdpProceedOn(appState *bpfmaniov1alpha1.ClusterBpfApplicationState) map[string][]bpfmaniov1alpha1.XdpProceedOnValue {
+	links := map[string][]bpfmaniov1alpha1.XdpProceedOnValue{}
+	for _, program := range appState.Status.Programs {
+		if program.XDP == nil {
+			continue
+		}
+		for _, l := range program.XDP.Links {
+			if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				links[program.Name+"/"+l.InterfaceName+l.NetnsPath] = l.ProceedOn
+			}
+		}
+	}
+	return links
+}
+
+// nsAppStateXdpProceedOn returns the proceedOn of each attached XDP link in a
+// BpfApplicationState, keyed by its program and interface.
+func nsAppStateXdpProceedOn(appState *bpfmaniov1alpha1.BpfApplicationState) map[string][]bpfmaniov1alpha1.XdpProceedOnValue {
+	links := map[string][]bpfmaniov1alpha1.XdpProceedOnValue{}
+	for _, program := range appState.Status.Programs {
+		if program.XDP == nil {
+			continue
+		}
+		for _, l := range program.XDP.Links {
+			if l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached {
+				links[program.Name+"/"+l.InterfaceName+l.NetnsPath] = l.ProceedOn
+			}
+		}
+	}
+	return links
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseXdpDispatcherReturnPolicy(t *testing.T) {
+	policy, err := ParseXdpDispatcherReturnPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, XdpDispatcherReturnWarn, policy)
+
+	policy, err = ParseXdpDispatcherReturnPolicy(" Fail ")
+	require.NoError(t, err)
+	require.Equal(t, XdpDispatcherReturnFail, policy)
+
+	_, err = ParseXdpDispatcherReturnPolicy("bogus")
+	require.Error(t, err)
+}
+
+func TestXdpDispatcherReturn(t *testing.T) {
+	programId := uint32(7)
+	newReconciler := func(policy XdpDispatcherReturnPolicy, proceedOn ...bpfmaniov1alpha1.XdpProceedOnValue) *ClXdpProgramReconciler {
+		return &ClXdpProgramReconciler{
+			ReconcilerCommon: ReconcilerCommon{XdpDispatcherReturnPolicy: policy},
+			ClProgramReconcilerCommon: ClProgramReconcilerCommon{
+				currentProgram: &bpfmaniov1alpha1.ClBpfApplicationProgram{Name: "XdpTest", Type: bpfmaniov1alpha1.ProgTypeXDP},
+				currentProgramState: &bpfmaniov1alpha1.ClBpfApplicationProgramState{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{ProgramId: &programId},
+				},
+			},
+			currentLink: &bpfmaniov1alpha1.ClXdpAttachInfoState{
+				AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{ShouldAttach: true},
+				InterfaceName:         "eth0",
+				ProceedOn:             proceedOn,
+			},
+		}
+	}
+	proceedOn := func(r *ClXdpProgramReconciler) []int32 {
+		return r.getAttachRequest().Attach.GetXdpAttachInfo().ProceedOn
+	}
+
+	tests := []struct {
+		name      string
+		policy    XdpDispatcherReturnPolicy
+		proceedOn []bpfmaniov1alpha1.XdpProceedOnValue
+		expected  []int32
+		err       bool
+	}{
+		{name: "present", policy: XdpDispatcherReturnFail, proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"}, expected: []int32{2, 31}},
+		{name: "bpfman default", policy: XdpDispatcherReturnFail},
+		{name: "default policy leaves it", proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass"}, expected: []int32{2}},
+		{name: "warn", policy: XdpDispatcherReturnWarn, proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass"}, expected: []int32{2}},
+		{name: "add", policy: XdpDispatcherReturnAdd, proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass"}, expected: []int32{2, 31}},
+		{name: "ignore", policy: XdpDispatcherReturnIgnore, proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass"}, expected: []int32{2}},
+		{name: "fail", policy: XdpDispatcherReturnFail, proceedOn: []bpfmaniov1alpha1.XdpProceedOnValue{"Pass"}, err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newReconciler(tc.policy, tc.proceedOn...)
+			err := r.checkXdpDispatcherReturn(context.TODO(), r, r.currentLink.ProceedOn)
+			if tc.err {
+				require.ErrorIs(t, err, errMissingDispatcherReturn)
+				require.Equal(t, bpfmaniov1alpha1.ApAttachError, r.currentLink.LinkStatus)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, proceedOn(r))
+		})
+	}
+
+	// A link that isn't going to be attached isn't checked.
+	r := newReconciler(XdpDispatcherReturnFail, "Pass")
+	r.currentLink.ShouldAttach = false
+	require.NoError(t, r.checkXdpDispatcherReturn(context.TODO(), r, r.currentLink.ProceedOn))
+}
+
+func TestXdpDispatcherReturnCondition(t *testing.T) {
+	xdpLink := func(iface string, status bpfmaniov1alpha1.LinkStatus,
+		proceedOn ...bpfmaniov1alpha1.XdpProceedOnValue) bpfmaniov1alpha1.ClXdpAttachInfoState {
+		return bpfmaniov1alpha1.ClXdpAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkStatus: status},
+			InterfaceName:         iface,
+			ProceedOn:             proceedOn,
+		}
+	}
+	appState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{{
+				BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "XdpTest"},
+				Type:                  bpfmaniov1alpha1.ProgTypeXDP,
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{
+					Links: []bpfmaniov1alpha1.ClXdpAttachInfoState{
+						xdpLink("eth0", bpfmaniov1alpha1.ApAttachAttached, "Pass", "DispatcherReturn"),
+						// bpfman's default includes DispatcherReturn.
+						xdpLink("eth1", bpfmaniov1alpha1.ApAttachAttached),
+						// Not attached, so it doesn't stop any chain.
+						xdpLink("eth2", bpfmaniov1alpha1.ApAttachError, "Pass"),
+					},
+				},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   XdpDispatcherReturnPolicy
+		missing  bool
+		expected bool
+	}{
+		{name: "included", policy: XdpDispatcherReturnWarn},
+		{name: "default policy", missing: true, expected: true},
+		{name: "warn", policy: XdpDispatcherReturnWarn, missing: true, expected: true},
+		{name: "add", policy: XdpDispatcherReturnAdd, missing: true},
+		{name: "ignore", policy: XdpDispatcherReturnIgnore, missing: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			appState := appState.DeepCopy()
+			if tc.missing {
+				links := &appState.Status.Programs[0].XDP.Links
+				*links = append(*links, xdpLink("eth3", bpfmaniov1alpha1.ApAttachAttached, "Pass"))
+			}
+			r := &ReconcilerCommon{XdpDispatcherReturnPolicy: tc.policy}
+			require.Equal(t, tc.expected, r.checkDispatcherReturnCondition(clAppStateXdpProceedOn(appState)))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// DefaultDriftCheckInterval is the default interval between the periodic
+// reconciles that catch links detached or programs unloaded outside of the
+// agent.
+const DefaultDriftCheckInterval = 5 * time.Minute
+
+// driftCheckTicker returns a runnable that sends a generic event for our node
+// on the returned channel every interval, until its context is cancelled.
+func driftCheckTicker(interval time.Duration, nodeName string) (manager.RunnableFunc, <-chan event.GenericEvent) {
+	events := make(chan event.GenericEvent)
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				select {
+				case events <- event.GenericEvent{Object: node}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}, events
+}
+
+// driftCheckSource returns a source that triggers a reconcile through h every
+// DriftCheckInterval. Each reconcile checks that bpfman still has every link
+// the application states say are attached, and reattaches the missing ones,
+// so this catches changes made outside of the agent, which nothing else would
+// trigger a reconcile for. It returns nil if DriftCheckInterval isn't
+// positive.
+func (r *ReconcilerCommon) driftCheckSource(mgr ctrl.Manager, h handler.EventHandler) (source.Source, error) {
+	if r.DriftCheckInterval <= 0 {
+		return nil, nil
+	}
+	ticker, events := driftCheckTicker(r.DriftCheckInterval, r.NodeName)
+	if err := mgr.Add(ticker); err != nil {
+		return nil, err
+	}
+	return source.Channel(events, h), nil
+}
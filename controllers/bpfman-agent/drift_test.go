@@ -0,0 +1,43 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftCheckTicker(t *testing.T) {
+	ticker, events := driftCheckTicker(10*time.Millisecond, "fake-node")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ticker(ctx) }()
+
+	select {
+	case e := <-events:
+		require.Equal(t, "fake-node", e.Object.GetName())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a drift check event")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errGatedOff is returned when an application isn't loaded because its
+// feature gate isn't enabled.
+var errGatedOff = errors.New("feature gate not enabled")
+
+// ParseFeatureGates parses a comma separated list of enabled feature gates,
+// such as "new-probes,xdp-v2".
+func ParseFeatureGates(s string) map[string]bool {
+	gates := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			gates[name] = true
+		}
+	}
+	return gates
+}
+
+// checkFeatureGate returns an error wrapping errGatedOff if gate, the feature
+// gate an application is annotated with, isn't enabled on the agent. An
+// application without a gate is always loaded.
+func (r *ReconcilerCommon) checkFeatureGate(gate string) error {
+	if gate == "" || r.FeatureGates[gate] {
+		return nil
+	}
+	return fmt.Errorf("feature gate %s isn't enabled: %w", gate, errGatedOff)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"fmt"
+	"maps"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// nodeGlobalData returns globalData with the first of an application's
+// globalDataOverrides that selects the agent's node merged over it, so the
+// override's values take precedence. globalData isn't modified.
+func (r *ReconcilerCommon) nodeGlobalData(globalData map[string][]byte,
+	overrides []bpfmaniov1alpha1.GlobalDataOverride) (map[string][]byte, error) {
+	for i := range overrides {
+		selected, err := isNodeSelected(&overrides[i].NodeSelector, r.ourNode.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("globalDataOverrides[%d]: %w", i, err)
+		}
+		if !selected {
+			continue
+		}
+		merged := make(map[string][]byte, len(globalData)+len(overrides[i].GlobalData))
+		maps.Copy(merged, globalData)
+		maps.Copy(merged, overrides[i].GlobalData)
+		return merged, nil
+	}
+	return globalData, nil
+}
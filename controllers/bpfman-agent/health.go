@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+const (
+	// DefaultBpfmanCheckTimeout bounds how long a single readiness probe
+	// waits for bpfman to answer.
+	DefaultBpfmanCheckTimeout = 2 * time.Second
+	// DefaultBpfmanCheckTTL is how long the result of a readiness probe is
+	// reused before bpfman is asked again.
+	DefaultBpfmanCheckTTL = 10 * time.Second
+)
+
+// BpfmanChecker is a controller-runtime healthz checker that reports whether
+// the bpfman daemon is reachable. The result of the last probe is cached for
+// TTL so frequent kubelet probes don't hammer the bpfman socket.
+type BpfmanChecker struct {
+	client  gobpfman.BpfmanClient
+	timeout time.Duration
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+// NewBpfmanChecker returns a BpfmanChecker for the given bpfman client.
+func NewBpfmanChecker(client gobpfman.BpfmanClient, timeout, ttl time.Duration) *BpfmanChecker {
+	return &BpfmanChecker{
+		client:  client,
+		timeout: timeout,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// Check pings bpfman with a lightweight List request and returns an error if
+// it can't be reached. It satisfies healthz.Checker.
+func (c *BpfmanChecker) Check(req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !c.lastCheck.IsZero() && now.Sub(c.lastCheck) < c.ttl {
+		return c.lastErr
+	}
+
+	ctx := context.Background()
+	if req != nil {
+		ctx = req.Context()
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	listOnlyBpfmanPrograms := true
+	_, err := c.client.List(ctx, &gobpfman.ListRequest{BpfmanProgramsOnly: &listOnlyBpfmanPrograms})
+	if err != nil {
+		err = fmt.Errorf("bpfman is unreachable: %w", err)
+	}
+
+	c.lastCheck = now
+	c.lastErr = err
+	return err
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// toggleBpfmanClient wraps the fake bpfman client so List can be made to fail
+// on demand.
+type toggleBpfmanClient struct {
+	*agenttestutils.BpfmanClientFake
+	available bool
+	calls     int
+}
+
+func (c *toggleBpfmanClient) List(ctx context.Context, in *gobpfman.ListRequest, opts ...grpc.CallOption) (*gobpfman.ListResponse, error) {
+	c.calls++
+	if !c.available {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return c.BpfmanClientFake.List(ctx, in, opts...)
+}
+
+func TestBpfmanChecker(t *testing.T) {
+	cli := &toggleBpfmanClient{BpfmanClientFake: agenttestutils.NewBpfmanClientFake(), available: true}
+	checker := NewBpfmanChecker(cli, time.Second, 10*time.Second)
+
+	now := time.Unix(1000, 0)
+	checker.now = func() time.Time { return now }
+
+	// bpfman is reachable, so the check passes.
+	require.NoError(t, checker.Check(nil))
+	require.Equal(t, 1, cli.calls)
+
+	// bpfman goes away, but the cached result is still within the TTL.
+	cli.available = false
+	now = now.Add(5 * time.Second)
+	require.NoError(t, checker.Check(nil))
+	require.Equal(t, 1, cli.calls)
+
+	// Once the TTL expires, bpfman is probed again and the check fails.
+	now = now.Add(10 * time.Second)
+	require.Error(t, checker.Check(nil))
+	require.Equal(t, 2, cli.calls)
+
+	// bpfman comes back, and the check recovers after the TTL.
+	cli.available = true
+	now = now.Add(10 * time.Second)
+	require.NoError(t, checker.Check(nil))
+	require.Equal(t, 3, cli.calls)
+}
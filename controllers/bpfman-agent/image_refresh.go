@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// minImageRefreshInterval is the shortest interval at which an image's tag is
+// checked for a new image.
+const minImageRefreshInterval = time.Minute
+
+// resolveImageDigest is overridden in tests.
+var resolveImageDigest = bpfmanagentinternal.ResolveImageDigest
+
+// imageRefresh tracks the checks of an application's image tag.
+type imageRefresh struct {
+	// lastCheck is when the tag was last checked.
+	lastCheck time.Time
+	// newDigest is the digest of a new image the tag referred to on the
+	// last check, which must be seen again before the programs are reloaded.
+	newDigest string
+}
+
+// imageRefreshInterval returns how often the tag of the image in byteCode is
+// checked for a new image, or 0 if it isn't.
+func imageRefreshInterval(byteCode *bpfmaniov1alpha1.ByteCodeSelector) time.Duration {
+	if byteCode.Image == nil || byteCode.Image.RefreshInterval == nil {
+		return 0
+	}
+	return max(byteCode.Image.RefreshInterval.Duration, minImageRefreshInterval)
+}
+
+// shorterInterval returns the shorter of the two intervals, ignoring those
+// that aren't positive.
+func shorterInterval(a, b time.Duration) time.Duration {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// recordImageDigest sets the digest of the image the application's programs
+// were just loaded from, if it has a refresh interval and bpfman didn't
+// report it, so there's something to compare the tag with later.
+func (r *ReconcilerCommon) recordImageDigest(ctx context.Context, byteCode *bpfmaniov1alpha1.ByteCodeSelector,
+	image *gobpfman.BytecodeImage, resolved *bpfmaniov1alpha1.ResolvedBytecode) {
+	if imageRefreshInterval(byteCode) == 0 || resolved == nil || resolved.Digest != "" {
+		return
+	}
+	digest, err := resolveImageDigest(ctx, image)
+	if err != nil {
+		r.Logger.Error(err, "Failed to resolve the digest of the bytecode image", "Image", image.GetUrl())
+		return
+	}
+	resolved.Digest = digest
+}
+
+// refreshImage checks, if the application has an image refresh interval and
+// it's time to, whether the image's tag refers to a different image than the
+// programs were loaded from. Once the same new image has been seen on two
+// checks in a row, it's pulled, and refreshImage returns true so the programs
+// are reloaded from it.
+func (r *ReconcilerCommon) refreshImage(ctx context.Context, rec ApplicationReconciler) bool {
+	interval := imageRefreshInterval(rec.getByteCode())
+	resolved := rec.getResolvedBytecode()
+	if interval == 0 || resolved == nil || resolved.Digest == "" {
+		return false
+	}
+
+	if r.imageRefreshes == nil {
+		r.imageRefreshes = map[string]*imageRefresh{}
+	}
+	refresh, ok := r.imageRefreshes[rec.getAppStateName()]
+	if !ok {
+		r.imageRefreshes[rec.getAppStateName()] = &imageRefresh{lastCheck: time.Now()}
+		return false
+	}
+	if time.Since(refresh.lastCheck) < interval {
+		return false
+	}
+	refresh.lastCheck = time.Now()
+
+	bytecode, err := bpfmanagentinternal.GetBytecode(r.Client, rec.getAppNamespace(), rec.getByteCode())
+	if err != nil {
+		r.Logger.Error(err, "Failed to get the bytecode image to refresh")
+		return false
+	}
+	image := bytecode.GetImage()
+	digest, err := resolveImageDigest(ctx, image)
+	if err != nil {
+		r.Logger.Error(err, "Failed to resolve the digest of the bytecode image", "Image", image.GetUrl())
+		return false
+	}
+	switch digest {
+	case resolved.Digest:
+		refresh.newDigest = ""
+		return false
+	case refresh.newDigest:
+	default:
+		r.Logger.Info("Bytecode image tag refers to a new image, waiting for the next check to reload",
+			"Image", image.GetUrl(), "Digest", digest)
+		refresh.newDigest = digest
+		return false
+	}
+
+	// Pull the new image, since a load would use the one bpfman has already.
+	pullImage := &gobpfman.BytecodeImage{
+		Url:             image.GetUrl(),
+		ImagePullPolicy: 0, // Always
+		Username:        image.Username,
+		Password:        image.Password,
+	}
+	if _, err := r.BpfmanClient.PullBytecode(ctx, &gobpfman.PullBytecodeRequest{Image: pullImage}); err != nil {
+		r.Logger.Error(err, "Failed to pull the new bytecode image", "Image", image.GetUrl())
+		return false
+	}
+	r.Logger.Info("Reloading programs from the new bytecode image", "Image", image.GetUrl(),
+		"Old Digest", resolved.Digest, "New Digest", digest)
+	return true
+}
+
+// forgetImageRefresh stops tracking the checks of the image of the
+// application with the given application state, since its programs have been
+// unloaded. It's called however they're unloaded, including when the
+// application is deleted or its programs are reloaded from a new image.
+func (r *ReconcilerCommon) forgetImageRefresh(appStateName string) {
+	delete(r.imageRefreshes, appStateName)
+}
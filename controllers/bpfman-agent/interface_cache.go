@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultInterfaceCacheTTL is how long the interfaces an interface selector
+// resolves to are reused before the node's interfaces are read again.
+const DefaultInterfaceCacheTTL = 5 * time.Second
+
+// InterfaceCache caches the node interfaces that interface selectors resolve
+// to, so reconciling many XDP, TC and TCX programs doesn't read the node's
+// interfaces over and over. Each agent has its own, shared by its
+// reconcilers, so it only ever holds the interfaces of the agent's node.
+// Entries are reused for the TTL, or until Invalidate is called because
+// interfaces were added or removed. It's safe for concurrent use. A nil
+// InterfaceCache doesn't cache anything.
+type InterfaceCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]interfaceCacheEntry
+}
+
+type interfaceCacheEntry struct {
+	interfaces []string
+	expires    time.Time
+}
+
+// NewInterfaceCache returns an empty InterfaceCache whose entries are reused
+// for ttl.
+func NewInterfaceCache(ttl time.Duration) *InterfaceCache {
+	return &InterfaceCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]interfaceCacheEntry{},
+	}
+}
+
+// get returns the interfaces cached for key, or calls lookup and caches what
+// it returns if they're missing or have expired. Errors aren't cached, so a
+// failed lookup is tried again next time.
+func (c *InterfaceCache) get(key string, lookup func() ([]string, error)) ([]string, error) {
+	if c == nil {
+		return lookup()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expires) {
+		return slices.Clone(entry.interfaces), nil
+	}
+
+	interfaces, err := lookup()
+	if err != nil {
+		delete(c.entries, key)
+		return nil, err
+	}
+	c.entries[key] = interfaceCacheEntry{interfaces: slices.Clone(interfaces), expires: now.Add(c.ttl)}
+	return interfaces, nil
+}
+
+// Invalidate drops everything in the cache. It's called when the node's
+// interfaces change.
+func (c *InterfaceCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
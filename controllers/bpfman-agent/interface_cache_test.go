@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceCache(t *testing.T) {
+	calls := 0
+	nodeInterfaces := []net.Interface{{Index: 1, Name: "lo"}, {Index: 2, Name: "eno1"}}
+	origNetInterfaces := netInterfaces
+	t.Cleanup(func() { netInterfaces = origNetInterfaces })
+	netInterfaces = func() ([]net.Interface, error) {
+		calls++
+		if nodeInterfaces == nil {
+			return nil, fmt.Errorf("netlink error")
+		}
+		return nodeInterfaces, nil
+	}
+
+	node := testutils.NewNode("fake-control-plane")
+	selector := &bpfmaniov1alpha1.InterfaceSelector{InterfaceIndexes: []int32{2}}
+	cache := NewInterfaceCache(5 * time.Second)
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+
+	// Two reconciles within the TTL read the node's interfaces once.
+	for range 2 {
+		interfaces, err := getInterfaces(selector, node, cache)
+		require.NoError(t, err)
+		require.Equal(t, []string{"eno1"}, interfaces)
+		now = now.Add(time.Second)
+	}
+	require.Equal(t, 1, calls)
+
+	// Interfaces named in the selector never need reading.
+	interfaces, err := getInterfaces(&bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}}, node, cache)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth0"}, interfaces)
+	require.Equal(t, 1, calls)
+
+	// They're read again once the TTL expires.
+	nodeInterfaces = []net.Interface{{Index: 1, Name: "lo"}, {Index: 2, Name: "ens3f0"}}
+	now = now.Add(5 * time.Second)
+	interfaces, err = getInterfaces(selector, node, cache)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ens3f0"}, interfaces)
+	require.Equal(t, 2, calls)
+
+	// An interface change invalidates the cache straight away, and errors
+	// aren't cached.
+	nodeInterfaces = nil
+	cache.Invalidate()
+	_, err = getInterfaces(selector, node, cache)
+	require.Error(t, err)
+	nodeInterfaces = []net.Interface{{Index: 2, Name: "eno1"}}
+	interfaces, err = getInterfaces(selector, node, cache)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eno1"}, interfaces)
+	require.Equal(t, 4, calls)
+
+	// Without a cache, every call reads the interfaces.
+	for range 2 {
+		_, err := getInterfaces(selector, node, nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 6, calls)
+}
+
+func TestInterfaceCacheConcurrent(t *testing.T) {
+	cache := NewInterfaceCache(time.Minute)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("key/%d", i%2)
+			interfaces, err := cache.get(key, func() ([]string, error) { return []string{key}, nil })
+			require.NoError(t, err)
+			require.Equal(t, []string{key}, interfaces)
+			cache.Invalidate()
+		}()
+	}
+	wg.Wait()
+}
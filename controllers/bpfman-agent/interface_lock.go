@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"sync"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// InterfaceLocks serializes attaching and detaching XDP, TC and TCX links on
+// each network interface. The ClusterBpfApplication and BpfApplication
+// reconcilers run at the same time, and the kernel and bpfman's dispatchers
+// can race when two programs are attached to the same interface at once.
+// Interfaces are told apart by their network namespace as well as their name.
+// Each agent has its own, shared by its reconcilers. A nil InterfaceLocks
+// doesn't lock anything.
+type InterfaceLocks struct {
+	mu    sync.Mutex
+	locks map[interfaceLockKey]*sync.Mutex
+}
+
+type interfaceLockKey struct {
+	iface string
+	netns string
+}
+
+// NewInterfaceLocks returns an InterfaceLocks with no interfaces locked.
+func NewInterfaceLocks() *InterfaceLocks {
+	return &InterfaceLocks{locks: map[interfaceLockKey]*sync.Mutex{}}
+}
+
+// lock waits until the interface with the given name in the network
+// namespace at netns, or the host's if it's empty, isn't locked, locks it,
+// and returns the function that unlocks it.
+func (l *InterfaceLocks) lock(iface, netns string) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	key := interfaceLockKey{iface: iface, netns: netns}
+	l.mu.Lock()
+	ifaceLock, ok := l.locks[key]
+	if !ok {
+		ifaceLock = &sync.Mutex{}
+		l.locks[key] = ifaceLock
+	}
+	l.mu.Unlock()
+
+	ifaceLock.Lock()
+	return ifaceLock.Unlock
+}
+
+// lockAttachRequest locks the interface the attach request is for, and
+// returns the function that unlocks it. Requests for programs that aren't
+// attached to an interface don't lock anything.
+func (l *InterfaceLocks) lockAttachRequest(req *gobpfman.AttachRequest) func() {
+	attach := req.GetAttach()
+	switch {
+	case attach.GetXdpAttachInfo() != nil:
+		return l.lock(attach.GetXdpAttachInfo().GetIface(), attach.GetXdpAttachInfo().GetNetns())
+	case attach.GetTcAttachInfo() != nil:
+		return l.lock(attach.GetTcAttachInfo().GetIface(), attach.GetTcAttachInfo().GetNetns())
+	case attach.GetTcxAttachInfo() != nil:
+		return l.lock(attach.GetTcxAttachInfo().GetIface(), attach.GetTcxAttachInfo().GetNetns())
+	default:
+		return func() {}
+	}
+}
+
+// lockLinkInterface locks the interface that rec's current link is attached
+// to, if it has one, and returns the function that unlocks it.
+func (r *ReconcilerCommon) lockLinkInterface(rec ProgramReconciler) func() {
+	if r.InterfaceLocks == nil {
+		return func() {}
+	}
+	return r.InterfaceLocks.lockAttachRequest(rec.getAttachRequest())
+}
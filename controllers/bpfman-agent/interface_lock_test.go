@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func xdpAttachRequest(iface, netns string) *gobpfman.AttachRequest {
+	attachInfo := &gobpfman.XDPAttachInfo{Iface: iface}
+	if netns != "" {
+		attachInfo.Netns = &netns
+	}
+	return &gobpfman.AttachRequest{
+		Attach: &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_XdpAttachInfo{XdpAttachInfo: attachInfo}},
+	}
+}
+
+// Concurrent attaches to the same interface run one at a time.
+func TestInterfaceLocksSerializeAttaches(t *testing.T) {
+	locks := NewInterfaceLocks()
+	var active, maxActive atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var req *gobpfman.AttachRequest
+			if i%2 == 0 {
+				req = xdpAttachRequest("eth0", "")
+			} else {
+				req = &gobpfman.AttachRequest{Attach: &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TcxAttachInfo{
+					TcxAttachInfo: &gobpfman.TCXAttachInfo{Iface: "eth0", Direction: "ingress"},
+				}}}
+			}
+			unlock := locks.lockAttachRequest(req)
+			defer unlock()
+			n := active.Add(1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			active.Add(-1)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), maxActive.Load())
+}
+
+// Interfaces with the same name in different network namespaces, and
+// programs that aren't attached to an interface, aren't held up by a locked
+// interface.
+func TestInterfaceLocksKeys(t *testing.T) {
+	locks := NewInterfaceLocks()
+	unlock := locks.lockAttachRequest(xdpAttachRequest("eth0", ""))
+	defer unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		locks.lockAttachRequest(xdpAttachRequest("eth0", "/var/run/netns/pod1"))()
+		locks.lockAttachRequest(xdpAttachRequest("eth1", ""))()
+		locks.lockAttachRequest(&gobpfman.AttachRequest{Attach: &gobpfman.AttachInfo{
+			Info: &gobpfman.AttachInfo_KprobeAttachInfo{KprobeAttachInfo: &gobpfman.KprobeAttachInfo{FnName: "try_to_wake_up"}},
+		}})()
+		var nilLocks *InterfaceLocks
+		nilLocks.lockAttachRequest(xdpAttachRequest("eth0", ""))()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock on another interface was held up")
+	}
+}
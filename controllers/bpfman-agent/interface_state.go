@@ -0,0 +1,164 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// interfaceDownRetryInterval is how often an application with XDP links
+// waiting for their interface to come up is reconciled again.
+const interfaceDownRetryInterval = 10 * time.Second
+
+// errInterfaceDown is returned when an XDP program isn't attached because its
+// interface is down or doesn't exist.
+var errInterfaceDown = errors.New("interface is not up")
+
+// lookupInterface returns the attributes of the named interface in the
+// network namespace at netnsPath, or the agent's namespace if netnsPath is
+// empty. It's a variable so tests can replace it.
+var lookupInterface = func(name, netnsPath string) (*netlink.LinkAttrs, error) {
+	link, err := linkByName(name, netnsPath)
+	if err != nil {
+		return nil, err
+	}
+	return link.Attrs(), nil
+}
+
+// linkByName returns the named interface in the network namespace at
+// netnsPath, or the agent's namespace if netnsPath is empty.
+func linkByName(name, netnsPath string) (netlink.Link, error) {
+	if netnsPath == "" {
+		return netlink.LinkByName(name)
+	}
+
+	h, err := netlinkHandleAt(netnsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+	return h.LinkByName(name)
+}
+
+// netlinkHandleAt returns a netlink handle for the network namespace at
+// netnsPath, or the agent's namespace if netnsPath is empty. The caller must
+// close it.
+func netlinkHandleAt(netnsPath string) (*netlink.Handle, error) {
+	if netnsPath == "" {
+		return netlink.NewHandle()
+	}
+
+	ns, err := netns.GetFromPath(netnsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network namespace %s: %w", netnsPath, err)
+	}
+	defer ns.Close()
+	h, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netlink socket in %s: %w", netnsPath, err)
+	}
+	return h, nil
+}
+
+// interfaceHookAttached returns whether the named interface in the network
+// namespace at netnsPath still has the hook that bpfman attaches programs
+// through: an XDP program, or for TC, a clsact qdisc. Either is lost if the
+// interface is deleted and created again. It's a variable so tests can
+// replace it.
+var interfaceHookAttached = func(name, netnsPath string, tc bool) (bool, error) {
+	h, err := netlinkHandleAt(netnsPath)
+	if err != nil {
+		return false, err
+	}
+	defer h.Close()
+	link, err := h.LinkByName(name)
+	if err != nil {
+		return false, err
+	}
+
+	if !tc {
+		xdp := link.Attrs().Xdp
+		return xdp != nil && xdp.Attached, nil
+	}
+	qdiscs, err := h.QdiscList(link)
+	if err != nil {
+		return false, fmt.Errorf("failed to list qdiscs on %s: %w", name, err)
+	}
+	for _, qdisc := range qdiscs {
+		if qdisc.Type() == "clsact" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkInterfaceUp returns an error wrapping errInterfaceDown if the
+// interface an XDP program is about to be attached to doesn't exist, is
+// administratively down or has no carrier. The error includes the MTU, since
+// an MTU that's too large for the driver is the other common reason for XDP
+// attach failures.
+func checkInterfaceUp(name, netnsPath string) error {
+	attrs, err := lookupInterface(name, netnsPath)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("interface %s not found: %w", name, errInterfaceDown)
+		}
+		return fmt.Errorf("failed to look up interface %s: %w", name, err)
+	}
+
+	if attrs.Flags&net.FlagUp == 0 {
+		return fmt.Errorf("interface %s (mtu %d) is administratively down: %w", name, attrs.MTU, errInterfaceDown)
+	}
+	// Virtual interfaces such as loopback and tun often report an unknown
+	// operational state while working fine.
+	if attrs.OperState != netlink.OperUp && attrs.OperState != netlink.OperUnknown {
+		return fmt.Errorf("interface %s (mtu %d) is operationally %s: %w", name, attrs.MTU, attrs.OperState, errInterfaceDown)
+	}
+	return nil
+}
+
+// checkXdpInterface is called before an XDP link is attached, and checks that
+// its interface is up so that attaching to a down interface gives a clear
+// error rather than whatever the kernel reports. If DeferAttachOnInterfaceDown
+// is set, the link's status is set to ApAttachDeferred instead of
+// ApAttachError so the attach is retried once the interface comes up.
+func (r *ReconcilerCommon) checkXdpInterface(ctx context.Context, rec ProgramReconciler, name, netnsPath string) error {
+	if r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+	err := checkInterfaceUp(name, netnsPath)
+	if err == nil {
+		return nil
+	}
+	if r.DeferAttachOnInterfaceDown && errors.Is(err, errInterfaceDown) {
+		r.Logger.Info("Deferring XDP attach until the interface is up", "reason", err.Error())
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachDeferred)
+	} else {
+		r.Logger.Error(err, "Not attaching XDP program")
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApAttachError)
+	}
+	return err
+}
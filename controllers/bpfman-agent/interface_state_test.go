@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+)
+
+// fakeInterfaces replaces lookupInterface for the duration of the test so it
+// finds the given interfaces, whatever the network namespace. The interfaces
+// can be changed by the test while it runs.
+func fakeInterfaces(t *testing.T, ifaces ...*netlink.LinkAttrs) {
+	byName := map[string]*netlink.LinkAttrs{}
+	for _, iface := range ifaces {
+		byName[iface.Name] = iface
+	}
+	orig := lookupInterface
+	lookupInterface = func(name, _ string) (*netlink.LinkAttrs, error) {
+		if iface, ok := byName[name]; ok {
+			return iface, nil
+		}
+		return nil, netlink.LinkNotFoundError{}
+	}
+	t.Cleanup(func() { lookupInterface = orig })
+}
+
+func upInterface(name string) *netlink.LinkAttrs {
+	return &netlink.LinkAttrs{Name: name, MTU: 1500, Flags: net.FlagUp, OperState: netlink.OperUp}
+}
+
+func TestCheckInterfaceUp(t *testing.T) {
+	fakeInterfaces(t,
+		upInterface("eth0"),
+		&netlink.LinkAttrs{Name: "lo", MTU: 65536, Flags: net.FlagUp, OperState: netlink.OperUnknown},
+		&netlink.LinkAttrs{Name: "eth1", MTU: 9000, OperState: netlink.OperDown},
+		&netlink.LinkAttrs{Name: "eth2", MTU: 1500, Flags: net.FlagUp, OperState: netlink.OperLowerLayerDown},
+	)
+
+	require.NoError(t, checkInterfaceUp("eth0", ""))
+	require.NoError(t, checkInterfaceUp("lo", ""))
+
+	err := checkInterfaceUp("eth1", "")
+	require.ErrorIs(t, err, errInterfaceDown)
+	require.ErrorContains(t, err, "interface eth1 (mtu 9000) is administratively down")
+
+	err = checkInterfaceUp("eth2", "/host/proc/1/ns/net")
+	require.ErrorIs(t, err, errInterfaceDown)
+	require.ErrorContains(t, err, "interface eth2 (mtu 1500) is operationally lower-layer-down")
+
+	err = checkInterfaceUp("eth3", "")
+	require.ErrorIs(t, err, errInterfaceDown)
+	require.ErrorContains(t, err, "interface eth3 not found")
+
+	lookupInterface = func(string, string) (*netlink.LinkAttrs, error) {
+		return nil, errors.New("permission denied")
+	}
+	err = checkInterfaceUp("eth0", "")
+	require.ErrorContains(t, err, "failed to look up interface eth0: permission denied")
+	require.NotErrorIs(t, err, errInterfaceDown)
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// bpfman doesn't provide a way to update map entries or to choose where maps
+// are pinned, so tail call maps are updated, and maps are pinned in an
+// application's mapPinPath, directly with the bpf() syscall, using the maps
+// that bpfman pins.
+//
+// Pointers in the bpf_attr structs are held as unsafe.Pointer rather than as
+// integers, so the memory they point to is kept alive and in place while the
+// syscall uses it. The kernel's pointer fields are 64 bits, which is the
+// size of an unsafe.Pointer on the 64 bit platforms the agent is built for.
+
+// bpfObjGetAttr is the BPF_OBJ_GET and BPF_OBJ_PIN variant of union bpf_attr.
+type bpfObjGetAttr struct {
+	pathname  unsafe.Pointer
+	bpfFd     uint32
+	fileFlags uint32
+}
+
+// bpfGetFdByIdAttr is the BPF_PROG_GET_FD_BY_ID variant of union bpf_attr.
+type bpfGetFdByIdAttr struct {
+	id        uint32
+	nextId    uint32
+	openFlags uint32
+}
+
+// bpfObjInfoAttr is the BPF_OBJ_GET_INFO_BY_FD variant of union bpf_attr.
+type bpfObjInfoAttr struct {
+	bpfFd   uint32
+	infoLen uint32
+	info    unsafe.Pointer
+}
+
+// bpfMapInfo is the start of struct bpf_map_info. The kernel only fills in
+// as much of it as it's given.
+type bpfMapInfo struct {
+	mapType uint32
+	id      uint32
+}
+
+// bpfMapElemAttr is the BPF_MAP_UPDATE_ELEM variant of union bpf_attr.
+type bpfMapElemAttr struct {
+	mapFd uint32
+	_     uint32
+	key   unsafe.Pointer
+	value unsafe.Pointer
+	flags uint64
+}
+
+func bpfSyscall(cmd int, attr unsafe.Pointer, size uintptr) (int, error) {
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, uintptr(cmd), uintptr(attr), size)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// UpdateProgArray sets the entry at index in the BPF_MAP_TYPE_PROG_ARRAY map
+// pinned at mapPath to the program with the given kernel id.
+func UpdateProgArray(mapPath string, index uint32, progId uint32) error {
+	mapFd, err := objGet(mapPath)
+	if err != nil {
+		return fmt.Errorf("failed to open map %s: %w", mapPath, err)
+	}
+	defer unix.Close(mapFd)
+
+	idAttr := bpfGetFdByIdAttr{id: progId}
+	progFd, err := bpfSyscall(unix.BPF_PROG_GET_FD_BY_ID, unsafe.Pointer(&idAttr), unsafe.Sizeof(idAttr))
+	if err != nil {
+		return fmt.Errorf("failed to open program %d: %w", progId, err)
+	}
+	defer unix.Close(progFd)
+
+	value := uint32(progFd)
+	elemAttr := bpfMapElemAttr{
+		mapFd: uint32(mapFd),
+		key:   unsafe.Pointer(&index),
+		value: unsafe.Pointer(&value),
+		flags: unix.BPF_ANY,
+	}
+	_, err = bpfSyscall(unix.BPF_MAP_UPDATE_ELEM, unsafe.Pointer(&elemAttr), unsafe.Sizeof(elemAttr))
+	runtime.KeepAlive(&elemAttr)
+	if err != nil {
+		return fmt.Errorf("failed to set index %d of map %s to program %d: %w", index, mapPath, progId, err)
+	}
+	return nil
+}
+
+// objGet opens the eBPF object pinned at path.
+func objGet(path string) (int, error) {
+	pathPtr, err := unix.BytePtrFromString(path)
+	if err != nil {
+		return -1, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	attr := bpfObjGetAttr{pathname: unsafe.Pointer(pathPtr)}
+	fd, err := bpfSyscall(unix.BPF_OBJ_GET, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(&attr)
+	return fd, err
+}
+
+// objPin pins the eBPF object fd at path.
+func objPin(fd int, path string) error {
+	pathPtr, err := unix.BytePtrFromString(path)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	attr := bpfObjGetAttr{pathname: unsafe.Pointer(pathPtr), bpfFd: uint32(fd)}
+	_, err = bpfSyscall(unix.BPF_OBJ_PIN, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(&attr)
+	return err
+}
+
+// mapId returns the kernel id of the map fd.
+func mapId(fd int) (uint32, error) {
+	info := bpfMapInfo{}
+	attr := bpfObjInfoAttr{
+		bpfFd:   uint32(fd),
+		infoLen: uint32(unsafe.Sizeof(info)),
+		info:    unsafe.Pointer(&info),
+	}
+	_, err := bpfSyscall(unix.BPF_OBJ_GET_INFO_BY_FD, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(&attr)
+	return info.id, err
+}
+
+// PinMaps pins each of the maps pinned in srcDir again in dstDir, under the
+// same name. A pin in dstDir that's already of the same map is left as it is,
+// but an error is returned if one is of a different map, since that pin isn't
+// the agent's to replace.
+func PinMaps(srcDir, dstDir string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return fmt.Errorf("failed to list maps: %w", err)
+	}
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dstDir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := pinMap(filepath.Join(srcDir, entry.Name()), filepath.Join(dstDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pinMap(src, dst string) error {
+	fd, err := objGet(src)
+	if err != nil {
+		return fmt.Errorf("failed to open map %s: %w", src, err)
+	}
+	defer unix.Close(fd)
+	id, err := mapId(fd)
+	if err != nil {
+		return fmt.Errorf("failed to get id of map %s: %w", src, err)
+	}
+
+	pinned, err := pinnedMapId(dst)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("%s is in the way of pinning map %s: %w", dst, src, err)
+	case pinned == id:
+		return nil
+	default:
+		return fmt.Errorf("%s is already a pin of another map, not of map %s", dst, src)
+	}
+	if err := objPin(fd, dst); err != nil {
+		return fmt.Errorf("failed to pin map %s at %s: %w", src, dst, err)
+	}
+	return nil
+}
+
+// UnpinMaps removes the pins in dstDir that PinMaps made of the maps pinned
+// in srcDir, which are the pins with the same name that are of the same map.
+// Anything else in dstDir is left alone, and dstDir is only removed if that
+// leaves it empty.
+func UnpinMaps(srcDir, dstDir string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return fmt.Errorf("failed to list maps: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id, err := pinnedMapId(filepath.Join(srcDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to get id of map %s: %w", entry.Name(), err)
+		}
+		dst := filepath.Join(dstDir, entry.Name())
+		if pinned, err := pinnedMapId(dst); err != nil || pinned != id {
+			continue
+		}
+		if err := os.Remove(dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove pin %s: %w", dst, err)
+		}
+	}
+	if err := os.Remove(dstDir); err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, unix.ENOTEMPTY) {
+		return fmt.Errorf("failed to remove %s: %w", dstDir, err)
+	}
+	return nil
+}
+
+// pinnedMapId returns the kernel id of the map pinned at path. The error
+// wraps os.ErrNotExist if nothing is pinned there.
+func pinnedMapId(path string) (uint32, error) {
+	fd, err := objGet(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unix.Close(fd)
+	return mapId(fd)
+}
@@ -19,6 +19,7 @@ package internal
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
 	"github.com/bpfman/bpfman-operator/internal"
@@ -45,7 +46,10 @@ func imagePullPolicyConversion(policy bpfmaniov1alpha1.PullPolicy) int32 {
 	}
 }
 
-func GetBytecode(c client.Client, b *bpfmaniov1alpha1.ByteCodeSelector) (*gobpfman.BytecodeLocation, error) {
+// GetBytecode returns the location bpfman should load the bytecode selected by
+// b from. namespace is the application's namespace, or empty for a
+// ClusterBpfApplication; a BpfApplication's configMapRef must be in it.
+func GetBytecode(c client.Client, namespace string, b *bpfmaniov1alpha1.ByteCodeSelector) (*gobpfman.BytecodeLocation, error) {
 	if b.Image != nil {
 		bytecodeImage := b.Image
 
@@ -86,6 +90,16 @@ func GetBytecode(c client.Client, b *bpfmaniov1alpha1.ByteCodeSelector) (*gobpfm
 				Password:        &password,
 			}},
 		}, nil
+	} else if b.ConfigMapRef != nil {
+		// bpfman can only load bytecode from an image or a file, so write the
+		// bytecode to a file. The caller must remove it with CleanupBytecode.
+		path, err := writeConfigMapBytecode(c, namespace, b.ConfigMapRef)
+		if err != nil {
+			return nil, err
+		}
+		return &gobpfman.BytecodeLocation{
+			Location: &gobpfman.BytecodeLocation_File{File: path},
+		}, nil
 	} else {
 		return &gobpfman.BytecodeLocation{
 			Location: &gobpfman.BytecodeLocation_File{File: *b.Path},
@@ -99,7 +113,7 @@ func LoadBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient,
 
 	res, err := bpfmanClient.Load(ctx, loadRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load bpfProgram via bpfman: %w", err)
+		return nil, fmt.Errorf("failed to load bpfProgram via bpfman: %w", bpfmanError(err))
 	}
 
 	return res.Programs, nil
@@ -114,8 +128,8 @@ func buildBpfmanUnloadRequest(id uint32) *gobpfman.UnloadRequest {
 func UnloadBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, id uint32) error {
 	_, err := bpfmanClient.Unload(ctx, buildBpfmanUnloadRequest(id))
 	if err != nil {
-		return fmt.Errorf("failed to unload bpfProgram via bpfman: %v",
-			err)
+		return fmt.Errorf("failed to unload bpfProgram via bpfman: %w",
+			bpfmanError(err))
 	}
 	return nil
 }
@@ -126,7 +140,7 @@ func AttachBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient
 
 	res, err := bpfmanClient.Attach(ctx, attachRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to attach bpfProgram via bpfman: %w", err)
+		return nil, fmt.Errorf("failed to attach bpfProgram via bpfman: %w", bpfmanError(err))
 	}
 
 	return &res.LinkId, nil
@@ -141,13 +155,18 @@ func buildBpfmanDetachRequest(id uint32) *gobpfman.DetachRequest {
 func DetachBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, id uint32) error {
 	_, err := bpfmanClient.Detach(ctx, buildBpfmanDetachRequest(id))
 	if err != nil {
-		return fmt.Errorf("failed to unload bpfProgram via bpfman: %v",
-			err)
+		return fmt.Errorf("failed to detach bpfProgram via bpfman: %w",
+			bpfmanError(err))
 	}
 	return nil
 }
 
-func ListBpfmanPrograms(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, programType internal.ProgramType) (map[string]*gobpfman.ListResponse_ListResult, error) {
+// ListBpfmanPrograms returns the programs of the given type that bpfman
+// loaded, by the value of their uuidKey metadata. Programs without it were
+// loaded by someone else, such as the agent of another operator sharing
+// bpfman, and are left out.
+func ListBpfmanPrograms(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, programType internal.ProgramType,
+	uuidKey string) (map[string]*gobpfman.ListResponse_ListResult, error) {
 	listOnlyBpfmanPrograms := true
 	listReq := gobpfman.ListRequest{
 		BpfmanProgramsOnly: &listOnlyBpfmanPrograms,
@@ -160,17 +179,15 @@ func ListBpfmanPrograms(ctx context.Context, bpfmanClient gobpfman.BpfmanClient,
 
 	listResponse, err := bpfmanClient.List(ctx, &listReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
 	}
 
 	for _, result := range listResponse.Results {
 		info := result.GetInfo()
 		if info != nil {
 			metadata := info.GetMetadata()
-			if uuid, ok := metadata[internal.UuidMetadataKey]; ok {
+			if uuid, ok := metadata[uuidKey]; ok {
 				out[uuid] = result
-			} else {
-				return nil, fmt.Errorf("unable to get uuid from program metadata")
 			}
 		}
 	}
@@ -185,24 +202,25 @@ func GetBpfmanProgramById(ctx context.Context, bpfmanClient gobpfman.BpfmanClien
 
 	getResponse, err := bpfmanClient.Get(ctx, &getReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get bpfProgram %d via bpfman: %w", id, bpfmanError(err))
 	}
 
 	return getResponse, nil
 }
 
-func GetBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, uuid types.UID) (*gobpfman.ListResponse_ListResult, error) {
+func GetBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, uuidKey string,
+	uuid types.UID) (*gobpfman.ListResponse_ListResult, error) {
 	listReq := gobpfman.ListRequest{
-		MatchMetadata: map[string]string{internal.UuidMetadataKey: string(uuid)},
+		MatchMetadata: map[string]string{uuidKey: string(uuid)},
 	}
 
 	listResponse, err := bpfmanClient.List(ctx, &listReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
 	}
 
 	if len(listResponse.Results) == 0 {
-		return nil, fmt.Errorf("unable to find program for uuid: %+v ", uuid)
+		return nil, fmt.Errorf("unable to find program for uuid: %+v: %w", uuid, ErrProgramNotFound)
 	} else if len(listResponse.Results) != 1 {
 		return nil, fmt.Errorf("multiple programs found for uuid: %+v instances: %d", uuid, len(listResponse.Results))
 	}
@@ -213,13 +231,105 @@ func GetBpfmanProgram(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, u
 func ListAllPrograms(ctx context.Context, bpfmanClient gobpfman.BpfmanClient) ([]*gobpfman.ListResponse_ListResult, error) {
 	listResponse, err := bpfmanClient.List(ctx, &gobpfman.ListRequest{})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
 	}
 
 	return listResponse.Results, nil
 }
 
-func ListBpfmanAttachments(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, programType internal.ProgramType) (map[string]*gobpfman.ListResponse_ListResult, error) {
+// GetKernelProgramName returns the kernel name of the program of the given
+// type and ID, whether or not bpfman loaded it, or "" if there isn't one.
+func GetKernelProgramName(ctx context.Context, bpfmanClient gobpfman.BpfmanClient,
+	progType internal.ProgramType, id uint32) (string, error) {
+	listOnlyBpfmanPrograms := false
+	listResponse, err := bpfmanClient.List(ctx, &gobpfman.ListRequest{
+		ProgramType:        progType.Uint32(),
+		BpfmanProgramsOnly: &listOnlyBpfmanPrograms,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
+	}
+
+	for _, result := range listResponse.Results {
+		if result.KernelInfo != nil && result.KernelInfo.Id == id {
+			return result.KernelInfo.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// FindBpfmanProgramsToAdopt finds the programs already loaded by bpfman that
+// an application with the given uuid adopts instead of loading its bytecode.
+// Each name must match exactly one loaded program. Programs whose uuidKey
+// metadata shows they were loaded for another application are ignored.
+func FindBpfmanProgramsToAdopt(ctx context.Context, bpfmanClient gobpfman.BpfmanClient,
+	uuidKey string, uuid types.UID, names []string) ([]*gobpfman.LoadResponseInfo, error) {
+	listOnlyBpfmanPrograms := true
+	listResponse, err := bpfmanClient.List(ctx, &gobpfman.ListRequest{
+		BpfmanProgramsOnly: &listOnlyBpfmanPrograms,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
+	}
+
+	programs := []*gobpfman.LoadResponseInfo{}
+	for _, name := range names {
+		var found *gobpfman.ListResponse_ListResult
+		for _, result := range listResponse.Results {
+			if result.Info == nil || result.Info.Name != name {
+				continue
+			}
+			if owner, ok := result.Info.Metadata[uuidKey]; ok && owner != string(uuid) {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("multiple loaded programs named %q, unable to choose one to adopt", name)
+			}
+			found = result
+		}
+		if found == nil {
+			return nil, fmt.Errorf("no loaded program named %q to adopt: %w", name, ErrProgramNotFound)
+		}
+		programs = append(programs, &gobpfman.LoadResponseInfo{
+			Info:       found.Info,
+			KernelInfo: found.KernelInfo,
+		})
+	}
+
+	return programs, nil
+}
+
+// FindBpfmanProgramsByOwner finds the programs with the given names that
+// bpfman has loaded for the application with the given uuid, as recorded in
+// their uuidKey metadata. Names that aren't loaded are left out of the result.
+func FindBpfmanProgramsByOwner(ctx context.Context, bpfmanClient gobpfman.BpfmanClient,
+	uuidKey, uuid string, names []string) ([]*gobpfman.LoadResponseInfo, error) {
+	listOnlyBpfmanPrograms := true
+	listResponse, err := bpfmanClient.List(ctx, &gobpfman.ListRequest{
+		BpfmanProgramsOnly: &listOnlyBpfmanPrograms,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
+	}
+
+	programs := []*gobpfman.LoadResponseInfo{}
+	for _, result := range listResponse.Results {
+		if result.Info == nil || result.Info.Metadata[uuidKey] != uuid ||
+			!slices.Contains(names, result.Info.Name) {
+			continue
+		}
+		programs = append(programs, &gobpfman.LoadResponseInfo{
+			Info:       result.Info,
+			KernelInfo: result.KernelInfo,
+		})
+	}
+	return programs, nil
+}
+
+// ListBpfmanAttachments is like ListBpfmanPrograms. It's used to find the
+// attach points of the applications bpfman has loaded.
+func ListBpfmanAttachments(ctx context.Context, bpfmanClient gobpfman.BpfmanClient, programType internal.ProgramType,
+	uuidKey string) (map[string]*gobpfman.ListResponse_ListResult, error) {
 	listOnlyBpfmanPrograms := true
 	listReq := gobpfman.ListRequest{
 		BpfmanProgramsOnly: &listOnlyBpfmanPrograms,
@@ -232,17 +342,15 @@ func ListBpfmanAttachments(ctx context.Context, bpfmanClient gobpfman.BpfmanClie
 
 	listResponse, err := bpfmanClient.List(ctx, &listReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to list bpfPrograms via bpfman: %w", bpfmanError(err))
 	}
 
 	for _, result := range listResponse.Results {
 		info := result.GetInfo()
 		if info != nil {
 			metadata := info.GetMetadata()
-			if uuid, ok := metadata[internal.UuidMetadataKey]; ok {
+			if uuid, ok := metadata[uuidKey]; ok {
 				out[uuid] = result
-			} else {
-				return nil, fmt.Errorf("unable to get uuid from program metadata")
 			}
 		}
 	}
@@ -273,6 +381,60 @@ func Build_kernel_info_annotations(p *gobpfman.ListResponse_ListResult) map[stri
 	return nil
 }
 
+// GetResolvedBytecode returns the bytecode source that was actually used for a
+// load.  bpfman reports the bytecode location for each loaded program, which
+// is preferred since, for images, it may identify the image by digest.  If it
+// doesn't, the location from the LoadRequest is used instead.
+func GetResolvedBytecode(requested *gobpfman.BytecodeLocation,
+	programs []*gobpfman.LoadResponseInfo) *bpfmaniov1alpha1.ResolvedBytecode {
+	location := requested
+	for _, program := range programs {
+		if program.GetInfo().GetBytecode() != nil {
+			location = program.Info.Bytecode
+			break
+		}
+	}
+
+	switch {
+	case location.GetImage() != nil:
+		resolved := &bpfmaniov1alpha1.ResolvedBytecode{Image: location.GetImage().Url}
+		if ref, err := reference.ParseNamed(resolved.Image); err == nil {
+			if digested, ok := ref.(reference.Digested); ok {
+				resolved.Digest = digested.Digest().String()
+			}
+		}
+		return resolved
+	case location.GetFile() != "":
+		return &bpfmaniov1alpha1.ResolvedBytecode{Path: location.GetFile()}
+	default:
+		return nil
+	}
+}
+
+// GetProgramKernelInfo returns the kernel's accounting for a program from the
+// kernel info bpfman reports for it, or nil if there isn't any.
+func GetProgramKernelInfo(kernelInfo *gobpfman.KernelProgramInfo) *bpfmaniov1alpha1.ProgramKernelInfo {
+	if kernelInfo == nil {
+		return nil
+	}
+	return &bpfmaniov1alpha1.ProgramKernelInfo{
+		MemlockBytes:   kernelInfo.GetBytesMemlock(),
+		JitedSizeBytes: kernelInfo.GetBytesJited(),
+		MapIds:         kernelInfo.GetMapIds(),
+	}
+}
+
+// GetBpfProgramKernelInfo returns the kernel's accounting for the program with
+// a given name, or nil if there isn't one.
+func GetBpfProgramKernelInfo(name string, programs []*gobpfman.LoadResponseInfo) *bpfmaniov1alpha1.ProgramKernelInfo {
+	for _, program := range programs {
+		if program.Info.Name == name {
+			return GetProgramKernelInfo(program.KernelInfo)
+		}
+	}
+	return nil
+}
+
 // GetId returns the id of a program with a given name
 func GetBpfProgramId(name string, programs []*gobpfman.LoadResponseInfo) (*uint32, error) {
 	for _, program := range programs {
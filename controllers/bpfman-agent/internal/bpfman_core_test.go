@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestGetResolvedBytecode(t *testing.T) {
+	const (
+		tagged   = "quay.io/bpfman-bytecode/go-xdp-counter:latest"
+		digest   = "sha256:4f6d4f5b3e1a4a8e1b9e2c3d5f7a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a"
+		digested = "quay.io/bpfman-bytecode/go-xdp-counter@" + digest
+	)
+
+	image := func(url string) *gobpfman.BytecodeLocation {
+		return &gobpfman.BytecodeLocation{
+			Location: &gobpfman.BytecodeLocation_Image{Image: &gobpfman.BytecodeImage{Url: url}},
+		}
+	}
+	loaded := func(loc *gobpfman.BytecodeLocation) []*gobpfman.LoadResponseInfo {
+		return []*gobpfman.LoadResponseInfo{{Info: &gobpfman.ProgramInfo{Name: "prog", Bytecode: loc}}}
+	}
+
+	tests := []struct {
+		name      string
+		requested *gobpfman.BytecodeLocation
+		programs  []*gobpfman.LoadResponseInfo
+		expected  *bpfmaniov1alpha1.ResolvedBytecode
+	}{
+		{
+			name:      "file",
+			requested: &gobpfman.BytecodeLocation{Location: &gobpfman.BytecodeLocation_File{File: "/tmp/hello.o"}},
+			programs:  loaded(nil),
+			expected:  &bpfmaniov1alpha1.ResolvedBytecode{Path: "/tmp/hello.o"},
+		},
+		{
+			name:      "image by tag without digest from bpfman",
+			requested: image(tagged),
+			programs:  loaded(nil),
+			expected:  &bpfmaniov1alpha1.ResolvedBytecode{Image: tagged},
+		},
+		{
+			name:      "image by tag with digest from bpfman",
+			requested: image(tagged),
+			programs:  loaded(image(digested)),
+			expected:  &bpfmaniov1alpha1.ResolvedBytecode{Image: digested, Digest: digest},
+		},
+		{
+			name:      "image by digest",
+			requested: image(digested),
+			programs:  loaded(nil),
+			expected:  &bpfmaniov1alpha1.ResolvedBytecode{Image: digested, Digest: digest},
+		},
+		{
+			name:      "no location",
+			requested: nil,
+			programs:  nil,
+			expected:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, GetResolvedBytecode(tc.requested, tc.programs))
+		})
+	}
+}
+
+// listBpfmanClient is a bpfman client whose List returns results.
+type listBpfmanClient struct {
+	gobpfman.BpfmanClient
+	results []*gobpfman.ListResponse_ListResult
+}
+
+func (c listBpfmanClient) List(context.Context, *gobpfman.ListRequest, ...grpc.CallOption) (*gobpfman.ListResponse, error) {
+	return &gobpfman.ListResponse{Results: c.results}, nil
+}
+
+func TestListBpfmanProgramsMetadataKeyPrefix(t *testing.T) {
+	teamA := internal.NewMetadataKeys("team-a.bpfman.io")
+	teamB := internal.NewMetadataKeys("team-b.bpfman.io")
+	program := func(uuidKey, uuid string) *gobpfman.ListResponse_ListResult {
+		return &gobpfman.ListResponse_ListResult{
+			Info: &gobpfman.ProgramInfo{Name: "prog", Metadata: map[string]string{uuidKey: uuid}},
+		}
+	}
+	c := listBpfmanClient{results: []*gobpfman.ListResponse_ListResult{
+		program(teamA.Uuid, "app-a"),
+		program(teamB.Uuid, "app-b"),
+		program(internal.NewMetadataKeys("").Uuid, "app-default"),
+	}}
+
+	// Each operator only sees the programs loaded with its own keys.
+	for keys, uuid := range map[internal.MetadataKeys]string{
+		teamA:                        "app-a",
+		teamB:                        "app-b",
+		internal.NewMetadataKeys(""): "app-default",
+	} {
+		programs, err := ListBpfmanPrograms(context.TODO(), c, internal.AllPrograms, keys.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, []string{uuid}, slices.Collect(maps.Keys(programs)))
+
+		attachments, err := ListBpfmanAttachments(context.TODO(), c, internal.AllPrograms, keys.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, []string{uuid}, slices.Collect(maps.Keys(attachments)))
+	}
+}
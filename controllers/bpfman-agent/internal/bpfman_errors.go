@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors returned by the bpfman wrappers, so callers can tell failures apart
+// with errors.Is(). The original gRPC error is also wrapped, so its message
+// and status are preserved.
+var (
+	// ErrProgramNotFound is returned when bpfman doesn't know the requested
+	// program or link.
+	ErrProgramNotFound = errors.New("program not found")
+	// ErrAlreadyLoaded is returned when bpfman already has the program or
+	// link being created.
+	ErrAlreadyLoaded = errors.New("program already loaded")
+	// ErrInvalidRequest is returned when bpfman rejects a request as invalid.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrBpfmanUnavailable is returned when bpfman can't be reached.
+	ErrBpfmanUnavailable = errors.New("bpfman unavailable")
+)
+
+// bpfmanError maps the gRPC status code of an error returned by bpfman to one
+// of the errors above. Errors with other codes are returned unchanged.
+func bpfmanError(err error) error {
+	var sentinel error
+	switch status.Code(err) {
+	case codes.NotFound:
+		sentinel = ErrProgramNotFound
+	case codes.AlreadyExists:
+		sentinel = ErrAlreadyLoaded
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		sentinel = ErrInvalidRequest
+	case codes.Unavailable, codes.DeadlineExceeded:
+		sentinel = ErrBpfmanUnavailable
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errBpfmanClient is a bpfman client whose calls all fail with err.
+type errBpfmanClient struct {
+	gobpfman.BpfmanClient
+	err error
+}
+
+func (c errBpfmanClient) Load(context.Context, *gobpfman.LoadRequest, ...grpc.CallOption) (*gobpfman.LoadResponse, error) {
+	return nil, c.err
+}
+
+func (c errBpfmanClient) Unload(context.Context, *gobpfman.UnloadRequest, ...grpc.CallOption) (*gobpfman.UnloadResponse, error) {
+	return nil, c.err
+}
+
+func (c errBpfmanClient) Attach(context.Context, *gobpfman.AttachRequest, ...grpc.CallOption) (*gobpfman.AttachResponse, error) {
+	return nil, c.err
+}
+
+func (c errBpfmanClient) Detach(context.Context, *gobpfman.DetachRequest, ...grpc.CallOption) (*gobpfman.DetachResponse, error) {
+	return nil, c.err
+}
+
+func (c errBpfmanClient) List(context.Context, *gobpfman.ListRequest, ...grpc.CallOption) (*gobpfman.ListResponse, error) {
+	return nil, c.err
+}
+
+func (c errBpfmanClient) Get(context.Context, *gobpfman.GetRequest, ...grpc.CallOption) (*gobpfman.GetResponse, error) {
+	return nil, c.err
+}
+
+func TestBpfmanErrors(t *testing.T) {
+	ctx := context.TODO()
+
+	calls := map[string]func(gobpfman.BpfmanClient) error{
+		"Load": func(c gobpfman.BpfmanClient) error {
+			_, err := LoadBpfmanProgram(ctx, c, &gobpfman.LoadRequest{})
+			return err
+		},
+		"Unload": func(c gobpfman.BpfmanClient) error {
+			return UnloadBpfmanProgram(ctx, c, 1)
+		},
+		"Attach": func(c gobpfman.BpfmanClient) error {
+			_, err := AttachBpfmanProgram(ctx, c, &gobpfman.AttachRequest{})
+			return err
+		},
+		"Detach": func(c gobpfman.BpfmanClient) error {
+			return DetachBpfmanProgram(ctx, c, 1)
+		},
+		"Get": func(c gobpfman.BpfmanClient) error {
+			_, err := GetBpfmanProgramById(ctx, c, 1)
+			return err
+		},
+		"List": func(c gobpfman.BpfmanClient) error {
+			_, err := ListBpfmanPrograms(ctx, c, internal.Xdp, internal.NewMetadataKeys("").Uuid)
+			return err
+		},
+		"ListAll": func(c gobpfman.BpfmanClient) error {
+			_, err := ListAllPrograms(ctx, c)
+			return err
+		},
+		"ListAttachments": func(c gobpfman.BpfmanClient) error {
+			_, err := ListBpfmanAttachments(ctx, c, internal.Xdp, internal.NewMetadataKeys("").Uuid)
+			return err
+		},
+	}
+
+	sentinels := []error{ErrProgramNotFound, ErrAlreadyLoaded, ErrInvalidRequest, ErrBpfmanUnavailable}
+
+	tests := []struct {
+		code codes.Code
+		want error
+	}{
+		{codes.NotFound, ErrProgramNotFound},
+		{codes.AlreadyExists, ErrAlreadyLoaded},
+		{codes.InvalidArgument, ErrInvalidRequest},
+		{codes.FailedPrecondition, ErrInvalidRequest},
+		{codes.Unavailable, ErrBpfmanUnavailable},
+		{codes.DeadlineExceeded, ErrBpfmanUnavailable},
+		{codes.Internal, nil},
+	}
+
+	for _, tt := range tests {
+		grpcErr := status.Error(tt.code, "bpfman says no")
+		client := errBpfmanClient{err: grpcErr}
+		for name, call := range calls {
+			t.Run(tt.code.String()+"/"+name, func(t *testing.T) {
+				err := call(client)
+				require.Error(t, err)
+				// The original gRPC error must survive the wrapping.
+				require.ErrorIs(t, err, grpcErr)
+				for _, sentinel := range sentinels {
+					if sentinel == tt.want {
+						require.ErrorIs(t, err, sentinel)
+					} else {
+						require.NotErrorIs(t, err, sentinel)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MaxConfigMapBytecodeSize is the largest bytecode object that can be loaded
+// from a ConfigMap. It matches the size limit of a ConfigMap.
+const MaxConfigMapBytecodeSize = 1024 * 1024
+
+// bytecodeDir is where bytecode read from a ConfigMap is written so bpfman can
+// load it. It's a variable so tests can point it elsewhere.
+var bytecodeDir = internal.BytecodeDir
+
+// getConfigMapBytecode reads the bytecode referenced by ref and returns it. If
+// namespace is set, as it is for a BpfApplication, ref must be in that
+// namespace, so the application can't read another namespace's ConfigMaps
+// through the agent.
+func getConfigMapBytecode(c client.Client, namespace string,
+	ref *bpfmaniov1alpha1.ByteCodeConfigMapSelector) ([]byte, error) {
+	if namespace != "" && ref.Namespace != namespace {
+		return nil, fmt.Errorf("bytecode ConfigMap namespace %s isn't the application's namespace %s",
+			ref.Namespace, namespace)
+	}
+
+	cm := &v1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get bytecode ConfigMap %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	tooLarge := func() error {
+		return fmt.Errorf("bytecode in ConfigMap %s/%s key %q exceeds the %d byte limit",
+			ref.Namespace, ref.Name, ref.Key, MaxConfigMapBytecodeSize)
+	}
+
+	if data, ok := cm.BinaryData[ref.Key]; ok {
+		if len(data) > MaxConfigMapBytecodeSize {
+			return nil, tooLarge()
+		}
+		return data, nil
+	}
+
+	encoded, ok := cm.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in bytecode ConfigMap %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+	// Check the encoded size first so an oversized value isn't decoded.
+	encoded = strings.TrimSpace(encoded)
+	if len(encoded) > base64.StdEncoding.EncodedLen(MaxConfigMapBytecodeSize) {
+		return nil, tooLarge()
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode bytecode in ConfigMap %s/%s key %q: %w",
+			ref.Namespace, ref.Name, ref.Key, err)
+	}
+	if len(data) > MaxConfigMapBytecodeSize {
+		return nil, tooLarge()
+	}
+	return data, nil
+}
+
+// writeConfigMapBytecode writes the bytecode referenced by ref to a temporary
+// file in bytecodeDir and returns its path.
+func writeConfigMapBytecode(c client.Client, namespace string,
+	ref *bpfmaniov1alpha1.ByteCodeConfigMapSelector) (string, error) {
+	data, err := getConfigMapBytecode(c, namespace, ref)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(bytecodeDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create bytecode directory: %w", err)
+	}
+	f, err := os.CreateTemp(bytecodeDir, "bytecode-*.o")
+	if err != nil {
+		return "", fmt.Errorf("failed to create bytecode file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write bytecode file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write bytecode file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// CleanupBytecode removes the temporary file that GetBytecode created for
+// bytecode read from a ConfigMap. It does nothing for other bytecode
+// sources. The programs stay loaded once the file is gone, so this is called
+// as soon as the load request is done.
+func CleanupBytecode(b *bpfmaniov1alpha1.ByteCodeSelector, location *gobpfman.BytecodeLocation) {
+	if b.ConfigMapRef == nil || location.GetFile() == "" {
+		return
+	}
+	if err := os.Remove(location.GetFile()); err != nil && !os.IsNotExist(err) {
+		log.Error(err, "failed to remove bytecode file", "path", location.GetFile())
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetBytecodeFromConfigMap(t *testing.T) {
+	bytecode := []byte("\x7fELF fake bytecode")
+	oversized := bytes.Repeat([]byte{0}, MaxConfigMapBytecodeSize+1)
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bytecode", Namespace: "default"},
+		Data: map[string]string{
+			"prog.o":   base64.StdEncoding.EncodeToString(bytecode),
+			"big.o":    base64.StdEncoding.EncodeToString(oversized),
+			"notb64":   "not base64!",
+			"padded":   "\n" + base64.StdEncoding.EncodeToString(bytecode) + "\n",
+			"binary.o": "ignored, binaryData wins",
+		},
+		BinaryData: map[string][]byte{
+			"binary.o":    bytecode,
+			"bigbinary.o": oversized,
+		},
+	}
+	otherCm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bytecode", Namespace: "other"},
+		BinaryData: map[string][]byte{"prog.o": bytecode},
+	}
+	cl := fake.NewClientBuilder().WithObjects(cm, otherCm).Build()
+
+	origBytecodeDir := bytecodeDir
+	bytecodeDir = t.TempDir()
+	t.Cleanup(func() { bytecodeDir = origBytecodeDir })
+
+	selector := func(cmNamespace, name, key string) *bpfmaniov1alpha1.ByteCodeSelector {
+		return &bpfmaniov1alpha1.ByteCodeSelector{
+			ConfigMapRef: &bpfmaniov1alpha1.ByteCodeConfigMapSelector{Name: name, Namespace: cmNamespace, Key: key},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		cmNamespace string
+		cmName      string
+		key         string
+		wantErr     string
+	}{
+		{name: "base64 data", cmName: "bytecode", key: "prog.o"},
+		{name: "base64 data with whitespace", cmName: "bytecode", key: "padded"},
+		{name: "binary data", cmName: "bytecode", key: "binary.o"},
+		{name: "missing key", cmName: "bytecode", key: "missing.o", wantErr: `key "missing.o" not found`},
+		{name: "missing ConfigMap", cmName: "nope", key: "prog.o", wantErr: "failed to get bytecode ConfigMap default/nope"},
+		{name: "oversized data", cmName: "bytecode", key: "big.o", wantErr: "exceeds the 1048576 byte limit"},
+		{name: "oversized binary data", cmName: "bytecode", key: "bigbinary.o", wantErr: "exceeds the 1048576 byte limit"},
+		{name: "invalid base64", cmName: "bytecode", key: "notb64", wantErr: "failed to decode bytecode"},
+		{name: "application's namespace", namespace: "default", cmName: "bytecode", key: "prog.o"},
+		{name: "another namespace for a cluster application", cmNamespace: "other", cmName: "bytecode", key: "prog.o"},
+		{
+			name:        "another namespace for a namespaced application",
+			namespace:   "default",
+			cmNamespace: "other",
+			cmName:      "bytecode",
+			key:         "prog.o",
+			wantErr:     "bytecode ConfigMap namespace other isn't the application's namespace default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmNamespace := tt.cmNamespace
+			if cmNamespace == "" {
+				cmNamespace = "default"
+			}
+			b := selector(cmNamespace, tt.cmName, tt.key)
+			location, err := GetBytecode(cl, tt.namespace, b)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				entries, err := os.ReadDir(bytecodeDir)
+				require.NoError(t, err)
+				require.Empty(t, entries, "no bytecode file should be left behind")
+				return
+			}
+			require.NoError(t, err)
+
+			path := location.GetFile()
+			require.NotEmpty(t, path)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, bytecode, data)
+
+			CleanupBytecode(b, location)
+			_, err = os.Stat(path)
+			require.True(t, os.IsNotExist(err), "bytecode file should be removed")
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrGlobalDataNotFound is returned when a ConfigMap, Secret or key
+// referenced by an application's globalDataFrom doesn't exist.
+var ErrGlobalDataNotFound = errors.New("global data not found")
+
+// GetGlobalData returns the global data for a load request, which is the
+// values read from sources merged with inline. Values in inline take
+// precedence. inline isn't modified. If namespace is set, as it is for a
+// BpfApplication, sources may only refer to ConfigMaps and Secrets in that
+// namespace, so the application can't read another namespace's Secrets
+// through the agent.
+func GetGlobalData(c client.Client, namespace string, inline map[string][]byte,
+	sources []bpfmaniov1alpha1.GlobalDataSource) (map[string][]byte, error) {
+	if len(sources) == 0 {
+		return inline, nil
+	}
+
+	globalData := map[string][]byte{}
+	for _, source := range sources {
+		if _, ok := inline[source.Name]; ok {
+			continue
+		}
+		var (
+			value []byte
+			err   error
+		)
+		switch {
+		case namespace != "" && keySelectorNamespace(source) != namespace:
+			err = fmt.Errorf("namespace %s isn't the application's namespace %s",
+				keySelectorNamespace(source), namespace)
+		case source.ConfigMapKeyRef != nil:
+			value, err = getConfigMapGlobalData(c, source.ConfigMapKeyRef)
+		case source.SecretKeyRef != nil:
+			value, err = getSecretGlobalData(c, source.SecretKeyRef)
+		default:
+			err = errors.New("neither configMapKeyRef nor secretKeyRef is set")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get global data %s: %w", source.Name, err)
+		}
+		globalData[source.Name] = value
+	}
+	for k, v := range inline {
+		globalData[k] = v
+	}
+	return globalData, nil
+}
+
+// keySelectorNamespace returns the namespace of the ConfigMap or Secret
+// source refers to.
+func keySelectorNamespace(source bpfmaniov1alpha1.GlobalDataSource) string {
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		return source.ConfigMapKeyRef.Namespace
+	case source.SecretKeyRef != nil:
+		return source.SecretKeyRef.Namespace
+	default:
+		return ""
+	}
+}
+
+// getConfigMapGlobalData reads the value referenced by ref from a ConfigMap,
+// from binaryData if the key is present there, and otherwise from data as a
+// base64 encoded string.
+func getConfigMapGlobalData(c client.Client, ref *bpfmaniov1alpha1.GlobalDataKeySelector) ([]byte, error) {
+	cm := &v1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("the ConfigMap %s/%s doesn't exist: %w", ref.Namespace, ref.Name, ErrGlobalDataNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ConfigMap %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	if data, ok := cm.BinaryData[ref.Key]; ok {
+		return data, nil
+	}
+	encoded, ok := cm.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in ConfigMap %s/%s: %w",
+			ref.Key, ref.Namespace, ref.Name, ErrGlobalDataNotFound)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key %q in ConfigMap %s/%s: %w",
+			ref.Key, ref.Namespace, ref.Name, err)
+	}
+	return data, nil
+}
+
+// getSecretGlobalData reads the value referenced by ref from a Secret.
+func getSecretGlobalData(c client.Client, ref *bpfmaniov1alpha1.GlobalDataKeySelector) ([]byte, error) {
+	secret := &v1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("the Secret %s/%s doesn't exist: %w", ref.Namespace, ref.Name, ErrGlobalDataNotFound)
+		}
+		return nil, fmt.Errorf("failed to get Secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in Secret %s/%s: %w",
+			ref.Key, ref.Namespace, ref.Name, ErrGlobalDataNotFound)
+	}
+	return data, nil
+}
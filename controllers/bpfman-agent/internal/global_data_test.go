@@ -0,0 +1,172 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetGlobalData(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Data: map[string]string{
+			"sampling": base64.StdEncoding.EncodeToString([]byte{0x01, 0x00}),
+			"notb64":   "not base64!",
+		},
+		BinaryData: map[string][]byte{
+			"table": {0xde, 0xad, 0xbe, 0xef},
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "default"},
+		Data: map[string][]byte{
+			"key": {0x0f, 0x0e},
+		},
+	}
+	otherSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "other"},
+		Data: map[string][]byte{
+			"key": {0x0a, 0x0b},
+		},
+	}
+	cl := fake.NewClientBuilder().WithObjects(cm, secret, otherSecret).Build()
+
+	fromConfigMap := func(variable, name, key string) bpfmaniov1alpha1.GlobalDataSource {
+		return bpfmaniov1alpha1.GlobalDataSource{
+			Name:            variable,
+			ConfigMapKeyRef: &bpfmaniov1alpha1.GlobalDataKeySelector{Name: name, Namespace: "default", Key: key},
+		}
+	}
+	fromSecret := func(variable, name, key string) bpfmaniov1alpha1.GlobalDataSource {
+		return bpfmaniov1alpha1.GlobalDataSource{
+			Name:         variable,
+			SecretKeyRef: &bpfmaniov1alpha1.GlobalDataKeySelector{Name: name, Namespace: "default", Key: key},
+		}
+	}
+
+	fromOtherSecret := bpfmaniov1alpha1.GlobalDataSource{
+		Name:         "key",
+		SecretKeyRef: &bpfmaniov1alpha1.GlobalDataKeySelector{Name: "keys", Namespace: "other", Key: "key"},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		inline    map[string][]byte
+		sources   []bpfmaniov1alpha1.GlobalDataSource
+		expected  map[string][]byte
+		notFound  bool
+		wantErr   string
+	}{
+		{
+			name:     "inline only",
+			inline:   map[string][]byte{"sampling": {0x02}},
+			expected: map[string][]byte{"sampling": {0x02}},
+		},
+		{
+			name:     "ConfigMap data",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromConfigMap("sampling", "config", "sampling")},
+			expected: map[string][]byte{"sampling": {0x01, 0x00}},
+		},
+		{
+			name:     "ConfigMap binary data",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromConfigMap("table", "config", "table")},
+			expected: map[string][]byte{"table": {0xde, 0xad, 0xbe, 0xef}},
+		},
+		{
+			name:     "Secret data",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromSecret("key", "keys", "key")},
+			expected: map[string][]byte{"key": {0x0f, 0x0e}},
+		},
+		{
+			name:   "merged with inline, which takes precedence",
+			inline: map[string][]byte{"sampling": {0x02}, "debug": {0x01}},
+			sources: []bpfmaniov1alpha1.GlobalDataSource{
+				fromConfigMap("sampling", "config", "sampling"),
+				fromSecret("key", "keys", "key"),
+			},
+			expected: map[string][]byte{"sampling": {0x02}, "debug": {0x01}, "key": {0x0f, 0x0e}},
+		},
+		{
+			name:     "missing ConfigMap",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromConfigMap("sampling", "nope", "sampling")},
+			notFound: true,
+			wantErr:  "the ConfigMap default/nope doesn't exist",
+		},
+		{
+			name:     "missing ConfigMap key",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromConfigMap("sampling", "config", "missing")},
+			notFound: true,
+			wantErr:  `key "missing" not found in ConfigMap default/config`,
+		},
+		{
+			name:     "missing Secret",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromSecret("key", "nope", "key")},
+			notFound: true,
+			wantErr:  "the Secret default/nope doesn't exist",
+		},
+		{
+			name:     "missing Secret key",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromSecret("key", "keys", "missing")},
+			notFound: true,
+			wantErr:  `key "missing" not found in Secret default/keys`,
+		},
+		{
+			name:     "Secret in another namespace for a cluster application",
+			sources:  []bpfmaniov1alpha1.GlobalDataSource{fromOtherSecret},
+			expected: map[string][]byte{"key": {0x0a, 0x0b}},
+		},
+		{
+			name:      "Secret in the application's namespace",
+			namespace: "default",
+			sources:   []bpfmaniov1alpha1.GlobalDataSource{fromSecret("key", "keys", "key")},
+			expected:  map[string][]byte{"key": {0x0f, 0x0e}},
+		},
+		{
+			name:      "Secret in another namespace for a namespaced application",
+			namespace: "default",
+			sources:   []bpfmaniov1alpha1.GlobalDataSource{fromOtherSecret},
+			wantErr:   "namespace other isn't the application's namespace default",
+		},
+		{
+			name:    "invalid base64",
+			sources: []bpfmaniov1alpha1.GlobalDataSource{fromConfigMap("sampling", "config", "notb64")},
+			wantErr: `failed to decode key "notb64"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalData, err := GetGlobalData(cl, tt.namespace, tt.inline, tt.sources)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Equal(t, tt.notFound, errors.Is(err, ErrGlobalDataNotFound))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, globalData)
+		})
+	}
+}
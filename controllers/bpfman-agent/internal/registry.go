@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/containers/image/v5/docker/reference"
+)
+
+// registryClient is the HTTP client used to talk to image registries.
+var registryClient = &http.Client{Timeout: 30 * time.Second}
+
+// manifestMediaTypes are the manifest types accepted when resolving a tag.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ResolveImageDigest asks the image's registry which digest the image's tag
+// currently refers to. Images referenced by digest resolve to that digest
+// without asking the registry. The image's username and password, if set,
+// are used to authenticate with the registry.
+func ResolveImageDigest(ctx context.Context, image *gobpfman.BytecodeImage) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image.GetUrl())
+	if err != nil {
+		return "", err
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		return digested.Digest().String(), nil
+	}
+	tagged := reference.TagNameOnly(named).(reference.Tagged)
+
+	domain := reference.Domain(named)
+	if domain == "docker.io" {
+		domain = "registry-1.docker.io"
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", domain, reference.Path(named), tagged.Tag())
+
+	res, err := headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		authorization, err := registryAuthorization(ctx, res.Header.Get("WWW-Authenticate"),
+			image.GetUsername(), image.GetPassword())
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate with %s: %w", domain, err)
+		}
+		if res, err = headManifest(ctx, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the manifest of %s: %s", image.GetUrl(), res.Status)
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry didn't report the digest of %s", image.GetUrl())
+	}
+	return digest, nil
+}
+
+func headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	res, err := registryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	return res, nil
+}
+
+// registryAuthorization returns the Authorization header to send to a
+// registry that challenged a request with the given WWW-Authenticate header.
+// Bearer challenges are answered with a token from the registry's token
+// service, which is given the username and password if there are any.
+func registryAuthorization(ctx context.Context, challenge, username, password string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("registry requires credentials")
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	values := map[string]string{}
+	for _, param := range splitChallengeParams(params) {
+		key, value, _ := strings.Cut(param, "=")
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("invalid authentication realm %q", values["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	res, err := registryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", res.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// splitChallengeParams splits the comma separated parameters of a
+// WWW-Authenticate header, leaving commas inside quoted values alone.
+func splitChallengeParams(params string) []string {
+	parts := []string{}
+	start, quoted := 0, false
+	for i, c := range params {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, params[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, params[start:])
+}
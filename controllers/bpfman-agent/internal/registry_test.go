@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveImageDigest(t *testing.T) {
+	const digest = "sha256:0d63b1ab2b2fbb4e3cc1c8d3cbd8a0aa2fbd2b71e48b4a14e4ecd0dd59b9d2a1"
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "secret" ||
+				r.URL.Query().Get("scope") != "repository:bpfman/hello:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token": "registry-token"}`)
+		case "/v2/bpfman/hello/manifests/latest":
+			if r.Header.Get("Authorization") != "Bearer registry-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+					`Bearer realm="%s/token",service="registry",scope="repository:bpfman/hello:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			require.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	origRegistryClient := registryClient
+	t.Cleanup(func() { registryClient = origRegistryClient })
+	registryClient = server.Client()
+
+	ctx := context.TODO()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	username, password := "user", "secret"
+	image := &gobpfman.BytecodeImage{Url: registry + "/bpfman/hello:latest", Username: &username, Password: &password}
+
+	// The tag is resolved with a token got with the image's credentials.
+	got, err := ResolveImageDigest(ctx, image)
+	require.NoError(t, err)
+	require.Equal(t, digest, got)
+
+	// An untagged image is the latest tag.
+	image.Url = registry + "/bpfman/hello"
+	got, err = ResolveImageDigest(ctx, image)
+	require.NoError(t, err)
+	require.Equal(t, digest, got)
+
+	// Without the right credentials there's no token.
+	wrong := "wrong"
+	_, err = ResolveImageDigest(ctx, &gobpfman.BytecodeImage{Url: image.Url, Username: &username, Password: &wrong})
+	require.ErrorContains(t, err, "token request failed: 403 Forbidden")
+
+	// Unknown images aren't found.
+	_, err = ResolveImageDigest(ctx, &gobpfman.BytecodeImage{Url: registry + "/bpfman/missing:latest"})
+	require.ErrorContains(t, err, "404 Not Found")
+
+	// An image referenced by digest doesn't need the registry.
+	got, err = ResolveImageDigest(ctx, &gobpfman.BytecodeImage{Url: "quay.io/bpfman/hello@" + digest})
+	require.NoError(t, err)
+	require.Equal(t, digest, got)
+}
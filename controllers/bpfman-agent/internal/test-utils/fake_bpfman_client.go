@@ -31,7 +31,23 @@ type BpfmanClientFake struct {
 	GetRequests          map[int]*gobpfman.GetRequest
 	Programs             map[int]*gobpfman.GetResponse
 	Links                map[int]bool
+	AttachRequests       map[int]*gobpfman.AttachRequest
 	PullBytecodeRequests map[int]*gobpfman.PullBytecodeRequest
+	// UnloadOrder holds the ids of the programs unloaded, in order.
+	UnloadOrder []int
+	// LoadErr, if set, is returned by Load.
+	LoadErr error
+	// LoadErrs are returned by successive calls to Load, before LoadErr.
+	// A nil entry lets that call succeed.
+	LoadErrs []error
+	// AttachErrs are returned by successive calls to Attach. A nil entry
+	// lets that call succeed.
+	AttachErrs []error
+	// ListResults is returned by List.
+	ListResults []*gobpfman.ListResponse_ListResult
+	// LoadedProgramTypes sets the kernel program type Load reports for the
+	// named programs. It's left unset for any others.
+	LoadedProgramTypes map[string]uint32
 }
 
 func NewBpfmanClientFake() *BpfmanClientFake {
@@ -42,6 +58,7 @@ func NewBpfmanClientFake() *BpfmanClientFake {
 		GetRequests:          map[int]*gobpfman.GetRequest{},
 		Programs:             map[int]*gobpfman.GetResponse{},
 		Links:                map[int]bool{},
+		AttachRequests:       map[int]*gobpfman.AttachRequest{},
 		PullBytecodeRequests: map[int]*gobpfman.PullBytecodeRequest{},
 	}
 }
@@ -54,6 +71,7 @@ func NewBpfmanClientFakeWithPrograms(programs map[int]*gobpfman.GetResponse) *Bp
 		GetRequests:          map[int]*gobpfman.GetRequest{},
 		Programs:             programs,
 		Links:                map[int]bool{},
+		AttachRequests:       map[int]*gobpfman.AttachRequest{},
 		PullBytecodeRequests: map[int]*gobpfman.PullBytecodeRequest{},
 	}
 }
@@ -61,6 +79,16 @@ func NewBpfmanClientFakeWithPrograms(programs map[int]*gobpfman.GetResponse) *Bp
 var currentID = 1000
 
 func (b *BpfmanClientFake) Load(ctx context.Context, in *gobpfman.LoadRequest, opts ...grpc.CallOption) (*gobpfman.LoadResponse, error) {
+	b.LoadRequests[len(b.LoadRequests)] = in
+	if len(b.LoadErrs) > 0 {
+		err := b.LoadErrs[0]
+		b.LoadErrs = b.LoadErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	} else if b.LoadErr != nil {
+		return nil, b.LoadErr
+	}
 
 	loadResponse := &gobpfman.LoadResponse{}
 	programs := make([]*gobpfman.LoadResponseInfo, 0)
@@ -74,8 +102,9 @@ func (b *BpfmanClientFake) Load(ctx context.Context, in *gobpfman.LoadRequest, o
 				Name: progName,
 			},
 			KernelInfo: &gobpfman.KernelProgramInfo{
-				Id:   uint32(currentID),
-				Name: progName,
+				Id:          uint32(currentID),
+				Name:        progName,
+				ProgramType: b.LoadedProgramTypes[progName],
 			},
 		}
 		programs = append(programs, loadResponseInfo)
@@ -89,6 +118,7 @@ func (b *BpfmanClientFake) Load(ctx context.Context, in *gobpfman.LoadRequest, o
 
 func (b *BpfmanClientFake) Unload(ctx context.Context, in *gobpfman.UnloadRequest, opts ...grpc.CallOption) (*gobpfman.UnloadResponse, error) {
 	b.UnloadRequests[int(in.Id)] = in
+	b.UnloadOrder = append(b.UnloadOrder, int(in.Id))
 	delete(b.Programs, int(in.Id))
 
 	return &gobpfman.UnloadResponse{}, nil
@@ -113,18 +143,27 @@ func (b *BpfmanClientFake) Get(ctx context.Context, in *gobpfman.GetRequest, opt
 }
 
 func (b *BpfmanClientFake) PullBytecode(ctx context.Context, in *gobpfman.PullBytecodeRequest, opts ...grpc.CallOption) (*gobpfman.PullBytecodeResponse, error) {
+	b.PullBytecodeRequests[len(b.PullBytecodeRequests)] = in
 	return &gobpfman.PullBytecodeResponse{}, nil
 }
 
 func (b *BpfmanClientFake) List(ctx context.Context, in *gobpfman.ListRequest, opts ...grpc.CallOption) (*gobpfman.ListResponse, error) {
-	return &gobpfman.ListResponse{}, nil
+	return &gobpfman.ListResponse{Results: b.ListResults}, nil
 }
 
 var currentLinkID = 1000
 
 func (b *BpfmanClientFake) Attach(ctx context.Context, in *gobpfman.AttachRequest, opts ...grpc.CallOption) (*gobpfman.AttachResponse, error) {
+	if len(b.AttachErrs) > 0 {
+		err := b.AttachErrs[0]
+		b.AttachErrs = b.AttachErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
 	currentLinkID++
 	b.Links[currentLinkID] = true
+	b.AttachRequests[currentLinkID] = in
 	b.Programs[int(in.Id)].Info.Links = append(b.Programs[int(in.Id)].Info.Links, uint32(currentLinkID))
 	return &gobpfman.AttachResponse{
 		LinkId: uint32(currentLinkID),
@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// errKernelTooOld is returned when an application isn't loaded because the
+// node's kernel is older than its minKernelVersion.
+var errKernelTooOld = errors.New("kernel too old")
+
+// kernelVersionRegexp matches the major, minor and optional patch numbers at
+// the start of a kernel release, such as 5.15.0-91-generic.
+var kernelVersionRegexp = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// kernelVersion is the major, minor and patch number of a kernel release.
+type kernelVersion [3]int
+
+func (v kernelVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// olderThan returns true if v is an older kernel than other.
+func (v kernelVersion) olderThan(other kernelVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+// parseKernelVersion parses the version at the start of a kernel release.
+// Anything after the version, such as a distribution suffix, is ignored, and
+// a missing patch number is taken as 0.
+func parseKernelVersion(release string) (kernelVersion, error) {
+	m := kernelVersionRegexp.FindStringSubmatch(release)
+	if m == nil {
+		return kernelVersion{}, fmt.Errorf("invalid kernel version %q", release)
+	}
+	var v kernelVersion
+	for i, s := range m[1:] {
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return kernelVersion{}, fmt.Errorf("invalid kernel version %q: %w", release, err)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// kernelRelease returns the release of the kernel running on node, which the
+// kubelet fills in from uname.
+func kernelRelease(node *v1.Node) (string, error) {
+	if node == nil || node.Status.NodeInfo.KernelVersion == "" {
+		return "", errors.New("the node status has no kernel version")
+	}
+	return node.Status.NodeInfo.KernelVersion, nil
+}
+
+// checkKernelVersion returns an error wrapping errKernelTooOld if the kernel
+// running on node is older than minVersion. An empty minVersion is always
+// satisfied.
+func checkKernelVersion(minVersion string, node *v1.Node) error {
+	if minVersion == "" {
+		return nil
+	}
+	if _, err := parseKernelVersion(minVersion); err != nil {
+		return fmt.Errorf("invalid minKernelVersion: %w", err)
+	}
+	release, err := kernelRelease(node)
+	if err != nil {
+		return fmt.Errorf("failed to get the node's kernel version: %w", err)
+	}
+	running, err := parseKernelVersion(release)
+	if err != nil {
+		return err
+	}
+	return checkMinKernelVersion(minVersion, release, running)
+}
+
+// checkMinKernelVersion returns an error wrapping errKernelTooOld if
+// running, the version of release, is older than minVersion. An empty
+// minVersion is always satisfied.
+func checkMinKernelVersion(minVersion, release string, running kernelVersion) error {
+	if minVersion == "" {
+		return nil
+	}
+	min, err := parseKernelVersion(minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid minKernelVersion: %w", err)
+	}
+	if running.olderThan(min) {
+		return fmt.Errorf("node kernel %s is older than the minimum kernel version %s: %w",
+			release, minVersion, errKernelTooOld)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKernelVersion(t *testing.T) {
+	tests := []struct {
+		release  string
+		expected kernelVersion
+	}{
+		{release: "5.15", expected: kernelVersion{5, 15, 0}},
+		{release: "6.1.55", expected: kernelVersion{6, 1, 55}},
+		{release: "5.15.0-91-generic", expected: kernelVersion{5, 15, 0}},
+		{release: "4.18.0-513.el8.x86_64", expected: kernelVersion{4, 18, 0}},
+		{release: "6.8.0+", expected: kernelVersion{6, 8, 0}},
+		{release: "6.10-rc1", expected: kernelVersion{6, 10, 0}},
+	}
+	for _, tc := range tests {
+		v, err := parseKernelVersion(tc.release)
+		require.NoError(t, err, tc.release)
+		require.Equal(t, tc.expected, v, tc.release)
+	}
+
+	for _, release := range []string{"", "6", "v6.1", "linux-6.1"} {
+		_, err := parseKernelVersion(release)
+		require.Error(t, err, release)
+	}
+}
+
+func TestKernelVersionOlderThan(t *testing.T) {
+	require.True(t, kernelVersion{5, 4, 0}.olderThan(kernelVersion{5, 15, 0}))
+	require.True(t, kernelVersion{5, 15, 0}.olderThan(kernelVersion{5, 15, 1}))
+	require.False(t, kernelVersion{5, 15, 0}.olderThan(kernelVersion{5, 15, 0}))
+	require.False(t, kernelVersion{6, 1, 0}.olderThan(kernelVersion{5, 15, 0}))
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// LoadConflictPolicy is what the agent does when bpfman refuses to load an
+// application's programs because they're already loaded, which happens when
+// the agent restarts after bpfman loaded the programs but before their ids
+// were recorded.
+type LoadConflictPolicy string
+
+const (
+	// LoadConflictFail reports the error, so the application isn't loaded
+	// until the programs are unloaded by hand.
+	LoadConflictFail LoadConflictPolicy = "fail"
+	// LoadConflictAdopt adopts the loaded programs if they match the
+	// application, and reports the error otherwise.
+	LoadConflictAdopt LoadConflictPolicy = "adopt"
+	// LoadConflictReload adopts the loaded programs if they match the
+	// application, and otherwise unloads them and loads the application
+	// again.
+	LoadConflictReload LoadConflictPolicy = "reload"
+)
+
+// ParseLoadConflictPolicy parses the value of --load-conflict-policy. An
+// empty string returns LoadConflictFail.
+func ParseLoadConflictPolicy(s string) (LoadConflictPolicy, error) {
+	switch policy := LoadConflictPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return LoadConflictFail, nil
+	case LoadConflictFail, LoadConflictAdopt, LoadConflictReload:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown load conflict policy %q: expected %s, %s or %s",
+			s, LoadConflictFail, LoadConflictAdopt, LoadConflictReload)
+	}
+}
+
+// loadedProgramDiff returns the fields of the load request that differ from
+// the program bpfman has loaded, each in the form "field: change", or nil if
+// they match. Bytecode from a ConfigMap is written to a new file for every
+// load, so only its global data is compared. Global data values aren't
+// included, only whether each key was added, removed or changed.
+func loadedProgramDiff(byteCode *bpfmaniov1alpha1.ByteCodeSelector, loadRequest *gobpfman.LoadRequest,
+	info *gobpfman.ProgramInfo) []string {
+	var diff []string
+	if byteCode.ConfigMapRef == nil {
+		want, got := loadRequest.GetBytecode(), info.GetBytecode()
+		if want.GetImage().GetUrl() != got.GetImage().GetUrl() || want.GetFile() != got.GetFile() {
+			diff = append(diff, fmt.Sprintf("bytecode: %q -> %q", bytecodeLocation(got), bytecodeLocation(want)))
+		}
+	}
+	keys := []string{}
+	for k := range loadRequest.GlobalData {
+		keys = append(keys, k)
+	}
+	for k := range info.GlobalData {
+		if _, ok := loadRequest.GlobalData[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		want, requested := loadRequest.GlobalData[k]
+		loaded, isLoaded := info.GlobalData[k]
+		switch {
+		case !isLoaded:
+			diff = append(diff, fmt.Sprintf("globalData.%s: added", k))
+		case !requested:
+			diff = append(diff, fmt.Sprintf("globalData.%s: removed", k))
+		case !bytes.Equal(want, loaded):
+			diff = append(diff, fmt.Sprintf("globalData.%s: changed", k))
+		}
+	}
+	return diff
+}
+
+// loadedProgramMismatch returns why a program that bpfman has loaded doesn't
+// match the load request, or an empty string if it does.
+func loadedProgramMismatch(byteCode *bpfmaniov1alpha1.ByteCodeSelector, loadRequest *gobpfman.LoadRequest,
+	info *gobpfman.ProgramInfo) string {
+	return strings.Join(loadedProgramDiff(byteCode, loadRequest, info), ", ")
+}
+
+func bytecodeLocation(location *gobpfman.BytecodeLocation) string {
+	if url := location.GetImage().GetUrl(); url != "" {
+		return url
+	}
+	return location.GetFile()
+}
+
+// findPersistedPrograms is used when PersistPrograms is set, and returns the
+// programs bpfman already has loaded for loadRequest's application if they all
+// match it, so they can be adopted without loading the bytecode again. It
+// returns nil if they aren't all loaded or don't match, and the bytecode is
+// loaded as usual.
+func (r *ReconcilerCommon) findPersistedPrograms(ctx context.Context, byteCode *bpfmaniov1alpha1.ByteCodeSelector,
+	loadRequest *gobpfman.LoadRequest) []*gobpfman.LoadResponseInfo {
+	names := []string{}
+	for _, info := range loadRequest.Info {
+		names = append(names, info.Name)
+	}
+	uuidKey := r.metadataKeys().Uuid
+	loaded, err := bpfmanagentinternal.FindBpfmanProgramsByOwner(ctx, r.BpfmanClient, uuidKey,
+		loadRequest.Metadata[uuidKey], names)
+	if err != nil {
+		r.Logger.Error(err, "failed to find persisted programs, loading them instead")
+		return nil
+	}
+	if len(loaded) == 0 {
+		return nil
+	}
+	if len(loaded) != len(names) {
+		r.Logger.Info("Not adopting persisted programs", "Reason",
+			fmt.Sprintf("%d of %d programs are loaded", len(loaded), len(names)))
+		return nil
+	}
+	for _, program := range loaded {
+		if reason := loadedProgramMismatch(byteCode, loadRequest, program.Info); reason != "" {
+			r.Logger.Info("Not adopting persisted programs", "Reason",
+				fmt.Sprintf("program %s: %s", program.Info.Name, reason))
+			return nil
+		}
+	}
+	r.Logger.Info("Adopting persisted programs", "Programs", names)
+	return loaded
+}
+
+// resolveLoadConflict is called when loadRequest failed with loadErr because
+// bpfman already has the programs loaded. Depending on the LoadConflictPolicy,
+// it returns the programs that were already loaded, or unloads them and tries
+// the load again.
+func (r *ReconcilerCommon) resolveLoadConflict(ctx context.Context, byteCode *bpfmaniov1alpha1.ByteCodeSelector,
+	loadRequest *gobpfman.LoadRequest, loadErr error) ([]*gobpfman.LoadResponseInfo, error) {
+	if r.LoadConflictPolicy != LoadConflictAdopt && r.LoadConflictPolicy != LoadConflictReload {
+		return nil, loadErr
+	}
+
+	names := []string{}
+	for _, info := range loadRequest.Info {
+		names = append(names, info.Name)
+	}
+	uuidKey := r.metadataKeys().Uuid
+	loaded, err := bpfmanagentinternal.FindBpfmanProgramsByOwner(ctx, r.BpfmanClient, uuidKey,
+		loadRequest.Metadata[uuidKey], names)
+	if err != nil {
+		return nil, fmt.Errorf("%w, and failed to find the loaded programs: %w", loadErr, err)
+	}
+
+	mismatch := ""
+	if len(loaded) != len(names) {
+		mismatch = fmt.Sprintf("%d of %d programs are loaded", len(loaded), len(names))
+	} else {
+		for _, program := range loaded {
+			if reason := loadedProgramMismatch(byteCode, loadRequest, program.Info); reason != "" {
+				mismatch = fmt.Sprintf("program %s: %s", program.Info.Name, reason)
+				break
+			}
+		}
+	}
+	if mismatch == "" {
+		r.Logger.Info("Adopting programs that were already loaded", "Programs", names)
+		return loaded, nil
+	}
+	if r.LoadConflictPolicy != LoadConflictReload {
+		return nil, fmt.Errorf("%w, and the loaded programs can't be adopted: %s", loadErr, mismatch)
+	}
+
+	r.Logger.Info("Reloading programs that were already loaded but don't match", "Reason", mismatch)
+	for _, program := range loaded {
+		r.Logger.V(1).Info("Reload diff", "Program", program.Info.Name,
+			"Changed", loadedProgramDiff(byteCode, loadRequest, program.Info))
+		if err := bpfmanagentinternal.UnloadBpfmanProgram(ctx, r.BpfmanClient, program.KernelInfo.Id); err != nil {
+			return nil, fmt.Errorf("failed to unload program %s to reload it: %w", program.Info.Name, err)
+		}
+	}
+	return bpfmanagentinternal.LoadBpfmanProgram(ctx, r.BpfmanClient, loadRequest)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLoadConflictPolicy(t *testing.T) {
+	policy, err := ParseLoadConflictPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, LoadConflictFail, policy)
+
+	policy, err = ParseLoadConflictPolicy(" Reload ")
+	require.NoError(t, err)
+	require.Equal(t, LoadConflictReload, policy)
+
+	_, err = ParseLoadConflictPolicy("replace")
+	require.ErrorContains(t, err, `unknown load conflict policy "replace"`)
+}
+
+func TestLoadedProgramDiff(t *testing.T) {
+	file := func(path string) *gobpfman.BytecodeLocation {
+		return &gobpfman.BytecodeLocation{Location: &gobpfman.BytecodeLocation_File{File: path}}
+	}
+	bytecodePath := "/tmp/hello.o"
+	byteCode := &bpfmaniov1alpha1.ByteCodeSelector{Path: &bytecodePath}
+	loadRequest := &gobpfman.LoadRequest{
+		Bytecode:   file(bytecodePath),
+		GlobalData: map[string][]byte{"kept": {1}, "changed": {2}, "added": {3}},
+	}
+
+	info := &gobpfman.ProgramInfo{
+		Bytecode:   file(bytecodePath),
+		GlobalData: map[string][]byte{"kept": {1}, "changed": {2}, "added": {3}},
+	}
+	require.Empty(t, loadedProgramDiff(byteCode, loadRequest, info))
+
+	info = &gobpfman.ProgramInfo{
+		Bytecode:   file("/tmp/goodbye.o"),
+		GlobalData: map[string][]byte{"kept": {1}, "changed": {4}, "removed": {5}},
+	}
+	require.Equal(t, []string{
+		`bytecode: "/tmp/goodbye.o" -> "/tmp/hello.o"`,
+		"globalData.added: added",
+		"globalData.changed: changed",
+		"globalData.removed: removed",
+	}, loadedProgramDiff(byteCode, loadRequest, info))
+
+	// Bytecode from a ConfigMap is written to a new file for every load.
+	configMap := &bpfmaniov1alpha1.ByteCodeSelector{ConfigMapRef: &bpfmaniov1alpha1.ByteCodeConfigMapSelector{Name: "bytecode", Namespace: "default"}}
+	info.GlobalData = loadRequest.GlobalData
+	require.Empty(t, loadedProgramDiff(configMap, loadRequest, info))
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+)
+
+// pinMaps and unpinMaps pin an application's maps in, and remove them from,
+// its mapPinPath. They're variables so tests can replace them.
+var (
+	pinMaps   = bpfmanagentinternal.PinMaps
+	unpinMaps = bpfmanagentinternal.UnpinMaps
+)
+
+// bpfmanMapsDir returns the directory bpfman pins the maps of an
+// application's loaded programs in, which is found from the first loaded
+// program. bpfman pins the maps of all the programs loaded from the same
+// bytecode in one directory. It returns "" if no program is loaded, or bpfman
+// hasn't pinned any maps.
+func (r *ReconcilerCommon) bpfmanMapsDir(ctx context.Context, ids []*uint32) (string, error) {
+	for _, id := range ids {
+		if id == nil {
+			continue
+		}
+		program, err := bpfmanagentinternal.GetBpfmanProgramById(ctx, r.BpfmanClient, *id)
+		if err != nil {
+			return "", err
+		}
+		return program.GetInfo().GetMapPinPath(), nil
+	}
+	return "", nil
+}
+
+// pinApplicationMaps pins the maps of an application's loaded programs in
+// its mapPinPath, if it has one. namespace is the application's namespace,
+// which is empty for a ClusterBpfApplication.
+//
+// bpfman's load request has no way to choose where maps are pinned, so the
+// agent pins the maps bpfman pinned again, see PinMaps.
+func (r *ReconcilerCommon) pinApplicationMaps(ctx context.Context, namespace, mapPinPath string, ids []*uint32) error {
+	if mapPinPath == "" {
+		return nil
+	}
+	// The CRD and the webhook check this too, but the agent doesn't rely on
+	// them before creating directories on the node.
+	if err := internal.CheckMapPinPath(mapPinPath, namespace); err != nil {
+		return err
+	}
+
+	srcDir, err := r.bpfmanMapsDir(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to find maps to pin: %w", err)
+	}
+	if srcDir == "" {
+		return nil
+	}
+	if err := pinMaps(srcDir, mapPinPath); err != nil {
+		return fmt.Errorf("failed to pin maps in %s: %w", mapPinPath, err)
+	}
+	r.Logger.V(1).Info("Pinned maps", "From", srcDir, "MapPinPath", mapPinPath)
+	return nil
+}
+
+// unpinApplicationMaps removes the pins pinApplicationMaps made in an
+// application's mapPinPath, if it has one. It's called before the programs
+// are unloaded, while bpfman still has the maps pinned to compare them with.
+func (r *ReconcilerCommon) unpinApplicationMaps(ctx context.Context, namespace, mapPinPath string, ids []*uint32) {
+	if mapPinPath == "" || internal.CheckMapPinPath(mapPinPath, namespace) != nil {
+		return
+	}
+	srcDir, err := r.bpfmanMapsDir(ctx, ids)
+	if err != nil || srcDir == "" {
+		r.Logger.Info("Leaving pinned maps, the programs' maps weren't found", "MapPinPath", mapPinPath)
+		return
+	}
+	if err := unpinMaps(srcDir, mapPinPath); err != nil {
+		r.Logger.Error(err, "failed to remove pinned maps", "MapPinPath", mapPinPath)
+	}
+}
+
+// clProgramIds returns the kernel ids of the programs in a
+// ClusterBpfApplicationState, which are nil for programs that aren't loaded.
+func clProgramIds(appState *bpfmaniov1alpha1.ClusterBpfApplicationState) []*uint32 {
+	ids := []*uint32{}
+	for _, program := range appState.Status.Programs {
+		ids = append(ids, program.ProgramId)
+	}
+	return ids
+}
+
+// nsProgramIds returns the kernel ids of the programs in a
+// BpfApplicationState, which are nil for programs that aren't loaded.
+func nsProgramIds(appState *bpfmaniov1alpha1.BpfApplicationState) []*uint32 {
+	ids := []*uint32{}
+	for _, program := range appState.Status.Programs {
+		ids = append(ids, program.ProgramId)
+	}
+	return ids
+}
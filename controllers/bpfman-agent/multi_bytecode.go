@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"k8s.io/utils/ptr"
+)
+
+// additionalLoadRequests moves the programs loaded from each of an
+// application's additionalByteCode objects out of loadRequest, and returns a
+// load request for each object, in the same order. The requests share
+// loadRequest's metadata and global data. cleanupAdditionalByteCode must be
+// called with the requests once they're done. namespace is as for
+// GetBytecode.
+func (r *ReconcilerCommon) additionalLoadRequests(namespace string, additional []bpfmaniov1alpha1.AdditionalByteCode,
+	loadRequest *gobpfman.LoadRequest) ([]*gobpfman.LoadRequest, error) {
+	requests := []*gobpfman.LoadRequest{}
+	for i := range additional {
+		info := []*gobpfman.LoadInfo{}
+		loadRequest.Info = slices.DeleteFunc(loadRequest.Info, func(l *gobpfman.LoadInfo) bool {
+			if slices.Contains(additional[i].Programs, l.Name) {
+				info = append(info, l)
+				return true
+			}
+			return false
+		})
+
+		bytecode, err := bpfmanagentinternal.GetBytecode(r.Client, namespace, &additional[i].ByteCode)
+		if err != nil {
+			cleanupAdditionalByteCode(additional, requests)
+			return nil, fmt.Errorf("failed to process additionalByteCode[%d] bytecode selector: %v", i, err)
+		}
+		requests = append(requests, &gobpfman.LoadRequest{
+			Bytecode:   bytecode,
+			Metadata:   loadRequest.Metadata,
+			GlobalData: loadRequest.GlobalData,
+			Uuid:       ptr.To(loadRequest.GetUuid()),
+			Info:       info,
+		})
+	}
+	if len(requests) > 0 && len(loadRequest.Info) == 0 {
+		cleanupAdditionalByteCode(additional, requests)
+		return nil, fmt.Errorf("no programs are left to load from byteCode")
+	}
+	return requests, nil
+}
+
+// cleanupAdditionalByteCode removes any files written for the bytecode of the
+// requests returned by additionalLoadRequests.
+func cleanupAdditionalByteCode(additional []bpfmaniov1alpha1.AdditionalByteCode, requests []*gobpfman.LoadRequest) {
+	for i, request := range requests {
+		bpfmanagentinternal.CleanupBytecode(&additional[i].ByteCode, request.Bytecode)
+	}
+}
+
+// loadAdditionalByteCode loads the programs of each of requests, after the
+// programs in loaded have been loaded from the application's byteCode, and
+// returns loaded with them added. The programs use the maps of mapOwnerId if
+// it's set, and otherwise those of the first program in loaded, so the maps
+// are shared across the objects. If persisted is set, programs kept loaded
+// while the agent was restarted are adopted, as the application's other
+// programs were. If a load fails, all of the programs are unloaded again.
+func (r *ReconcilerCommon) loadAdditionalByteCode(ctx context.Context, requests []*gobpfman.LoadRequest,
+	additional []bpfmaniov1alpha1.AdditionalByteCode, mapOwnerId *uint32, loaded []*gobpfman.LoadResponseInfo,
+	persisted bool) ([]*gobpfman.LoadResponseInfo, error) {
+	if len(requests) == 0 {
+		return loaded, nil
+	}
+	if mapOwnerId == nil && len(loaded) > 0 {
+		mapOwnerId = ptr.To(loaded[0].GetKernelInfo().GetId())
+	}
+
+	for i, request := range requests {
+		request.MapOwnerId = mapOwnerId
+		var programs []*gobpfman.LoadResponseInfo
+		if persisted {
+			programs = r.findPersistedPrograms(ctx, &additional[i].ByteCode, request)
+		}
+		if programs == nil {
+			var err error
+			programs, err = bpfmanagentinternal.LoadBpfmanProgram(ctx, r.BpfmanClient, request)
+			if err != nil {
+				r.unloadPrograms(ctx, loaded)
+				return nil, fmt.Errorf("failed to load additionalByteCode[%d]: %w", i, err)
+			}
+		}
+		loaded = append(loaded, programs...)
+	}
+	return loaded, nil
+}
+
+// unloadPrograms unloads programs after a failed load, those loaded last
+// first, so programs sharing another's maps are unloaded before it.
+func (r *ReconcilerCommon) unloadPrograms(ctx context.Context, programs []*gobpfman.LoadResponseInfo) {
+	for _, program := range slices.Backward(programs) {
+		id := program.GetKernelInfo().GetId()
+		if err := bpfmanagentinternal.UnloadBpfmanProgram(ctx, r.BpfmanClient, id); err != nil {
+			r.Logger.Error(err, "failed to unload program", "ProgramId", id)
+		}
+	}
+}
+
+// mapUsersFirst reorders an unload order, as indexes into names, so programs
+// loaded from an application's additionalByteCode, which use the maps of its
+// other programs, are unloaded before them. Otherwise programs keep their
+// order.
+func mapUsersFirst(order []int, names []string, additional []bpfmaniov1alpha1.AdditionalByteCode) []int {
+	if len(additional) == 0 {
+		return order
+	}
+	isUser := func(i int) bool {
+		return slices.ContainsFunc(additional, func(a bpfmaniov1alpha1.AdditionalByteCode) bool {
+			return slices.Contains(a.Programs, names[i])
+		})
+	}
+	users := []int{}
+	owners := []int{}
+	for _, i := range order {
+		if isUser(i) {
+			users = append(users, i)
+		} else {
+			owners = append(owners, i)
+		}
+	}
+	return append(users, owners...)
+}
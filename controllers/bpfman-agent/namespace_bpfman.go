@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"fmt"
+	"strings"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseNamespaceBpfmanSockets parses a comma separated list of
+// namespace=socket pairs, such as "tenant-a=/run/bpfman-tenant-a/bpfman.sock",
+// into the path of the socket of the bpfman serving each namespace. An empty
+// string returns an empty map.
+func ParseNamespaceBpfmanSockets(s string) (map[string]string, error) {
+	sockets := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return sockets, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		namespace, path, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		namespace, path = strings.TrimSpace(namespace), strings.TrimSpace(path)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid namespace socket %q, expected <namespace>=<socket path>", pair)
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+		}
+		if _, found := sockets[namespace]; found {
+			return nil, fmt.Errorf("namespace %s is given more than one socket", namespace)
+		}
+		sockets[namespace] = path
+	}
+	return sockets, nil
+}
+
+// bpfmanClientFor returns the client of the bpfman that loads the programs
+// of BpfApplications in namespace: the namespace's own bpfman from clients if
+// it has one, and global otherwise.
+func bpfmanClientFor(clients map[string]gobpfman.BpfmanClient, namespace string,
+	global gobpfman.BpfmanClient) gobpfman.BpfmanClient {
+	if client, ok := clients[namespace]; ok {
+		return client
+	}
+	return global
+}
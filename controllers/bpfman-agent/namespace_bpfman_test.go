@@ -0,0 +1,43 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNamespaceBpfmanSockets(t *testing.T) {
+	sockets, err := ParseNamespaceBpfmanSockets("")
+	require.NoError(t, err)
+	require.Empty(t, sockets)
+
+	sockets, err = ParseNamespaceBpfmanSockets(" tenant-a=/run/a/bpfman.sock, tenant-b = /run/b/bpfman.sock")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"tenant-a": "/run/a/bpfman.sock",
+		"tenant-b": "/run/b/bpfman.sock",
+	}, sockets)
+
+	_, err = ParseNamespaceBpfmanSockets("tenant-a")
+	require.ErrorContains(t, err, "expected <namespace>=<socket path>")
+	_, err = ParseNamespaceBpfmanSockets("Tenant_A=/run/a/bpfman.sock")
+	require.ErrorContains(t, err, "invalid namespace")
+	_, err = ParseNamespaceBpfmanSockets("tenant-a=/run/a/bpfman.sock,tenant-a=/run/b/bpfman.sock")
+	require.ErrorContains(t, err, "more than one socket")
+}
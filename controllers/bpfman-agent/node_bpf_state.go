@@ -0,0 +1,182 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/go-logr/logr"
+)
+
+// +kubebuilder:rbac:groups=bpfman.io,resources=nodebpfstates,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=bpfman.io,resources=nodebpfstates/status,verbs=get;update
+
+// NodeBpfStateReconciler keeps the NodeBpfState named after the agent's node
+// up to date with the programs bpfman lists on the node, including those
+// bpfman didn't load, so every node's eBPF programs can be seen through the
+// Kubernetes API. bpfman is listed at most once every Interval.
+type NodeBpfStateReconciler struct {
+	client.Client
+	BpfmanClient gobpfman.BpfmanClient
+	Logger       logr.Logger
+	NodeName     string
+	Interval     time.Duration
+
+	lastUpdate time.Time
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeBpfStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ourNode := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.NodeName
+	})
+	// Nothing in Kubernetes changes when programs are loaded outside of the
+	// agent, so after the node's initial create event the NodeBpfState is
+	// refreshed by requeueing every Interval.
+	nodeCreated := predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return e.Object.GetName() == r.NodeName },
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+	stateRequest := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.NodeName}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodebpfstate").
+		For(&bpfmaniov1alpha1.NodeBpfState{}, builder.WithPredicates(ourNode)).
+		Watches(&v1.Node{}, stateRequest, builder.WithPredicates(nodeCreated)).
+		Complete(r)
+}
+
+func (r *NodeBpfStateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Logger = ctrl.Log.WithName("node-bpf-state")
+
+	// Events within the interval, such as our own updates, are picked up
+	// together when it's over.
+	if wait := r.Interval - time.Since(r.lastUpdate); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	results, err := bpfmanagentinternal.ListAllPrograms(ctx, r.BpfmanClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	programs := nodeBpfPrograms(results)
+
+	state := &bpfmaniov1alpha1.NodeBpfState{}
+	err = r.Get(ctx, req.NamespacedName, state)
+	switch {
+	case errors.IsNotFound(err):
+		node := &v1.Node{}
+		if err := r.Get(ctx, types.NamespacedName{Name: r.NodeName}, node); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get node %s: %w", r.NodeName, err)
+		}
+		// The node owns its NodeBpfState, so it's deleted with the node.
+		state = &bpfmaniov1alpha1.NodeBpfState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   req.Name,
+				Labels: map[string]string{internal.K8sHostLabel: r.NodeName},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				}},
+			},
+		}
+		r.Logger.Info("Creating NodeBpfState", "Name", state.Name)
+		if err := r.Create(ctx, state); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create NodeBpfState %s: %w", state.Name, err)
+		}
+	case err != nil:
+		return ctrl.Result{}, fmt.Errorf("failed to get NodeBpfState %s: %w", req.Name, err)
+	case state.Status.Node == r.NodeName && equality.Semantic.DeepEqual(state.Status.Programs, programs):
+		r.lastUpdate = time.Now()
+		return ctrl.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	state.Status = bpfmaniov1alpha1.NodeBpfStateStatus{
+		Node:        r.NodeName,
+		LastUpdated: metav1.Now(),
+		Programs:    programs,
+	}
+	r.Logger.V(1).Info("Updating NodeBpfState", "Name", state.Name, "Programs", len(programs))
+	if err := r.Status().Update(ctx, state); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update NodeBpfState %s status: %w", state.Name, err)
+	}
+	r.lastUpdate = time.Now()
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+// nodeBpfPrograms converts the programs bpfman lists to their NodeBpfState
+// form, ordered by ID so the list only changes when the programs do.
+func nodeBpfPrograms(results []*gobpfman.ListResponse_ListResult) []bpfmaniov1alpha1.NodeBpfProgram {
+	programs := []bpfmaniov1alpha1.NodeBpfProgram{}
+	for _, result := range results {
+		kernelInfo := result.GetKernelInfo()
+		if kernelInfo == nil {
+			continue
+		}
+		program := bpfmaniov1alpha1.NodeBpfProgram{
+			Id:            kernelInfo.Id,
+			Name:          kernelInfo.Name,
+			Type:          internal.ProgramType(kernelInfo.ProgramType).String(),
+			LoadedAt:      kernelInfo.LoadedAt,
+			Tag:           kernelInfo.Tag,
+			GplCompatible: kernelInfo.GplCompatible,
+			MapIds:        kernelInfo.MapIds,
+			BtfId:         kernelInfo.BtfId,
+			BytesXlated:   kernelInfo.BytesXlated,
+			Jited:         kernelInfo.Jited,
+			BytesJited:    kernelInfo.BytesJited,
+			BytesMemlock:  kernelInfo.BytesMemlock,
+			VerifiedInsns: kernelInfo.VerifiedInsns,
+		}
+		if info := result.GetInfo(); info != nil {
+			program.BpfmanName = info.Name
+			program.Links = info.Links
+		}
+		programs = append(programs, program)
+	}
+	slices.SortFunc(programs, func(a, b bpfmaniov1alpha1.NodeBpfProgram) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return programs
+}
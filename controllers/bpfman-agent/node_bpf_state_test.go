@@ -0,0 +1,135 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	testutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNodeBpfState(t *testing.T) {
+	var (
+		nodeName = "fake-control-plane"
+		ctx      = context.TODO()
+	)
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, UID: "node-uid"}}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().
+		WithStatusSubresource(&bpfmaniov1alpha1.NodeBpfState{}).
+		WithRuntimeObjects(node).
+		Build()
+
+	cli := testutils.NewBpfmanClientFake()
+	// A program bpfman loaded, listed after one it didn't.
+	cli.ListResults = []*gobpfman.ListResponse_ListResult{
+		{
+			Info: &gobpfman.ProgramInfo{Name: "xdp_stats", Links: []uint32{1001}},
+			KernelInfo: &gobpfman.KernelProgramInfo{
+				Id:            42,
+				Name:          "xdp_stats",
+				ProgramType:   uint32(internal.Xdp),
+				Tag:           "abcdef0123456789",
+				GplCompatible: true,
+				MapIds:        []uint32{7, 8},
+				Jited:         true,
+				BytesJited:    512,
+			},
+		},
+		{
+			KernelInfo: &gobpfman.KernelProgramInfo{
+				Id:          3,
+				Name:        "sd_fw_ingress",
+				ProgramType: uint32(internal.CgroupSkb),
+			},
+		},
+	}
+
+	r := &NodeBpfStateReconciler{
+		Client:       cl,
+		BpfmanClient: cli,
+		NodeName:     nodeName,
+		Interval:     time.Minute,
+	}
+	key := types.NamespacedName{Name: nodeName}
+	req := reconcile.Request{NamespacedName: key}
+
+	nodeBpfState := func() *bpfmaniov1alpha1.NodeBpfState {
+		state := &bpfmaniov1alpha1.NodeBpfState{}
+		require.NoError(t, cl.Get(ctx, key, state))
+		return state
+	}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, r.Interval, res.RequeueAfter)
+
+	state := nodeBpfState()
+	require.Len(t, state.OwnerReferences, 1)
+	require.Equal(t, node.UID, state.OwnerReferences[0].UID)
+	require.Equal(t, nodeName, state.Status.Node)
+	require.False(t, state.Status.LastUpdated.IsZero())
+	require.Equal(t, []bpfmaniov1alpha1.NodeBpfProgram{
+		{
+			Id:   3,
+			Name: "sd_fw_ingress",
+			Type: "cgroup_skb",
+		},
+		{
+			Id:            42,
+			Name:          "xdp_stats",
+			Type:          "xdp",
+			BpfmanName:    "xdp_stats",
+			Links:         []uint32{1001},
+			Tag:           "abcdef0123456789",
+			GplCompatible: true,
+			MapIds:        []uint32{7, 8},
+			Jited:         true,
+			BytesJited:    512,
+		},
+	}, state.Status.Programs)
+
+	// A program unloaded within the interval is picked up once it's over.
+	cli.ListResults = cli.ListResults[:1]
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Greater(t, res.RequeueAfter, time.Duration(0))
+	require.Len(t, nodeBpfState().Status.Programs, 2)
+
+	r.lastUpdate = time.Now().Add(-r.Interval)
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, r.Interval, res.RequeueAfter)
+	programs := nodeBpfState().Status.Programs
+	require.Len(t, programs, 1)
+	require.Equal(t, uint32(42), programs[0].Id)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/go-logr/logr"
+)
+
+// +kubebuilder:rbac:groups=core,resources=nodes/status,verbs=get;patch
+
+// NodeConditionBpfProgramsHealthy is the type of the Node condition that
+// reports whether the BPF applications on the node are healthy.
+const NodeConditionBpfProgramsHealthy v1.NodeConditionType = "BpfProgramsHealthy"
+
+// NodeConditionReconciler sets the BpfProgramsHealthy condition on the agent's
+// node from the ClusterBpfApplicationStates and BpfApplicationStates for the
+// node, so BPF program failures show up alongside the node's other health
+// conditions. The condition is false if any of them has failed.
+type NodeConditionReconciler struct {
+	client.Client
+	Logger   logr.Logger
+	NodeName string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeConditionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ourNode := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.NodeName
+	})
+	ourStates := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[internal.K8sHostLabel] == r.NodeName
+	})
+	nodeRequest := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.NodeName}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodecondition").
+		// The node is watched so the condition is set when the agent starts
+		// and put back if it's removed.
+		For(&v1.Node{}, builder.WithPredicates(ourNode)).
+		Watches(&bpfmaniov1alpha1.ClusterBpfApplicationState{}, nodeRequest, builder.WithPredicates(ourStates)).
+		Watches(&bpfmaniov1alpha1.BpfApplicationState{}, nodeRequest, builder.WithPredicates(ourStates)).
+		Complete(r)
+}
+
+func (r *NodeConditionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Logger = ctrl.Log.WithName("node-condition")
+
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: r.NodeName}}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := r.List(ctx, clAppStates, opts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := r.List(ctx, nsAppStates, opts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+
+	failed := []string{}
+	for _, appState := range clAppStates.Items {
+		if appStateFailed(appState.Status.Conditions) {
+			failed = append(failed, appState.Name)
+		}
+	}
+	for _, appState := range nsAppStates.Items {
+		if appStateFailed(appState.Status.Conditions) {
+			failed = append(failed, appState.Namespace+"/"+appState.Name)
+		}
+	}
+	slices.Sort(failed)
+
+	node := &v1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: r.NodeName}, node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, r.setNodeCondition(ctx, node, programsHealthyCondition(failed))
+}
+
+// appStateFailed returns true if an application state reports a failure. A
+// state without a condition hasn't been reconciled yet, so it isn't treated as
+// failed.
+func appStateFailed(conditions []metav1.Condition) bool {
+	return len(conditions) != 0 && helpers.IsBpfAppStateConditionFailure(conditions)
+}
+
+// programsHealthyCondition returns the BpfProgramsHealthy condition for a node
+// on which the given application states have failed.
+func programsHealthyCondition(failed []string) v1.NodeCondition {
+	if len(failed) == 0 {
+		return v1.NodeCondition{
+			Type:    NodeConditionBpfProgramsHealthy,
+			Status:  v1.ConditionTrue,
+			Reason:  "BpfProgramsHealthy",
+			Message: "All BPF applications on the node are healthy",
+		}
+	}
+	return v1.NodeCondition{
+		Type:    NodeConditionBpfProgramsHealthy,
+		Status:  v1.ConditionFalse,
+		Reason:  "BpfProgramsFailed",
+		Message: fmt.Sprintf("BPF applications have failed on the node: %s", strings.Join(failed, ", ")),
+	}
+}
+
+// setNodeCondition patches cond into the node's status if it has changed. A
+// strategic merge patch only touches this condition, so it doesn't race with
+// the kubelet's updates to the others.
+func (r *NodeConditionReconciler) setNodeCondition(ctx context.Context, node *v1.Node, cond v1.NodeCondition) error {
+	i := slices.IndexFunc(node.Status.Conditions, func(c v1.NodeCondition) bool {
+		return c.Type == cond.Type
+	})
+	if i >= 0 {
+		existing := node.Status.Conditions[i]
+		if existing.Status == cond.Status && existing.Reason == cond.Reason && existing.Message == cond.Message {
+			return nil
+		}
+	}
+
+	patch := client.StrategicMergeFrom(node.DeepCopy())
+	now := metav1.Now()
+	cond.LastHeartbeatTime = now
+	cond.LastTransitionTime = now
+	if i >= 0 {
+		if node.Status.Conditions[i].Status == cond.Status {
+			cond.LastTransitionTime = node.Status.Conditions[i].LastTransitionTime
+		}
+		node.Status.Conditions[i] = cond
+	} else {
+		node.Status.Conditions = append(node.Status.Conditions, cond)
+	}
+
+	r.Logger.Info("Setting node condition", "Node", node.Name, "Type", cond.Type, "Status", cond.Status,
+		"Message", cond.Message)
+	if err := r.Status().Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to set %s condition on node %s: %w", cond.Type, node.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNodeConditionBpfProgramsHealthy(t *testing.T) {
+	var (
+		fakeNode  = testutils.NewNode("fake-control-plane")
+		otherNode = testutils.NewNode("other-node")
+		ctx       = context.TODO()
+	)
+
+	// The kubelet's conditions are left alone.
+	fakeNode.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+
+	stateLabels := func(node string) map[string]string {
+		return map[string]string{internal.K8sHostLabel: node}
+	}
+	clAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-app-12345", Labels: stateLabels(fakeNode.Name)},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()},
+		},
+	}
+	nsAppState := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-app-12345", Namespace: "default", Labels: stateLabels(fakeNode.Name)},
+		Status: bpfmaniov1alpha1.BpfApplicationStateStatus{
+			Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()},
+		},
+	}
+	// A failure on another node doesn't affect this one.
+	otherAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-app-67890", Labels: stateLabels(otherNode.Name)},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondError.Condition()},
+		},
+	}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().
+		WithStatusSubresource(fakeNode, clAppState, nsAppState).
+		WithRuntimeObjects(fakeNode, otherNode, clAppState, nsAppState, otherAppState).
+		Build()
+
+	r := &NodeConditionReconciler{
+		Client:   cl,
+		NodeName: fakeNode.Name,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: fakeNode.Name}}
+
+	nodeCondition := func() *v1.NodeCondition {
+		node := &v1.Node{}
+		require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: fakeNode.Name}, node))
+		require.Equal(t, v1.NodeReady, node.Status.Conditions[0].Type)
+		for i := range node.Status.Conditions {
+			if node.Status.Conditions[i].Type == NodeConditionBpfProgramsHealthy {
+				return &node.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond := nodeCondition()
+	require.NotNil(t, cond)
+	require.Equal(t, v1.ConditionTrue, cond.Status)
+
+	// An errored application flips the condition to false.
+	nsAppState.Status.Conditions = []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondError.Condition()}
+	require.NoError(t, cl.Status().Update(ctx, nsAppState))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond = nodeCondition()
+	require.Equal(t, v1.ConditionFalse, cond.Status)
+	require.Equal(t, "BpfProgramsFailed", cond.Reason)
+	require.Equal(t, "BPF applications have failed on the node: default/ns-app-12345", cond.Message)
+
+	// It's true again once the application recovers.
+	nsAppState.Status.Conditions = []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()}
+	require.NoError(t, cl.Status().Update(ctx, nsAppState))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, v1.ConditionTrue, nodeCondition().Status)
+}
@@ -18,6 +18,7 @@ package bpfmanagent
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -25,12 +26,14 @@ import (
 	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
 	"github.com/bpfman/bpfman-operator/internal"
 	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -344,6 +347,11 @@ func TestNsBpfApplicationControllerCreate(t *testing.T) {
 
 	require.Equal(t, internal.NsBpfApplicationControllerFinalizer, bpfAppState2.Finalizers[0])
 
+	// Make sure the bytecode source used for the load was recorded.
+	require.NotNil(t, bpfAppState2.Status.ResolvedBytecode)
+	require.Equal(t, bytecodePath, bpfAppState2.Status.ResolvedBytecode.Path)
+	require.Empty(t, bpfAppState2.Status.ResolvedBytecode.Image)
+
 	for _, program := range bpfAppState2.Status.Programs {
 		r.Logger.Info("ProgramAttachStatus check", "program", program.Name, "status", program.ProgramLinkStatus)
 		require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, program.ProgramLinkStatus)
@@ -370,3 +378,151 @@ func TestNsBpfApplicationControllerCreate(t *testing.T) {
 	// Check that the bpfAppState was not updated
 	require.True(t, reflect.DeepEqual(bpfAppState2, bpfAppState3))
 }
+
+func TestNsBpfApplicationNamespaceBpfman(t *testing.T) {
+	var (
+		fakePid = int32(4490)
+		ctx     = context.TODO()
+	)
+
+	fakeNode := testutils.NewNode("fake-control-plane")
+	tenantApp := newTestNsUprobeApp("tenant-app", "tenant", fakePid)
+	otherApp := newTestNsUprobeApp("other-app", "other", fakePid)
+
+	r, nodeCli := newTestNsReconciler(t, fakeNode, tenantApp, otherApp)
+	r.Containers = &FakeContainerGetter{
+		containerList: &[]ContainerInfo{
+			{podName: "my-pod", containerName: "my-container", pid: fakePid},
+		},
+	}
+	tenantCli := agenttestutils.NewBpfmanClientFake()
+	r.NamespaceBpfmanClients = map[string]gobpfman.BpfmanClient{"tenant": tenantCli}
+
+	// Each pass ends after an application's BpfApplicationState is created
+	// or updated, so it takes a few to load both applications.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "tenant-app", Namespace: "tenant"}}
+	for range 10 {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// The tenant namespace's application is loaded and attached by its own
+	// bpfman, and the other by the node's.
+	require.Len(t, tenantCli.LoadRequests, 1)
+	require.Len(t, tenantCli.Links, 1)
+	require.Len(t, nodeCli.LoadRequests, 1)
+	require.Len(t, nodeCli.Links, 1)
+	require.Equal(t, nodeCli, r.BpfmanClient)
+
+	for _, app := range []*bpfmaniov1alpha1.BpfApplication{tenantApp, otherApp} {
+		r.currentApp = app
+		appState, err := r.getBpfAppState(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, appState)
+		require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), appState.Status.Conditions[0].Type)
+	}
+}
+
+func TestNsBpfApplicationNamespaceUpdateLimit(t *testing.T) {
+	var (
+		fakePid = int32(4490)
+		ctx     = context.TODO()
+	)
+
+	tests := []struct {
+		name string
+		qps  float64
+		// quietLoaded is whether the quiet namespace's application is loaded
+		// while the flooding namespace's keeps changing.
+		quietLoaded bool
+	}{
+		{name: "unlimited", qps: 0, quietLoaded: false},
+		{name: "limited", qps: 1, quietLoaded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeNode := testutils.NewNode("fake-control-plane")
+			// The flooding namespace's application is listed first.
+			floodApp := newTestNsUprobeApp("flood-app", "flood", fakePid)
+			quietApp := newTestNsUprobeApp("quiet-app", "quiet", fakePid)
+
+			r, _ := newTestNsReconciler(t, fakeNode, floodApp, quietApp)
+			r.Containers = &FakeContainerGetter{
+				containerList: &[]ContainerInfo{
+					{podName: "my-pod", containerName: "my-container", pid: fakePid},
+				},
+			}
+			r.NamespaceUpdateQPS = tt.qps
+			r.NamespaceUpdateBurst = 3
+
+			// Each forced resync of the flooding namespace's application
+			// updates its BpfApplicationState, which ends the pass.
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "flood-app", Namespace: "flood"}}
+			for i := range 10 {
+				app := &bpfmaniov1alpha1.BpfApplication{}
+				require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "flood-app", Namespace: "flood"}, app))
+				app.SetAnnotations(map[string]string{internal.ForceResyncAnnotation: fmt.Sprintf("resync-%d", i)})
+				require.NoError(t, r.Client.Update(ctx, app))
+
+				_, err := r.Reconcile(ctx, req)
+				require.NoError(t, err)
+			}
+
+			r.currentApp = quietApp
+			quietAppState, err := r.getBpfAppState(ctx)
+			require.NoError(t, err)
+			if !tt.quietLoaded {
+				require.Nil(t, quietAppState)
+				return
+			}
+			require.NotNil(t, quietAppState)
+			require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondSuccess), quietAppState.Status.Conditions[0].Type)
+			require.NotNil(t, quietAppState.Status.Programs[0].ProgramId)
+			require.Equal(t, bpfmaniov1alpha1.ProgAttachSuccess, quietAppState.Status.Programs[0].ProgramLinkStatus)
+		})
+	}
+}
+
+func TestNsBpfApplicationConfigMapBytecodeNamespace(t *testing.T) {
+	var (
+		fakePid = int32(4490)
+		ctx     = context.TODO()
+	)
+
+	// The agent can read ConfigMaps in every namespace, but a BpfApplication
+	// may only load bytecode from its own.
+	otherCm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bytecode", Namespace: "other"},
+		BinaryData: map[string][]byte{"prog.o": []byte("\x7fELF fake bytecode")},
+	}
+
+	bpfApp := newTestNsUprobeApp("tenant-app", "tenant", fakePid)
+	bpfApp.Spec.ByteCode = bpfmaniov1alpha1.ByteCodeSelector{
+		ConfigMapRef: &bpfmaniov1alpha1.ByteCodeConfigMapSelector{
+			Name:      "bytecode",
+			Namespace: "other",
+			Key:       "prog.o",
+		},
+	}
+
+	fakeNode := testutils.NewNode("fake-control-plane")
+	r, cli := newTestNsReconciler(t, fakeNode, bpfApp, otherCm)
+	r.Containers = &FakeContainerGetter{
+		containerList: &[]ContainerInfo{
+			{podName: "my-pod", containerName: "my-container", pid: fakePid},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "tenant-app", Namespace: "tenant"}}
+	reconcileUntilSettled(t, r, req)
+
+	require.Empty(t, cli.LoadRequests)
+	appState, err := r.getBpfAppState(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, appState)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppStateCondError), appState.Status.Conditions[0].Type)
+	require.NotNil(t, appState.Status.LastError)
+	require.Contains(t, appState.Status.LastError.Message,
+		"bytecode ConfigMap namespace other isn't the application's namespace tenant")
+}
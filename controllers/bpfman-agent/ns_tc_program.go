@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -58,7 +59,8 @@ func (r *NsTcProgramReconciler) isAttached(ctx context.Context) bool {
 	if r.currentProgramState.ProgramId == nil || r.currentLink.LinkId == nil {
 		return false
 	}
-	return r.doesLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId)
+	return r.doesInterfaceLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId,
+		r.currentLink.InterfaceName, r.currentLink.NetnsPath, true)
 }
 
 func (r *NsTcProgramReconciler) getUUID() string {
@@ -95,13 +97,18 @@ func (r *NsTcProgramReconciler) getNamespace() string {
 
 func (r *NsTcProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 
+	var netnsPath *string = nil
+	if len(r.currentLink.NetnsPath) > 0 {
+		netnsPath = &r.currentLink.NetnsPath
+	}
+
 	attachInfo := &gobpfman.TCAttachInfo{
 		Priority:  r.currentLink.Priority,
 		Iface:     r.currentLink.InterfaceName,
 		Direction: directionToStr(r.currentLink.Direction),
 		ProceedOn: tcProceedOnToInt(r.currentLink.ProceedOn),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
-		Netns:     &r.currentLink.NetnsPath,
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
+		Netns:     netnsPath,
 	}
 
 	return &gobpfman.AttachRequest{
@@ -133,6 +140,11 @@ func (r *NsTcProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 
 	if r.currentProgram.TC != nil && r.currentProgram.TC.Links != nil {
 		for _, attachInfo := range r.currentProgram.TC.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(ctx, attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -181,18 +193,21 @@ func (r *NsTcProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.TcAtta
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.TC.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Direction, Priority, ProceedOn, and network namespace.
+		// same: InterfaceName, Direction, Priority, ProceedOn, network
+		// namespace, and Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
 			a.Direction == attachInfoState.Direction &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(a.ProceedOn, attachInfoState.ProceedOn) &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			sameProceedOn(a.ProceedOn, attachInfoState.ProceedOn) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.TC.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -209,6 +224,13 @@ func (r *NsTcProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range r.currentProgramState.TC.Links {
 		r.currentLink = &r.currentProgramState.TC.Links[i]
+		point := attachPoint{hook: tcAttachHook(r.currentLink.Direction, false), iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching TC program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -259,13 +281,31 @@ func (r *NsTcProgramReconciler) removeLinks(links []bpfmaniov1alpha1.TcAttachInf
 // points.
 func (r *NsTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.TcAttachInfo,
 ) ([]bpfmaniov1alpha1.TcAttachInfoState, error) {
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get interfaces for TcProgram: %v", err)
 	}
 
 	nodeLinks := []bpfmaniov1alpha1.TcAttachInfoState{}
 
+	createLinkEntry := func(interfaceName, netnsPath string) bpfmaniov1alpha1.TcAttachInfoState {
+		return bpfmaniov1alpha1.TcAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
+				ShouldAttach: true,
+				UUID:         uuid.New().String(),
+				LinkId:       nil,
+				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
+			},
+			InterfaceName: interfaceName,
+			NetnsPath:     netnsPath,
+			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
+			Direction:     attachInfo.Direction,
+			ProceedOn:     attachInfo.ProceedOn,
+		}
+	}
+
 	// See if there are any matching network namespaces on this node.
 	containerInfo, err := r.Containers.GetContainers(
 		ctx,
@@ -282,23 +322,31 @@ func (r *NsTcProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo
 		// Just use one container per pod to get the pod's network
 		// namespace.
 		containerInfo = GetOneContainerPerPod(containerInfo)
+		hostNetwork := false
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so they're attached to it once, below, rather than
+			// once each.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
+			// TC attaches to an interface inside the pod's network namespace,
+			// so make sure the namespace can be resolved. The pod may have
+			// exited since its containers were listed.
+			if r.getNetnsId(netnsPath) == nil {
+				r.Logger.Info("Skipping pod with unresolvable network namespace",
+					"pod", container.podName, "netnsPath", netnsPath)
+				continue
+			}
 			for _, iface := range interfaces {
-				link := bpfmaniov1alpha1.TcAttachInfoState{
-					AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
-						ShouldAttach: true,
-						UUID:         uuid.New().String(),
-						LinkId:       nil,
-						LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
-					},
-					InterfaceName: iface,
-					NetnsPath:     netnsPath,
-					Priority:      attachInfo.Priority,
-					Direction:     attachInfo.Direction,
-					ProceedOn:     attachInfo.ProceedOn,
-				}
-				nodeLinks = append(nodeLinks, link)
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
+			}
+		}
+		if hostNetwork {
+			for _, iface := range interfaces {
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 			}
 		}
 	}
@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -99,7 +100,7 @@ func (r *NsTcxProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Priority:  r.currentLink.Priority,
 		Iface:     r.currentLink.InterfaceName,
 		Direction: directionToStr(r.currentLink.Direction),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 		Netns:     &r.currentLink.NetnsPath,
 	}
 
@@ -132,6 +133,11 @@ func (r *NsTcxProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 
 	if r.currentProgram.TCX != nil && r.currentProgram.TCX.Links != nil {
 		for _, attachInfo := range r.currentProgram.TCX.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(ctx, attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -180,17 +186,20 @@ func (r *NsTcxProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.TcxAt
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.TCX.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Direction, Priority, and network namespace.
+		// same: InterfaceName, Direction, Priority, network namespace, and
+		// Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
 			a.Direction == attachInfoState.Direction &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.TCX.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -207,6 +216,19 @@ func (r *NsTcxProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range r.currentProgramState.TCX.Links {
 		r.currentLink = &r.currentProgramState.TCX.Links[i]
+		if err := r.checkTcxDirection(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath,
+			r.currentLink.Direction); err != nil {
+			r.Logger.Error(err, "Not attaching TCX program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		point := attachPoint{hook: tcAttachHook(r.currentLink.Direction, true), iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching TCX program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -233,13 +255,18 @@ func (r *NsTcxProgramReconciler) processLinks(ctx context.Context) error {
 }
 
 func (r *NsTcxProgramReconciler) updateProgramAttachStatus() {
+	status := bpfmaniov1alpha1.ProgAttachSuccess
 	for _, link := range r.currentProgramState.TCX.Links {
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApUnsupportedDirection {
+			status = bpfmaniov1alpha1.ProgAttachUnsupportedDirection
+			continue
+		}
 		if !isAttachSuccess(link.ShouldAttach, link.LinkStatus) {
 			r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachError)
 			return
 		}
 	}
-	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
+	r.setProgramLinkStatus(status)
 }
 
 // removeLinks removes links from a slice of links based on the keys in the map.
@@ -257,13 +284,30 @@ func (r *NsTcxProgramReconciler) removeLinks(links []bpfmaniov1alpha1.TcxAttachI
 // points.
 func (r *NsTcxProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.TcxAttachInfo,
 ) ([]bpfmaniov1alpha1.TcxAttachInfoState, error) {
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get interfaces for TcxNsProgram: %v", err)
 	}
 
 	nodeLinks := []bpfmaniov1alpha1.TcxAttachInfoState{}
 
+	createLinkEntry := func(interfaceName, netnsPath string) bpfmaniov1alpha1.TcxAttachInfoState {
+		return bpfmaniov1alpha1.TcxAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
+				ShouldAttach: true,
+				UUID:         uuid.New().String(),
+				LinkId:       nil,
+				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
+			},
+			InterfaceName: interfaceName,
+			NetnsPath:     netnsPath,
+			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
+			Direction:     attachInfo.Direction,
+		}
+	}
+
 	// There is a network namespace selector, so see if there are any matching
 	// pods on this node.
 	containerInfo, err := r.Containers.GetContainers(
@@ -281,22 +325,23 @@ func (r *NsTcxProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 		// Just use one container per pod to get the pod's network
 		// namespace.
 		containerInfo = GetOneContainerPerPod(containerInfo)
+		hostNetwork := false
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so they're attached to it once, below, rather than
+			// once each.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
 			for _, iface := range interfaces {
-				link := bpfmaniov1alpha1.TcxAttachInfoState{
-					AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
-						ShouldAttach: true,
-						UUID:         uuid.New().String(),
-						LinkId:       nil,
-						LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
-					},
-					InterfaceName: iface,
-					NetnsPath:     netnsPath,
-					Priority:      attachInfo.Priority,
-					Direction:     attachInfo.Direction,
-				}
-				nodeLinks = append(nodeLinks, link)
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
+			}
+		}
+		if hostNetwork {
+			for _, iface := range interfaces {
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 			}
 		}
 	}
@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -32,6 +33,9 @@ type NsUprobeProgramReconciler struct {
 	ReconcilerCommon
 	NsProgramReconcilerCommon
 	currentLink *bpfmaniov1alpha1.UprobeAttachInfoState
+	// waitingForContainers is set by updateLinks if a container selector
+	// that names pods doesn't match any containers yet.
+	waitingForContainers bool
 }
 
 func (r *NsUprobeProgramReconciler) getProgId() *uint32 {
@@ -96,7 +100,7 @@ func (r *NsUprobeProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 		Offset:   r.currentLink.Offset,
 		Target:   r.currentLink.Target,
 		Pid:      r.currentLink.Pid,
-		Metadata: map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		Metadata: r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 	}
 
 	containerPid := int32(r.currentLink.ContainerPid)
@@ -133,6 +137,11 @@ func (r *NsUprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 	appLinks := r.getAppLinks()
 	for _, attachInfo := range *appLinks {
+		if !linkEnabled(attachInfo.Enabled) {
+			// Links from a disabled entry keep ShouldAttach false, so
+			// they're detached until it's enabled again.
+			continue
+		}
 		expectedLinks, error := r.getExpectedLinks(ctx, attachInfo)
 		if error != nil {
 			return fmt.Errorf("failed to get node links: %v", error)
@@ -160,17 +169,20 @@ func (r *NsUprobeProgramReconciler) updateLinks(ctx context.Context, isBeingDele
 
 func (r *NsUprobeProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.UprobeAttachInfoState,
 	links *[]bpfmaniov1alpha1.UprobeAttachInfoState) *int {
+	matches := []int{}
 	for i, a := range *links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: Function, Offset, Target, Pid, and ContainerPid.
+		// same: Function, Offset, Target, BuildID, Pid, ContainerPid, and
+		// Metadata.
 		if a.Function == attachInfoState.Function && a.Offset == attachInfoState.Offset &&
-			a.Target == attachInfoState.Target &&
+			a.Target == attachInfoState.Target && a.BuildID == attachInfoState.BuildID &&
 			reflect.DeepEqual(a.Pid, attachInfoState.Pid) &&
-			a.ContainerPid == attachInfoState.ContainerPid {
-			return &i
+			a.ContainerPid == attachInfoState.ContainerPid &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil
+	return r.keptLink(matches, func(i int) *uint32 { return (*links)[i].LinkId })
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -188,6 +200,12 @@ func (r *NsUprobeProgramReconciler) processLinks(ctx context.Context) error {
 	var lastReconcileLinkError error = nil
 	for i := range *appStateLinks {
 		r.currentLink = &(*appStateLinks)[i]
+		if err := r.checkUprobeBuildID(ctx, r, r.currentLink.BuildID, r.currentLink.Target,
+			r.currentLink.ContainerPid); err != nil {
+			r.Logger.Error(err, "Not attaching uprobe", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -221,6 +239,10 @@ func (r *NsUprobeProgramReconciler) updateProgramAttachStatus() {
 			return
 		}
 	}
+	if r.waitingForContainers {
+		r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachWaitingForContainers)
+		return
+	}
 	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
 }
 
@@ -284,6 +306,8 @@ func (r *NsUprobeProgramReconciler) getExpectedLinks(ctx context.Context, attach
 		return nil, fmt.Errorf("failed to get container pids: %v", err)
 	}
 
+	containerInfo = filterContainersByImage(containerInfo, attachInfo.Containers.ContainerImages)
+
 	if containerInfo != nil && len(*containerInfo) != 0 {
 		// Containers were found, so create links.
 		for i := range *containerInfo {
@@ -295,15 +319,22 @@ func (r *NsUprobeProgramReconciler) getExpectedLinks(ctx context.Context, attach
 					UUID:         uuid.New().String(),
 					LinkId:       nil,
 					LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+					Metadata:     attachInfo.Metadata,
 				},
 				Function:     attachInfo.Function,
 				Offset:       attachInfo.Offset,
 				Target:       attachInfo.Target,
+				BuildID:      attachInfo.BuildID,
 				Pid:          attachInfo.Pid,
 				ContainerPid: containerPid,
 			}
 			nodeLinks = append(nodeLinks, link)
 		}
+	} else if selectsPods(attachInfo.Containers.Pods) || len(attachInfo.Containers.ContainerImages) != 0 {
+		// The pods may not have been scheduled or started yet.
+		r.Logger.Info("Waiting for containers matching the container selector",
+			"Namespace", r.namespace, "Pods", attachInfo.Containers.Pods)
+		r.waitingForContainers = true
 	}
 
 	return nodeLinks, nil
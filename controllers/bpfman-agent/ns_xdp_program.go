@@ -19,6 +19,7 @@ package bpfmanagent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 
 	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
@@ -58,7 +59,8 @@ func (r *NsXdpProgramReconciler) isAttached(ctx context.Context) bool {
 	if r.currentProgramState.ProgramId == nil || r.currentLink.LinkId == nil {
 		return false
 	}
-	return r.doesLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId)
+	return r.doesInterfaceLinkExist(ctx, *r.currentProgramState.ProgramId, *r.currentLink.LinkId,
+		r.currentLink.InterfaceName, r.currentLink.NetnsPath, false)
 }
 
 func (r *NsXdpProgramReconciler) getUUID() string {
@@ -98,8 +100,8 @@ func (r *NsXdpProgramReconciler) getAttachRequest() *gobpfman.AttachRequest {
 	attachInfo := &gobpfman.XDPAttachInfo{
 		Priority:  r.currentLink.Priority,
 		Iface:     r.currentLink.InterfaceName,
-		ProceedOn: xdpProceedOnToInt(r.currentLink.ProceedOn),
-		Metadata:  map[string]string{internal.UuidMetadataKey: string(r.currentLink.UUID)},
+		ProceedOn: r.xdpProceedOn(r.currentLink.ProceedOn),
+		Metadata:  r.linkMetadata(&r.currentLink.AttachInfoStateCommon, r.currentProgram.LinkPersistence),
 		Netns:     &r.currentLink.NetnsPath,
 	}
 
@@ -132,6 +134,11 @@ func (r *NsXdpProgramReconciler) updateLinks(ctx context.Context, isBeingDeleted
 
 	if r.currentProgram.XDP != nil && r.currentProgram.XDP.Links != nil {
 		for _, attachInfo := range r.currentProgram.XDP.Links {
+			if !linkEnabled(attachInfo.Enabled) {
+				// Links from a disabled entry keep ShouldAttach false, so
+				// they're detached until it's enabled again.
+				continue
+			}
 			expectedLinks, error := r.getExpectedLinks(ctx, attachInfo)
 			if error != nil {
 				return fmt.Errorf("failed to get node links: %v", error)
@@ -179,17 +186,20 @@ func (r *NsXdpProgramReconciler) findLink(attachInfoState bpfmaniov1alpha1.XdpAt
 		return nil, fmt.Errorf("failed to get netnsId for path %s", attachInfoState.NetnsPath)
 	}
 	r.Logger.V(1).Info("findlink", "New Path", attachInfoState.NetnsPath, "NetnsId", newNetnsId)
+	matches := []int{}
 	for i, a := range r.currentProgramState.XDP.Links {
 		// attachInfoState is the same as a if the the following fields are the
-		// same: InterfaceName, Priority, ProceedOn, and network namespace.
+		// same: InterfaceName, Priority, ProceedOn, network namespace, and
+		// Metadata.
 		if a.InterfaceName == attachInfoState.InterfaceName &&
-			a.Priority == attachInfoState.Priority &&
-			reflect.DeepEqual(a.ProceedOn, attachInfoState.ProceedOn) &&
-			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) {
-			return &i, nil
+			samePriority(a.Priority, a.AutoPriority, attachInfoState.Priority, attachInfoState.AutoPriority) &&
+			sameProceedOn(a.ProceedOn, attachInfoState.ProceedOn) &&
+			reflect.DeepEqual(r.getNetnsId(a.NetnsPath), newNetnsId) &&
+			maps.Equal(a.Metadata, attachInfoState.Metadata) {
+			matches = append(matches, i)
 		}
 	}
-	return nil, nil
+	return r.keptLink(matches, func(i int) *uint32 { return r.currentProgramState.XDP.Links[i].LinkId }), nil
 }
 
 // processLinks calls reconcileBpfLink() for each link. It
@@ -204,8 +214,36 @@ func (r *NsXdpProgramReconciler) processLinks(ctx context.Context) error {
 	linksToRemove := make(map[int]bool)
 
 	var lastReconcileLinkError error = nil
+	// deferredLinkError is returned if there were no other errors but one or
+	// more links are waiting for their interface to come up.
+	var deferredLinkError error = nil
 	for i := range r.currentProgramState.XDP.Links {
 		r.currentLink = &r.currentProgramState.XDP.Links[i]
+		if err := r.checkXdpInterface(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath); err != nil {
+			if r.currentLink.LinkStatus == bpfmaniov1alpha1.ApAttachDeferred {
+				deferredLinkError = err
+			} else {
+				lastReconcileLinkError = err
+			}
+			continue
+		}
+		if err := r.checkForeignXdpProgram(ctx, r, r.currentLink.InterfaceName, r.currentLink.NetnsPath); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		if err := r.checkXdpDispatcherReturn(ctx, r, r.currentLink.ProceedOn); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
+		point := attachPoint{hook: HookXDP, iface: r.currentLink.InterfaceName,
+			netnsPath: r.currentLink.NetnsPath}
+		if err := r.assignAutoPriority(ctx, r, r.currentLink.AutoPriority, point, &r.currentLink.Priority); err != nil {
+			r.Logger.Error(err, "Not attaching XDP program", "index", i)
+			lastReconcileLinkError = err
+			continue
+		}
 		remove, err := r.reconcileBpfLink(ctx, r)
 		if err != nil {
 			r.Logger.Error(err, "failed to reconcile bpf attachment", "index", i)
@@ -228,17 +266,31 @@ func (r *NsXdpProgramReconciler) processLinks(ctx context.Context) error {
 
 	r.updateProgramAttachStatus()
 
+	if lastReconcileLinkError == nil {
+		return deferredLinkError
+	}
 	return lastReconcileLinkError
 }
 
 func (r *NsXdpProgramReconciler) updateProgramAttachStatus() {
+	status := bpfmaniov1alpha1.ProgAttachSuccess
 	for _, link := range r.currentProgramState.XDP.Links {
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApForeignXdpProgram {
+			status = bpfmaniov1alpha1.ProgAttachForeignXdpProgram
+			continue
+		}
+		if link.ShouldAttach && link.LinkStatus == bpfmaniov1alpha1.ApAttachDeferred {
+			if status == bpfmaniov1alpha1.ProgAttachSuccess {
+				status = bpfmaniov1alpha1.ProgAttachDeferred
+			}
+			continue
+		}
 		if !isAttachSuccess(link.ShouldAttach, link.LinkStatus) {
 			r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachError)
 			return
 		}
 	}
-	r.setProgramLinkStatus(bpfmaniov1alpha1.ProgAttachSuccess)
+	r.setProgramLinkStatus(status)
 }
 
 // removeLinks removes links from a slice of links based on the keys in the map.
@@ -256,13 +308,30 @@ func (r *NsXdpProgramReconciler) removeLinks(links []bpfmaniov1alpha1.XdpAttachI
 // points.
 func (r *NsXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInfo bpfmaniov1alpha1.XdpAttachInfo,
 ) ([]bpfmaniov1alpha1.XdpAttachInfoState, error) {
-	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode)
+	interfaces, err := getInterfaces(&attachInfo.InterfaceSelector, r.ourNode, r.InterfaceCache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get interfaces for XdpNsProgram: %v", err)
 	}
 
 	nodeLinks := []bpfmaniov1alpha1.XdpAttachInfoState{}
 
+	createLinkEntry := func(interfaceName, netnsPath string) bpfmaniov1alpha1.XdpAttachInfoState {
+		return bpfmaniov1alpha1.XdpAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
+				ShouldAttach: true,
+				UUID:         uuid.New().String(),
+				LinkId:       nil,
+				LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
+				Metadata:     attachInfo.Metadata,
+			},
+			InterfaceName: interfaceName,
+			NetnsPath:     netnsPath,
+			Priority:      attachInfo.Priority,
+			AutoPriority:  attachInfo.AutoPriority,
+			ProceedOn:     attachInfo.ProceedOn,
+		}
+	}
+
 	// There is a network namespace selector, so see if there are any matching
 	// pods on this node.
 	containerInfo, err := r.Containers.GetContainers(
@@ -280,22 +349,23 @@ func (r *NsXdpProgramReconciler) getExpectedLinks(ctx context.Context, attachInf
 		// Just use one container per pod to get the pod's network
 		// namespace.
 		containerInfo = GetOneContainerPerPod(containerInfo)
+		hostNetwork := false
 		for _, container := range *containerInfo {
-			netnsPath := netnsPathFromPID(container.pid)
+			netnsPath := r.netnsPathFromPID(container.pid)
+			// Pods that use the host network share the host's network
+			// namespace, so they're attached to it once, below, rather than
+			// once each.
+			if r.inHostNetns(netnsPath) {
+				hostNetwork = true
+				continue
+			}
 			for _, iface := range interfaces {
-				link := bpfmaniov1alpha1.XdpAttachInfoState{
-					AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{
-						ShouldAttach: true,
-						UUID:         uuid.New().String(),
-						LinkId:       nil,
-						LinkStatus:   bpfmaniov1alpha1.ApAttachNotAttached,
-					},
-					InterfaceName: iface,
-					NetnsPath:     netnsPath,
-					Priority:      attachInfo.Priority,
-					ProceedOn:     attachInfo.ProceedOn,
-				}
-				nodeLinks = append(nodeLinks, link)
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, netnsPath))
+			}
+		}
+		if hostNetwork {
+			for _, iface := range interfaces {
+				nodeLinks = append(nodeLinks, createLinkEntry(iface, ""))
 			}
 		}
 	}
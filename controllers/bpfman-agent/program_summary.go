@@ -0,0 +1,352 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/go-logr/logr"
+)
+
+// +kubebuilder:rbac:groups=core,namespace=bpfman,resources=configmaps,verbs=get;create;update;delete
+
+// ProgramSummaryKey is the key of the program summary in the ConfigMap's data.
+const ProgramSummaryKey = "programs.json"
+
+// ProgramSummaryName returns the name of the ConfigMap that summarizes the
+// programs loaded on nodeName.
+func ProgramSummaryName(nodeName string) string {
+	return internal.TruncateName("bpfman-programs-"+nodeName, 253)
+}
+
+// ProgramSummary is the summary of the programs loaded on a node, as kept in
+// its ConfigMap.
+type ProgramSummary struct {
+	NodeName string                `json:"nodeName"`
+	Programs []ProgramSummaryEntry `json:"programs"`
+}
+
+// ProgramSummaryEntry summarizes one program loaded on a node. AttachPoints
+// describes each of the program's attached links.
+type ProgramSummaryEntry struct {
+	Kind         string                             `json:"kind"`
+	Namespace    string                             `json:"namespace,omitempty"`
+	Application  string                             `json:"application"`
+	Name         string                             `json:"name"`
+	Type         bpfmaniov1alpha1.EBPFProgType      `json:"type"`
+	ProgramId    *uint32                            `json:"programId,omitempty"`
+	Status       bpfmaniov1alpha1.ProgramLinkStatus `json:"status"`
+	AttachPoints []string                           `json:"attachPoints"`
+}
+
+// ProgramSummaryReconciler keeps a ConfigMap summarizing the programs loaded
+// on the agent's node up to date from the ClusterBpfApplicationStates and
+// BpfApplicationStates for the node, so a node's programs can be seen without
+// reading every application state object. The ConfigMap is updated at most
+// once every Interval.
+type ProgramSummaryReconciler struct {
+	client.Client
+	Logger    logr.Logger
+	NodeName  string
+	Namespace string
+	Interval  time.Duration
+
+	lastUpdate time.Time
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProgramSummaryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ourStates := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[internal.K8sHostLabel] == r.NodeName
+	})
+	summaryRequest := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{
+			Namespace: r.Namespace,
+			Name:      ProgramSummaryName(r.NodeName),
+		}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("programsummary").
+		Watches(&bpfmaniov1alpha1.ClusterBpfApplicationState{}, summaryRequest, builder.WithPredicates(ourStates)).
+		Watches(&bpfmaniov1alpha1.BpfApplicationState{}, summaryRequest, builder.WithPredicates(ourStates)).
+		Complete(r)
+}
+
+func (r *ProgramSummaryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Logger = ctrl.Log.WithName("program-summary")
+
+	// Changes within the interval are picked up together when it's over.
+	if wait := r.Interval - time.Since(r.lastUpdate); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	summary, err := GetProgramSummary(ctx, r.Client, r.NodeName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to marshal program summary: %w", err)
+	}
+
+	cm := &v1.ConfigMap{}
+	err = r.Get(ctx, req.NamespacedName, cm)
+	switch {
+	case errors.IsNotFound(err):
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      req.Name,
+				Namespace: req.Namespace,
+				Labels:    map[string]string{internal.K8sHostLabel: r.NodeName},
+			},
+			Data: map[string]string{ProgramSummaryKey: string(data)},
+		}
+		r.Logger.Info("Creating program summary", "Name", cm.Name, "Programs", len(summary.Programs))
+		if err := r.Create(ctx, cm); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create program summary %s: %w", cm.Name, err)
+		}
+	case err != nil:
+		return ctrl.Result{}, fmt.Errorf("failed to get program summary %s: %w", req.Name, err)
+	case cm.Data[ProgramSummaryKey] == string(data):
+		return ctrl.Result{}, nil
+	default:
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[ProgramSummaryKey] = string(data)
+		r.Logger.V(1).Info("Updating program summary", "Name", cm.Name, "Programs", len(summary.Programs))
+		if err := r.Update(ctx, cm); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update program summary %s: %w", cm.Name, err)
+		}
+	}
+	r.lastUpdate = time.Now()
+	return ctrl.Result{}, nil
+}
+
+// DeleteProgramSummary deletes the ConfigMap summarizing the programs on
+// nodeName, so it doesn't outlive the agent.
+func DeleteProgramSummary(ctx context.Context, c client.Client, namespace, nodeName string) error {
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: ProgramSummaryName(nodeName)}}
+	if err := c.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete program summary %s: %w", cm.Name, err)
+	}
+	return nil
+}
+
+// GetProgramSummary returns the summary of the programs on nodeName, read from
+// their application state objects. Programs are ordered by application, and
+// then as in the application.
+func GetProgramSummary(ctx context.Context, reader client.Reader, nodeName string) (*ProgramSummary, error) {
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: nodeName}}
+	summary := &ProgramSummary{NodeName: nodeName, Programs: []ProgramSummaryEntry{}}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := reader.List(ctx, clAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	for _, appState := range clAppStates.Items {
+		for _, program := range appState.Status.Programs {
+			summary.Programs = append(summary.Programs, ProgramSummaryEntry{
+				Kind:         "ClusterBpfApplication",
+				Application:  appStateOwnerName(&appState),
+				Name:         program.Name,
+				Type:         program.Type,
+				ProgramId:    program.ProgramId,
+				Status:       program.ProgramLinkStatus,
+				AttachPoints: clProgramAttachPoints(program),
+			})
+		}
+	}
+
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := reader.List(ctx, nsAppStates, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+	for _, appState := range nsAppStates.Items {
+		for _, program := range appState.Status.Programs {
+			summary.Programs = append(summary.Programs, ProgramSummaryEntry{
+				Kind:         "BpfApplication",
+				Namespace:    appState.Namespace,
+				Application:  appStateOwnerName(&appState),
+				Name:         program.Name,
+				Type:         program.Type,
+				ProgramId:    program.ProgramId,
+				Status:       program.ProgramLinkStatus,
+				AttachPoints: nsProgramAttachPoints(program),
+			})
+		}
+	}
+
+	// Sort so the summary only changes when the programs do.
+	slices.SortStableFunc(summary.Programs, func(a, b ProgramSummaryEntry) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Application, b.Application))
+	})
+	return summary, nil
+}
+
+// interfaceAttachPoint describes a link to a network interface.
+func interfaceAttachPoint(hook AttachHook, iface, netnsPath string) string {
+	if netnsPath == "" {
+		return fmt.Sprintf("%s %s", hook, iface)
+	}
+	return fmt.Sprintf("%s %s in netns %s", hook, iface, netnsPath)
+}
+
+// functionAttachPoint describes a link to a function, at offset bytes into it.
+func functionAttachPoint(function string, offset uint64) string {
+	if offset == 0 {
+		return function
+	}
+	return fmt.Sprintf("%s+%d", function, offset)
+}
+
+// uprobeAttachPoint describes a uprobe or uretprobe link.
+func uprobeAttachPoint(target, function string, offset uint64) string {
+	return target + ":" + functionAttachPoint(function, offset)
+}
+
+func attached(l bpfmaniov1alpha1.AttachInfoStateCommon) bool {
+	return l.LinkStatus == bpfmaniov1alpha1.ApAttachAttached
+}
+
+// clProgramAttachPoints describes the attached links of a
+// ClusterBpfApplicationState program.
+func clProgramAttachPoints(program bpfmaniov1alpha1.ClBpfApplicationProgramState) []string {
+	points := []string{}
+	switch {
+	case program.XDP != nil:
+		for _, l := range program.XDP.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(HookXDP, l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.TC != nil:
+		for _, l := range program.TC.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(tcAttachHook(l.Direction, false), l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.TCX != nil:
+		for _, l := range program.TCX.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(tcAttachHook(l.Direction, true), l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.FEntry != nil:
+		for _, l := range program.FEntry.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, program.FEntry.Function)
+			}
+		}
+	case program.FExit != nil:
+		for _, l := range program.FExit.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, program.FExit.Function)
+			}
+		}
+	case program.KProbe != nil:
+		for _, l := range program.KProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, functionAttachPoint(l.Function, l.Offset))
+			}
+		}
+	case program.KRetProbe != nil:
+		for _, l := range program.KRetProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, l.Function)
+			}
+		}
+	case program.UProbe != nil:
+		for _, l := range program.UProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, uprobeAttachPoint(l.Target, l.Function, l.Offset))
+			}
+		}
+	case program.URetProbe != nil:
+		for _, l := range program.URetProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, uprobeAttachPoint(l.Target, l.Function, l.Offset))
+			}
+		}
+	case program.TracePoint != nil:
+		for _, l := range program.TracePoint.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, l.Name)
+			}
+		}
+	}
+	return points
+}
+
+// nsProgramAttachPoints describes the attached links of a BpfApplicationState
+// program.
+func nsProgramAttachPoints(program bpfmaniov1alpha1.BpfApplicationProgramState) []string {
+	points := []string{}
+	switch {
+	case program.XDP != nil:
+		for _, l := range program.XDP.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(HookXDP, l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.TC != nil:
+		for _, l := range program.TC.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(tcAttachHook(l.Direction, false), l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.TCX != nil:
+		for _, l := range program.TCX.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, interfaceAttachPoint(tcAttachHook(l.Direction, true), l.InterfaceName, l.NetnsPath))
+			}
+		}
+	case program.UProbe != nil:
+		for _, l := range program.UProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, uprobeAttachPoint(l.Target, l.Function, l.Offset))
+			}
+		}
+	case program.URetProbe != nil:
+		for _, l := range program.URetProbe.Links {
+			if attached(l.AttachInfoStateCommon) {
+				points = append(points, uprobeAttachPoint(l.Target, l.Function, l.Offset))
+			}
+		}
+	}
+	return points
+}
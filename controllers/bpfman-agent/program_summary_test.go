@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestProgramSummary(t *testing.T) {
+	var (
+		nodeName  = "fake-control-plane"
+		programId = uint32(42)
+		ctx       = context.TODO()
+	)
+
+	xdpLink := func(iface string) bpfmaniov1alpha1.ClXdpAttachInfoState {
+		return bpfmaniov1alpha1.ClXdpAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkStatus: bpfmaniov1alpha1.ApAttachAttached},
+			InterfaceName:         iface,
+		}
+	}
+	clAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "xdp-app-12345",
+			Labels: map[string]string{internal.K8sHostLabel: nodeName, internal.BpfAppStateOwner: "xdp-app"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: bpfmaniov1alpha1.SchemeGroupVersion.String(),
+				Kind:       "ClusterBpfApplication",
+				Name:       "xdp-app",
+				UID:        "xdp-app-uid",
+				Controller: &[]bool{true}[0],
+			}},
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{{
+				BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{
+					Name:              "xdp_stats",
+					ProgramId:         &programId,
+					ProgramLinkStatus: bpfmaniov1alpha1.ProgAttachSuccess,
+				},
+				Type: bpfmaniov1alpha1.ProgTypeXDP,
+				XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{
+					Links: []bpfmaniov1alpha1.ClXdpAttachInfoState{xdpLink("eth0")},
+				},
+			}},
+		},
+	}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().
+		WithStatusSubresource(clAppState).
+		WithRuntimeObjects(clAppState).
+		Build()
+
+	r := &ProgramSummaryReconciler{
+		Client:    cl,
+		NodeName:  nodeName,
+		Namespace: internal.BpfmanNamespace,
+		Interval:  time.Minute,
+	}
+	key := types.NamespacedName{Namespace: internal.BpfmanNamespace, Name: ProgramSummaryName(nodeName)}
+	req := reconcile.Request{NamespacedName: key}
+
+	programSummary := func() *ProgramSummary {
+		cm := &v1.ConfigMap{}
+		require.NoError(t, cl.Get(ctx, key, cm))
+		require.Equal(t, nodeName, cm.Labels[internal.K8sHostLabel])
+		summary := &ProgramSummary{}
+		require.NoError(t, json.Unmarshal([]byte(cm.Data[ProgramSummaryKey]), summary))
+		return summary
+	}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+	require.Equal(t, &ProgramSummary{
+		NodeName: nodeName,
+		Programs: []ProgramSummaryEntry{{
+			Kind:         "ClusterBpfApplication",
+			Application:  "xdp-app",
+			Name:         "xdp_stats",
+			Type:         bpfmaniov1alpha1.ProgTypeXDP,
+			ProgramId:    &programId,
+			Status:       bpfmaniov1alpha1.ProgAttachSuccess,
+			AttachPoints: []string{"XDP eth0"},
+		}},
+	}, programSummary())
+
+	// A link attached within the interval is picked up once it's over.
+	clAppState.Status.Programs[0].XDP.Links = append(clAppState.Status.Programs[0].XDP.Links, xdpLink("eth1"))
+	require.NoError(t, cl.Status().Update(ctx, clAppState))
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Greater(t, res.RequeueAfter, time.Duration(0))
+	require.Equal(t, []string{"XDP eth0"}, programSummary().Programs[0].AttachPoints)
+
+	r.lastUpdate = time.Now().Add(-r.Interval)
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+	require.Equal(t, []string{"XDP eth0", "XDP eth1"}, programSummary().Programs[0].AttachPoints)
+
+	// The summary is deleted when the agent shuts down.
+	require.NoError(t, DeleteProgramSummary(ctx, cl, internal.BpfmanNamespace, nodeName))
+	require.True(t, errors.IsNotFound(cl.Get(ctx, key, &v1.ConfigMap{})))
+	require.NoError(t, DeleteProgramSummary(ctx, cl, internal.BpfmanNamespace, nodeName))
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// errProgramTypeMismatch is returned when bpfman loads a program as a
+// different kernel program type than the one requested for it, which happens
+// when the bytecode holds a different type of program than the application
+// declares.
+var errProgramTypeMismatch = errors.New("program type mismatch")
+
+// checkProgramTypes verifies that the kernel program type of each loaded
+// program is the one expected for it, as given by the program reconciler's
+// getProgType(). If any program doesn't match, all of the loaded programs are
+// unloaded again, so that they aren't left loaded without being recorded, and
+// an error wrapping errProgramTypeMismatch is returned.
+func (r *ReconcilerCommon) checkProgramTypes(ctx context.Context, expected map[string]internal.ProgramType,
+	loaded []*gobpfman.LoadResponseInfo) error {
+	var mismatch error
+	for _, program := range loaded {
+		want, ok := expected[program.GetInfo().GetName()]
+		// A program type of 0 (unspec) means bpfman didn't report the type,
+		// so there's nothing to check.
+		got := internal.ProgramType(program.GetKernelInfo().GetProgramType())
+		if !ok || got == internal.Unspec || got == want {
+			continue
+		}
+		mismatch = fmt.Errorf("program %s was loaded as a %s program, expected %s: %w",
+			program.GetInfo().GetName(), got, want, errProgramTypeMismatch)
+		break
+	}
+	if mismatch == nil {
+		return nil
+	}
+
+	for _, program := range loaded {
+		if err := bpfmanagentinternal.UnloadBpfmanProgram(ctx, r.BpfmanClient, program.GetKernelInfo().GetId()); err != nil {
+			r.Logger.Error(err, "failed to unload program with the wrong type", "ProgramId", program.GetKernelInfo().GetId())
+		}
+	}
+	return mismatch
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultPodEventQPS is the default sustained rate at which pod events
+	// may trigger a reconcile.
+	DefaultPodEventQPS = 5.0
+	// DefaultPodEventBurst is the default number of pod-triggered reconciles
+	// allowed before DefaultPodEventQPS applies.
+	DefaultPodEventBurst = 10
+	// DefaultEventCoalesceWindow is the default time node and pod events are
+	// held back so a burst of them, such as at startup, collapses into a
+	// single reconcile.
+	DefaultEventCoalesceWindow = 100 * time.Millisecond
+	// DefaultNamespaceUpdateQPS is the default sustained rate at which the
+	// BpfApplicationStates in a namespace may be updated.
+	DefaultNamespaceUpdateQPS = 2.0
+	// DefaultNamespaceUpdateBurst is the default number of updates to the
+	// BpfApplicationStates in a namespace allowed before
+	// DefaultNamespaceUpdateQPS applies.
+	DefaultNamespaceUpdateBurst = 10
+
+	// resyncRequestName is the request name used for all node and pod events.
+	// Each agent reconcile is a full pass over every application on the node,
+	// so it doesn't matter which node or pod triggered it. Funnelling these
+	// events into a single key lets the workqueue collapse a burst of them
+	// into a single pending reconcile.
+	resyncRequestName = "resync"
+)
+
+// perItemBucketRateLimiter is a token bucket rate limiter that keeps a
+// separate bucket for each item, such as the namespace of the
+// BpfApplicationStates being updated.
+type perItemBucketRateLimiter[T comparable] struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[T]*rate.Limiter
+}
+
+func newPerItemBucketRateLimiter[T comparable](qps float64, burst int) *perItemBucketRateLimiter[T] {
+	return &perItemBucketRateLimiter[T]{
+		limit:    rate.Limit(qps),
+		burst:    burst,
+		limiters: map[T]*rate.Limiter{},
+	}
+}
+
+func (r *perItemBucketRateLimiter[T]) limiter(item T) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limiter, ok := r.limiters[item]
+	if !ok {
+		limiter = rate.NewLimiter(r.limit, r.burst)
+		r.limiters[item] = limiter
+	}
+	return limiter
+}
+
+// Delay returns how long the item must wait before it may be processed,
+// without consuming a token.
+func (r *perItemBucketRateLimiter[T]) Delay(item T) time.Duration {
+	tokens := r.limiter(item).TokensAt(time.Now())
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / float64(r.limit) * float64(time.Second))
+}
+
+// Take consumes a token for the item, even if none are left, in which case
+// the item's next Delay is longer.
+func (r *perItemBucketRateLimiter[T]) Take(item T) {
+	r.limiter(item).ReserveN(time.Now(), 1)
+}
+
+// reserve reserves a token from limiter and returns how long to wait before
+// proceeding. The reservation is kept even when the caller has to wait, so
+// every delayed request is paid for and the requests that eventually run
+// don't exceed the limiter's rate.
+func reserve(limiter *rate.Limiter) time.Duration {
+	return limiter.Reserve().Delay()
+}
+
+// coalescingEventHandler returns an event handler that enqueues a single
+// reconcile request for all events. Each request is delayed by window, and
+// further as needed so reconciles don't exceed qps (with the given burst). A
+// qps of 0 or less disables rate limiting. Because the workqueue
+// de-duplicates pending requests, events received while a request is waiting
+// are folded into it, and the reconcile that eventually runs sees the latest
+// state of every node and pod.
+func coalescingEventHandler(qps float64, burst int, window time.Duration) handler.EventHandler {
+	var limiter *rate.Limiter
+	if qps > 0 {
+		limiter = rate.NewLimiter(rate.Limit(qps), burst)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: resyncRequestName}}
+
+	enqueue := func(q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		delay := window
+		if limiter != nil {
+			if d := reserve(limiter); d > delay {
+				delay = d
+			}
+		}
+		if delay > 0 {
+			q.AddAfter(req, delay)
+		} else {
+			q.Add(req)
+		}
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, _ event.TypedCreateEvent[client.Object], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q)
+		},
+		UpdateFunc: func(_ context.Context, _ event.TypedUpdateEvent[client.Object], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q)
+		},
+		DeleteFunc: func(_ context.Context, _ event.TypedDeleteEvent[client.Object], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q)
+		},
+		GenericFunc: func(_ context.Context, _ event.TypedGenericEvent[client.Object], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q)
+		},
+	}
+}
+
+// resyncEventHandler returns the handler used for node and pod events. The
+// same handler must be used for both, so they share the rate limit. If both
+// rate limiting and coalescing are disabled, every event enqueues a request
+// for the object that changed.
+func (r *ReconcilerCommon) resyncEventHandler() handler.EventHandler {
+	if r.PodEventQPS <= 0 && r.EventCoalesceWindow <= 0 {
+		return &handler.EnqueueRequestForObject{}
+	}
+	return coalescingEventHandler(r.PodEventQPS, r.PodEventBurst, r.EventCoalesceWindow)
+}
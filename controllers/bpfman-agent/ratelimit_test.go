@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReserve(t *testing.T) {
+	limiter := rate.NewLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		require.Zero(t, reserve(limiter), "call %d should be within the burst", i)
+	}
+	delay := reserve(limiter)
+	require.Greater(t, delay, time.Duration(0))
+
+	// Delayed calls keep their reservation, so the next one waits longer.
+	require.Greater(t, reserve(limiter), delay)
+}
+
+func TestPerItemBucketRateLimiterTake(t *testing.T) {
+	limiter := newPerItemBucketRateLimiter[string](1, 2)
+
+	require.Zero(t, limiter.Delay("a"))
+	limiter.Take("a")
+	limiter.Take("a")
+	delay := limiter.Delay("a")
+	require.Greater(t, delay, time.Duration(0))
+	require.LessOrEqual(t, delay, time.Second)
+
+	// Take always consumes a token, so the delay grows.
+	limiter.Take("a")
+	require.Greater(t, limiter.Delay("a"), delay)
+
+	require.Zero(t, limiter.Delay("b"))
+}
+
+func TestRateLimitedPodHandlerBoundsReconciles(t *testing.T) {
+	const (
+		qps    = 10
+		burst  = 2
+		events = 100
+	)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	var mu sync.Mutex
+	var reconciles []time.Time
+	go func() {
+		for {
+			req, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+			mu.Lock()
+			reconciles = append(reconciles, time.Now())
+			mu.Unlock()
+			queue.Forget(req)
+			queue.Done(req)
+		}
+	}()
+
+	h := coalescingEventHandler(qps, burst, 0)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < events; i++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}}
+		switch i % 3 {
+		case 0:
+			h.Create(ctx, event.CreateEvent{Object: pod}, queue)
+		case 1:
+			h.Update(ctx, event.UpdateEvent{ObjectOld: pod, ObjectNew: pod}, queue)
+		case 2:
+			h.Delete(ctx, event.DeleteEvent{Object: pod}, queue)
+		}
+	}
+	lastEvent := time.Now()
+
+	// Wait long enough for any delayed request to be processed.
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	elapsed := time.Since(start)
+	maxReconciles := burst + int(elapsed.Seconds()*qps) + 1
+	require.NotEmpty(t, reconciles)
+	require.LessOrEqual(t, len(reconciles), maxReconciles)
+	require.Less(t, len(reconciles), events)
+
+	// The last pod event must be followed by a reconcile so the final state
+	// reflects the latest set of pods.
+	require.False(t, reconciles[len(reconciles)-1].Before(lastEvent),
+		"no reconcile ran after the last pod event")
+}
+
+func TestRateLimitedPodHandlerBoundsReconcilesPerSecond(t *testing.T) {
+	const (
+		qps    = 10
+		burst  = 2
+		events = 100
+	)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	var mu sync.Mutex
+	var reconciles []time.Time
+	go func() {
+		for {
+			req, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+			mu.Lock()
+			reconciles = append(reconciles, time.Now())
+			mu.Unlock()
+			queue.Forget(req)
+			queue.Done(req)
+		}
+	}()
+
+	// Spread the events over a second, so delayed requests become due while
+	// events are still arriving.
+	h := coalescingEventHandler(qps, burst, 0)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < events; i++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}}
+		h.Update(ctx, event.UpdateEvent{ObjectOld: pod, ObjectNew: pod}, queue)
+		time.Sleep(time.Second / events)
+	}
+	end := start.Add(time.Second)
+	time.Sleep(time.Until(end))
+
+	mu.Lock()
+	defer mu.Unlock()
+	ran := 0
+	for _, reconciled := range reconciles {
+		if reconciled.Before(end) {
+			ran++
+		}
+	}
+	require.NotZero(t, ran)
+	require.LessOrEqual(t, ran, qps+burst)
+}
+
+func TestCoalescingEventHandlerCollapsesBurst(t *testing.T) {
+	const (
+		pods   = 50
+		window = 200 * time.Millisecond
+	)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	// podStore stands in for the informer cache that a reconcile lists from.
+	var mu sync.Mutex
+	podStore := map[string]bool{}
+	var podsSeen []int
+	go func() {
+		for {
+			req, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+			mu.Lock()
+			podsSeen = append(podsSeen, len(podStore))
+			mu.Unlock()
+			queue.Forget(req)
+			queue.Done(req)
+		}
+	}()
+
+	// A node and a pod event share the same handler, as they do in
+	// SetupWithManager.
+	h := coalescingEventHandler(DefaultPodEventQPS, DefaultPodEventBurst, window)
+	ctx := context.Background()
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "fake-control-plane"}}
+	h.Create(ctx, event.CreateEvent{Object: node}, queue)
+	for i := 0; i < pods; i++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}}
+		mu.Lock()
+		podStore[pod.Name] = true
+		mu.Unlock()
+		h.Create(ctx, event.CreateEvent{Object: pod}, queue)
+	}
+	h.Update(ctx, event.UpdateEvent{ObjectOld: node, ObjectNew: node}, queue)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(podsSeen) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Give any stray request time to be processed.
+	time.Sleep(2 * window)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []int{pods}, podsSeen, "expected a single reconcile that sees every pod")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errSharedMapNotFound is returned when no program on the node has the map
+// named by an application's sharedMap.
+var errSharedMapNotFound = errors.New("shared map not found")
+
+// resolveSharedMap returns the id of the bpfman program that owns the map
+// named by ref, to be passed as a load request's MapOwnerId, or nil if ref
+// is nil. bpfman pins each program's maps by name in its map pin path, so the
+// owner is the program whose maps include one with that name. Programs
+// loaded for the application itself, and programs that use another
+// program's maps, are skipped. If owner isn't nil, only programs for which it
+// returns true for the uuid metadata are considered.
+func (r *ReconcilerCommon) resolveSharedMap(ctx context.Context, ref *bpfmaniov1alpha1.SharedMapReference,
+	appUID string, owner func(uuid string) bool) (*uint32, error) {
+	if ref == nil {
+		return nil, nil
+	}
+
+	keys := r.metadataKeys()
+	programs, err := bpfmanagentinternal.ListAllPrograms(ctx, r.BpfmanClient)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []uint32{}
+	for _, program := range programs {
+		info := program.GetInfo()
+		if info == nil || program.GetKernelInfo() == nil {
+			continue
+		}
+		metadata := info.GetMetadata()
+		switch {
+		case info.MapOwnerId != nil, info.GetMapPinPath() == "":
+			continue
+		case metadata[keys.Uuid] == appUID:
+			continue
+		case owner != nil && !owner(metadata[keys.Uuid]):
+			continue
+		case ref.ApplicationName != "" && metadata[keys.ProgramName] != ref.ApplicationName:
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(info.GetMapPinPath(), ref.Name)); err != nil {
+			continue
+		}
+		if id := program.GetKernelInfo().GetId(); !slices.Contains(owners, id) {
+			owners = append(owners, id)
+		}
+	}
+
+	switch len(owners) {
+	case 0:
+		return nil, fmt.Errorf("no program on the node has a map named %s: %w", ref.Name, errSharedMapNotFound)
+	case 1:
+		r.Logger.V(1).Info("Resolved shared map", "Map", ref.Name, "MapOwnerId", owners[0])
+		return &owners[0], nil
+	default:
+		slices.Sort(owners)
+		return nil, fmt.Errorf("programs %v all have a map named %s, set sharedMap.applicationName to choose one",
+			owners, ref.Name)
+	}
+}
+
+// namespaceAppStateUIDs returns a function that reports whether a bpfman.io/uuid
+// is the UID of a BpfApplicationState for the node in the given namespace,
+// which is how a BpfApplication's shared map is kept to its own namespace.
+func (r *ReconcilerCommon) namespaceAppStateUIDs(ctx context.Context, namespace string) (func(uuid string) bool, error) {
+	appStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := r.List(ctx, appStates, client.InNamespace(namespace),
+		client.MatchingLabels{internal.K8sHostLabel: r.NodeName}); err != nil {
+		return nil, fmt.Errorf("failed to list BpfApplicationStates in %s: %w", namespace, err)
+	}
+	uids := map[string]bool{}
+	for _, appState := range appStates.Items {
+		uids[string(appState.UID)] = true
+	}
+	return func(uuid string) bool { return uids[uuid] }, nil
+}
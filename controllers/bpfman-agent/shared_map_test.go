@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// mapOwnerProgram returns a bpfman program, loaded for the application with
+// the given name and UID, whose maps are pinned in a temporary directory.
+func mapOwnerProgram(t *testing.T, id uint32, appName, appUID string, maps ...string) *gobpfman.ListResponse_ListResult {
+	mapPinPath := t.TempDir()
+	for _, m := range maps {
+		require.NoError(t, os.WriteFile(filepath.Join(mapPinPath, m), nil, 0o600))
+	}
+	return &gobpfman.ListResponse_ListResult{
+		Info: &gobpfman.ProgramInfo{
+			MapPinPath: mapPinPath,
+			Metadata:   map[string]string{internal.NewMetadataKeys("").Uuid: appUID, internal.NewMetadataKeys("").ProgramName: appName},
+		},
+		KernelInfo: &gobpfman.KernelProgramInfo{Id: id},
+	}
+}
+
+func TestResolveSharedMap(t *testing.T) {
+	ctx := context.TODO()
+	mapUser := mapOwnerProgram(t, 9, "map-user", "user-uid", "counts")
+	mapUser.Info.MapOwnerId = &[]uint32{7}[0]
+	programs := []*gobpfman.ListResponse_ListResult{
+		mapOwnerProgram(t, 5, "self", "self-uid", "counts"),
+		mapOwnerProgram(t, 7, "owner-a", "uid-a", "counts", "flows"),
+		mapOwnerProgram(t, 8, "owner-b", "uid-b", "counts"),
+		mapUser,
+	}
+
+	tests := []struct {
+		name    string
+		ref     *bpfmaniov1alpha1.SharedMapReference
+		owner   func(string) bool
+		want    *uint32
+		wantErr string
+	}{
+		{
+			name: "no shared map",
+		},
+		{
+			name: "unique map",
+			ref:  &bpfmaniov1alpha1.SharedMapReference{Name: "flows"},
+			want: &[]uint32{7}[0],
+		},
+		{
+			name:    "missing map",
+			ref:     &bpfmaniov1alpha1.SharedMapReference{Name: "missing"},
+			wantErr: "no program on the node has a map named missing: shared map not found",
+		},
+		{
+			name:    "several owners",
+			ref:     &bpfmaniov1alpha1.SharedMapReference{Name: "counts"},
+			wantErr: "programs [7 8] all have a map named counts, set sharedMap.applicationName to choose one",
+		},
+		{
+			name: "owner chosen by application name",
+			ref:  &bpfmaniov1alpha1.SharedMapReference{Name: "counts", ApplicationName: "owner-b"},
+			want: &[]uint32{8}[0],
+		},
+		{
+			name:  "owner limited to a namespace",
+			ref:   &bpfmaniov1alpha1.SharedMapReference{Name: "counts"},
+			owner: func(uuid string) bool { return uuid == "uid-a" },
+			want:  &[]uint32{7}[0],
+		},
+		{
+			name:    "owner in another namespace",
+			ref:     &bpfmaniov1alpha1.SharedMapReference{Name: "counts", ApplicationName: "owner-b"},
+			owner:   func(uuid string) bool { return uuid == "uid-a" },
+			wantErr: "no program on the node has a map named counts: shared map not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := agenttestutils.NewBpfmanClientFake()
+			cli.ListResults = programs
+			r := &ReconcilerCommon{BpfmanClient: cli}
+
+			got, err := r.resolveSharedMap(ctx, tc.ref, "self-uid", tc.owner)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var programTypes = []bpfmaniov1alpha1.EBPFProgType{
+	bpfmaniov1alpha1.ProgTypeXDP,
+	bpfmaniov1alpha1.ProgTypeTC,
+	bpfmaniov1alpha1.ProgTypeTCX,
+	bpfmaniov1alpha1.ProgTypeFentry,
+	bpfmaniov1alpha1.ProgTypeFexit,
+	bpfmaniov1alpha1.ProgTypeKprobe,
+	bpfmaniov1alpha1.ProgTypeKretprobe,
+	bpfmaniov1alpha1.ProgTypeUprobe,
+	bpfmaniov1alpha1.ProgTypeUretprobe,
+	bpfmaniov1alpha1.ProgTypeTracepoint,
+}
+
+// ParseProgramTypes parses a comma separated list of program types, such as
+// "XDP,TC,TCX", into a set. Type names aren't case sensitive. An empty string
+// returns an empty set.
+func ParseProgramTypes(s string) (map[bpfmaniov1alpha1.EBPFProgType]bool, error) {
+	types := map[bpfmaniov1alpha1.EBPFProgType]bool{}
+	if strings.TrimSpace(s) == "" {
+		return types, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, t := range programTypes {
+			if strings.EqualFold(name, string(t)) {
+				types[t] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown program type %q", name)
+		}
+	}
+	return types, nil
+}
+
+// clAppStateLinkIds returns the IDs of the links in a
+// ClusterBpfApplicationState for programs of the given types or with the given
+// names.
+func clAppStateLinkIds(appState *bpfmaniov1alpha1.ClusterBpfApplicationState,
+	types map[bpfmaniov1alpha1.EBPFProgType]bool, names map[string]bool) []uint32 {
+	ids := []uint32{}
+	add := func(id *uint32) {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	for _, program := range appState.Status.Programs {
+		if !types[program.Type] && !names[program.Name] {
+			continue
+		}
+		switch {
+		case program.XDP != nil:
+			for _, l := range program.XDP.Links {
+				add(l.LinkId)
+			}
+		case program.TC != nil:
+			for _, l := range program.TC.Links {
+				add(l.LinkId)
+			}
+		case program.TCX != nil:
+			for _, l := range program.TCX.Links {
+				add(l.LinkId)
+			}
+		case program.FEntry != nil:
+			for _, l := range program.FEntry.Links {
+				add(l.LinkId)
+			}
+		case program.FExit != nil:
+			for _, l := range program.FExit.Links {
+				add(l.LinkId)
+			}
+		case program.KProbe != nil:
+			for _, l := range program.KProbe.Links {
+				add(l.LinkId)
+			}
+		case program.KRetProbe != nil:
+			for _, l := range program.KRetProbe.Links {
+				add(l.LinkId)
+			}
+		case program.UProbe != nil:
+			for _, l := range program.UProbe.Links {
+				add(l.LinkId)
+			}
+		case program.URetProbe != nil:
+			for _, l := range program.URetProbe.Links {
+				add(l.LinkId)
+			}
+		case program.TracePoint != nil:
+			for _, l := range program.TracePoint.Links {
+				add(l.LinkId)
+			}
+		}
+	}
+	return ids
+}
+
+// nsAppStateLinkIds returns the IDs of the links in a BpfApplicationState for
+// programs of the given types or with the given names.
+func nsAppStateLinkIds(appState *bpfmaniov1alpha1.BpfApplicationState,
+	types map[bpfmaniov1alpha1.EBPFProgType]bool, names map[string]bool) []uint32 {
+	ids := []uint32{}
+	add := func(id *uint32) {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	for _, program := range appState.Status.Programs {
+		if !types[program.Type] && !names[program.Name] {
+			continue
+		}
+		switch {
+		case program.XDP != nil:
+			for _, l := range program.XDP.Links {
+				add(l.LinkId)
+			}
+		case program.TC != nil:
+			for _, l := range program.TC.Links {
+				add(l.LinkId)
+			}
+		case program.TCX != nil:
+			for _, l := range program.TCX.Links {
+				add(l.LinkId)
+			}
+		case program.UProbe != nil:
+			for _, l := range program.UProbe.Links {
+				add(l.LinkId)
+			}
+		case program.URetProbe != nil:
+			for _, l := range program.URetProbe.Links {
+				add(l.LinkId)
+			}
+		}
+	}
+	return ids
+}
+
+// clDetachOnExitPrograms returns the names of the programs in the
+// ClusterBpfApplication that owns appState whose links are detached when the
+// agent exits. The application is found from appState's controller, since the
+// BpfAppStateOwner label is truncated for long names.
+func clDetachOnExitPrograms(ctx context.Context, reader client.Reader,
+	appState *bpfmaniov1alpha1.ClusterBpfApplicationState, logger logr.Logger) (map[string]bool, error) {
+	owner := metav1.GetControllerOf(appState)
+	if owner == nil {
+		logger.Info("ClusterBpfApplicationState has no owner, not detaching its DetachOnAgentExit links",
+			"AppState Name", appState.Name)
+		return nil, nil
+	}
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: owner.Name}, app); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("ClusterBpfApplication not found, not detaching its DetachOnAgentExit links",
+				"Name", owner.Name, "AppState Name", appState.Name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ClusterBpfApplication %s: %w", owner.Name, err)
+	}
+	if app.UID != owner.UID {
+		logger.Info("ClusterBpfApplication was replaced, not detaching its DetachOnAgentExit links",
+			"Name", owner.Name, "AppState Name", appState.Name)
+		return nil, nil
+	}
+	names := map[string]bool{}
+	for _, program := range app.Spec.Programs {
+		if program.LinkPersistence == bpfmaniov1alpha1.LinkDetachOnAgentExit {
+			names[program.Name] = true
+		}
+	}
+	return names, nil
+}
+
+// nsDetachOnExitPrograms returns the names of the programs in the
+// BpfApplication that owns appState whose links are detached when the agent
+// exits. The application is found as for clDetachOnExitPrograms.
+func nsDetachOnExitPrograms(ctx context.Context, reader client.Reader,
+	appState *bpfmaniov1alpha1.BpfApplicationState, logger logr.Logger) (map[string]bool, error) {
+	owner := metav1.GetControllerOf(appState)
+	if owner == nil {
+		logger.Info("BpfApplicationState has no owner, not detaching its DetachOnAgentExit links",
+			"AppState Name", appState.Name, "Namespace", appState.Namespace)
+		return nil, nil
+	}
+	app := &bpfmaniov1alpha1.BpfApplication{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: appState.Namespace, Name: owner.Name}, app); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("BpfApplication not found, not detaching its DetachOnAgentExit links",
+				"Name", owner.Name, "Namespace", appState.Namespace, "AppState Name", appState.Name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get BpfApplication %s/%s: %w", appState.Namespace, owner.Name, err)
+	}
+	if app.UID != owner.UID {
+		logger.Info("BpfApplication was replaced, not detaching its DetachOnAgentExit links",
+			"Name", owner.Name, "Namespace", appState.Namespace, "AppState Name", appState.Name)
+		return nil, nil
+	}
+	names := map[string]bool{}
+	for _, program := range app.Spec.Programs {
+		if program.LinkPersistence == bpfmaniov1alpha1.LinkDetachOnAgentExit {
+			names[program.Name] = true
+		}
+	}
+	return names, nil
+}
+
+// DetachOnShutdown detaches the links that the agent attached on nodeName for
+// all programs of the given types, and for programs whose linkPersistence is
+// DetachOnAgentExit, using the application state objects to find them. The
+// programs stay loaded, and the application state objects aren't changed, so
+// the next agent to start sees the links are missing and reattaches them.
+// A BpfApplication's links are detached by the bpfman in
+// namespaceBpfmanClients that serves its namespace, if there is one, and by
+// bpfmanClient otherwise.
+//
+// It's meant to be called once the manager has stopped, so it takes a client
+// that reads directly from the API server rather than from the cache.
+func DetachOnShutdown(ctx context.Context, reader client.Reader, bpfmanClient gobpfman.BpfmanClient,
+	namespaceBpfmanClients map[string]gobpfman.BpfmanClient, nodeName string,
+	progTypes map[bpfmaniov1alpha1.EBPFProgType]bool, logger logr.Logger) error {
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: nodeName}}
+
+	// The links to detach, with the client of the bpfman that attached them.
+	type link struct {
+		id     uint32
+		client gobpfman.BpfmanClient
+	}
+	links := []link{}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := reader.List(ctx, clAppStates, opts...); err != nil {
+		return fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	for i := range clAppStates.Items {
+		names, err := clDetachOnExitPrograms(ctx, reader, &clAppStates.Items[i], logger)
+		if err != nil {
+			return err
+		}
+		for _, id := range clAppStateLinkIds(&clAppStates.Items[i], progTypes, names) {
+			links = append(links, link{id: id, client: bpfmanClient})
+		}
+	}
+
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := reader.List(ctx, nsAppStates, opts...); err != nil {
+		return fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+	for i := range nsAppStates.Items {
+		names, err := nsDetachOnExitPrograms(ctx, reader, &nsAppStates.Items[i], logger)
+		if err != nil {
+			return err
+		}
+		nsClient := bpfmanClientFor(namespaceBpfmanClients, nsAppStates.Items[i].Namespace, bpfmanClient)
+		for _, id := range nsAppStateLinkIds(&nsAppStates.Items[i], progTypes, names) {
+			links = append(links, link{id: id, client: nsClient})
+		}
+	}
+
+	// Keep going on errors, so one bad link doesn't leave the rest attached.
+	var errs []error
+	for _, l := range links {
+		err := bpfmanagentinternal.DetachBpfmanProgram(ctx, l.client, l.id)
+		switch {
+		case errors.Is(err, bpfmanagentinternal.ErrProgramNotFound):
+			// Already gone.
+		case err != nil:
+			errs = append(errs, fmt.Errorf("link %d: %w", l.id, err))
+		default:
+			logger.Info("detached link on shutdown", "LinkId", l.id)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestParseProgramTypes(t *testing.T) {
+	types, err := ParseProgramTypes("")
+	require.NoError(t, err)
+	require.Empty(t, types)
+
+	types, err = ParseProgramTypes("XDP, tc,TCX,uprobe")
+	require.NoError(t, err)
+	require.Equal(t, map[bpfmaniov1alpha1.EBPFProgType]bool{
+		bpfmaniov1alpha1.ProgTypeXDP:    true,
+		bpfmaniov1alpha1.ProgTypeTC:     true,
+		bpfmaniov1alpha1.ProgTypeTCX:    true,
+		bpfmaniov1alpha1.ProgTypeUprobe: true,
+	}, types)
+
+	_, err = ParseProgramTypes("XDP,bogus")
+	require.Error(t, err)
+}
+
+func TestDetachOnShutdown(t *testing.T) {
+	const nodeName = "fake-control-plane"
+	ctx := context.TODO()
+	id := func(i uint32) *uint32 { return &i }
+	nodeLabels := map[string]string{internal.K8sHostLabel: nodeName}
+
+	clAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "cl-app", Labels: nodeLabels},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				{
+					Type: bpfmaniov1alpha1.ProgTypeXDP,
+					XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClXdpAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(1)}},
+							// Not attached yet, so there's nothing to detach.
+							{},
+						},
+					},
+				},
+				{
+					Type: bpfmaniov1alpha1.ProgTypeUprobe,
+					UProbe: &bpfmaniov1alpha1.ClUprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClUprobeAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(2)}},
+						},
+					},
+				},
+			},
+		},
+	}
+	nsAppState := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-app", Namespace: "default", Labels: nodeLabels},
+		Status: bpfmaniov1alpha1.BpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgramState{
+				{
+					Type: bpfmaniov1alpha1.ProgTypeTC,
+					TC: &bpfmaniov1alpha1.TcProgramInfoState{
+						Links: []bpfmaniov1alpha1.TcAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(3)}},
+						},
+					},
+				},
+				{
+					Type: bpfmaniov1alpha1.ProgTypeUretprobe,
+					URetProbe: &bpfmaniov1alpha1.UprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.UprobeAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(4)}},
+						},
+					},
+				},
+			},
+		},
+	}
+	// An application state for another node must be left alone.
+	otherNodeAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{Name: "cl-app-other", Labels: map[string]string{internal.K8sHostLabel: "other"}},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				{
+					Type: bpfmaniov1alpha1.ProgTypeXDP,
+					XDP: &bpfmaniov1alpha1.ClXdpProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClXdpAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(5)}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(clAppState, nsAppState, otherNodeAppState).Build()
+
+	cli := agenttestutils.NewBpfmanClientFake()
+	for i := 1; i <= 5; i++ {
+		cli.Links[i] = true
+	}
+
+	// Detach the network programs but leave the uprobes attached.
+	types, err := ParseProgramTypes("XDP,TC,TCX")
+	require.NoError(t, err)
+	err = DetachOnShutdown(ctx, cl, cli, nil, nodeName, types, logf.Log)
+	require.NoError(t, err)
+
+	require.Equal(t, map[int]bool{2: true, 4: true, 5: true}, cli.Links)
+
+	// With no types, nothing is detached.
+	err = DetachOnShutdown(ctx, cl, cli, nil, nodeName, map[bpfmaniov1alpha1.EBPFProgType]bool{}, logf.Log)
+	require.NoError(t, err)
+	require.Len(t, cli.Links, 3)
+}
+
+func TestDetachOnShutdownNamespaceBpfman(t *testing.T) {
+	const nodeName = "fake-control-plane"
+	ctx := context.TODO()
+	id := func(i uint32) *uint32 { return &i }
+
+	nsAppState := func(namespace string) *bpfmaniov1alpha1.BpfApplicationState {
+		return &bpfmaniov1alpha1.BpfApplicationState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ns-app",
+				Namespace: namespace,
+				Labels:    map[string]string{internal.K8sHostLabel: nodeName},
+			},
+			Status: bpfmaniov1alpha1.BpfApplicationStateStatus{
+				Programs: []bpfmaniov1alpha1.BpfApplicationProgramState{
+					{
+						Type: bpfmaniov1alpha1.ProgTypeTC,
+						TC: &bpfmaniov1alpha1.TcProgramInfoState{
+							Links: []bpfmaniov1alpha1.TcAttachInfoState{
+								{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(1)}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(nsAppState("tenant"), nsAppState("default")).Build()
+
+	// Each bpfman has its own link 1, so detaching through the wrong one
+	// would leave a link attached and detach the other application's.
+	nodeCli := agenttestutils.NewBpfmanClientFake()
+	nodeCli.Links[1] = true
+	nodeCli.Links[2] = true
+	tenantCli := agenttestutils.NewBpfmanClientFake()
+	tenantCli.Links[1] = true
+	tenantCli.Links[2] = true
+
+	types, err := ParseProgramTypes("TC")
+	require.NoError(t, err)
+	err = DetachOnShutdown(ctx, cl, nodeCli, map[string]gobpfman.BpfmanClient{"tenant": tenantCli},
+		nodeName, types, logf.Log)
+	require.NoError(t, err)
+
+	require.Equal(t, map[int]bool{2: true}, nodeCli.Links)
+	require.Equal(t, map[int]bool{2: true}, tenantCli.Links)
+}
+
+func TestDetachOnShutdownLinkPersistence(t *testing.T) {
+	const nodeName = "fake-control-plane"
+	ctx := context.TODO()
+	id := func(i uint32) *uint32 { return &i }
+	nodeLabels := func(app string) map[string]string {
+		return map[string]string{internal.K8sHostLabel: nodeName, internal.BpfAppStateOwner: internal.AppStateOwnerLabel(app)}
+	}
+	controlledBy := func(kind, name string, uid types.UID) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{
+			APIVersion: bpfmaniov1alpha1.SchemeGroupVersion.String(),
+			Kind:       kind,
+			Name:       name,
+			UID:        uid,
+			Controller: &[]bool{true}[0],
+		}}
+	}
+	kprobeLink := func(i uint32) bpfmaniov1alpha1.ClKprobeAttachInfoState {
+		return bpfmaniov1alpha1.ClKprobeAttachInfoState{
+			AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(i)},
+		}
+	}
+
+	clApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "cl-app", UID: "cl-app-uid"},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{Name: "transient", Type: bpfmaniov1alpha1.ProgTypeKprobe, LinkPersistence: bpfmaniov1alpha1.LinkDetachOnAgentExit},
+				{Name: "persistent", Type: bpfmaniov1alpha1.ProgTypeKprobe},
+			},
+		},
+	}
+	clAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "cl-app-12345",
+			Labels:          nodeLabels("cl-app"),
+			OwnerReferences: controlledBy("ClusterBpfApplication", "cl-app", "cl-app-uid"),
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "transient"},
+					Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfoState{kprobeLink(1), kprobeLink(2)},
+					},
+				},
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "persistent"},
+					Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfoState{kprobeLink(3)},
+					},
+				},
+			},
+		},
+	}
+	nsApp := &bpfmaniov1alpha1.BpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-app", Namespace: "default", UID: "ns-app-uid"},
+		Spec: bpfmaniov1alpha1.BpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgram{
+				{Name: "transient", Type: bpfmaniov1alpha1.ProgTypeTC, LinkPersistence: bpfmaniov1alpha1.LinkDetachOnAgentExit},
+			},
+		},
+	}
+	nsAppState := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "ns-app-12345",
+			Namespace:       "default",
+			Labels:          nodeLabels("ns-app"),
+			OwnerReferences: controlledBy("BpfApplication", "ns-app", "ns-app-uid"),
+		},
+		Status: bpfmaniov1alpha1.BpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgramState{
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "transient"},
+					Type:                  bpfmaniov1alpha1.ProgTypeTC,
+					TC: &bpfmaniov1alpha1.TcProgramInfoState{
+						Links: []bpfmaniov1alpha1.TcAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(4)}},
+						},
+					},
+				},
+			},
+		},
+	}
+	// An application state whose application is gone is left alone.
+	orphanAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "gone-12345",
+			Labels:          nodeLabels("gone"),
+			OwnerReferences: controlledBy("ClusterBpfApplication", "gone", "gone-uid"),
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "transient"},
+					Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfoState{kprobeLink(5)},
+					},
+				},
+			},
+		},
+	}
+
+	// An application whose name is too long for a label value is found
+	// from the application state's owner rather than its truncated label.
+	longName := strings.Repeat("a", 70)
+	longApp := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: longName, UID: "long-app-uid"},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{Name: "transient", Type: bpfmaniov1alpha1.ProgTypeKprobe, LinkPersistence: bpfmaniov1alpha1.LinkDetachOnAgentExit},
+			},
+		},
+	}
+	longAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "long-12345",
+			Labels:          nodeLabels(longName),
+			OwnerReferences: controlledBy("ClusterBpfApplication", longName, "long-app-uid"),
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgramState{
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "transient"},
+					Type:                  bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfoState{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfoState{kprobeLink(6)},
+					},
+				},
+			},
+		},
+	}
+	// An application state left by a deleted application isn't detached
+	// because of a new application that has the same name.
+	replacedAppState := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "ns-app-67890",
+			Namespace:       "default",
+			Labels:          nodeLabels("ns-app"),
+			OwnerReferences: controlledBy("BpfApplication", "ns-app", "old-ns-app-uid"),
+		},
+		Status: bpfmaniov1alpha1.BpfApplicationStateStatus{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgramState{
+				{
+					BpfProgramStateCommon: bpfmaniov1alpha1.BpfProgramStateCommon{Name: "transient"},
+					Type:                  bpfmaniov1alpha1.ProgTypeTC,
+					TC: &bpfmaniov1alpha1.TcProgramInfoState{
+						Links: []bpfmaniov1alpha1.TcAttachInfoState{
+							{AttachInfoStateCommon: bpfmaniov1alpha1.AttachInfoStateCommon{LinkId: id(7)}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testScheme(t)
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(clApp, clAppState, nsApp, nsAppState, orphanAppState,
+		longApp, longAppState, replacedAppState).Build()
+
+	cli := agenttestutils.NewBpfmanClientFake()
+	for i := 1; i <= 7; i++ {
+		cli.Links[i] = true
+	}
+
+	// Only the links of the DetachOnAgentExit programs are detached, even
+	// with no types given.
+	err := DetachOnShutdown(ctx, cl, cli, nil, nodeName, map[bpfmaniov1alpha1.EBPFProgType]bool{}, logf.Log)
+	require.NoError(t, err)
+	require.Equal(t, map[int]bool{3: true, 5: true, 7: true}, cli.Links)
+}
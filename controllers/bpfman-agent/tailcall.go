@@ -0,0 +1,247 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+)
+
+// updateProgArray sets an entry in a tail call map. It's a variable so tests
+// can replace it.
+var updateProgArray = bpfmanagentinternal.UpdateProgArray
+
+// errTailCallNotLoaded is returned when a program with tail calls, or one of
+// its targets, hasn't been loaded yet, so the reconcile should be retried.
+var errTailCallNotLoaded = errors.New("program not loaded yet")
+
+// tailCallProgram is a program in an application along with its kernel id,
+// if it has been loaded, and the programs it tail calls.
+type tailCallProgram struct {
+	name      string
+	id        *uint32
+	tailCalls *bpfmaniov1alpha1.TailCallInfo
+}
+
+// populateTailCalls adds the kernel id of each tail call target to the tail
+// call map of the program that calls it. Targets are looked up by name in
+// programs, so they must be in the same application.
+func (r *ReconcilerCommon) populateTailCalls(ctx context.Context, programs []tailCallProgram) error {
+	ids := map[string]*uint32{}
+	for _, p := range programs {
+		ids[p.name] = p.id
+	}
+
+	for _, p := range programs {
+		if p.tailCalls == nil {
+			continue
+		}
+		if p.id == nil {
+			return fmt.Errorf("program %s: %w", p.name, errTailCallNotLoaded)
+		}
+		program, err := bpfmanagentinternal.GetBpfmanProgramById(ctx, r.BpfmanClient, *p.id)
+		if err != nil {
+			return fmt.Errorf("program %s: failed to find tail call map: %w", p.name, err)
+		}
+		mapPath := filepath.Join(program.GetInfo().GetMapPinPath(), p.tailCalls.MapName)
+
+		for _, target := range p.tailCalls.Targets {
+			id, ok := ids[target.BpfFunctionName]
+			if !ok {
+				return fmt.Errorf("program %s: tail call target %s is not a program in this application",
+					p.name, target.BpfFunctionName)
+			}
+			if id == nil {
+				return fmt.Errorf("program %s: tail call target %s: %w", p.name, target.BpfFunctionName, errTailCallNotLoaded)
+			}
+			if err := updateProgArray(mapPath, target.Index, *id); err != nil {
+				return fmt.Errorf("program %s: %w", p.name, err)
+			}
+			r.Logger.V(1).Info("Set tail call", "Program", p.name, "Map", mapPath,
+				"Index", target.Index, "Target", target.BpfFunctionName, "TargetId", *id)
+		}
+	}
+	return nil
+}
+
+// clTailCallPrograms returns the programs in a ClusterBpfApplication, with
+// their ids from its ClusterBpfApplicationState.
+func clTailCallPrograms(app *bpfmaniov1alpha1.ClusterBpfApplication,
+	appState *bpfmaniov1alpha1.ClusterBpfApplicationState) []tailCallProgram {
+	programs := []tailCallProgram{}
+	for _, prog := range app.Spec.Programs {
+		p := tailCallProgram{name: prog.Name, tailCalls: prog.TailCalls}
+		for _, progState := range appState.Status.Programs {
+			if progState.Name == prog.Name {
+				p.id = progState.ProgramId
+			}
+		}
+		programs = append(programs, p)
+	}
+	return programs
+}
+
+// nsTailCallPrograms returns the programs in a BpfApplication, with their ids
+// from its BpfApplicationState.
+func nsTailCallPrograms(app *bpfmaniov1alpha1.BpfApplication,
+	appState *bpfmaniov1alpha1.BpfApplicationState) []tailCallProgram {
+	programs := []tailCallProgram{}
+	for _, prog := range app.Spec.Programs {
+		p := tailCallProgram{name: prog.Name, tailCalls: prog.TailCalls}
+		for _, progState := range appState.Status.Programs {
+			if progState.Name == prog.Name {
+				p.id = progState.ProgramId
+			}
+		}
+		programs = append(programs, p)
+	}
+	return programs
+}
+
+// tailCallTargets returns, for each program, the indexes of the other
+// programs it tail calls.
+func tailCallTargets(programs []tailCallProgram) [][]int {
+	index := map[string]int{}
+	for i, p := range programs {
+		index[p.name] = i
+	}
+	targets := make([][]int, len(programs))
+	for i, p := range programs {
+		if p.tailCalls == nil {
+			continue
+		}
+		for _, target := range p.tailCalls.Targets {
+			if t, ok := index[target.BpfFunctionName]; ok && t != i {
+				targets[i] = append(targets[i], t)
+			}
+		}
+	}
+	return targets
+}
+
+// dependencyOrder orders n programs so that each comes before the programs
+// listed for it in before, keeping their order otherwise. Programs in a
+// cycle can't be ordered, so they keep their order too, and cyclic is true.
+func dependencyOrder(n int, before [][]int) (order []int, cyclic bool) {
+	// waiting counts the programs still to be ordered that must come before
+	// each program.
+	waiting := make([]int, n)
+	for _, later := range before {
+		for _, i := range later {
+			waiting[i]++
+		}
+	}
+
+	done := make([]bool, n)
+	for len(order) < n {
+		next := -1
+		for i := range n {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			// The rest are in a cycle, so take the first of them.
+			cyclic = true
+			for i := range n {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, i := range before[next] {
+			waiting[i]--
+		}
+	}
+	return order, cyclic
+}
+
+// unloadOrder returns the order to unload programs in, as indexes into
+// programs. A program that tail calls others is unloaded before its targets,
+// so its tail call map never refers to a program that has gone. Otherwise,
+// including for programs that tail call each other, programs keep their
+// order.
+func unloadOrder(programs []tailCallProgram) []int {
+	order, _ := dependencyOrder(len(programs), tailCallTargets(programs))
+	return order
+}
+
+// reconcileOrder returns the order to reconcile programs in, as indexes into
+// programs. A program is reconciled after the programs it tail calls, so its
+// links aren't attached before its targets are. Otherwise, programs keep
+// their order. cyclic is true if some programs tail call each other, in which
+// case those keep their order.
+func reconcileOrder(programs []tailCallProgram) (order []int, cyclic bool) {
+	callers := make([][]int, len(programs))
+	for i, targets := range tailCallTargets(programs) {
+		for _, t := range targets {
+			callers[t] = append(callers[t], i)
+		}
+	}
+	return dependencyOrder(len(programs), callers)
+}
+
+// clUnloadOrder returns the order to unload the programs in a
+// ClusterBpfApplicationState in, as indexes into its programs.
+func clUnloadOrder(app *bpfmaniov1alpha1.ClusterBpfApplication,
+	appState *bpfmaniov1alpha1.ClusterBpfApplicationState) []int {
+	tailCalls := map[string]*bpfmaniov1alpha1.TailCallInfo{}
+	for _, prog := range app.Spec.Programs {
+		tailCalls[prog.Name] = prog.TailCalls
+	}
+	programs := []tailCallProgram{}
+	names := []string{}
+	for _, progState := range appState.Status.Programs {
+		programs = append(programs, tailCallProgram{
+			name:      progState.Name,
+			id:        progState.ProgramId,
+			tailCalls: tailCalls[progState.Name],
+		})
+		names = append(names, progState.Name)
+	}
+	return mapUsersFirst(unloadOrder(programs), names, app.Spec.AdditionalByteCode)
+}
+
+// nsUnloadOrder returns the order to unload the programs in a
+// BpfApplicationState in, as indexes into its programs.
+func nsUnloadOrder(app *bpfmaniov1alpha1.BpfApplication,
+	appState *bpfmaniov1alpha1.BpfApplicationState) []int {
+	tailCalls := map[string]*bpfmaniov1alpha1.TailCallInfo{}
+	for _, prog := range app.Spec.Programs {
+		tailCalls[prog.Name] = prog.TailCalls
+	}
+	programs := []tailCallProgram{}
+	names := []string{}
+	for _, progState := range appState.Status.Programs {
+		programs = append(programs, tailCallProgram{
+			name:      progState.Name,
+			id:        progState.ProgramId,
+			tailCalls: tailCalls[progState.Name],
+		})
+		names = append(names, progState.Name)
+	}
+	return mapUsersFirst(unloadOrder(programs), names, app.Spec.AdditionalByteCode)
+}
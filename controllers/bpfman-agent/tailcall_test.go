@@ -0,0 +1,202 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	agenttestutils "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal/test-utils"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type tailCallUpdate struct {
+	mapPath string
+	index   uint32
+	progId  uint32
+}
+
+// recordTailCalls replaces updateProgArray for the duration of the test and
+// returns the updates made.
+func recordTailCalls(t *testing.T) *[]tailCallUpdate {
+	updates := &[]tailCallUpdate{}
+	orig := updateProgArray
+	updateProgArray = func(mapPath string, index uint32, progId uint32) error {
+		*updates = append(*updates, tailCallUpdate{mapPath, index, progId})
+		return nil
+	}
+	t.Cleanup(func() { updateProgArray = orig })
+	return updates
+}
+
+func TestPopulateTailCalls(t *testing.T) {
+	ctx := context.TODO()
+	updates := recordTailCalls(t)
+	dispatcherId, targetId := uint32(1), uint32(2)
+	cli := agenttestutils.NewBpfmanClientFakeWithPrograms(map[int]*gobpfman.GetResponse{
+		int(dispatcherId): {Info: &gobpfman.ProgramInfo{Name: "dispatcher", MapPinPath: "/run/bpfman/fs/maps/1"}},
+	})
+	r := &ReconcilerCommon{BpfmanClient: cli}
+
+	tailCalls := &bpfmaniov1alpha1.TailCallInfo{
+		MapName: "jmp_table",
+		Targets: []bpfmaniov1alpha1.TailCallTarget{{Index: 3, BpfFunctionName: "target"}},
+	}
+
+	t.Run("target not loaded", func(t *testing.T) {
+		err := r.populateTailCalls(ctx, []tailCallProgram{
+			{name: "dispatcher", id: &dispatcherId, tailCalls: tailCalls},
+			{name: "target"},
+		})
+		require.ErrorIs(t, err, errTailCallNotLoaded)
+		require.Empty(t, *updates)
+	})
+
+	t.Run("target not in application", func(t *testing.T) {
+		err := r.populateTailCalls(ctx, []tailCallProgram{
+			{name: "dispatcher", id: &dispatcherId, tailCalls: tailCalls},
+		})
+		require.ErrorContains(t, err, "tail call target target is not a program in this application")
+		require.NotErrorIs(t, err, errTailCallNotLoaded)
+	})
+
+	t.Run("map under pin path", func(t *testing.T) {
+		err := r.populateTailCalls(ctx, []tailCallProgram{
+			{name: "dispatcher", id: &dispatcherId, tailCalls: tailCalls},
+			{name: "target", id: &targetId},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []tailCallUpdate{{"/run/bpfman/fs/maps/1/jmp_table", 3, targetId}}, *updates)
+	})
+}
+
+func TestUnloadOrder(t *testing.T) {
+	calls := func(targets ...string) *bpfmaniov1alpha1.TailCallInfo {
+		tailCalls := &bpfmaniov1alpha1.TailCallInfo{MapName: "jmp_table"}
+		for i, target := range targets {
+			tailCalls.Targets = append(tailCalls.Targets,
+				bpfmaniov1alpha1.TailCallTarget{Index: uint32(i), BpfFunctionName: target})
+		}
+		return tailCalls
+	}
+
+	tests := []struct {
+		name     string
+		programs []tailCallProgram
+		expected []int
+	}{
+		{
+			name:     "no tail calls",
+			programs: []tailCallProgram{{name: "a"}, {name: "b"}, {name: "c"}},
+			expected: []int{0, 1, 2},
+		},
+		{
+			name: "chain",
+			programs: []tailCallProgram{
+				{name: "a"},
+				{name: "b", tailCalls: calls("a")},
+				{name: "c", tailCalls: calls("b")},
+			},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name: "shared target",
+			programs: []tailCallProgram{
+				{name: "target"},
+				{name: "first", tailCalls: calls("target")},
+				{name: "second", tailCalls: calls("target")},
+			},
+			expected: []int{1, 2, 0},
+		},
+		{
+			name: "cycle and self",
+			programs: []tailCallProgram{
+				{name: "a", tailCalls: calls("b")},
+				{name: "b", tailCalls: calls("a", "b")},
+				{name: "c", tailCalls: calls("a", "missing")},
+			},
+			expected: []int{2, 0, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, unloadOrder(tc.programs))
+		})
+	}
+}
+
+func TestReconcileOrder(t *testing.T) {
+	calls := func(targets ...string) *bpfmaniov1alpha1.TailCallInfo {
+		tailCalls := &bpfmaniov1alpha1.TailCallInfo{MapName: "jmp_table"}
+		for i, target := range targets {
+			tailCalls.Targets = append(tailCalls.Targets,
+				bpfmaniov1alpha1.TailCallTarget{Index: uint32(i), BpfFunctionName: target})
+		}
+		return tailCalls
+	}
+
+	tests := []struct {
+		name     string
+		programs []tailCallProgram
+		expected []int
+		cyclic   bool
+	}{
+		{
+			name:     "no tail calls",
+			programs: []tailCallProgram{{name: "a"}, {name: "b"}, {name: "c"}},
+			expected: []int{0, 1, 2},
+		},
+		{
+			name: "caller declared before its targets",
+			programs: []tailCallProgram{
+				{name: "dispatcher", tailCalls: calls("a", "b")},
+				{name: "a", tailCalls: calls("b")},
+				{name: "b"},
+			},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name: "self",
+			programs: []tailCallProgram{
+				{name: "a", tailCalls: calls("a", "missing")},
+				{name: "b"},
+			},
+			expected: []int{0, 1},
+		},
+		{
+			name: "cycle",
+			programs: []tailCallProgram{
+				{name: "a", tailCalls: calls("b")},
+				{name: "b", tailCalls: calls("a")},
+				{name: "c", tailCalls: calls("a")},
+			},
+			expected: []int{0, 1, 2},
+			cyclic:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order, cyclic := reconcileOrder(tc.programs)
+			require.Equal(t, tc.expected, order)
+			require.Equal(t, tc.cyclic, cyclic)
+		})
+	}
+}
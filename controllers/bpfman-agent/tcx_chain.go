@@ -0,0 +1,72 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+)
+
+// resolveTcxChain returns prog with the priority of each of its TCX links
+// adjusted to honour the before and after references of the TCX programs in
+// programs. chainLinks returns the chain links of a program's TCX links, and
+// withPriorities returns a copy of a program with its TCX links' priorities
+// replaced. prog itself isn't modified.
+func resolveTcxChain[P any](programs []P, prog *P, chainLinks func(*P) []internal.TcxChainLink,
+	withPriorities func(*P, []int32) *P) (*P, error) {
+	links := []internal.TcxChainLink{}
+	for i := range programs {
+		links = append(links, chainLinks(&programs[i])...)
+	}
+
+	priorities, err := internal.ResolveTcxChainPriorities(links)
+	if err != nil {
+		return nil, err
+	}
+	progLinks := chainLinks(prog)
+	if len(progLinks) == 0 {
+		return prog, nil
+	}
+	for offset := range links {
+		if links[offset].Program == progLinks[0].Program {
+			return withPriorities(prog, priorities[offset:offset+len(progLinks)]), nil
+		}
+	}
+	return prog, nil
+}
+
+// withClTcxPriorities returns a copy of a ClusterBpfApplication TCX program
+// with the priorities of its links replaced by priorities.
+func withClTcxPriorities(prog *bpfmaniov1alpha1.ClBpfApplicationProgram,
+	priorities []int32) *bpfmaniov1alpha1.ClBpfApplicationProgram {
+	resolved := prog.DeepCopy()
+	for i := range resolved.TCX.Links {
+		resolved.TCX.Links[i].Priority = priorities[i]
+	}
+	return resolved
+}
+
+// withNsTcxPriorities is the BpfApplication equivalent of
+// withClTcxPriorities.
+func withNsTcxPriorities(prog *bpfmaniov1alpha1.BpfApplicationProgram,
+	priorities []int32) *bpfmaniov1alpha1.BpfApplicationProgram {
+	resolved := prog.DeepCopy()
+	for i := range resolved.TCX.Links {
+		resolved.TCX.Links[i].Priority = priorities[i]
+	}
+	return resolved
+}
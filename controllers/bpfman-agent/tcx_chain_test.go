@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTcxChain(t *testing.T) {
+	programs := []bpfmaniov1alpha1.ClBpfApplicationProgram{
+		{
+			Name: "first",
+			Type: bpfmaniov1alpha1.ProgTypeTCX,
+			TCX: &bpfmaniov1alpha1.ClTcxProgramInfo{
+				Links: []bpfmaniov1alpha1.ClTcxAttachInfo{
+					{Direction: bpfmaniov1alpha1.TCIngress, Priority: 1000, Before: "second"},
+				},
+			},
+		},
+		{
+			Name: "second",
+			Type: bpfmaniov1alpha1.ProgTypeTCX,
+			TCX: &bpfmaniov1alpha1.ClTcxProgramInfo{
+				Links: []bpfmaniov1alpha1.ClTcxAttachInfo{
+					{Direction: bpfmaniov1alpha1.TCIngress, Priority: 1000},
+				},
+			},
+		},
+	}
+
+	resolved, err := resolveTcxChain(programs, &programs[0], internal.ClTcxChainLinks, withClTcxPriorities)
+	require.NoError(t, err)
+	require.Equal(t, int32(999), resolved.TCX.Links[0].Priority)
+	// The application spec must not be modified.
+	require.Equal(t, int32(1000), programs[0].TCX.Links[0].Priority)
+
+	resolved, err = resolveTcxChain(programs, &programs[1], internal.ClTcxChainLinks, withClTcxPriorities)
+	require.NoError(t, err)
+	require.Equal(t, int32(1000), resolved.TCX.Links[0].Priority)
+
+	programs[1].TCX.Links[0].Before = "first"
+	_, err = resolveTcxChain(programs, &programs[0], internal.ClTcxChainLinks, withClTcxPriorities)
+	require.ErrorContains(t, err, "form a cycle")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// errUnsupportedDirection is returned when a TCX program isn't attached
+// because its interface doesn't support the requested direction.
+var errUnsupportedDirection = errors.New("unsupported TCX direction")
+
+// tcxLinkDirections lists the TCX directions supported by kinds of interface
+// that don't support both. An ifb device only sees the packets redirected to
+// it as it transmits them, so nothing is seen on ingress.
+var tcxLinkDirections = map[string][]bpfmaniov1alpha1.TCDirectionType{
+	"ifb": {bpfmaniov1alpha1.TCEgress},
+}
+
+// lookupLinkType returns the kind of the named interface, such as veth or
+// ifb, in the network namespace at netnsPath, or the agent's namespace if
+// netnsPath is empty. It's a variable so tests can replace it.
+var lookupLinkType = func(name, netnsPath string) (string, error) {
+	link, err := linkByName(name, netnsPath)
+	if err != nil {
+		return "", err
+	}
+	return link.Type(), nil
+}
+
+// tcxDirections returns the TCX directions the named interface supports.
+func tcxDirections(name, netnsPath string) ([]bpfmaniov1alpha1.TCDirectionType, error) {
+	linkType, err := lookupLinkType(name, netnsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up interface %s: %w", name, err)
+	}
+	if directions, ok := tcxLinkDirections[linkType]; ok {
+		return directions, nil
+	}
+	return []bpfmaniov1alpha1.TCDirectionType{bpfmaniov1alpha1.TCIngress, bpfmaniov1alpha1.TCEgress}, nil
+}
+
+// checkTcxDirection is called before a TCX link is attached, and checks that
+// its interface supports the link's direction, so the link is reported as
+// ApUnsupportedDirection rather than failing inside bpfman. If the interface
+// can't be looked up, the attach goes ahead and bpfman reports any error.
+func (r *ReconcilerCommon) checkTcxDirection(ctx context.Context, rec ProgramReconciler, name, netnsPath string,
+	direction bpfmaniov1alpha1.TCDirectionType) error {
+	if r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+	directions, err := tcxDirections(name, netnsPath)
+	if err != nil {
+		r.Logger.V(1).Info("Not checking TCX direction", "reason", err.Error())
+		return nil
+	}
+	if !slices.Contains(directions, direction) {
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApUnsupportedDirection)
+		return fmt.Errorf("interface %s doesn't support the %s direction: %w", name, direction, errUnsupportedDirection)
+	}
+	return nil
+}
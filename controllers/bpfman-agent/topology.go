@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"fmt"
+	"path/filepath"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// sysNodePath is where the kernel lists the node's NUMA nodes. It's a
+// variable so tests can replace it.
+var sysNodePath = "/sys/devices/system/node"
+
+// numaNodeCount returns the number of NUMA nodes on this node.
+func numaNodeCount() (int, error) {
+	nodes, err := filepath.Glob(filepath.Join(sysNodePath, "node[0-9]*"))
+	if err != nil {
+		return 0, err
+	}
+	if len(nodes) == 0 {
+		return 0, fmt.Errorf("no NUMA nodes found in %s", sysNodePath)
+	}
+	return len(nodes), nil
+}
+
+// topologyMismatches compares the expected topology with the node's, and
+// returns a description of each difference. The CPU count is taken from the
+// node's cpu capacity. Anything that can't be detected isn't compared.
+func topologyMismatches(expected *bpfmaniov1alpha1.NodeTopology, node *v1.Node) ([]string, error) {
+	mismatches := []string{}
+	if expected == nil {
+		return mismatches, nil
+	}
+
+	if expected.CPUs != nil {
+		if capacity, ok := node.Status.Capacity[v1.ResourceCPU]; ok && capacity.Value() != int64(*expected.CPUs) {
+			mismatches = append(mismatches, fmt.Sprintf("expected %d CPUs, node has %d", *expected.CPUs, capacity.Value()))
+		}
+	}
+
+	if expected.NUMANodes != nil {
+		numaNodes, err := numaNodeCount()
+		if err != nil {
+			return mismatches, fmt.Errorf("failed to detect NUMA nodes: %w", err)
+		}
+		if numaNodes != int(*expected.NUMANodes) {
+			mismatches = append(mismatches, fmt.Sprintf("expected %d NUMA nodes, node has %d", *expected.NUMANodes, numaNodes))
+		}
+	}
+	return mismatches, nil
+}
+
+// checkTopology logs a warning for each way this node's topology differs from
+// the expected topology and returns true if there were any.
+func (r *ReconcilerCommon) checkTopology(expected *bpfmaniov1alpha1.NodeTopology) bool {
+	mismatches, err := topologyMismatches(expected, r.ourNode)
+	if err != nil {
+		r.Logger.Error(err, "failed to check the node topology")
+	}
+	for _, m := range mismatches {
+		r.Logger.Info("WARNING: node topology doesn't match the expected topology", "mismatch", m)
+	}
+	return len(mismatches) > 0
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+// newTopologyNode returns a node with 16 CPUs and, in a fake sysfs, two NUMA
+// nodes.
+func newTopologyNode(t *testing.T) *v1.Node {
+	origSysNodePath := sysNodePath
+	sysNodePath = t.TempDir()
+	t.Cleanup(func() { sysNodePath = origSysNodePath })
+	for _, dir := range []string{"node0", "node1", "power"} {
+		require.NoError(t, os.Mkdir(filepath.Join(sysNodePath, dir), 0o755))
+	}
+
+	node := testutils.NewNode("fake-control-plane")
+	node.Status.Capacity = v1.ResourceList{v1.ResourceCPU: resource.MustParse("16")}
+	return node
+}
+
+func TestTopologyMismatches(t *testing.T) {
+	node := newTopologyNode(t)
+
+	tests := []struct {
+		name       string
+		expected   *bpfmaniov1alpha1.NodeTopology
+		mismatches []string
+	}{
+		{
+			name:       "no expected topology",
+			mismatches: []string{},
+		},
+		{
+			name:       "matching",
+			expected:   &bpfmaniov1alpha1.NodeTopology{CPUs: ptr.To[int32](16), NUMANodes: ptr.To[int32](2)},
+			mismatches: []string{},
+		},
+		{
+			name:       "too few CPUs",
+			expected:   &bpfmaniov1alpha1.NodeTopology{CPUs: ptr.To[int32](64)},
+			mismatches: []string{"expected 64 CPUs, node has 16"},
+		},
+		{
+			name:     "CPUs and NUMA nodes",
+			expected: &bpfmaniov1alpha1.NodeTopology{CPUs: ptr.To[int32](8), NUMANodes: ptr.To[int32](1)},
+			mismatches: []string{
+				"expected 8 CPUs, node has 16",
+				"expected 1 NUMA nodes, node has 2",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mismatches, err := topologyMismatches(tc.expected, node)
+			require.NoError(t, err)
+			require.Equal(t, tc.mismatches, mismatches)
+		})
+	}
+}
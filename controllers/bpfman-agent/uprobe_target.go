@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// errTargetNotFound is returned when a uprobe isn't attached because its
+// target doesn't exist on the node.
+var errTargetNotFound = errors.New("uprobe target not found")
+
+// checkUprobeTarget is called before a uprobe link that isn't in a container
+// is attached. If its target is an absolute path, such as a statically linked
+// binary, it checks that the file exists on the host, and if it doesn't, the
+// link's status is set to ApTargetNotFound. Library names are left for bpfman
+// to resolve, and if the host's files can't be seen, the attach goes ahead and
+// bpfman reports any error.
+func (r *ReconcilerCommon) checkUprobeTarget(ctx context.Context, rec ProgramReconciler, target string) error {
+	if !filepath.IsAbs(target) || r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+
+	// The agent runs in a container, so the host's files are seen through
+	// the root of its pid 1.
+	if _, err := os.Stat(r.containerBinaryPath(1, "/")); err != nil {
+		r.Logger.V(1).Info("Not checking the uprobe target", "reason", err.Error())
+		return nil
+	}
+	_, err := os.Stat(r.containerBinaryPath(1, target))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApTargetNotFound)
+		return fmt.Errorf("%s doesn't exist on the node: %w", target, errTargetNotFound)
+	default:
+		r.Logger.V(1).Info("Not checking the uprobe target", "reason", err.Error())
+		return nil
+	}
+}
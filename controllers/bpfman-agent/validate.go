@@ -0,0 +1,260 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// NodeCapabilities is what ValidateManifest checks a manifest against.
+type NodeCapabilities interface {
+	// CheckBpfman returns an error if bpfman can't be reached.
+	CheckBpfman(ctx context.Context) error
+	// KernelRelease returns the release of the running kernel, as from uname.
+	KernelRelease() (string, error)
+	// HasKernelBTF returns true if the kernel exposes its BTF, which fentry
+	// and fexit programs need.
+	HasKernelBTF() bool
+	// Interfaces returns the network interfaces in the host network
+	// namespace.
+	Interfaces() ([]net.Interface, error)
+}
+
+// ValidationCheck is the result of one check of a manifest against a node.
+type ValidationCheck struct {
+	// Check names what was checked.
+	Check string `json:"check"`
+	// Satisfied is true if the node passed the check.
+	Satisfied bool `json:"satisfied"`
+	// Message says why the check failed.
+	Message string `json:"message,omitempty"`
+}
+
+// ValidationReport is the result of checking a manifest against a node.
+type ValidationReport struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Satisfiable is true if the node passed every check.
+	Satisfiable bool              `json:"satisfiable"`
+	Checks      []ValidationCheck `json:"checks"`
+}
+
+func (r *ValidationReport) add(check string, err error) {
+	c := ValidationCheck{Check: check, Satisfied: err == nil}
+	if err != nil {
+		c.Message = err.Error()
+		r.Satisfiable = false
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// programTypeMinKernel is the oldest kernel bpfman can load and attach each
+// program type on, that is the release of the upstream kernel commit adding
+// what bpfman attaches it with.
+var programTypeMinKernel = map[bpfmaniov1alpha1.EBPFProgType]kernelVersion{
+	// XDP and TC programs are attached through dispatchers, with freplace
+	// programs attached to more than one dispatcher as they're replaced:
+	// 4a1e7c0c63e0 ("bpf: Support attaching freplace programs to multiple
+	// attach points").
+	bpfmaniov1alpha1.ProgTypeXDP: {5, 10, 0},
+	bpfmaniov1alpha1.ProgTypeTC:  {5, 10, 0},
+	// e420bed02507 ("bpf: Add fd-based tcx multi-prog infra with link
+	// support").
+	bpfmaniov1alpha1.ProgTypeTCX: {6, 6, 0},
+	// fec56f5890d9 ("bpf: Introduce BPF trampoline").
+	bpfmaniov1alpha1.ProgTypeFentry: {5, 5, 0},
+	bpfmaniov1alpha1.ProgTypeFexit:  {5, 5, 0},
+	// Probes are attached through perf events of the kprobe and uprobe PMUs:
+	// e12f03d7031a ("perf/core: Implement the 'perf_kprobe' PMU") and
+	// 33ea4b24277b ("perf/core: Implement the 'perf_uprobe' PMU").
+	bpfmaniov1alpha1.ProgTypeKprobe:    {4, 17, 0},
+	bpfmaniov1alpha1.ProgTypeKretprobe: {4, 17, 0},
+	bpfmaniov1alpha1.ProgTypeUprobe:    {4, 17, 0},
+	bpfmaniov1alpha1.ProgTypeUretprobe: {4, 17, 0},
+	// 98b5c2c65c29 ("perf, bpf: allow bpf programs attach to tracepoints").
+	bpfmaniov1alpha1.ProgTypeTracepoint: {4, 7, 0},
+}
+
+// ValidateManifest checks, without loading anything, whether the node
+// described by caps can satisfy the ClusterBpfApplication or BpfApplication
+// in manifest: that bpfman is reachable, that the kernel is new enough for
+// the application's minKernelVersion and program types, and that the
+// interfaces it names in the host network namespace exist. An error is
+// returned only if the manifest can't be read; checks the node fails are
+// reported in the returned report.
+func ValidateManifest(ctx context.Context, manifest []byte, caps NodeCapabilities) (*ValidationReport, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(manifest, &typeMeta); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	var (
+		meta      metav1.ObjectMeta
+		common    bpfmaniov1alpha1.BpfAppCommon
+		progTypes = map[string]bpfmaniov1alpha1.EBPFProgType{}
+		names     []string
+		selectors []bpfmaniov1alpha1.InterfaceSelector
+	)
+	switch typeMeta.Kind {
+	case "ClusterBpfApplication":
+		var app bpfmaniov1alpha1.ClusterBpfApplication
+		if err := yaml.UnmarshalStrict(manifest, &app); err != nil {
+			return nil, fmt.Errorf("failed to parse ClusterBpfApplication: %w", err)
+		}
+		meta, common = app.ObjectMeta, app.Spec.BpfAppCommon
+		for _, prog := range app.Spec.Programs {
+			names = append(names, prog.Name)
+			progTypes[prog.Name] = prog.Type
+			selectors = append(selectors, hostInterfaceSelectors(prog)...)
+		}
+	case "BpfApplication":
+		var app bpfmaniov1alpha1.BpfApplication
+		if err := yaml.UnmarshalStrict(manifest, &app); err != nil {
+			return nil, fmt.Errorf("failed to parse BpfApplication: %w", err)
+		}
+		// A BpfApplication's interfaces are in its pods' network
+		// namespaces, so they can't be checked here.
+		meta, common = app.ObjectMeta, app.Spec.BpfAppCommon
+		for _, prog := range app.Spec.Programs {
+			names = append(names, prog.Name)
+			progTypes[prog.Name] = prog.Type
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected ClusterBpfApplication or BpfApplication", typeMeta.Kind)
+	}
+
+	report := &ValidationReport{
+		Kind:        typeMeta.Kind,
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Satisfiable: true,
+	}
+
+	report.add("bpfman", caps.CheckBpfman(ctx))
+
+	release, err := caps.KernelRelease()
+	var running kernelVersion
+	if err == nil {
+		running, err = parseKernelVersion(release)
+	}
+	// Without the kernel version, only the checks that don't need it are
+	// made.
+	kernelKnown := err == nil
+	if !kernelKnown {
+		report.add("kernelVersion", fmt.Errorf("failed to get the kernel version: %w", err))
+	} else {
+		report.add("kernelVersion", checkMinKernelVersion(common.MinKernelVersion, release, running))
+	}
+
+	for _, name := range names {
+		check := "program/" + name
+		progType := progTypes[name]
+		min, ok := programTypeMinKernel[progType]
+		switch {
+		case !ok:
+			report.add(check, fmt.Errorf("unsupported program type %q", progType))
+		case kernelKnown && running.olderThan(min):
+			report.add(check, fmt.Errorf("%s programs need kernel %s or newer, node kernel is %s",
+				progType, min, release))
+		case (progType == bpfmaniov1alpha1.ProgTypeFentry || progType == bpfmaniov1alpha1.ProgTypeFexit) &&
+			!caps.HasKernelBTF():
+			report.add(check, fmt.Errorf("%s programs need the kernel's BTF, which the node doesn't expose", progType))
+		default:
+			report.add(check, nil)
+		}
+	}
+
+	if len(selectors) > 0 {
+		ifaces, err := caps.Interfaces()
+		if err != nil {
+			report.add("interfaces", fmt.Errorf("failed to read node interfaces: %w", err))
+			return report, nil
+		}
+		byName := map[string]bool{}
+		byIndex := map[int32]bool{}
+		for _, iface := range ifaces {
+			byName[iface.Name] = true
+			byIndex[int32(iface.Index)] = true
+		}
+		seen := map[string]bool{}
+		for _, selector := range selectors {
+			for _, name := range selector.Interfaces {
+				check := "interface/" + name
+				if seen[check] {
+					continue
+				}
+				seen[check] = true
+				if !byName[name] {
+					report.add(check, fmt.Errorf("interface %s not found", name))
+				} else {
+					report.add(check, nil)
+				}
+			}
+			for _, index := range selector.InterfaceIndexes {
+				check := "interfaceIndex/" + strconv.Itoa(int(index))
+				if seen[check] {
+					continue
+				}
+				seen[check] = true
+				if !byIndex[index] {
+					report.add(check, fmt.Errorf("no interface has index %d", index))
+				} else {
+					report.add(check, nil)
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// hostInterfaceSelectors returns the interface selectors of a
+// ClusterBpfApplication program's links that are in the host network
+// namespace.
+func hostInterfaceSelectors(prog bpfmaniov1alpha1.ClBpfApplicationProgram) []bpfmaniov1alpha1.InterfaceSelector {
+	selectors := []bpfmaniov1alpha1.InterfaceSelector{}
+	switch {
+	case prog.XDP != nil:
+		for _, link := range prog.XDP.Links {
+			if link.NetworkNamespaces == nil {
+				selectors = append(selectors, link.InterfaceSelector)
+				selectors = append(selectors, link.AdditionalInterfaceSelectors...)
+			}
+		}
+	case prog.TC != nil:
+		for _, link := range prog.TC.Links {
+			if link.NetworkNamespaces == nil {
+				selectors = append(selectors, link.InterfaceSelector)
+			}
+		}
+	case prog.TCX != nil:
+		for _, link := range prog.TCX.Links {
+			if link.NetworkNamespaces == nil {
+				selectors = append(selectors, link.InterfaceSelector)
+			}
+		}
+	}
+	return selectors
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNodeCapabilities struct {
+	bpfmanErr  error
+	release    string
+	btf        bool
+	interfaces []net.Interface
+}
+
+func (c *fakeNodeCapabilities) CheckBpfman(ctx context.Context) error { return c.bpfmanErr }
+func (c *fakeNodeCapabilities) KernelRelease() (string, error)        { return c.release, nil }
+func (c *fakeNodeCapabilities) HasKernelBTF() bool                    { return c.btf }
+func (c *fakeNodeCapabilities) Interfaces() ([]net.Interface, error)  { return c.interfaces, nil }
+
+const validateClusterManifest = `
+apiVersion: bpfman.io/v1alpha1
+kind: ClusterBpfApplication
+metadata:
+  name: xdp-and-fentry
+spec:
+  nodeSelector: {}
+  minKernelVersion: "5.15"
+  byteCode:
+    image:
+      url: quay.io/bpfman-bytecode/go-app-counter:latest
+  programs:
+    - name: xdp_stats
+      type: XDP
+      xdp:
+        links:
+          - interfaceSelector:
+              interfaces: [eth0]
+            priority: 55
+          - interfaceSelector:
+              interfaces: [eth0]
+            networkNamespaces:
+              pods: {}
+              namespace: app
+    - name: test_fentry
+      type: FEntry
+      fentry:
+        function: do_unlinkat
+        links:
+          - mode: Attach
+`
+
+const validateNamespaceManifest = `
+apiVersion: bpfman.io/v1alpha1
+kind: BpfApplication
+metadata:
+  name: tcx-stats
+  namespace: app
+spec:
+  nodeSelector: {}
+  byteCode:
+    image:
+      url: quay.io/bpfman-bytecode/go-app-counter:latest
+  programs:
+    - name: tcx_stats
+      type: TCX
+      tcx:
+        links:
+          - interfaceSelector:
+              interfaces: [eth9]
+            direction: ingress
+            priority: 100
+            networkNamespaces:
+              pods: {}
+`
+
+func checkMessages(report *ValidationReport) map[string]string {
+	checks := map[string]string{}
+	for _, c := range report.Checks {
+		checks[c.Check] = c.Message
+	}
+	return checks
+}
+
+func TestValidateManifestSatisfiable(t *testing.T) {
+	caps := &fakeNodeCapabilities{
+		release:    "6.8.0-45-generic",
+		btf:        true,
+		interfaces: []net.Interface{{Index: 1, Name: "lo"}, {Index: 2, Name: "eth0"}},
+	}
+
+	report, err := ValidateManifest(context.TODO(), []byte(validateClusterManifest), caps)
+	require.NoError(t, err)
+	require.True(t, report.Satisfiable, "%+v", report.Checks)
+	require.Equal(t, "ClusterBpfApplication", report.Kind)
+	require.Equal(t, "xdp-and-fentry", report.Name)
+	require.Equal(t, map[string]string{
+		"bpfman":              "",
+		"kernelVersion":       "",
+		"program/xdp_stats":   "",
+		"program/test_fentry": "",
+		"interface/eth0":      "",
+	}, checkMessages(report))
+
+	// A BpfApplication's interfaces are in its pods, so they aren't checked.
+	report, err = ValidateManifest(context.TODO(), []byte(validateNamespaceManifest), caps)
+	require.NoError(t, err)
+	require.True(t, report.Satisfiable, "%+v", report.Checks)
+	require.Equal(t, "app", report.Namespace)
+	require.Len(t, report.Checks, 3)
+}
+
+func TestValidateManifestUnsatisfiable(t *testing.T) {
+	caps := &fakeNodeCapabilities{
+		bpfmanErr:  errors.New("connection refused"),
+		release:    "5.14.0-427.el9.x86_64",
+		interfaces: []net.Interface{{Index: 1, Name: "lo"}},
+	}
+
+	report, err := ValidateManifest(context.TODO(), []byte(validateClusterManifest), caps)
+	require.NoError(t, err)
+	require.False(t, report.Satisfiable)
+	checks := checkMessages(report)
+	require.Equal(t, "connection refused", checks["bpfman"])
+	require.Contains(t, checks["kernelVersion"], "older than the minimum kernel version 5.15")
+	require.Equal(t, "", checks["program/xdp_stats"])
+	require.Contains(t, checks["program/test_fentry"], "need the kernel's BTF")
+	require.Equal(t, "interface eth0 not found", checks["interface/eth0"])
+
+	caps = &fakeNodeCapabilities{release: "6.1.0"}
+	report, err = ValidateManifest(context.TODO(), []byte(validateNamespaceManifest), caps)
+	require.NoError(t, err)
+	require.False(t, report.Satisfiable)
+	require.Equal(t, "TCX programs need kernel 6.6.0 or newer, node kernel is 6.1.0",
+		checkMessages(report)["program/tcx_stats"])
+}
+
+func TestValidateManifestInvalid(t *testing.T) {
+	caps := &fakeNodeCapabilities{release: "6.8.0"}
+
+	_, err := ValidateManifest(context.TODO(), []byte("kind: Pod\n"), caps)
+	require.ErrorContains(t, err, `unsupported kind "Pod"`)
+
+	// Misspelt fields are errors rather than silently ignored.
+	_, err = ValidateManifest(context.TODO(), []byte("kind: BpfApplication\nspec:\n  programz: []\n"), caps)
+	require.ErrorContains(t, err, "programz")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	bpfmanagentinternal "github.com/bpfman/bpfman-operator/controllers/bpfman-agent/internal"
+	"github.com/bpfman/bpfman-operator/internal"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// xdpDispatcherName is the kernel name of the dispatcher program that bpfman
+// attaches to an interface to run its XDP programs.
+const xdpDispatcherName = "xdp_dispatcher"
+
+// errForeignXdpProgram is returned when an XDP program isn't attached because
+// its interface already has an XDP program that bpfman didn't attach.
+var errForeignXdpProgram = errors.New("interface has a foreign XDP program")
+
+// foreignXdpProgram returns the ID and name of the XDP program attached to the
+// named interface if it isn't bpfman's dispatcher, or 0 if there isn't one.
+func foreignXdpProgram(ctx context.Context, client gobpfman.BpfmanClient, name, netnsPath string) (uint32, string, error) {
+	attrs, err := lookupInterface(name, netnsPath)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to look up interface %s: %w", name, err)
+	}
+	if attrs.Xdp == nil || !attrs.Xdp.Attached || attrs.Xdp.ProgId == 0 {
+		return 0, "", nil
+	}
+
+	progName, err := bpfmanagentinternal.GetKernelProgramName(ctx, client, internal.Xdp, attrs.Xdp.ProgId)
+	if err != nil {
+		return 0, "", err
+	}
+	if progName == xdpDispatcherName {
+		return 0, "", nil
+	}
+	return attrs.Xdp.ProgId, progName, nil
+}
+
+// checkForeignXdpProgram is called before an XDP link is attached, and checks
+// that its interface doesn't already have an XDP program that bpfman didn't
+// attach, since attaching bpfman's dispatcher would replace it. If there is
+// one, the link's status is set to ApForeignXdpProgram, unless
+// ReplaceForeignXdpPrograms is set. If the interface can't be looked up, the
+// attach goes ahead and bpfman reports any error.
+func (r *ReconcilerCommon) checkForeignXdpProgram(ctx context.Context, rec ProgramReconciler, name, netnsPath string) error {
+	if r.ReplaceForeignXdpPrograms || r.rollingBack || !rec.shouldAttach() || rec.isAttached(ctx) {
+		return nil
+	}
+	id, progName, err := foreignXdpProgram(ctx, r.BpfmanClient, name, netnsPath)
+	if err != nil {
+		r.Logger.V(1).Info("Not checking for a foreign XDP program", "reason", err.Error())
+		return nil
+	}
+	if id == 0 {
+		return nil
+	}
+	rec.setCurrentLinkStatus(bpfmaniov1alpha1.ApForeignXdpProgram)
+	return fmt.Errorf("interface %s already has XDP program %d (%q) that bpfman didn't attach: %w",
+		name, id, progName, errForeignXdpProgram)
+}
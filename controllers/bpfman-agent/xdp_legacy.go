@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+// defaultXdpPriority is the priority of an XDP link that doesn't set one, as
+// set by the priority field's +kubebuilder:default.
+const defaultXdpPriority int32 = 1000
+
+// defaultXdpProceedOn is the proceedOn of an XDP link that doesn't set one.
+var defaultXdpProceedOn = []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"}
+
+// convertLegacyXdpLinks moves the deprecated interfaceselector, priority and
+// proceedon fields of each XDP program in app, from before a program could
+// have a list of links, into a single link, so older objects keep working.
+// They're only used if the program has no links, and are cleared either way,
+// so converting again never adds a second link. Only the agent's copy of the
+// application is changed, it's never written back.
+func convertLegacyXdpLinks(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+	for i := range app.Spec.Programs {
+		xdp := app.Spec.Programs[i].XDP
+		if xdp == nil || xdp.InterfaceSelector == nil {
+			continue
+		}
+		if len(xdp.Links) == 0 {
+			link := bpfmaniov1alpha1.ClXdpAttachInfo{
+				InterfaceSelector: *xdp.InterfaceSelector,
+				Priority:          defaultXdpPriority,
+				ProceedOn:         slices.Clone(defaultXdpProceedOn),
+			}
+			if xdp.Priority != nil {
+				link.Priority = *xdp.Priority
+			}
+			if len(xdp.ProceedOn) != 0 {
+				link.ProceedOn = xdp.ProceedOn
+			}
+			xdp.Links = []bpfmaniov1alpha1.ClXdpAttachInfo{link}
+		}
+		xdp.InterfaceSelector = nil
+		xdp.Priority = nil
+		xdp.ProceedOn = nil
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanagent
+
+import (
+	"encoding/json"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertLegacyXdpLinks(t *testing.T) {
+	// An XDP program written before programs had a list of links.
+	legacy := `{
+		"metadata": {"name": "legacy-xdp"},
+		"spec": {
+			"byteCode": {"path": "/tmp/hello.o"},
+			"programs": [{
+				"name": "xdp_pass",
+				"type": "XDP",
+				"xdp": {
+					"interfaceselector": {"interfaces": ["eth0"]},
+					"priority": 55,
+					"proceedon": ["Pass", "Drop"]
+				}
+			}]
+		}
+	}`
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{}
+	require.NoError(t, json.Unmarshal([]byte(legacy), app))
+
+	convertLegacyXdpLinks(app)
+	xdp := app.Spec.Programs[0].XDP
+	require.Equal(t, []bpfmaniov1alpha1.ClXdpAttachInfo{
+		{
+			InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+			Priority:          55,
+			ProceedOn:         []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "Drop"},
+		},
+	}, xdp.Links)
+	require.Nil(t, xdp.InterfaceSelector)
+	require.Nil(t, xdp.Priority)
+	require.Nil(t, xdp.ProceedOn)
+
+	// Round-tripping the converted application doesn't add another link.
+	data, err := json.Marshal(app)
+	require.NoError(t, err)
+	roundTripped := &bpfmaniov1alpha1.ClusterBpfApplication{}
+	require.NoError(t, json.Unmarshal(data, roundTripped))
+	convertLegacyXdpLinks(roundTripped)
+	require.Equal(t, xdp.Links, roundTripped.Spec.Programs[0].XDP.Links)
+
+	// Without priority and proceedon, the link gets the defaults.
+	app.Spec.Programs[0].XDP = &bpfmaniov1alpha1.ClXdpProgramInfo{
+		InterfaceSelector: &bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+	}
+	convertLegacyXdpLinks(app)
+	require.Len(t, app.Spec.Programs[0].XDP.Links, 1)
+	require.Equal(t, defaultXdpPriority, app.Spec.Programs[0].XDP.Links[0].Priority)
+	require.Equal(t, []bpfmaniov1alpha1.XdpProceedOnValue{"Pass", "DispatcherReturn"}, app.Spec.Programs[0].XDP.Links[0].ProceedOn)
+
+	// If there are links, the legacy fields are ignored.
+	links := []bpfmaniov1alpha1.ClXdpAttachInfo{
+		{InterfaceSelector: bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth1"}}, Priority: 10},
+	}
+	app.Spec.Programs[0].XDP = &bpfmaniov1alpha1.ClXdpProgramInfo{
+		Links:             links,
+		InterfaceSelector: &bpfmaniov1alpha1.InterfaceSelector{Interfaces: []string{"eth0"}},
+	}
+	convertLegacyXdpLinks(app)
+	require.Equal(t, links, app.Spec.Programs[0].XDP.Links)
+	require.Nil(t, app.Spec.Programs[0].XDP.InterfaceSelector)
+}
@@ -26,6 +26,9 @@ import (
 	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
 
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -260,3 +263,556 @@ func TestAppProgramReconcile(t *testing.T) {
 func TestAppUpdateStatus(t *testing.T) {
 	appProgramReconcile(t, true)
 }
+
+func TestAppNodeCoverage(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		ctx          = context.TODO()
+	)
+
+	// Two of the three nodes are selected.
+	nodes := []*corev1.Node{testutils.NewNode("node-1"), testutils.NewNode("node-2"), testutils.NewNode("node-3")}
+	nodes[0].Labels["role"] = "worker"
+	nodes[1].Labels["role"] = "worker"
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"role": "worker"}},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+
+	// The application failed to load on node-2.
+	objs := []runtime.Object{app}
+	states := map[string]bpfmaniov1alpha1.BpfApplicationStateConditionType{
+		"node-1": bpfmaniov1alpha1.BpfAppStateCondSuccess,
+		"node-2": bpfmaniov1alpha1.BpfAppStateCondError,
+		"node-3": bpfmaniov1alpha1.BpfAppStateCondSuccess,
+	}
+	for _, node := range nodes {
+		objs = append(objs, node, &bpfmaniov1alpha1.ClusterBpfApplicationState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   bpfAppName + "-" + node.Name,
+				Labels: map[string]string{internal.BpfAppStateOwner: app.Name, internal.K8sHostLabel: node.Name},
+			},
+			Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+				Conditions: []metav1.Condition{states[node.Name].Condition()},
+			},
+		})
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).WithRuntimeObjects(objs...).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client: cl,
+				Scheme: s,
+			},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(2), app.Status.NodesExpected)
+	require.Equal(t, int32(1), app.Status.NodesLoaded)
+	require.Len(t, app.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondPartialNodeCoverage), app.Status.Conditions[0].Type)
+	require.Contains(t, app.Status.Conditions[0].Message, "loaded on 1 of 2 selected nodes")
+	require.Contains(t, app.Status.Conditions[0].Message, bpfAppName+"-node-2")
+
+	// Once it loads on node-2 the application is fully covered.
+	bpfAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName + "-node-2"}, bpfAppState))
+	bpfAppState.Status.Conditions = []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()}
+	require.NoError(t, cl.Status().Update(ctx, bpfAppState))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(2), app.Status.NodesExpected)
+	require.Equal(t, int32(2), app.Status.NodesLoaded)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondSuccess), app.Status.Conditions[0].Type)
+}
+
+// An error a node reported for an earlier spec isn't shown once the spec
+// changes, since the change may have fixed it. The application is reconciling
+// until the node reports on the new spec.
+func TestAppSpecChangeClearsError(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		ctx          = context.TODO()
+		node         = testutils.NewNode("node-1")
+	)
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+			Generation: 1,
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+	stateCondition := func(cond bpfmaniov1alpha1.BpfApplicationStateConditionType, generation int64) []metav1.Condition {
+		c := cond.Condition()
+		c.ObservedGeneration = generation
+		return []metav1.Condition{c}
+	}
+	bpfAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   bpfAppName + "-" + node.Name,
+			Labels: map[string]string{internal.BpfAppStateOwner: app.Name, internal.K8sHostLabel: node.Name},
+		},
+		Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+			Conditions: stateCondition(bpfmaniov1alpha1.BpfAppStateCondError, 1),
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).
+		WithRuntimeObjects(app, node, bpfAppState).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client: cl,
+				Scheme: s,
+			},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	appCondition := func() metav1.Condition {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+		require.Len(t, app.Status.Conditions, 1)
+		return app.Status.Conditions[0]
+	}
+	setStateCondition := func(cond bpfmaniov1alpha1.BpfApplicationStateConditionType, generation int64) {
+		require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppState.Name}, bpfAppState))
+		bpfAppState.Status.Conditions = stateCondition(cond, generation)
+		require.NoError(t, cl.Status().Update(ctx, bpfAppState))
+	}
+
+	cond := appCondition()
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondError), cond.Type)
+	require.Equal(t, int64(1), cond.ObservedGeneration)
+
+	// The spec changes, and the error is cleared before the agent has
+	// reconciled the change.
+	app.Generation = 2
+	require.NoError(t, cl.Update(ctx, app))
+	cond = appCondition()
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondReconciling), cond.Type)
+	require.Equal(t, int64(2), cond.ObservedGeneration)
+	require.Contains(t, cond.Message, bpfAppState.Name)
+
+	// An error the agent reports for the new spec is shown.
+	setStateCondition(bpfmaniov1alpha1.BpfAppStateCondError, 2)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondError), appCondition().Type)
+
+	// And so is its success once the spec is fixed.
+	app.Generation = 3
+	require.NoError(t, cl.Update(ctx, app))
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondReconciling), appCondition().Type)
+	setStateCondition(bpfmaniov1alpha1.BpfAppStateCondSuccess, 3)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondSuccess), appCondition().Type)
+}
+
+// The selected nodes are known from the node list before any agent has
+// created a ClusterBpfApplicationState, and nodes that aren't selected aren't
+// waited for.
+func TestAppNodeCoverageBeforeAgents(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		ctx          = context.TODO()
+	)
+
+	// Three of the four nodes are selected.
+	nodes := []*corev1.Node{testutils.NewNode("node-1"), testutils.NewNode("node-2"), testutils.NewNode("node-3"),
+		testutils.NewNode("control-plane")}
+	for _, node := range nodes[:3] {
+		node.Labels["role"] = "worker"
+	}
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"role": "worker"}},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+
+	objs := []runtime.Object{app}
+	for _, node := range nodes {
+		objs = append(objs, node)
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).WithRuntimeObjects(objs...).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client: cl,
+				Scheme: s,
+			},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(3), app.Status.NodesExpected)
+	require.Equal(t, int32(0), app.Status.NodesLoaded)
+	require.Len(t, app.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondPending), app.Status.Conditions[0].Type)
+	require.Equal(t, "BpfApplication loaded on 0 of 3 selected nodes, waiting for the agents on the following nodes: [node-1 node-2 node-3]",
+		app.Status.Conditions[0].Message)
+
+	// Once the selected nodes have loaded it, the application is fully
+	// covered without waiting for the control-plane node.
+	for _, node := range nodes[:3] {
+		require.NoError(t, cl.Create(ctx, &bpfmaniov1alpha1.ClusterBpfApplicationState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   bpfAppName + "-" + node.Name,
+				Labels: map[string]string{internal.BpfAppStateOwner: app.Name, internal.K8sHostLabel: node.Name},
+			},
+			Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+				Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()},
+			},
+		}))
+	}
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(3), app.Status.NodesExpected)
+	require.Equal(t, int32(3), app.Status.NodesLoaded)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondSuccess), app.Status.Conditions[0].Type)
+}
+
+// Nodes with taints the agent DaemonSet doesn't tolerate don't run an agent,
+// so they aren't expected to load the application.
+func TestAppNodeCoverageTaints(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		ctx          = context.TODO()
+	)
+
+	nodes := []*corev1.Node{testutils.NewNode("node-1"), testutils.NewNode("tainted"), testutils.NewNode("control-plane"),
+		testutils.NewNode("not-ready"), testutils.NewNode("preferred")}
+	nodes[1].Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	// Tolerated by the DaemonSet.
+	nodes[2].Spec.Taints = []corev1.Taint{{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule}}
+	// Tolerated by every DaemonSet pod.
+	nodes[3].Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute}}
+	// Doesn't stop pods being scheduled.
+	nodes[4].Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectPreferNoSchedule}}
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: internal.BpfmanDsName, Namespace: internal.BpfmanNamespace},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{
+						Key:      "node-role.kubernetes.io/control-plane",
+						Operator: corev1.TolerationOpExists,
+						Effect:   corev1.TaintEffectNoSchedule,
+					}},
+				},
+			},
+		},
+	}
+
+	// A DaemonSet with the same name in another namespace isn't the agent's.
+	otherDs := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: internal.BpfmanDsName, Namespace: "aaa"},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				},
+			},
+		},
+	}
+
+	objs := []runtime.Object{app, otherDs, ds}
+	for _, node := range nodes {
+		objs = append(objs, node)
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).WithRuntimeObjects(objs...).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client: cl,
+				Scheme: s,
+			},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(4), app.Status.NodesExpected)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondPending), app.Status.Conditions[0].Type)
+	require.Equal(t, "BpfApplication loaded on 0 of 4 selected nodes, waiting for the agents on the following nodes: [control-plane node-1 not-ready preferred]",
+		app.Status.Conditions[0].Message)
+
+	// Once the other nodes have loaded it, the application is fully covered
+	// without waiting for the tainted node.
+	for _, node := range []*corev1.Node{nodes[0], nodes[2], nodes[3], nodes[4]} {
+		require.NoError(t, cl.Create(ctx, &bpfmaniov1alpha1.ClusterBpfApplicationState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   bpfAppName + "-" + node.Name,
+				Labels: map[string]string{internal.BpfAppStateOwner: app.Name, internal.K8sHostLabel: node.Name},
+			},
+			Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+				Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondSuccess.Condition()},
+			},
+		}))
+	}
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(4), app.Status.NodesExpected)
+	require.Equal(t, int32(4), app.Status.NodesLoaded)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondSuccess), app.Status.Conditions[0].Type)
+}
+
+// When the operator is installed in another namespace, the tolerations come
+// from the DaemonSet in that namespace, not from one in the default bpfman
+// namespace.
+func TestAppNodeCoverageDaemonSetNamespace(t *testing.T) {
+	var (
+		bpfAppName      = "fakeAppProgram"
+		bytecodePath    = "/tmp/hello.o"
+		bpfmanNamespace = "bpfman-system"
+		ctx             = context.TODO()
+	)
+
+	nodes := []*corev1.Node{testutils.NewNode("node-1"), testutils.NewNode("tainted")}
+	nodes[1].Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: internal.BpfmanDsName, Namespace: bpfmanNamespace},
+	}
+	// Tolerates everything, but isn't in the namespace the operator is
+	// installed in.
+	defaultDs := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: internal.BpfmanDsName, Namespace: internal.BpfmanNamespace},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				},
+			},
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).
+		WithRuntimeObjects(app, ds, defaultDs, nodes[0], nodes[1]).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client:          cl,
+				Scheme:          s,
+				BpfmanNamespace: bpfmanNamespace,
+			},
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Equal(t, int32(1), app.Status.NodesExpected)
+	require.Equal(t, "BpfApplication loaded on 0 of 1 selected nodes, waiting for the agents on the following nodes: [node-1]",
+		app.Status.Conditions[0].Message)
+}
+
+// A ClusterBpfApplicationState left by a deleted application with the same
+// name doesn't decide the new application's status, and isn't changed.
+func TestAppIgnoresForeignAppStates(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		fakeNode     = testutils.NewNode("fake-control-plane")
+		ctx          = context.TODO()
+	)
+
+	app := &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       bpfAppName,
+			UID:        "new-app-uid",
+			Finalizers: []string{internal.BpfmanOperatorFinalizer},
+		},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				NodeSelector: metav1.LabelSelector{},
+				ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+					Path: &bytecodePath,
+				},
+			},
+		},
+	}
+
+	appState := func(name string, ownerUID types.UID, cond bpfmaniov1alpha1.BpfApplicationStateConditionType) *bpfmaniov1alpha1.ClusterBpfApplicationState {
+		return &bpfmaniov1alpha1.ClusterBpfApplicationState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{internal.BpfAppStateOwner: bpfAppName, internal.K8sHostLabel: fakeNode.Name},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: bpfmaniov1alpha1.SchemeGroupVersion.String(),
+						Kind:       "ClusterBpfApplication",
+						Name:       bpfAppName,
+						UID:        ownerUID,
+						Controller: &[]bool{true}[0],
+					},
+				},
+				Finalizers: []string{internal.ClBpfApplicationControllerFinalizer},
+			},
+			Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+				Conditions: []metav1.Condition{cond.Condition()},
+			},
+		}
+	}
+	ownState := appState(bpfAppName+"-new", app.UID, bpfmaniov1alpha1.BpfAppStateCondSuccess)
+	staleState := appState(bpfAppName+"-old", "old-app-uid", bpfmaniov1alpha1.BpfAppStateCondError)
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithStatusSubresource(app, &bpfmaniov1alpha1.ClusterBpfApplicationState{}).
+		WithRuntimeObjects(fakeNode, app, ownState, staleState).Build()
+
+	r := &BpfApplicationReconciler{
+		ClusterApplicationReconciler: ClusterApplicationReconciler{
+			ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+				Client: cl,
+				Scheme: s,
+			},
+		},
+	}
+
+	before := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: staleState.Name}, before))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+	require.Len(t, app.Status.Conditions, 1)
+	require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondSuccess), app.Status.Conditions[0].Type)
+	require.Equal(t, int32(1), app.Status.NodesLoaded)
+
+	after := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: staleState.Name}, after))
+	require.Equal(t, before.ResourceVersion, after.ResourceVersion)
+
+	// The stale state doesn't hold up the application's deletion either.
+	require.NoError(t, cl.Delete(ctx, app))
+	ownAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: ownState.Name}, ownAppState))
+	ownAppState.Finalizers = nil
+	require.NoError(t, cl.Update(ctx, ownAppState))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.True(t, apierrors.IsNotFound(cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app)))
+}
@@ -110,7 +110,7 @@ func (r *BpfApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 }
 
 //lint:ignore U1000 Linter claims function unused, but generics confusing linter
-func (r *BpfApplicationReconciler) updateStatus(ctx context.Context, _namespace string, name string, cond bpfmaniov1alpha1.BpfApplicationConditionType, message string) (ctrl.Result, error) {
+func (r *BpfApplicationReconciler) updateStatus(ctx context.Context, _namespace string, name string, coverage nodeCoverage, cond bpfmaniov1alpha1.BpfApplicationConditionType, message string) (ctrl.Result, error) {
 	// TODO: Does this still happen?
 	// Sometimes we end up with a stale FentryProgram due to races, do this
 	// get to ensure we're up to date before attempting a status update.
@@ -120,5 +120,5 @@ func (r *BpfApplicationReconciler) updateStatus(ctx context.Context, _namespace
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationOperator}, nil
 	}
 
-	return r.updateCondition(ctx, app, &app.Status.Conditions, cond, message)
+	return r.updateCondition(ctx, app, &app.Status, coverage, cond, message)
 }
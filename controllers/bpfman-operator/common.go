@@ -19,12 +19,18 @@ package bpfmanoperator
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -48,6 +54,7 @@ type BpfProgOper interface {
 	GetName() string
 	GetLabels() map[string]string
 	GetConditions() []metav1.Condition
+	GetClientObject() client.Object
 }
 
 type BpfProgListOper[T any] interface {
@@ -59,6 +66,15 @@ type ReconcilerCommon[T BpfProgOper, TL BpfProgListOper[T]] struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Logger logr.Logger
+	// FinalizerTimeout is how long an application being deleted waits for the
+	// agents to unload it before the operator removes its finalizer anyway,
+	// so an unresponsive bpfman can't block the deletion forever. Disabled if
+	// not positive.
+	FinalizerTimeout time.Duration
+	// BpfmanNamespace is the namespace the operator is installed in, where
+	// the config reconciler deploys the bpfman DaemonSet alongside the
+	// bpfman-config ConfigMap. If empty, internal.BpfmanNamespace is used.
+	BpfmanNamespace string
 }
 
 // ApplicationReconciler defines a k8s reconciler which can program bpfman.
@@ -75,11 +91,138 @@ type ApplicationReconciler[T BpfProgOper, TL BpfProgListOper[T]] interface {
 	updateStatus(ctx context.Context,
 		namespace string,
 		name string,
+		coverage nodeCoverage,
 		cond bpfmaniov1alpha1.BpfApplicationConditionType,
 		message string) (ctrl.Result, error)
 	getFinalizer() string
 }
 
+// nodeCoverage counts the nodes selected by an application's nodeSelector, and
+// how many of them it has been successfully loaded and attached on. waiting
+// names the selected nodes whose agent hasn't created a BpfApplicationState
+// yet.
+type nodeCoverage struct {
+	expected int32
+	loaded   int32
+	waiting  []string
+}
+
+// appNodeSelector returns the nodeSelector of a BpfApplication or
+// ClusterBpfApplication.
+func appNodeSelector(app client.Object) *metav1.LabelSelector {
+	switch app := app.(type) {
+	case *bpfmaniov1alpha1.ClusterBpfApplication:
+		return &app.Spec.NodeSelector
+	case *bpfmaniov1alpha1.BpfApplication:
+		return &app.Spec.NodeSelector
+	}
+	return &metav1.LabelSelector{}
+}
+
+// daemonSetTolerations returns the tolerations the DaemonSet controller adds to
+// every DaemonSet pod, so its pods run on nodes with these taints whatever the
+// DaemonSet's own tolerations are.
+func daemonSetTolerations(hostNetwork bool) []corev1.Toleration {
+	tolerations := []corev1.Toleration{
+		{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	if hostNetwork {
+		tolerations = append(tolerations, corev1.Toleration{Key: corev1.TaintNodeNetworkUnavailable,
+			Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule})
+	}
+	return tolerations
+}
+
+// toleratesNode returns true if tolerations tolerate all of the node's
+// NoSchedule and NoExecute taints, so a DaemonSet pod can run on it.
+func toleratesNode(node *corev1.Node, tolerations []corev1.Toleration) bool {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !slices.ContainsFunc(tolerations, func(t corev1.Toleration) bool { return t.ToleratesTaint(taint) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// bpfmanNamespace returns the namespace the bpfman DaemonSet is deployed in.
+func (r *ReconcilerCommon[T, TL]) bpfmanNamespace() string {
+	if r.BpfmanNamespace == "" {
+		return internal.BpfmanNamespace
+	}
+	return r.BpfmanNamespace
+}
+
+// agentNodes returns the nodes that the bpfman agent DaemonSet tolerates, since
+// no agent runs on the others to load an application. If the DaemonSet
+// doesn't exist yet, all the nodes are returned.
+func (r *ReconcilerCommon[T, TL]) agentNodes(ctx context.Context, nodes []corev1.Node) ([]corev1.Node, error) {
+	ds := &appsv1.DaemonSet{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: r.bpfmanNamespace(), Name: internal.BpfmanDsName}, ds)
+	if errors.IsNotFound(err) {
+		return nodes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DaemonSet %s: %w", internal.BpfmanDsName, err)
+	}
+	podSpec := &ds.Spec.Template.Spec
+	tolerations := append(slices.Clone(podSpec.Tolerations), daemonSetTolerations(podSpec.HostNetwork)...)
+
+	tolerated := []corev1.Node{}
+	for i := range nodes {
+		if toleratesNode(&nodes[i], tolerations) {
+			tolerated = append(tolerated, nodes[i])
+		}
+	}
+	return tolerated, nil
+}
+
+// getNodeCoverage evaluates app's nodeSelector against nodes to find the nodes
+// it should be loaded on, and counts how many of them have a
+// BpfApplicationState reporting success. The expected nodes come from the
+// node list rather than the BpfApplicationStates, so they're right before any
+// agent has acted.
+func getNodeCoverage[T BpfProgOper](app client.Object, nodes []corev1.Node, appStates []T) (nodeCoverage, error) {
+	selector, err := metav1.LabelSelectorAsSelector(appNodeSelector(app))
+	if err != nil {
+		return nodeCoverage{}, fmt.Errorf("failed to parse nodeSelector: %w", err)
+	}
+
+	reported := map[string]bool{}
+	succeeded := map[string]bool{}
+	for _, appState := range appStates {
+		node := appState.GetLabels()[internal.K8sHostLabel]
+		reported[node] = true
+		conditions := appState.GetConditions()
+		if len(conditions) != 0 && conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondSuccess) {
+			succeeded[node] = true
+		}
+	}
+
+	coverage := nodeCoverage{}
+	for _, node := range nodes {
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		coverage.expected++
+		if succeeded[node.Name] {
+			coverage.loaded++
+		}
+		if !reported[node.Name] {
+			coverage.waiting = append(coverage.waiting, node.Name)
+		}
+	}
+	return coverage, nil
+}
+
 func reconcileBpfApplication[T BpfProgOper, TL BpfProgListOper[T]](
 	ctx context.Context,
 	rec ApplicationReconciler[T, TL],
@@ -104,6 +247,11 @@ func reconcileBpfApplication[T BpfProgOper, TL BpfProgListOper[T]](
 		return ctrl.Result{}, nil
 	}
 
+	// BpfApplicationState objects left by another application with the same
+	// name, such as one deleted before its agents cleaned up, are ignored so
+	// they don't decide this application's status.
+	appStates := ownedAppStates(r.Logger, app, (*bpfAppStateObjs).GetItems())
+
 	// List all nodes since a BpfApplicationState object will always be created for each
 	nodes := &corev1.NodeList{}
 	if err := r.List(ctx, nodes, &client.ListOptions{}); err != nil {
@@ -111,29 +259,35 @@ func reconcileBpfApplication[T BpfProgOper, TL BpfProgListOper[T]](
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationOperator}, nil
 	}
 
-	// If the application isn't being deleted, make sure that each node has at
-	// least one BpfApplicationState object.  If not, Return Pending Status.
-	if app.GetDeletionTimestamp().IsZero() {
-		for _, node := range nodes.Items {
-			nodeFound := false
-			for _, appState := range (*bpfAppStateObjs).GetItems() {
-				bpfProgramState := appState.GetLabels()[internal.K8sHostLabel]
-				if node.Name == bpfProgramState {
-					nodeFound = true
-					break
-				}
-			}
-			if !nodeFound {
-				return rec.updateStatus(ctx, appNamespace, appName, bpfmaniov1alpha1.BpfAppCondPending, "")
-			}
-		}
+	// Nodes the agent doesn't tolerate won't run it, so they aren't expected
+	// to load the application.
+	agentNodes, err := r.agentNodes(ctx, nodes.Items)
+	if err != nil {
+		r.Logger.Error(err, "failed getting the agent's nodes for full reconcile")
+		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationOperator}, nil
+	}
+
+	coverage, err := getNodeCoverage(app, agentNodes, appStates)
+	if err != nil {
+		r.Logger.Error(err, "failed getting node coverage for full reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	// If the application isn't being deleted, make sure that each selected
+	// node has at least one BpfApplicationState object.  If not, Return
+	// Pending Status.
+	if app.GetDeletionTimestamp().IsZero() && len(coverage.waiting) != 0 {
+		return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondPending,
+			fmt.Sprintf("BpfApplication loaded on %d of %d selected nodes, waiting for the agents on the following nodes: %v",
+				coverage.loaded, coverage.expected, coverage.waiting))
 	}
 
 	pendingBpfApplications := []string{}
 	failedBpfApplications := []string{}
+	reconcilingBpfApplications := []string{}
 	finalApplied := []string{}
 	// Make sure no BpfApplications had any issues in the loading or unloading process
-	for _, bpfAppState := range (*bpfAppStateObjs).GetItems() {
+	for _, bpfAppState := range appStates {
 
 		if rec.containsFinalizer(&bpfAppState, rec.getFinalizer()) {
 			finalApplied = append(finalApplied, bpfAppState.GetName())
@@ -141,7 +295,14 @@ func reconcileBpfApplication[T BpfProgOper, TL BpfProgListOper[T]](
 
 		conditions := bpfAppState.GetConditions()
 		if bpfmanHelpers.IsBpfAppStateConditionFailure(conditions) {
-			failedBpfApplications = append(failedBpfApplications, bpfAppState.GetName())
+			// An error reported for an earlier spec may already be fixed
+			// by the current one, so it isn't reported until the agent
+			// has tried the current spec.
+			if isStaleCondition(conditions, app.GetGeneration()) {
+				reconcilingBpfApplications = append(reconcilingBpfApplications, bpfAppState.GetName())
+			} else {
+				failedBpfApplications = append(failedBpfApplications, bpfAppState.GetName())
+			}
 		} else if bpfmanHelpers.IsBpfAppStateConditionPending(conditions) {
 			pendingBpfApplications = append(pendingBpfApplications, bpfAppState.GetName())
 		}
@@ -155,18 +316,60 @@ func reconcileBpfApplication[T BpfProgOper, TL BpfProgListOper[T]](
 			return r.removeFinalizer(ctx, app, internal.BpfmanOperatorFinalizer)
 		}
 
-		return rec.updateStatus(ctx, appNamespace, appName, bpfmaniov1alpha1.BpfAppCondDeleteError,
+		remaining, expired := r.finalizerTimeoutRemaining(app)
+		if expired {
+			return r.forceFinalize(ctx, app, finalApplied)
+		}
+
+		res, err := rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondDeleteError,
 			fmt.Sprintf("Program Deletion failed on the following BpfApplicationState objects: %v", finalApplied))
+		if err == nil && remaining > 0 && !res.Requeue {
+			// Come back when the timeout expires, in case nothing else
+			// changes before then.
+			res = ctrl.Result{Requeue: true, RequeueAfter: remaining}
+		}
+		return res, err
 	}
 
-	if len(failedBpfApplications) != 0 {
-		return rec.updateStatus(ctx, appNamespace, appName, bpfmaniov1alpha1.BpfAppCondError,
+	if len(failedBpfApplications) != 0 && coverage.loaded > 0 && coverage.loaded < coverage.expected {
+		return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondPartialNodeCoverage,
+			fmt.Sprintf("BpfApplication loaded on %d of %d selected nodes, Reconciliation failed on the following BpfApplicationState objects: %v",
+				coverage.loaded, coverage.expected, failedBpfApplications))
+	} else if len(failedBpfApplications) != 0 {
+		return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondError,
 			fmt.Sprintf("BpfApplication Reconciliation failed on the following BpfApplicationState objects: %v", failedBpfApplications))
+	} else if len(reconcilingBpfApplications) != 0 {
+		return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondReconciling,
+			fmt.Sprintf("BpfApplication spec changed, waiting for the following BpfApplicationState objects to apply it: %v", reconcilingBpfApplications))
 	} else if len(pendingBpfApplications) != 0 {
-		return rec.updateStatus(ctx, appNamespace, appName, bpfmaniov1alpha1.BpfAppCondPending,
+		return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondPending,
 			fmt.Sprintf("BpfApplication Reconciliation is pending on the following BpfApplicationState objects: %v", pendingBpfApplications))
 	}
-	return rec.updateStatus(ctx, appNamespace, appName, bpfmaniov1alpha1.BpfAppCondSuccess, "")
+	return rec.updateStatus(ctx, appNamespace, appName, coverage, bpfmaniov1alpha1.BpfAppCondSuccess, "")
+}
+
+// isStaleCondition returns true if conditions were reported for a generation
+// of the application older than generation. Conditions that don't record a
+// generation aren't taken as stale.
+func isStaleCondition(conditions []metav1.Condition, generation int64) bool {
+	return len(conditions) != 0 && conditions[0].ObservedGeneration != 0 &&
+		conditions[0].ObservedGeneration < generation
+}
+
+// ownedAppStates returns the application states controlled by app, logging
+// any that another application controls.
+func ownedAppStates[T BpfProgOper](logger logr.Logger, app client.Object, appStates []T) []T {
+	owned := []T{}
+	for _, appState := range appStates {
+		owner := metav1.GetControllerOf(appState.GetClientObject())
+		if owner != nil && owner.UID != app.GetUID() {
+			logger.Info("WARNING: ignoring BpfApplicationState of another application with the same name",
+				"Name", app.GetName(), "AppState Name", appState.GetName(), "Owner UID", owner.UID)
+			continue
+		}
+		owned = append(owned, appState)
+	}
+	return owned
 }
 
 func (r *ReconcilerCommon[T, TL]) removeFinalizer(ctx context.Context, bpfApp client.Object, finalizer string) (ctrl.Result, error) {
@@ -183,6 +386,41 @@ func (r *ReconcilerCommon[T, TL]) removeFinalizer(ctx context.Context, bpfApp cl
 	return ctrl.Result{}, nil
 }
 
+// finalizerTimeoutRemaining returns how long is left before the
+// FinalizerTimeout of an application being deleted expires, and whether it
+// has. It returns 0 and false if there's no FinalizerTimeout.
+func (r *ReconcilerCommon[T, TL]) finalizerTimeoutRemaining(app client.Object) (time.Duration, bool) {
+	if r.FinalizerTimeout <= 0 {
+		return 0, false
+	}
+	remaining := time.Until(app.GetDeletionTimestamp().Add(r.FinalizerTimeout))
+	return remaining, remaining <= 0
+}
+
+// forceFinalize removes the bpfman-operator finalizer from an application
+// whose BpfApplicationState objects still have their finalizers after the
+// FinalizerTimeout, and annotates it with ForceFinalizedAnnotation, so the
+// deletion isn't blocked forever by an unresponsive bpfman.
+func (r *ReconcilerCommon[T, TL]) forceFinalize(ctx context.Context, app client.Object, finalApplied []string) (ctrl.Result, error) {
+	r.Logger.Info("Finalizer timeout expired, removing finalizer anyway. Programs may be left loaded in the kernel",
+		"Namespace", app.GetNamespace(), "Name", app.GetName(), "FinalizerTimeout", r.FinalizerTimeout,
+		"BpfApplicationStates", finalApplied)
+
+	annotations := app.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[internal.ForceFinalizedAnnotation] = strings.Join(finalApplied, ",")
+	app.SetAnnotations(annotations)
+	controllerutil.RemoveFinalizer(app, internal.BpfmanOperatorFinalizer)
+
+	if err := r.Update(ctx, app); err != nil {
+		r.Logger.Error(err, "failed to force remove bpfApp Finalizer")
+		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationOperator}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
 func (r *ReconcilerCommon[T, TL]) addFinalizer(ctx context.Context, app client.Object, finalizer string) (ctrl.Result, error) {
 	controllerutil.AddFinalizer(app, finalizer)
 
@@ -199,22 +437,38 @@ func (r *ReconcilerCommon[T, TL]) addFinalizer(ctx context.Context, app client.O
 func (r *ReconcilerCommon[T, TL]) updateCondition(
 	ctx context.Context,
 	obj client.Object,
-	conditions *[]metav1.Condition,
+	status *bpfmaniov1alpha1.BpfAppStatus,
+	coverage nodeCoverage,
 	cond bpfmaniov1alpha1.BpfApplicationConditionType,
 	message string,
 ) (ctrl.Result, error) {
+	conditions := &status.Conditions
 
 	r.Logger.V(1).Info("updateCondition()", "existing conds", conditions, "new cond", cond)
 
+	coverageChanged := status.NodesExpected != coverage.expected || status.NodesLoaded != coverage.loaded
+	status.NodesExpected = coverage.expected
+	status.NodesLoaded = coverage.loaded
+
+	newCond := cond.Condition(message)
+	newCond.ObservedGeneration = obj.GetGeneration()
+
 	if conditions != nil {
 		numConditions := len(*conditions)
 
 		if numConditions == 1 {
-			if (*conditions)[0].Type == string(cond) {
+			if (*conditions)[0].Type == string(cond) &&
+				(*conditions)[0].ObservedGeneration == obj.GetGeneration() && !coverageChanged {
 				r.Logger.Info("No change in status", "existing condition", (*conditions)[0].Type)
 				// No change, so just return false -- not updated
 				return ctrl.Result{}, nil
 			} else {
+				// The condition only transitions when its type changes, so
+				// it keeps its transition time if just the generation or
+				// the node coverage changed.
+				if (*conditions)[0].Type == string(cond) {
+					newCond.LastTransitionTime = (*conditions)[0].LastTransitionTime
+				}
 				// We're changing the condition, so delete this one.  The
 				// new condition will be added below.
 				*conditions = nil
@@ -229,7 +483,7 @@ func (r *ReconcilerCommon[T, TL]) updateCondition(
 		// if numConditions == 0, just add the new condition below.
 	}
 
-	meta.SetStatusCondition(conditions, cond.Condition(message))
+	meta.SetStatusCondition(conditions, newCond)
 
 	r.Logger.Info("Calling KubeAPI to update Program condition", "Type", obj.GetObjectKind().GroupVersionKind().Kind,
 		"Name", obj.GetName(), "condition", cond.Condition(message).Type)
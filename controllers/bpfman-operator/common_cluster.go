@@ -44,7 +44,7 @@ func (r *ClusterApplicationReconciler) getAppStateList(
 
 	// Only list BpfApplicationState objects for this BpfApplication
 	opts := []client.ListOption{
-		client.MatchingLabels{internal.BpfAppStateOwner: appName},
+		client.MatchingLabels{internal.BpfAppStateOwner: internal.AppStateOwnerLabel(appName)},
 	}
 
 	err := r.List(ctx, appStateList, opts...)
@@ -44,7 +44,7 @@ func (r *NamespaceApplicationReconciler) getAppStateList(
 
 	// Only list BpfApplicationState objects for this Program
 	opts := []client.ListOption{
-		client.MatchingLabels{internal.BpfAppStateOwner: appName},
+		client.MatchingLabels{internal.BpfAppStateOwner: internal.AppStateOwnerLabel(appName)},
 		client.InNamespace(appNamespace),
 	}
 
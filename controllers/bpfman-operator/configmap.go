@@ -19,8 +19,10 @@ package bpfmanoperator
 import (
 	"context"
 	"io"
+	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -369,6 +371,9 @@ func LoadAndConfigureBpfmanDs(config *corev1.ConfigMap, path string, isOpenshift
 	bpfmanLogLevel := config.Data["bpfman.log.level"]
 	bpfmanAgentLogLevel := config.Data["bpfman.agent.log.level"]
 	bpfmanHealthProbeAddr := config.Data["bpfman.agent.healthprobe.addr"]
+	bpfmanAgentFeatureGates := config.Data["bpfman.agent.feature.gates"]
+	bpfmanAgentMetadataKeyPrefix := config.Data["bpfman.agent.metadata.key.prefix"]
+	bpfmanAgentNodeCondition := config.Data["bpfman.agent.node.condition"]
 	bpfmanConfigs := config.Data["bpfman.toml"]
 
 	// Annotate the log level on the ds so we get automatic restarts on changes.
@@ -379,6 +384,9 @@ func LoadAndConfigureBpfmanDs(config *corev1.ConfigMap, path string, isOpenshift
 	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.loglevel"] = bpfmanLogLevel
 	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.agent.loglevel"] = bpfmanAgentLogLevel
 	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.agent.healthprobeaddr"] = bpfmanHealthProbeAddr
+	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.agent.featuregates"] = bpfmanAgentFeatureGates
+	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.agent.metadatakeyprefix"] = bpfmanAgentMetadataKeyPrefix
+	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.agent.nodecondition"] = bpfmanAgentNodeCondition
 	staticBpfmanDeployment.Spec.Template.ObjectMeta.Annotations["bpfman.io.bpfman.toml"] = bpfmanConfigs
 	staticBpfmanDeployment.Name = internal.BpfmanDsName
 	staticBpfmanDeployment.Namespace = config.Namespace
@@ -394,9 +402,25 @@ func LoadAndConfigureBpfmanDs(config *corev1.ConfigMap, path string, isOpenshift
 					if strings.Contains(arg, "health-probe-bind-address") {
 						staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args[aindex] =
 							"--health-probe-bind-address=" + bpfmanHealthProbeAddr
+						setHealthProbePort(&staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex], bpfmanHealthProbeAddr)
 					}
 				}
 			}
+			if bpfmanAgentFeatureGates != "" {
+				staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args = append(
+					staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args,
+					"--feature-gates="+bpfmanAgentFeatureGates)
+			}
+			if bpfmanAgentMetadataKeyPrefix != "" {
+				staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args = append(
+					staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args,
+					"--metadata-key-prefix="+bpfmanAgentMetadataKeyPrefix)
+			}
+			if nodeCondition, _ := strconv.ParseBool(bpfmanAgentNodeCondition); nodeCondition {
+				staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args = append(
+					staticBpfmanDeployment.Spec.Template.Spec.Containers[cindex].Args,
+					"--node-condition")
+			}
 		default:
 			// Do nothing
 		}
@@ -407,6 +431,26 @@ func LoadAndConfigureBpfmanDs(config *corev1.ConfigMap, path string, isOpenshift
 	return staticBpfmanDeployment
 }
 
+// setHealthProbePort sets the agent container's healthz port, which its
+// readiness probe polls, to the port of the health probe bind address addr.
+// If addr has no valid port the agent can't listen on it either, so the port
+// is left as it is.
+func setHealthProbePort(container *corev1.Container, addr string) {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil || port <= 0 || port > 65535 {
+		return
+	}
+	for i := range container.Ports {
+		if container.Ports[i].Name == internal.BpfmanAgentHealthPortName {
+			container.Ports[i].ContainerPort = int32(port)
+		}
+	}
+}
+
 func LoadAndConfigureMetricsProxyDs(config *corev1.ConfigMap, path string, isOpenshift bool) *appsv1.DaemonSet {
 	// Load static metrics-proxy deployment from disk
 	file, err := os.Open(path)
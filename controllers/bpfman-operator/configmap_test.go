@@ -33,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -255,3 +256,97 @@ func TestBpfmanConfigReconcileAndDeleteNEW(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadAndConfigureBpfmanDsFeatureGates(t *testing.T) {
+	_, bpfmanConfig, _, _, _ := setupTestEnvironment(false)
+	path := resolveConfigPath(internal.BpfmanDaemonManifestPath)
+
+	agentArgs := func(ds *appsv1.DaemonSet) []string {
+		for _, container := range ds.Spec.Template.Spec.Containers {
+			if container.Name == internal.BpfmanAgentContainerName {
+				return container.Args
+			}
+		}
+		t.Fatalf("no %s container", internal.BpfmanAgentContainerName)
+		return nil
+	}
+
+	// Without any feature gates the agent isn't passed the flag.
+	ds := LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+	for _, arg := range agentArgs(ds) {
+		require.NotContains(t, arg, "--feature-gates")
+	}
+
+	// The gates are passed to the agent, and annotated on the pod template
+	// so the agents restart when they change.
+	bpfmanConfig.Data["bpfman.agent.feature.gates"] = "new-probes,xdp-v2"
+	ds = LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+	require.Contains(t, agentArgs(ds), "--feature-gates=new-probes,xdp-v2")
+	require.Equal(t, "new-probes,xdp-v2", ds.Spec.Template.Annotations["bpfman.io.bpfman.agent.featuregates"])
+}
+
+func TestLoadAndConfigureBpfmanDsNodeCondition(t *testing.T) {
+	_, bpfmanConfig, _, _, _ := setupTestEnvironment(false)
+	path := resolveConfigPath(internal.BpfmanDaemonManifestPath)
+
+	agentArgs := func(ds *appsv1.DaemonSet) []string {
+		for _, container := range ds.Spec.Template.Spec.Containers {
+			if container.Name == internal.BpfmanAgentContainerName {
+				return container.Args
+			}
+		}
+		t.Fatalf("no %s container", internal.BpfmanAgentContainerName)
+		return nil
+	}
+
+	// The node condition is off unless the config turns it on.
+	for _, value := range []string{"", "false", "not-a-bool"} {
+		bpfmanConfig.Data["bpfman.agent.node.condition"] = value
+		ds := LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+		require.NotContains(t, agentArgs(ds), "--node-condition")
+	}
+
+	// The flag is passed to the agent, and the setting is annotated on the
+	// pod template so the agents restart when it changes.
+	bpfmanConfig.Data["bpfman.agent.node.condition"] = "true"
+	ds := LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+	require.Contains(t, agentArgs(ds), "--node-condition")
+	require.Equal(t, "true", ds.Spec.Template.Annotations["bpfman.io.bpfman.agent.nodecondition"])
+}
+
+func TestLoadAndConfigureBpfmanDsHealthProbeAddr(t *testing.T) {
+	_, bpfmanConfig, _, _, _ := setupTestEnvironment(false)
+	path := resolveConfigPath(internal.BpfmanDaemonManifestPath)
+
+	agent := func(ds *appsv1.DaemonSet) corev1.Container {
+		for _, container := range ds.Spec.Template.Spec.Containers {
+			if container.Name == internal.BpfmanAgentContainerName {
+				return container
+			}
+		}
+		t.Fatalf("no %s container", internal.BpfmanAgentContainerName)
+		return corev1.Container{}
+	}
+	healthPort := func(container corev1.Container) int32 {
+		for _, port := range container.Ports {
+			if port.Name == internal.BpfmanAgentHealthPortName {
+				return port.ContainerPort
+			}
+		}
+		t.Fatalf("no %s port", internal.BpfmanAgentHealthPortName)
+		return 0
+	}
+
+	// The readiness probe polls the named port, not a fixed number.
+	ds := LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+	container := agent(ds)
+	require.Equal(t, intstr.FromString(internal.BpfmanAgentHealthPortName), container.ReadinessProbe.HTTPGet.Port)
+	require.Equal(t, int32(8175), healthPort(container))
+
+	// Overriding the address moves the port the probe polls with it.
+	bpfmanConfig.Data["bpfman.agent.healthprobe.addr"] = ":9440"
+	ds = LoadAndConfigureBpfmanDs(bpfmanConfig, path, false)
+	container = agent(ds)
+	require.Contains(t, container.Args, "--health-probe-bind-address=:9440")
+	require.Equal(t, int32(9440), healthPort(container))
+}
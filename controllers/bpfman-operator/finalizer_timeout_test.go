@@ -0,0 +1,125 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanoperator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	internal "github.com/bpfman/bpfman-operator/internal"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestAppFinalizerTimeout(t *testing.T) {
+	var (
+		bpfAppName   = "fakeAppProgram"
+		bytecodePath = "/tmp/hello.o"
+		fakeNode     = testutils.NewNode("fake-control-plane")
+		ctx          = context.TODO()
+	)
+
+	tests := []struct {
+		name         string
+		deletedSince time.Duration
+		// forceFinalized is whether the finalizer is removed even though the
+		// agent hasn't removed the BpfApplicationState's.
+		forceFinalized bool
+	}{
+		{name: "within timeout", deletedSince: time.Minute, forceFinalized: false},
+		{name: "timeout exceeded", deletedSince: time.Hour, forceFinalized: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deletionTimestamp := metav1.NewTime(time.Now().Add(-tt.deletedSince))
+			app := &bpfmaniov1alpha1.ClusterBpfApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              bpfAppName,
+					DeletionTimestamp: &deletionTimestamp,
+					// Another controller's finalizer keeps the object around
+					// after the operator's is removed.
+					Finalizers: []string{internal.BpfmanOperatorFinalizer, "example.com/finalizer"},
+				},
+				Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+					BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+						NodeSelector: metav1.LabelSelector{},
+						ByteCode: bpfmaniov1alpha1.ByteCodeSelector{
+							Path: &bytecodePath,
+						},
+					},
+				},
+			}
+
+			// The agent hasn't been able to unload the application, so the
+			// BpfApplicationState still has its finalizer.
+			bpfAppState := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       bpfAppName + "-" + fakeNode.Name,
+					Labels:     map[string]string{internal.BpfAppStateOwner: app.Name, internal.K8sHostLabel: fakeNode.Name},
+					Finalizers: []string{internal.ClBpfApplicationControllerFinalizer},
+				},
+				Status: bpfmaniov1alpha1.ClBpfApplicationStateStatus{
+					Conditions: []metav1.Condition{bpfmaniov1alpha1.BpfAppStateCondBpfmanUnavailable.Condition()},
+				},
+			}
+
+			s := scheme.Scheme
+			s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, app)
+			s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+			s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+
+			cl := fake.NewClientBuilder().WithStatusSubresource(app).WithRuntimeObjects(fakeNode, app, bpfAppState).Build()
+
+			r := &BpfApplicationReconciler{
+				ClusterApplicationReconciler: ClusterApplicationReconciler{
+					ReconcilerCommon: ReconcilerCommon[bpfmaniov1alpha1.ClusterBpfApplicationState, bpfmaniov1alpha1.ClusterBpfApplicationStateList]{
+						Client:           cl,
+						Scheme:           s,
+						FinalizerTimeout: 10 * time.Minute,
+					},
+				},
+			}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bpfAppName}}
+			res, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: bpfAppName}, app))
+			if !tt.forceFinalized {
+				require.Contains(t, app.Finalizers, internal.BpfmanOperatorFinalizer)
+				require.NotContains(t, app.Annotations, internal.ForceFinalizedAnnotation)
+				require.Equal(t, string(bpfmaniov1alpha1.BpfAppCondDeleteError), app.Status.Conditions[0].Type)
+				// It's requeued for when the timeout expires.
+				require.True(t, res.Requeue)
+				require.Greater(t, res.RequeueAfter, 8*time.Minute)
+				require.LessOrEqual(t, res.RequeueAfter, 9*time.Minute)
+				return
+			}
+			require.NotContains(t, app.Finalizers, internal.BpfmanOperatorFinalizer)
+			require.Equal(t, bpfAppState.Name, app.Annotations[internal.ForceFinalizedAnnotation])
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanoperator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	internal "github.com/bpfman/bpfman-operator/internal"
+	"github.com/go-logr/logr"
+)
+
+// +kubebuilder:rbac:groups=bpfman.io,resources=clusterbpfapplicationstates,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=bpfman.io,resources=bpfapplicationstates,verbs=get;list;watch;update;patch;delete
+
+// NodeCleanupReconciler deletes the ClusterBpfApplicationState and
+// BpfApplicationState objects of nodes that have been removed from the
+// cluster. The agent on a removed node can't detach its programs or remove its
+// finalizers, so the objects would otherwise stay around forever.
+type NodeCleanupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeCleanupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodecleanup").
+		For(&corev1.Node{}, builder.WithPredicates(nodeDeletedPredicate())).
+		// The state objects are watched too, so the objects of nodes that
+		// were removed while the operator wasn't running are cleaned up
+		// when it starts.
+		Watches(
+			&bpfmaniov1alpha1.ClusterBpfApplicationState{},
+			handler.EnqueueRequestsFromMapFunc(stateNodeRequest),
+			builder.WithPredicates(stateCreatedPredicate()),
+		).
+		Watches(
+			&bpfmaniov1alpha1.BpfApplicationState{},
+			handler.EnqueueRequestsFromMapFunc(stateNodeRequest),
+			builder.WithPredicates(stateCreatedPredicate()),
+		).
+		Complete(r)
+}
+
+// Only deleted nodes need cleaning up.
+func nodeDeletedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// A state object's node is checked once, when the object is first seen.
+func stateCreatedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// stateNodeRequest maps a state object to a request for the node it belongs to.
+func stateNodeRequest(ctx context.Context, obj client.Object) []reconcile.Request {
+	nodeName := obj.GetLabels()[internal.K8sHostLabel]
+	if nodeName == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: nodeName}}}
+}
+
+func (r *NodeCleanupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Logger = ctrl.Log.WithName("nodeCleanup")
+
+	node := &corev1.Node{}
+	err := r.Get(ctx, req.NamespacedName, node)
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+	if !errors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed getting Node %s: %w", req.Name, err)
+	}
+
+	opts := []client.ListOption{client.MatchingLabels{internal.K8sHostLabel: req.Name}}
+
+	clAppStates := &bpfmaniov1alpha1.ClusterBpfApplicationStateList{}
+	if err := r.List(ctx, clAppStates, opts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ClusterBpfApplicationStates: %w", err)
+	}
+	for i := range clAppStates.Items {
+		if err := r.deleteAppState(ctx, &clAppStates.Items[i], internal.ClBpfApplicationControllerFinalizer); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	nsAppStates := &bpfmaniov1alpha1.BpfApplicationStateList{}
+	if err := r.List(ctx, nsAppStates, opts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list BpfApplicationStates: %w", err)
+	}
+	for i := range nsAppStates.Items {
+		if err := r.deleteAppState(ctx, &nsAppStates.Items[i], internal.NsBpfApplicationControllerFinalizer); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// deleteAppState removes the agent's finalizer from a state object and
+// deletes it. There's nothing to detach, since the node is gone.
+func (r *NodeCleanupReconciler) deleteAppState(ctx context.Context, appState client.Object, finalizer string) error {
+	r.Logger.Info("Deleting application state of removed node", "Name", appState.GetName(),
+		"Namespace", appState.GetNamespace(), "Node", appState.GetLabels()[internal.K8sHostLabel])
+
+	if controllerutil.RemoveFinalizer(appState, finalizer) {
+		if err := r.Update(ctx, appState); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to remove finalizer from %s: %w", appState.GetName(), err)
+		}
+	}
+	if err := r.Delete(ctx, appState); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete %s: %w", appState.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpfmanoperator
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	internal "github.com/bpfman/bpfman-operator/internal"
+	testutils "github.com/bpfman/bpfman-operator/internal/test-utils"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNodeCleanupReconcile(t *testing.T) {
+	var (
+		ctx         = context.TODO()
+		liveNode    = testutils.NewNode("live-node")
+		removedNode = "removed-node"
+	)
+
+	meta := func(name, namespace, nodeName, finalizer string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Labels:     map[string]string{internal.K8sHostLabel: nodeName},
+			Finalizers: []string{finalizer},
+		}
+	}
+	clRemoved := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: meta("app-removed", "", removedNode, internal.ClBpfApplicationControllerFinalizer),
+	}
+	clLive := &bpfmaniov1alpha1.ClusterBpfApplicationState{
+		ObjectMeta: meta("app-live", "", liveNode.Name, internal.ClBpfApplicationControllerFinalizer),
+	}
+	nsRemoved := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: meta("app-removed", "bpfman", removedNode, internal.NsBpfApplicationControllerFinalizer),
+	}
+	nsLive := &bpfmaniov1alpha1.BpfApplicationState{
+		ObjectMeta: meta("app-live", "bpfman", liveNode.Name, internal.NsBpfApplicationControllerFinalizer),
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.ClusterBpfApplicationStateList{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.BpfApplicationState{})
+	s.AddKnownTypes(bpfmaniov1alpha1.SchemeGroupVersion, &bpfmaniov1alpha1.BpfApplicationStateList{})
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(liveNode, clRemoved, clLive, nsRemoved, nsLive).Build()
+
+	r := &NodeCleanupReconciler{Client: cl, Scheme: s}
+
+	// Nothing is deleted for a node that still exists.
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: liveNode.Name}})
+	require.NoError(t, err)
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(clLive), &bpfmaniov1alpha1.ClusterBpfApplicationState{}))
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(nsLive), &bpfmaniov1alpha1.BpfApplicationState{}))
+
+	// The removed node's state objects are deleted despite their finalizers.
+	_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: removedNode}})
+	require.NoError(t, err)
+
+	err = cl.Get(ctx, client.ObjectKeyFromObject(clRemoved), &bpfmaniov1alpha1.ClusterBpfApplicationState{})
+	require.True(t, errors.IsNotFound(err))
+	err = cl.Get(ctx, client.ObjectKeyFromObject(nsRemoved), &bpfmaniov1alpha1.BpfApplicationState{})
+	require.True(t, errors.IsNotFound(err))
+
+	clState := &bpfmaniov1alpha1.ClusterBpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(clLive), clState))
+	require.Equal(t, []string{internal.ClBpfApplicationControllerFinalizer}, clState.Finalizers)
+	nsState := &bpfmaniov1alpha1.BpfApplicationState{}
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(nsLive), nsState))
+	require.Equal(t, []string{internal.NsBpfApplicationControllerFinalizer}, nsState.Finalizers)
+}
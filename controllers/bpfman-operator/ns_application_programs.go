@@ -120,6 +120,7 @@ func (r *BpfNsApplicationReconciler) updateStatus(
 	ctx context.Context,
 	namespace string,
 	name string,
+	coverage nodeCoverage,
 	cond bpfmaniov1alpha1.BpfApplicationConditionType,
 	message string,
 ) (ctrl.Result, error) {
@@ -132,5 +133,5 @@ func (r *BpfNsApplicationReconciler) updateStatus(
 		return ctrl.Result{Requeue: true, RequeueAfter: retryDurationOperator}, nil
 	}
 
-	return r.updateCondition(ctx, app, &app.Status.Conditions, cond, message)
+	return r.updateCondition(ctx, app, &app.Status, coverage, cond, message)
 }
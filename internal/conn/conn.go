@@ -28,7 +28,13 @@ import (
 //var log = ctrl.Log.WithName("bpfman-conn")
 
 func CreateConnection(ctx context.Context, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
-	addr := fmt.Sprintf("unix://%s", internal.DefaultPath)
+	return CreateConnectionToSocket(ctx, internal.DefaultPath, creds)
+}
+
+// CreateConnectionToSocket connects to the bpfman listening on the unix
+// socket at path, such as a namespace-scoped bpfman.
+func CreateConnectionToSocket(ctx context.Context, path string, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
+	addr := fmt.Sprintf("unix://%s", path)
 	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, fmt.Errorf("unable to establish connection to %s: %w", addr, err)
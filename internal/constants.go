@@ -21,8 +21,6 @@ import "fmt"
 const (
 	K8sHostLabel                = "kubernetes.io/hostname"
 	DiscoveredLabel             = "bpfman.io/discoveredProgram"
-	UuidMetadataKey             = "bpfman.io/uuid"
-	ProgramNameKey              = "bpfman.io/ProgramName"
 	BpfmanNamespace             = "bpfman"
 	BpfmanOperatorName          = "bpfman-operator"
 	BpfmanDsName                = "bpfman-daemon"
@@ -32,6 +30,7 @@ const (
 	BpfmanRestrictedSccName     = "bpfman-restricted"
 	BpfmanContainerName         = "bpfman"
 	BpfmanAgentContainerName    = "bpfman-agent"
+	BpfmanAgentHealthPortName   = "healthz"
 	BpfmanMetricsProxyContainer = "metrics-proxy"
 	BpfmanDaemonManifestPath    = "./config/bpfman-deployment/daemonset.yaml"
 	BpfmanMetricsProxyPath      = "./config/bpfman-deployment/metrics-proxy-daemonset.yaml"
@@ -43,8 +42,83 @@ const (
 	DefaultEnabled              = true
 	BpfAppStateOwner            = "bpfman.io/ownedByProgram"
 	NetNsPath                   = "/run/netns"
+	// BytecodeDir is a directory shared by the bpfman and bpfman-agent
+	// containers, where the agent writes bytecode loaded from a ConfigMap so
+	// bpfman can read it.
+	BytecodeDir = "/run/bpfman-bytecode"
+	// ForceResyncAnnotation can be set on a BpfApplication or
+	// ClusterBpfApplication, typically to a timestamp. Changing its value
+	// forces the bpfman agents to do a full reconcile of the application.
+	ForceResyncAnnotation = "bpfman.io/force-resync"
+	// AppIdentityAnnotation can be set on a BpfApplication or
+	// ClusterBpfApplication to give it an identity that doesn't depend on its
+	// name. An application created with the same identity as an existing one
+	// takes over that application's BpfApplicationStates, and the programs
+	// loaded for them, so an application can be renamed by creating it under
+	// the new name and then deleting the old one. The identity must be a
+	// valid label value.
+	AppIdentityAnnotation = "bpfman.io/identity"
+	// AppIdentityLabel is set on the BpfApplicationStates of applications
+	// that have an identity, to find them by it.
+	AppIdentityLabel = "bpfman.io/identity"
+	// FeatureGateAnnotation can be set on a BpfApplication or
+	// ClusterBpfApplication to the name of a feature gate. The bpfman agents
+	// only load the application while that gate is enabled in the
+	// bpfman.agent.feature.gates key of the bpfman-config ConfigMap.
+	FeatureGateAnnotation = "bpfman.io/feature-gate"
+	// ForceFinalizedAnnotation is set on a BpfApplication or
+	// ClusterBpfApplication when the operator removed its finalizer because
+	// the application's programs weren't unloaded within the finalizer
+	// timeout. Its value lists the BpfApplicationState objects that were
+	// still waiting to be unloaded, whose programs may still be loaded.
+	ForceFinalizedAnnotation = "bpfman.io/ForceFinalized"
+	// BpffsPath is where the bpf filesystem is mounted.
+	BpffsPath = "/sys/fs/bpf"
 )
 
+// DefaultMetadataKeyPrefix is the prefix of the keys of the metadata the
+// agent sets on the programs and links it has bpfman load and attach, unless
+// it's given another one.
+const DefaultMetadataKeyPrefix = "bpfman.io"
+
+// MetadataKeys are the keys of the metadata the agent sets on the programs
+// and links it has bpfman load and attach. The agents of operators that share
+// a bpfman use different prefixes, so they don't see each other's programs.
+type MetadataKeys struct {
+	// Uuid is the key of the UID of the application state a program was
+	// loaded for, and of a link's UUID.
+	Uuid string
+	// ProgramName is the key of the name of the application a program was
+	// loaded for.
+	ProgramName string
+	// MapPinPath is the key of the mapPinPath of the application a program
+	// was loaded for.
+	MapPinPath string
+	// LinkPersistence is the key of the linkPersistence of the program a
+	// link was attached for.
+	LinkPersistence string
+}
+
+// NewMetadataKeys returns the metadata keys with the given prefix, or with
+// DefaultMetadataKeyPrefix if it's empty.
+func NewMetadataKeys(prefix string) MetadataKeys {
+	if prefix == "" {
+		prefix = DefaultMetadataKeyPrefix
+	}
+	return MetadataKeys{
+		Uuid:            prefix + "/uuid",
+		ProgramName:     prefix + "/ProgramName",
+		MapPinPath:      prefix + "/MapPinPath",
+		LinkPersistence: prefix + "/LinkPersistence",
+	}
+}
+
+// Reserved returns true if key is one of the keys, which users can't set in
+// a link's metadata.
+func (k MetadataKeys) Reserved(key string) bool {
+	return key == k.Uuid || key == k.ProgramName || key == k.MapPinPath || key == k.LinkPersistence
+}
+
 // -----------------------------------------------------------------------------
 // Finalizers
 // -----------------------------------------------------------------------------
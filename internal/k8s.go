@@ -17,10 +17,37 @@ limitations under the License.
 package internal
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// TruncateName returns name if it's at most maxLen characters long.
+// Otherwise, it returns as much of name as fits followed by "-" and a hash of
+// the whole name, so that long names sharing a prefix don't end up the same.
+// The result is always the same for the same name.
+func TruncateName(name string, maxLen int) string {
+	if len(name) <= maxLen {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:8]
+	prefix := strings.TrimRight(name[:maxLen-len(hash)-1], "-._")
+	return prefix + "-" + hash
+}
+
+// AppStateOwnerLabel returns the value of the BpfAppStateOwner label that
+// the application states of the application with the given name have.
+// Application names can be longer than a label value, so they're truncated
+// with TruncateName.
+func AppStateOwnerLabel(appName string) string {
+	return TruncateName(appName, validation.LabelValueMaxLength)
+}
+
 // Only reconcile if a program has been created for a controller's node.
 func BpfNodePredicate(nodeName string) predicate.Funcs {
 	return predicate.Funcs{
@@ -0,0 +1,50 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MapPinRoot is the directory on the bpf filesystem that the bpfman agents
+// own. An application's mapPinPath must be under it, so the agents never pin
+// maps in, or remove pins from, directories used by anything else.
+const MapPinRoot = BpffsPath + "/bpfman-operator"
+
+// MapPinPathRoot returns the directory an application's mapPinPath must be
+// under. A ClusterBpfApplication, whose namespace is empty, can use any
+// directory under MapPinRoot, and a BpfApplication one under its namespace's
+// directory, so applications in one namespace can't use the pin paths of
+// another's.
+func MapPinPathRoot(namespace string) string {
+	if namespace == "" {
+		return MapPinRoot
+	}
+	return filepath.Join(MapPinRoot, "namespaces", namespace)
+}
+
+// CheckMapPinPath returns an error if mapPinPath isn't a clean path to a
+// directory under the MapPinPathRoot of an application in namespace.
+func CheckMapPinPath(mapPinPath, namespace string) error {
+	root := MapPinPathRoot(namespace)
+	if filepath.Clean(mapPinPath) != mapPinPath || !strings.HasPrefix(mapPinPath, root+"/") {
+		return fmt.Errorf("mapPinPath %s must be a directory under %s", mapPinPath, root)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMapPinPath(t *testing.T) {
+	require.NoError(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/app", ""))
+	require.NoError(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/team/app", ""))
+	require.NoError(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/namespaces/team/app", ""))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator", ""))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/app", ""))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/tc/globals", ""))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/../../etc", ""))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/app/", ""))
+	require.Error(t, CheckMapPinPath("/var/lib/app", ""))
+
+	// A namespaced application can only use its namespace's directory.
+	require.NoError(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/namespaces/team/app", "team"))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/namespaces/team", "team"))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/namespaces/other/app", "team"))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/app", "team"))
+	require.Error(t, CheckMapPinPath("/sys/fs/bpf/bpfman-operator/namespaces/team/../other/app", "team"))
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+)
+
+const (
+	MinTcxPriority = 0
+	MaxTcxPriority = 1000
+)
+
+// TcxChainLink is a TCX link in an application, along with the before and
+// after references used to order it relative to the other TCX programs in
+// the application.
+type TcxChainLink struct {
+	Program   string
+	Direction bpfmaniov1alpha1.TCDirectionType
+	Priority  int32
+	Before    string
+	After     string
+}
+
+// ResolveTcxChainPriorities returns the priority of each link, adjusted so
+// every before and after reference is honoured. A link that runs after
+// another gets a higher priority than it, starting from the configured
+// priorities and raising them where needed. If that would go beyond
+// MaxTcxPriority, the earlier links are lowered instead. An error is returned
+// if a reference doesn't name another TCX program in links, or if the
+// references form a cycle.
+func ResolveTcxChainPriorities(links []TcxChainLink) ([]int32, error) {
+	priorities := make([]int32, len(links))
+	programs := map[string]bool{}
+	chained := false
+	for i, l := range links {
+		priorities[i] = l.Priority
+		programs[l.Program] = true
+		chained = chained || l.Before != "" || l.After != ""
+	}
+	if !chained {
+		return priorities, nil
+	}
+
+	// Build the graph of links, with an edge from each link to the links
+	// that must run after it.
+	succs := make([][]int, len(links))
+	preds := make([][]int, len(links))
+	addEdge := func(from, to int) {
+		succs[from] = append(succs[from], to)
+		preds[to] = append(preds[to], from)
+	}
+	for i, l := range links {
+		for _, ref := range []struct{ kind, name string }{{"before", l.Before}, {"after", l.After}} {
+			if ref.name == "" {
+				continue
+			}
+			if ref.name == l.Program || !programs[ref.name] {
+				return nil, fmt.Errorf("TCX program %s: %s %q is not another TCX program in the application",
+					l.Program, ref.kind, ref.name)
+			}
+			for j, other := range links {
+				if other.Program != ref.name || other.Direction != l.Direction {
+					continue
+				}
+				if ref.kind == "before" {
+					addEdge(i, j)
+				} else {
+					addEdge(j, i)
+				}
+			}
+		}
+	}
+
+	// Sort the links topologically, always picking the first ready link so
+	// the result is deterministic.
+	inDegree := make([]int, len(links))
+	for i := range links {
+		inDegree[i] = len(preds[i])
+	}
+	order := []int{}
+	done := make([]bool, len(links))
+	for len(order) < len(links) {
+		next := -1
+		for i := range links {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("TCX before/after references form a cycle: %s", tcxChainCycle(links, preds, done))
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, s := range succs[next] {
+			inDegree[s]--
+		}
+	}
+
+	for _, n := range order {
+		for _, s := range succs[n] {
+			priorities[s] = max(priorities[s], priorities[n]+1)
+		}
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		priorities[n] = min(priorities[n], MaxTcxPriority)
+		for _, s := range succs[n] {
+			priorities[n] = min(priorities[n], priorities[s]-1)
+		}
+		if priorities[n] < MinTcxPriority {
+			return nil, fmt.Errorf("TCX program %s: too many chained programs to fit between priority %d and %d",
+				links[n].Program, MinTcxPriority, MaxTcxPriority)
+		}
+	}
+
+	return priorities, nil
+}
+
+// tcxChainCycle describes a cycle among the links that couldn't be sorted.
+// Each of those links has a predecessor that also couldn't be sorted, so
+// walking back through them must eventually revisit a link.
+func tcxChainCycle(links []TcxChainLink, preds [][]int, done []bool) string {
+	start := -1
+	for i := range links {
+		if !done[i] {
+			start = i
+			break
+		}
+	}
+
+	seen := map[int]int{}
+	path := []int{}
+	for n := start; ; {
+		if at, ok := seen[n]; ok {
+			path = path[at:]
+			break
+		}
+		seen[n] = len(path)
+		path = append(path, n)
+		for _, p := range preds[n] {
+			if !done[p] {
+				n = p
+				break
+			}
+		}
+	}
+
+	// The path was walked backwards, so reverse it, starting from the first
+	// link in the cycle, and close the loop.
+	first := 0
+	for i := range path {
+		if path[i] < path[first] {
+			first = i
+		}
+	}
+	names := []string{}
+	for i := range path {
+		names = append(names, links[path[(first-i+len(path))%len(path)]].Program)
+	}
+	names = append(names, names[0])
+	return strings.Join(names, " -> ")
+}
+
+// ClTcxChainLinks returns the chain links of a ClusterBpfApplication
+// program's TCX links, or nil if it isn't a TCX program.
+func ClTcxChainLinks(prog *bpfmaniov1alpha1.ClBpfApplicationProgram) []TcxChainLink {
+	if prog.Type != bpfmaniov1alpha1.ProgTypeTCX || prog.TCX == nil {
+		return nil
+	}
+	links := []TcxChainLink{}
+	for _, l := range prog.TCX.Links {
+		links = append(links, TcxChainLink{prog.Name, l.Direction, l.Priority, l.Before, l.After})
+	}
+	return links
+}
+
+// NsTcxChainLinks is the BpfApplication equivalent of ClTcxChainLinks.
+func NsTcxChainLinks(prog *bpfmaniov1alpha1.BpfApplicationProgram) []TcxChainLink {
+	if prog.Type != bpfmaniov1alpha1.ProgTypeTCX || prog.TCX == nil {
+		return nil
+	}
+	links := []TcxChainLink{}
+	for _, l := range prog.TCX.Links {
+		links = append(links, TcxChainLink{prog.Name, l.Direction, l.Priority, l.Before, l.After})
+	}
+	return links
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTcxChainPriorities(t *testing.T) {
+	ingress := bpfmaniov1alpha1.TCIngress
+	egress := bpfmaniov1alpha1.TCEgress
+
+	t.Run("no references keeps priorities", func(t *testing.T) {
+		priorities, err := ResolveTcxChainPriorities([]TcxChainLink{
+			{Program: "a", Direction: ingress, Priority: 500},
+			{Program: "b", Direction: ingress, Priority: 100},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int32{500, 100}, priorities)
+	})
+
+	t.Run("simple chain", func(t *testing.T) {
+		// c runs after b, which runs after a. b's egress link isn't
+		// chained, because a has no egress link.
+		priorities, err := ResolveTcxChainPriorities([]TcxChainLink{
+			{Program: "c", Direction: ingress, Priority: 50, After: "b"},
+			{Program: "b", Direction: ingress, Priority: 50, After: "a"},
+			{Program: "a", Direction: ingress, Priority: 50},
+			{Program: "b", Direction: egress, Priority: 50, After: "a"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int32{52, 51, 50, 50}, priorities)
+	})
+
+	t.Run("before lowers priority at the limit", func(t *testing.T) {
+		priorities, err := ResolveTcxChainPriorities([]TcxChainLink{
+			{Program: "a", Direction: ingress, Priority: 1000, Before: "b"},
+			{Program: "b", Direction: ingress, Priority: 1000},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int32{999, 1000}, priorities)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := ResolveTcxChainPriorities([]TcxChainLink{
+			{Program: "a", Direction: ingress, Priority: 1000, After: "c"},
+			{Program: "b", Direction: ingress, Priority: 1000, After: "a"},
+			{Program: "c", Direction: ingress, Priority: 1000, Before: "a", After: "b"},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "form a cycle")
+		require.Contains(t, err.Error(), "a -> b -> c -> a")
+	})
+
+	t.Run("unknown program", func(t *testing.T) {
+		_, err := ResolveTcxChainPriorities([]TcxChainLink{
+			{Program: "a", Direction: ingress, Priority: 1000, After: "missing"},
+		})
+		require.ErrorContains(t, err, `after "missing" is not another TCX program`)
+	})
+}
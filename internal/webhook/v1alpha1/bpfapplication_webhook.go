@@ -0,0 +1,487 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// immutableField is a field that's only used when an application's programs
+// are loaded, or its per-node state objects are created, so changing it would
+// have no effect until that happens again.
+type immutableField[T any] struct {
+	path *field.Path
+	get  func(*T) any
+}
+
+// clusterBpfApplicationImmutableFields lists the fields of a
+// ClusterBpfApplication that can't be changed once it's created. The name
+// and type of each program are also immutable, and programs can't be added or
+// removed, see validatePrograms.
+var clusterBpfApplicationImmutableFields = []immutableField[bpfmaniov1alpha1.ClusterBpfApplication]{
+	{field.NewPath("spec", "byteCode"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.ByteCode }},
+	{field.NewPath("spec", "additionalByteCode"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.AdditionalByteCode }},
+	{field.NewPath("spec", "globalData"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.GlobalData }},
+	{field.NewPath("spec", "globalDataFrom"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.GlobalDataFrom }},
+	{field.NewPath("spec", "globalDataOverrides"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.GlobalDataOverrides }},
+	{field.NewPath("spec", "mapOwnerSelector"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.MapOwnerSelector }},
+	{field.NewPath("spec", "mapPinPath"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.MapPinPath }},
+	{field.NewPath("spec", "adoptExisting"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.AdoptExisting }},
+	{field.NewPath("spec", "bpfAppStateLabels"), func(a *bpfmaniov1alpha1.ClusterBpfApplication) any { return a.Spec.BpfAppStateLabels }},
+}
+
+// bpfApplicationImmutableFields lists the fields of a BpfApplication that
+// can't be changed once it's created. The name and type of each program are
+// also immutable, and programs can't be added or removed, see
+// validatePrograms.
+var bpfApplicationImmutableFields = []immutableField[bpfmaniov1alpha1.BpfApplication]{
+	{field.NewPath("spec", "byteCode"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.ByteCode }},
+	{field.NewPath("spec", "additionalByteCode"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.AdditionalByteCode }},
+	{field.NewPath("spec", "globalData"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.GlobalData }},
+	{field.NewPath("spec", "globalDataFrom"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.GlobalDataFrom }},
+	{field.NewPath("spec", "globalDataOverrides"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.GlobalDataOverrides }},
+	{field.NewPath("spec", "mapOwnerSelector"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.MapOwnerSelector }},
+	{field.NewPath("spec", "mapPinPath"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.MapPinPath }},
+	{field.NewPath("spec", "adoptExisting"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.AdoptExisting }},
+	{field.NewPath("spec", "bpfAppStateLabels"), func(a *bpfmaniov1alpha1.BpfApplication) any { return a.Spec.BpfAppStateLabels }},
+}
+
+func immutableMessage(kind string) string {
+	return fmt.Sprintf("field is immutable, delete and recreate the %s to change it", kind)
+}
+
+func validateImmutableFields[T any](fields []immutableField[T], kind string, oldObj, newObj *T) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, f := range fields {
+		if !equality.Semantic.DeepEqual(f.get(oldObj), f.get(newObj)) {
+			errs = append(errs, field.Forbidden(f.path, immutableMessage(kind)))
+		}
+	}
+	return errs
+}
+
+// validateAppCommon checks the fields shared by both kinds of application,
+// and the ordering of the application's TCX links. namespace is the
+// application's namespace, which is empty for a ClusterBpfApplication.
+func validateAppCommon(common *bpfmaniov1alpha1.BpfAppCommon, namespace string, progs []programKey,
+	tcxLinks []internal.TcxChainLink) field.ErrorList {
+	errs := metav1validation.ValidateLabels(common.BpfAppStateLabels, field.NewPath("spec", "bpfAppStateLabels"))
+	if common.MapPinPath != "" {
+		if err := internal.CheckMapPinPath(common.MapPinPath, namespace); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "mapPinPath"), common.MapPinPath, err.Error()))
+		}
+	}
+	errs = append(errs, validateAdditionalByteCode(common.AdditionalByteCode, progs)...)
+	return append(errs, validateTcxChain(tcxLinks)...)
+}
+
+// validateTcxChain checks that the before and after references of an
+// application's TCX links name its other TCX programs, and don't form a cycle
+// or need more priorities than there are, so the agents can always order the
+// links.
+func validateTcxChain(links []internal.TcxChainLink) field.ErrorList {
+	if _, err := internal.ResolveTcxChainPriorities(links); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "programs"), field.OmitValueType{}, err.Error())}
+	}
+	return nil
+}
+
+// validateAdditionalByteCode checks that each program loaded from an
+// additional object is one of the application's programs, that none is
+// loaded from more than one object, and that at least one program is left to
+// load from byteCode.
+func validateAdditionalByteCode(additional []bpfmaniov1alpha1.AdditionalByteCode, progs []programKey) field.ErrorList {
+	errs := field.ErrorList{}
+	if len(additional) == 0 {
+		return errs
+	}
+	path := field.NewPath("spec", "additionalByteCode")
+
+	names := map[string]bool{}
+	for _, p := range progs {
+		names[p.name] = true
+	}
+	listed := map[string]bool{}
+	for i, a := range additional {
+		for j, name := range a.Programs {
+			progPath := path.Index(i).Child("programs").Index(j)
+			switch {
+			case !names[name]:
+				errs = append(errs, field.NotFound(progPath, name))
+			case listed[name]:
+				errs = append(errs, field.Duplicate(progPath, name))
+			default:
+				listed[name] = true
+			}
+		}
+	}
+	if len(names) > 0 && len(listed) == len(names) {
+		errs = append(errs, field.Invalid(path, len(listed),
+			"at least one program must be loaded from spec.byteCode"))
+	}
+	return errs
+}
+
+// linkMetadata is the metadata of one of an application's links, and its
+// path.
+type linkMetadata struct {
+	path     *field.Path
+	metadata map[string]string
+}
+
+// appendLinkMetadata appends the metadata of each of a program's links, whose
+// path is path.
+func appendLinkMetadata[L any](all []linkMetadata, path *field.Path, links []L,
+	metadata func(*L) map[string]string) []linkMetadata {
+	for i := range links {
+		all = append(all, linkMetadata{path.Child("links").Index(i).Child("metadata"), metadata(&links[i])})
+	}
+	return all
+}
+
+// metadataKeys returns the keys of the metadata the bpfman agents set
+// themselves, with the prefix configured in the bpfman-config ConfigMap. If
+// reader is nil, or there's no ConfigMap, the default prefix is used.
+func metadataKeys(ctx context.Context, reader client.Reader) (internal.MetadataKeys, error) {
+	config := &corev1.ConfigMap{}
+	if reader != nil {
+		err := reader.Get(ctx, types.NamespacedName{Namespace: internal.BpfmanNamespace, Name: internal.BpfmanConfigName}, config)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return internal.MetadataKeys{}, fmt.Errorf("failed to get the %s ConfigMap: %w", internal.BpfmanConfigName, err)
+		}
+	}
+	return internal.NewMetadataKeys(config.Data["bpfman.agent.metadata.key.prefix"]), nil
+}
+
+// validateLinkMetadata checks that no link's metadata sets one of the keys
+// the agents set themselves.
+func validateLinkMetadata(keys internal.MetadataKeys, links []linkMetadata) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, l := range links {
+		for _, key := range slices.Sorted(maps.Keys(l.metadata)) {
+			if keys.Reserved(key) {
+				errs = append(errs, field.Forbidden(l.path.Key(key), fmt.Sprintf("the %s metadata key is reserved", key)))
+			}
+		}
+	}
+	return errs
+}
+
+// programKey is the name and type of a program in an application.
+type programKey struct {
+	name     string
+	progType bpfmaniov1alpha1.EBPFProgType
+}
+
+// validatePrograms checks that no programs have been added or removed, and
+// that none has changed type.
+func validatePrograms(kind string, oldProgs, newProgs []programKey) field.ErrorList {
+	errs := field.ErrorList{}
+	path := field.NewPath("spec", "programs")
+
+	oldTypes := map[string]bpfmaniov1alpha1.EBPFProgType{}
+	for _, p := range oldProgs {
+		oldTypes[p.name] = p.progType
+	}
+	for i, p := range newProgs {
+		oldType, ok := oldTypes[p.name]
+		if !ok {
+			errs = append(errs, field.Forbidden(path.Index(i),
+				fmt.Sprintf("program %s can't be added, delete and recreate the %s to change its programs", p.name, kind)))
+			continue
+		}
+		if oldType != p.progType {
+			errs = append(errs, field.Forbidden(path.Index(i).Child("type"), immutableMessage(kind)))
+		}
+		delete(oldTypes, p.name)
+	}
+	for _, p := range oldProgs {
+		if _, ok := oldTypes[p.name]; ok {
+			errs = append(errs, field.Forbidden(path,
+				fmt.Sprintf("program %s can't be removed, delete and recreate the %s to change its programs", p.name, kind)))
+		}
+	}
+	return errs
+}
+
+// +kubebuilder:webhook:path=/validate-bpfman-io-v1alpha1-clusterbpfapplication,mutating=false,failurePolicy=fail,sideEffects=None,groups=bpfman.io,resources=clusterbpfapplications,verbs=create;update,versions=v1alpha1,name=vclusterbpfapplication-v1alpha1.bpfman.io,admissionReviewVersions=v1
+
+// ClusterBpfApplicationCustomValidator rejects ClusterBpfApplications with
+// invalid bpfAppStateLabels, mapPinPath, TCX link ordering or link metadata,
+// and updates that change immutable fields.
+type ClusterBpfApplicationCustomValidator struct {
+	// Reader reads the bpfman-config ConfigMap, for the agents' metadata key
+	// prefix. If it's nil, the default prefix is used.
+	Reader client.Reader
+}
+
+var _ admission.CustomValidator = &ClusterBpfApplicationCustomValidator{}
+
+// SetupClusterBpfApplicationWebhookWithManager registers the
+// ClusterBpfApplication webhook with the manager.
+func SetupClusterBpfApplicationWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&bpfmaniov1alpha1.ClusterBpfApplication{}).
+		WithValidator(&ClusterBpfApplicationCustomValidator{Reader: mgr.GetClient()}).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *ClusterBpfApplicationCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	app, ok := obj.(*bpfmaniov1alpha1.ClusterBpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterBpfApplication but got %T", obj)
+	}
+
+	keys, err := metadataKeys(ctx, v.Reader)
+	if err != nil {
+		return nil, err
+	}
+	errs := validateAppCommon(&app.Spec.BpfAppCommon, "", clProgramKeys(app), clTcxChainLinks(app))
+	errs = append(errs, validateLinkMetadata(keys, clLinkMetadata(app))...)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(bpfmaniov1alpha1.SchemeGroupVersion.WithKind("ClusterBpfApplication").GroupKind(), app.Name, errs)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *ClusterBpfApplicationCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldApp, ok := oldObj.(*bpfmaniov1alpha1.ClusterBpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterBpfApplication for the old object but got %T", oldObj)
+	}
+	newApp, ok := newObj.(*bpfmaniov1alpha1.ClusterBpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterBpfApplication for the new object but got %T", newObj)
+	}
+
+	keys, err := metadataKeys(ctx, v.Reader)
+	if err != nil {
+		return nil, err
+	}
+	const kind = "ClusterBpfApplication"
+	errs := validateAppCommon(&newApp.Spec.BpfAppCommon, "", clProgramKeys(newApp), clTcxChainLinks(newApp))
+	errs = append(errs, validateLinkMetadata(keys, clLinkMetadata(newApp))...)
+	errs = append(errs, validateImmutableFields(clusterBpfApplicationImmutableFields, kind, oldApp, newApp)...)
+	errs = append(errs, validatePrograms(kind, clProgramKeys(oldApp), clProgramKeys(newApp))...)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(bpfmaniov1alpha1.SchemeGroupVersion.WithKind(kind).GroupKind(), newApp.Name, errs)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *ClusterBpfApplicationCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func clProgramKeys(app *bpfmaniov1alpha1.ClusterBpfApplication) []programKey {
+	keys := []programKey{}
+	for _, p := range app.Spec.Programs {
+		keys = append(keys, programKey{p.Name, p.Type})
+	}
+	return keys
+}
+
+func clTcxChainLinks(app *bpfmaniov1alpha1.ClusterBpfApplication) []internal.TcxChainLink {
+	links := []internal.TcxChainLink{}
+	for i := range app.Spec.Programs {
+		links = append(links, internal.ClTcxChainLinks(&app.Spec.Programs[i])...)
+	}
+	return links
+}
+
+func clLinkMetadata(app *bpfmaniov1alpha1.ClusterBpfApplication) []linkMetadata {
+	all := []linkMetadata{}
+	for i, p := range app.Spec.Programs {
+		path := field.NewPath("spec", "programs").Index(i)
+		if p.XDP != nil {
+			all = appendLinkMetadata(all, path.Child("xdp"), p.XDP.Links,
+				func(l *bpfmaniov1alpha1.ClXdpAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.TC != nil {
+			all = appendLinkMetadata(all, path.Child("tc"), p.TC.Links,
+				func(l *bpfmaniov1alpha1.ClTcAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.TCX != nil {
+			all = appendLinkMetadata(all, path.Child("tcx"), p.TCX.Links,
+				func(l *bpfmaniov1alpha1.ClTcxAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.FEntry != nil {
+			all = appendLinkMetadata(all, path.Child("fentry"), p.FEntry.Links,
+				func(l *bpfmaniov1alpha1.ClFentryAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.FExit != nil {
+			all = appendLinkMetadata(all, path.Child("fexit"), p.FExit.Links,
+				func(l *bpfmaniov1alpha1.ClFexitAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.KProbe != nil {
+			all = appendLinkMetadata(all, path.Child("kprobe"), p.KProbe.Links,
+				func(l *bpfmaniov1alpha1.ClKprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.KRetProbe != nil {
+			all = appendLinkMetadata(all, path.Child("kretprobe"), p.KRetProbe.Links,
+				func(l *bpfmaniov1alpha1.ClKretprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.UProbe != nil {
+			all = appendLinkMetadata(all, path.Child("uprobe"), p.UProbe.Links,
+				func(l *bpfmaniov1alpha1.ClUprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.URetProbe != nil {
+			all = appendLinkMetadata(all, path.Child("uretprobe"), p.URetProbe.Links,
+				func(l *bpfmaniov1alpha1.ClUprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.TracePoint != nil {
+			all = appendLinkMetadata(all, path.Child("tracepoint"), p.TracePoint.Links,
+				func(l *bpfmaniov1alpha1.ClTracepointAttachInfo) map[string]string { return l.Metadata })
+		}
+	}
+	return all
+}
+
+// +kubebuilder:webhook:path=/validate-bpfman-io-v1alpha1-bpfapplication,mutating=false,failurePolicy=fail,sideEffects=None,groups=bpfman.io,resources=bpfapplications,verbs=create;update,versions=v1alpha1,name=vbpfapplication-v1alpha1.bpfman.io,admissionReviewVersions=v1
+
+// BpfApplicationCustomValidator rejects BpfApplications with invalid
+// bpfAppStateLabels, mapPinPath, TCX link ordering or link metadata, and
+// updates that change immutable fields.
+type BpfApplicationCustomValidator struct {
+	// Reader reads the bpfman-config ConfigMap, for the agents' metadata key
+	// prefix. If it's nil, the default prefix is used.
+	Reader client.Reader
+}
+
+var _ admission.CustomValidator = &BpfApplicationCustomValidator{}
+
+// SetupBpfApplicationWebhookWithManager registers the BpfApplication webhook
+// with the manager.
+func SetupBpfApplicationWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&bpfmaniov1alpha1.BpfApplication{}).
+		WithValidator(&BpfApplicationCustomValidator{Reader: mgr.GetClient()}).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *BpfApplicationCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	app, ok := obj.(*bpfmaniov1alpha1.BpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a BpfApplication but got %T", obj)
+	}
+
+	keys, err := metadataKeys(ctx, v.Reader)
+	if err != nil {
+		return nil, err
+	}
+	errs := validateAppCommon(&app.Spec.BpfAppCommon, app.Namespace, nsProgramKeys(app), nsTcxChainLinks(app))
+	errs = append(errs, validateLinkMetadata(keys, nsLinkMetadata(app))...)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(bpfmaniov1alpha1.SchemeGroupVersion.WithKind("BpfApplication").GroupKind(), app.Name, errs)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *BpfApplicationCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldApp, ok := oldObj.(*bpfmaniov1alpha1.BpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a BpfApplication for the old object but got %T", oldObj)
+	}
+	newApp, ok := newObj.(*bpfmaniov1alpha1.BpfApplication)
+	if !ok {
+		return nil, fmt.Errorf("expected a BpfApplication for the new object but got %T", newObj)
+	}
+
+	keys, err := metadataKeys(ctx, v.Reader)
+	if err != nil {
+		return nil, err
+	}
+	const kind = "BpfApplication"
+	errs := validateAppCommon(&newApp.Spec.BpfAppCommon, newApp.Namespace, nsProgramKeys(newApp), nsTcxChainLinks(newApp))
+	errs = append(errs, validateLinkMetadata(keys, nsLinkMetadata(newApp))...)
+	errs = append(errs, validateImmutableFields(bpfApplicationImmutableFields, kind, oldApp, newApp)...)
+	errs = append(errs, validatePrograms(kind, nsProgramKeys(oldApp), nsProgramKeys(newApp))...)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(bpfmaniov1alpha1.SchemeGroupVersion.WithKind(kind).GroupKind(), newApp.Name, errs)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *BpfApplicationCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func nsProgramKeys(app *bpfmaniov1alpha1.BpfApplication) []programKey {
+	keys := []programKey{}
+	for _, p := range app.Spec.Programs {
+		keys = append(keys, programKey{p.Name, p.Type})
+	}
+	return keys
+}
+
+func nsTcxChainLinks(app *bpfmaniov1alpha1.BpfApplication) []internal.TcxChainLink {
+	links := []internal.TcxChainLink{}
+	for i := range app.Spec.Programs {
+		links = append(links, internal.NsTcxChainLinks(&app.Spec.Programs[i])...)
+	}
+	return links
+}
+
+func nsLinkMetadata(app *bpfmaniov1alpha1.BpfApplication) []linkMetadata {
+	all := []linkMetadata{}
+	for i, p := range app.Spec.Programs {
+		path := field.NewPath("spec", "programs").Index(i)
+		if p.XDP != nil {
+			all = appendLinkMetadata(all, path.Child("xdp"), p.XDP.Links,
+				func(l *bpfmaniov1alpha1.XdpAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.TC != nil {
+			all = appendLinkMetadata(all, path.Child("tc"), p.TC.Links,
+				func(l *bpfmaniov1alpha1.TcAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.TCX != nil {
+			all = appendLinkMetadata(all, path.Child("tcx"), p.TCX.Links,
+				func(l *bpfmaniov1alpha1.TcxAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.UProbe != nil {
+			all = appendLinkMetadata(all, path.Child("uprobe"), p.UProbe.Links,
+				func(l *bpfmaniov1alpha1.UprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+		if p.URetProbe != nil {
+			all = appendLinkMetadata(all, path.Child("uretprobe"), p.URetProbe.Links,
+				func(l *bpfmaniov1alpha1.UprobeAttachInfo) map[string]string { return l.Metadata })
+		}
+	}
+	return all
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2025 The bpfman Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	bpfmaniov1alpha1 "github.com/bpfman/bpfman-operator/apis/v1alpha1"
+	"github.com/bpfman/bpfman-operator/internal"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newClusterBpfApplication() *bpfmaniov1alpha1.ClusterBpfApplication {
+	path := "/tmp/hello.o"
+	return &bpfmaniov1alpha1.ClusterBpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: bpfmaniov1alpha1.ClBpfApplicationSpec{
+			BpfAppCommon: bpfmaniov1alpha1.BpfAppCommon{
+				ByteCode:   bpfmaniov1alpha1.ByteCodeSelector{Path: &path},
+				GlobalData: map[string][]byte{"sampling": {0x01}},
+			},
+			Programs: []bpfmaniov1alpha1.ClBpfApplicationProgram{
+				{
+					Name: "kprobe_test",
+					Type: bpfmaniov1alpha1.ProgTypeKprobe,
+					KProbe: &bpfmaniov1alpha1.ClKprobeProgramInfo{
+						Links: []bpfmaniov1alpha1.ClKprobeAttachInfo{{Function: "try_to_wake_up"}},
+					},
+				},
+				{
+					Name: "tracepoint_test",
+					Type: bpfmaniov1alpha1.ProgTypeTracepoint,
+					TracePoint: &bpfmaniov1alpha1.ClTracepointProgramInfo{
+						Links: []bpfmaniov1alpha1.ClTracepointAttachInfo{{Name: "syscalls/sys_enter_kill"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestClusterBpfApplicationValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		update  func(*bpfmaniov1alpha1.ClusterBpfApplication)
+		invalid []string
+	}{
+		{
+			name: "node selector and links are mutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.NodeSelector = metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}}
+				app.Spec.Programs[0].KProbe.Links = append(app.Spec.Programs[0].KProbe.Links,
+					bpfmaniov1alpha1.ClKprobeAttachInfo{Function: "do_sys_open"})
+			},
+		},
+		{
+			name: "program order is mutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.Programs[0], app.Spec.Programs[1] = app.Spec.Programs[1], app.Spec.Programs[0]
+			},
+		},
+		{
+			name: "bytecode is immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				path := "/tmp/other.o"
+				app.Spec.ByteCode.Path = &path
+			},
+			invalid: []string{"spec.byteCode"},
+		},
+		{
+			name: "global data is immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.GlobalData["sampling"] = []byte{0x02}
+			},
+			invalid: []string{"spec.globalData"},
+		},
+		{
+			name: "map pin path is immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.MapPinPath = "/sys/fs/bpf/bpfman-operator/app"
+			},
+			invalid: []string{"spec.mapPinPath"},
+		},
+		{
+			name: "program type is immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.Programs[0].Type = bpfmaniov1alpha1.ProgTypeKretprobe
+			},
+			invalid: []string{"spec.programs[0].type"},
+		},
+		{
+			name: "program name is immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.Programs[1].Name = "renamed"
+			},
+			invalid: []string{"spec.programs[1]", "spec.programs"},
+		},
+		{
+			name: "state labels are immutable",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.BpfAppStateLabels = map[string]string{"team": "networking"}
+			},
+			invalid: []string{"spec.bpfAppStateLabels"},
+		},
+		{
+			name: "programs can't be removed",
+			update: func(app *bpfmaniov1alpha1.ClusterBpfApplication) {
+				app.Spec.Programs = app.Spec.Programs[:1]
+			},
+			invalid: []string{"spec.programs"},
+		},
+	}
+
+	v := &ClusterBpfApplicationCustomValidator{}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldApp := newClusterBpfApplication()
+			newApp := oldApp.DeepCopy()
+			tc.update(newApp)
+
+			_, err := v.ValidateUpdate(context.TODO(), oldApp, newApp)
+			if len(tc.invalid) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+			require.Contains(t, err.Error(), "delete and recreate the ClusterBpfApplication")
+			causes := []string{}
+			for _, c := range err.(*apierrors.StatusError).ErrStatus.Details.Causes {
+				causes = append(causes, c.Field)
+			}
+			require.Equal(t, tc.invalid, causes)
+		})
+	}
+}
+
+func TestClusterBpfApplicationValidateCreate(t *testing.T) {
+	v := &ClusterBpfApplicationCustomValidator{}
+
+	app := newClusterBpfApplication()
+	app.Spec.BpfAppStateLabels = map[string]string{"team": "networking", "example.com/cost-center": "1234"}
+	_, err := v.ValidateCreate(context.TODO(), app)
+	require.NoError(t, err)
+
+	app.Spec.BpfAppStateLabels["not a valid key"] = "value"
+	_, err = v.ValidateCreate(context.TODO(), app)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+	require.ErrorContains(t, err, "spec.bpfAppStateLabels")
+	require.ErrorContains(t, err, "not a valid key")
+}
+
+func TestClusterBpfApplicationValidateAdditionalByteCode(t *testing.T) {
+	v := &ClusterBpfApplicationCustomValidator{}
+	path := "/tmp/tracepoint.o"
+
+	tests := []struct {
+		name     string
+		programs [][]string
+		invalid  []string
+	}{
+		{"valid", [][]string{{"tracepoint_test"}}, nil},
+		{"unknown program", [][]string{{"tracepoint_test", "missing"}}, []string{"spec.additionalByteCode[0].programs[1]"}},
+		{"listed twice", [][]string{{"tracepoint_test"}, {"tracepoint_test"}}, []string{"spec.additionalByteCode[1].programs[0]"}},
+		{"nothing left for byteCode", [][]string{{"tracepoint_test", "kprobe_test"}}, []string{"spec.additionalByteCode"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newClusterBpfApplication()
+			for _, progs := range tc.programs {
+				app.Spec.AdditionalByteCode = append(app.Spec.AdditionalByteCode, bpfmaniov1alpha1.AdditionalByteCode{
+					ByteCode: bpfmaniov1alpha1.ByteCodeSelector{Path: &path},
+					Programs: progs,
+				})
+			}
+			_, err := v.ValidateCreate(context.TODO(), app)
+			if tc.invalid == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+			causes := []string{}
+			for _, cause := range err.(*apierrors.StatusError).ErrStatus.Details.Causes {
+				causes = append(causes, cause.Field)
+			}
+			require.Equal(t, tc.invalid, causes)
+		})
+	}
+
+	// The objects the programs are loaded from can't be changed.
+	oldApp := newClusterBpfApplication()
+	newApp := oldApp.DeepCopy()
+	newApp.Spec.AdditionalByteCode = []bpfmaniov1alpha1.AdditionalByteCode{{
+		ByteCode: bpfmaniov1alpha1.ByteCodeSelector{Path: &path},
+		Programs: []string{"tracepoint_test"},
+	}}
+	_, err := v.ValidateUpdate(context.TODO(), oldApp, newApp)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.additionalByteCode")
+}
+
+func TestBpfApplicationValidateUpdate(t *testing.T) {
+	oldApp := &bpfmaniov1alpha1.BpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: bpfmaniov1alpha1.BpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgram{
+				{Name: "xdp_test", Type: bpfmaniov1alpha1.ProgTypeXDP, XDP: &bpfmaniov1alpha1.XdpProgramInfo{}},
+			},
+		},
+	}
+	v := &BpfApplicationCustomValidator{}
+
+	// Changing the links is allowed.
+	newApp := oldApp.DeepCopy()
+	newApp.Spec.Programs[0].XDP.Links = []bpfmaniov1alpha1.XdpAttachInfo{{Priority: 100}}
+	_, err := v.ValidateUpdate(context.TODO(), oldApp, newApp)
+	require.NoError(t, err)
+
+	// Adopting the programs is only possible at creation.
+	newApp = oldApp.DeepCopy()
+	newApp.Spec.AdoptExisting = true
+	_, err = v.ValidateUpdate(context.TODO(), oldApp, newApp)
+	require.True(t, apierrors.IsInvalid(err))
+	require.ErrorContains(t, err, "spec.adoptExisting")
+	require.ErrorContains(t, err, "delete and recreate the BpfApplication")
+
+	// Create and delete are always allowed.
+	_, err = v.ValidateCreate(context.TODO(), newApp)
+	require.NoError(t, err)
+	_, err = v.ValidateDelete(context.TODO(), newApp)
+	require.NoError(t, err)
+}
+
+func TestBpfApplicationValidateMapPinPath(t *testing.T) {
+	v := &BpfApplicationCustomValidator{}
+	app := &bpfmaniov1alpha1.BpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team"},
+		Spec: bpfmaniov1alpha1.BpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgram{
+				{Name: "xdp_test", Type: bpfmaniov1alpha1.ProgTypeXDP, XDP: &bpfmaniov1alpha1.XdpProgramInfo{}},
+			},
+		},
+	}
+
+	app.Spec.MapPinPath = "/sys/fs/bpf/bpfman-operator/namespaces/team/app"
+	_, err := v.ValidateCreate(context.TODO(), app)
+	require.NoError(t, err)
+
+	// A BpfApplication can't pin its maps outside its namespace's directory.
+	for _, path := range []string{
+		"/sys/fs/bpf/tc/globals",
+		"/sys/fs/bpf/bpfman-operator/app",
+		"/sys/fs/bpf/bpfman-operator/namespaces/other/app",
+	} {
+		app.Spec.MapPinPath = path
+		_, err = v.ValidateCreate(context.TODO(), app)
+		require.True(t, apierrors.IsInvalid(err), "expected an Invalid error for %s, got %v", path, err)
+		require.ErrorContains(t, err, "spec.mapPinPath")
+	}
+}
+
+func TestBpfApplicationValidateTcxChain(t *testing.T) {
+	app := &bpfmaniov1alpha1.BpfApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: bpfmaniov1alpha1.BpfApplicationSpec{
+			Programs: []bpfmaniov1alpha1.BpfApplicationProgram{
+				{
+					Name: "first",
+					Type: bpfmaniov1alpha1.ProgTypeTCX,
+					TCX: &bpfmaniov1alpha1.TcxProgramInfo{
+						Links: []bpfmaniov1alpha1.TcxAttachInfo{{Direction: bpfmaniov1alpha1.TCIngress, Before: "second"}},
+					},
+				},
+				{
+					Name: "second",
+					Type: bpfmaniov1alpha1.ProgTypeTCX,
+					TCX: &bpfmaniov1alpha1.TcxProgramInfo{
+						Links: []bpfmaniov1alpha1.TcxAttachInfo{{Direction: bpfmaniov1alpha1.TCIngress}},
+					},
+				},
+			},
+		},
+	}
+	v := &BpfApplicationCustomValidator{}
+	_, err := v.ValidateCreate(context.TODO(), app)
+	require.NoError(t, err)
+
+	// References that form a cycle are rejected on create and update.
+	newApp := app.DeepCopy()
+	newApp.Spec.Programs[1].TCX.Links[0].Before = "first"
+	_, err = v.ValidateCreate(context.TODO(), newApp)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+	require.ErrorContains(t, err, "spec.programs")
+	require.ErrorContains(t, err, "first -> second -> first")
+	_, err = v.ValidateUpdate(context.TODO(), app, newApp)
+	require.ErrorContains(t, err, "form a cycle")
+
+	// So are references to programs that aren't in the application.
+	newApp = app.DeepCopy()
+	newApp.Spec.Programs[0].TCX.Links[0].Before = "missing"
+	_, err = v.ValidateCreate(context.TODO(), newApp)
+	require.ErrorContains(t, err, `before "missing" is not another TCX program`)
+}
+
+func TestClusterBpfApplicationValidateLinkMetadata(t *testing.T) {
+	v := &ClusterBpfApplicationCustomValidator{}
+
+	// The agents' keys are reserved, with the default prefix when there's
+	// no reader.
+	app := newClusterBpfApplication()
+	app.Spec.Programs[0].KProbe.Links[0].Metadata = map[string]string{
+		"team":                      "networking",
+		"bpfman.io/uuid":            "fake",
+		"bpfman.io/LinkPersistence": "DetachOnAgentExit",
+	}
+	_, err := v.ValidateCreate(context.TODO(), app)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+	causes := []string{}
+	for _, c := range err.(*apierrors.StatusError).ErrStatus.Details.Causes {
+		causes = append(causes, c.Field)
+	}
+	require.Equal(t, []string{
+		"spec.programs[0].kprobe.links[0].metadata[bpfman.io/LinkPersistence]",
+		"spec.programs[0].kprobe.links[0].metadata[bpfman.io/uuid]",
+	}, causes)
+
+	// With a configured prefix, the keys with that prefix are reserved
+	// instead.
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: internal.BpfmanConfigName, Namespace: internal.BpfmanNamespace},
+		Data:       map[string]string{"bpfman.agent.metadata.key.prefix": "team-a.bpfman.io"},
+	}
+	v.Reader = fake.NewClientBuilder().WithObjects(config).Build()
+	_, err = v.ValidateCreate(context.TODO(), app)
+	require.NoError(t, err)
+
+	newApp := app.DeepCopy()
+	newApp.Spec.Programs[0].KProbe.Links[0].Metadata["team-a.bpfman.io/uuid"] = "fake"
+	_, err = v.ValidateUpdate(context.TODO(), app, newApp)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+	require.ErrorContains(t, err, "the team-a.bpfman.io/uuid metadata key is reserved")
+}
@@ -32,6 +32,7 @@ type BpfmanV1alpha1Interface interface {
 	BpfApplicationStatesGetter
 	ClusterBpfApplicationsGetter
 	ClusterBpfApplicationStatesGetter
+	NodeBpfStatesGetter
 }
 
 // BpfmanV1alpha1Client is used to interact with features provided by the bpfman.io group.
@@ -55,6 +56,10 @@ func (c *BpfmanV1alpha1Client) ClusterBpfApplicationStates() ClusterBpfApplicati
 	return newClusterBpfApplicationStates(c)
 }
 
+func (c *BpfmanV1alpha1Client) NodeBpfStates() NodeBpfStateInterface {
+	return newNodeBpfStates(c)
+}
+
 // NewForConfig creates a new BpfmanV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -32,6 +32,8 @@ type Interface interface {
 	ClusterBpfApplications() ClusterBpfApplicationInformer
 	// ClusterBpfApplicationStates returns a ClusterBpfApplicationStateInformer.
 	ClusterBpfApplicationStates() ClusterBpfApplicationStateInformer
+	// NodeBpfStates returns a NodeBpfStateInformer.
+	NodeBpfStates() NodeBpfStateInformer
 }
 
 type version struct {
@@ -64,3 +66,8 @@ func (v *version) ClusterBpfApplications() ClusterBpfApplicationInformer {
 func (v *version) ClusterBpfApplicationStates() ClusterBpfApplicationStateInformer {
 	return &clusterBpfApplicationStateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// NodeBpfStates returns a NodeBpfStateInformer.
+func (v *version) NodeBpfStates() NodeBpfStateInformer {
+	return &nodeBpfStateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -169,7 +169,15 @@ func IsBpfAppStateConditionFailure(conditions []metav1.Condition) bool {
 
 	return conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondError) ||
 		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondProgramListChangedError) ||
-		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondUnloadError)
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondUnloadError) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondProgramTypeMismatch) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondUnsupportedDirection) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondForeignXdpProgram) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondTargetNotFound) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondGlobalDataNotFound) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondSharedMapNotFound) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondFunctionNotInBytecode) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondBpfmanUnavailable)
 }
 
 func IsBpfAppStateConditionPending(conditions []metav1.Condition) bool {
@@ -185,5 +193,8 @@ func IsBpfAppStateConditionPending(conditions []metav1.Condition) bool {
 		log.Info("more than one condition found", "numConditions", numConditions)
 	}
 
-	return conditions[0].Type == string(bpfmaniov1alpha1.BpfAppCondPending)
+	// An application waiting for containers to start on a node is pending
+	// rather than failed, since it will attach once they do.
+	return conditions[0].Type == string(bpfmaniov1alpha1.BpfAppCondPending) ||
+		conditions[0].Type == string(bpfmaniov1alpha1.BpfAppStateCondWaitingForContainers)
 }